@@ -71,6 +71,44 @@ describe 's3-unversioned-blobstore-backup-restorer job' do
         end
       end
 
+      context 'and a bucket has a retention policy' do
+        it 'prunes old backups after completing the backup' do
+          post_backup_unlock_script = post_backup_unlock_template.render({
+            "enabled" => true,
+            "buckets" => {
+              "droplets" => {
+                "name" => "the_droplets_bucket",
+                "retention" => { "keep_last" => 7 },
+                "backup" => { "name" => "the_backup_droplets_bucket" }
+              }
+            }
+          })
+          expect(post_backup_unlock_script).to include("--unversioned-prune")
+          expect(post_backup_unlock_script.index("--unversioned-backup-complete")).to be < post_backup_unlock_script.index("--unversioned-prune")
+        end
+
+        it 'does not fail the backup when pruning fails' do
+          post_backup_unlock_script = post_backup_unlock_template.render({
+            "enabled" => true,
+            "buckets" => {
+              "droplets" => {
+                "name" => "the_droplets_bucket",
+                "retention" => { "keep_last" => 7 },
+                "backup" => { "name" => "the_backup_droplets_bucket" }
+              }
+            }
+          })
+          expect(post_backup_unlock_script).to match(/if ! \S+s3-blobstore-backup-restore \\\n.*\n\s*--unversioned-prune\n\s*then/)
+        end
+      end
+
+      context 'and no bucket has a retention policy' do
+        it 'does not prune old backups' do
+          post_backup_unlock_script = post_backup_unlock_template.render({"enabled" => true})
+          expect(post_backup_unlock_script).not_to include("--unversioned-prune")
+        end
+      end
+
       context 'and it is configured correctly' do
         it 'succeeds' do
           manifest = {
@@ -1,8 +1,10 @@
 package azure
 
 import (
-	"encoding/json"
-	"os"
+	"fmt"
+
+	"common/configloader"
+	"common/retry"
 )
 
 type ContainerConfig struct {
@@ -11,6 +13,10 @@ type ContainerConfig struct {
 	StorageKey     string            `json:"azure_storage_key"`
 	Environment    Environment       `json:"environment"`
 	RestoreFrom    RestoreFromConfig `json:"restore_from"`
+	Retry          retry.Policy      `json:"retry"`
+	// MaxRequestsPerSecond limits the requests made for the container, or
+	// does not limit them when it is zero.
+	MaxRequestsPerSecond float64 `json:"max_requests_per_second,omitempty"`
 }
 
 type RestoreFromConfig struct {
@@ -19,18 +25,21 @@ type RestoreFromConfig struct {
 }
 
 func ParseConfig(configFilePath string) (map[string]ContainerConfig, error) {
-	configContents, err := os.ReadFile(configFilePath)
-	if err != nil {
-		return nil, err
-	}
-
 	var config map[string]ContainerConfig
-	err = json.Unmarshal(configContents, &config)
+	err := configloader.Load(configFilePath, &config)
 	if err != nil {
 		return nil, err
 	}
 
 	for key, containerConfig := range config {
+		if err := containerConfig.Retry.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config for container %s: %s", key, err)
+		}
+
+		if err := retry.ValidateRequestsPerSecond(containerConfig.MaxRequestsPerSecond); err != nil {
+			return nil, fmt.Errorf("invalid config for container %s: %s", key, err)
+		}
+
 		if config[key].Environment == "" {
 			containerConfig.Environment = "AzureCloud"
 			config[key] = containerConfig
@@ -96,6 +96,97 @@ var _ = Describe("ParseConfig", func() {
 		})
 	})
 
+	Context("when the config file is YAML and refers to environment variables", func() {
+		BeforeEach(func() {
+			os.Setenv("AZURE_TEST_STORAGE_KEY", "my-storage-key")
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("AZURE_TEST_STORAGE_KEY")
+		})
+
+		It("parses the config with the variables replaced", func() {
+			configFile, err := os.CreateTemp("", "azure_config*.yml")
+			Expect(err).NotTo(HaveOccurred())
+			configYaml := `
+container_id:
+  name: container_name
+  azure_storage_account: my-storage-account
+  azure_storage_key: ${AZURE_TEST_STORAGE_KEY}
+`
+			os.WriteFile(configFile.Name(), []byte(configYaml), 0644)
+
+			config, err := azure.ParseConfig(configFile.Name())
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config["container_id"]).To(Equal(azure.ContainerConfig{
+				Name:           "container_name",
+				StorageAccount: "my-storage-account",
+				StorageKey:     "my-storage-key",
+				Environment:    "AzureCloud",
+			}))
+		})
+	})
+
+	Context("when the config file sets a retry policy and request rate", func() {
+		It("parses them", func() {
+			configFile, err := os.CreateTemp("", "azure_config")
+			Expect(err).NotTo(HaveOccurred())
+			configJson := `{
+				"container_id": {
+					"name": "container_name",
+					"retry": {"max_attempts": 8, "base_delay_ms": 100, "jitter": 0.2},
+					"max_requests_per_second": 500
+				}
+			}`
+			os.WriteFile(configFile.Name(), []byte(configJson), 0644)
+
+			config, err := azure.ParseConfig(configFile.Name())
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config["container_id"].Retry.MaxAttempts).To(Equal(8))
+			Expect(config["container_id"].Retry.BaseDelayMS).To(Equal(100))
+			Expect(*config["container_id"].Retry.Jitter).To(Equal(0.2))
+			Expect(config["container_id"].MaxRequestsPerSecond).To(Equal(500.0))
+		})
+	})
+
+	DescribeTable("when the retry policy or request rate is invalid",
+		func(containerJson, expectedError string) {
+			configFile, err := os.CreateTemp("", "azure_config")
+			Expect(err).NotTo(HaveOccurred())
+			os.WriteFile(configFile.Name(), []byte(`{"container_id": `+containerJson+`}`), 0644)
+
+			_, err = azure.ParseConfig(configFile.Name())
+
+			Expect(err).To(MatchError(expectedError))
+		},
+		Entry("negative max attempts", `{"retry": {"max_attempts": -1}}`,
+			"invalid config for container container_id: retry.max_attempts must be at least 1"),
+		Entry("a jitter above 1", `{"retry": {"jitter": 2}}`,
+			"invalid config for container container_id: retry.jitter must be between 0 and 1"),
+		Entry("a negative request rate", `{"max_requests_per_second": -5}`,
+			"invalid config for container container_id: max_requests_per_second must not be negative"),
+	)
+
+	Context("when the config file has an unknown key", func() {
+		It("returns an error", func() {
+			configFile, err := os.CreateTemp("", "azure_config")
+			Expect(err).NotTo(HaveOccurred())
+			configJson := `{
+				"container_id": {
+					"name": "container_name",
+					"azure_storage_acount": "my-storage-account"
+				}
+			}`
+			os.WriteFile(configFile.Name(), []byte(configJson), 0644)
+
+			_, err = azure.ParseConfig(configFile.Name())
+
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "azure_storage_acount"`)))
+		})
+	})
+
 	Context("when the config file does not exist", func() {
 		It("returns an error", func() {
 			_, err := azure.ParseConfig("")
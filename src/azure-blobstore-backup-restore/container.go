@@ -3,18 +3,26 @@ package azure
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"net/url"
 	"strings"
 
 	"time"
 
 	"azure-blobstore-backup-restore/containerURL"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+
+	"common/retry"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
@@ -33,9 +41,49 @@ type SDKContainer struct {
 	containerClient *container.Client
 	serviceClient   *service.Client
 	environment     Environment
+	retryPolicy     retry.Policy
+	limiter         *retry.Limiter
+}
+
+// ContainerOptions controls how the requests made for a container are retried
+// and limited.
+type ContainerOptions struct {
+	Retry retry.Policy
+	// MaxRequestsPerSecond limits the requests made for the container, or
+	// does not limit them when it is zero.
+	MaxRequestsPerSecond float64
+}
+
+// copyFailedError is returned when Azure fails a blob copy which it had
+// started, which is worth starting again.
+type copyFailedError struct {
+	message string
 }
 
-func buildServiceClient(storageAccount StorageAccount, environment Environment) (*service.Client, error) {
+func (e copyFailedError) Error() string {
+	return e.message
+}
+
+// limiterPolicy waits for the limiter before each attempt of each request.
+type limiterPolicy struct {
+	limiter *retry.Limiter
+}
+
+func (p limiterPolicy) Do(req *policy.Request) (*http.Response, error) {
+	p.limiter.Wait()
+	return req.Next()
+}
+
+// clientOptions leaves retrying requests to the container's retry policy,
+// which retries whole blob copies rather than single requests.
+func clientOptions(limiter *retry.Limiter) azcore.ClientOptions {
+	return azcore.ClientOptions{
+		Retry:            policy.RetryOptions{MaxRetries: -1},
+		PerRetryPolicies: []policy.Policy{limiterPolicy{limiter: limiter}},
+	}
+}
+
+func buildServiceClient(storageAccount StorageAccount, environment Environment, limiter *retry.Limiter) (*service.Client, error) {
 	creds, err := buildCredential(storageAccount)
 	if err != nil {
 		return nil, err
@@ -51,7 +99,9 @@ func buildServiceClient(storageAccount StorageAccount, environment Environment)
 		return nil, fmt.Errorf("invalid account name: '%s'", storageAccount.Name)
 	}
 
-	serviceClient, err := service.NewClientWithSharedKeyCredential(serviceURL.String(), creds, nil)
+	serviceClient, err := service.NewClientWithSharedKeyCredential(serviceURL.String(), creds, &service.ClientOptions{
+		ClientOptions: clientOptions(limiter),
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -60,8 +110,13 @@ func buildServiceClient(storageAccount StorageAccount, environment Environment)
 }
 
 func NewSDKContainer(name string, storageAccount StorageAccount, environment Environment) (SDKContainer, error) {
+	return NewSDKContainerWithOptions(name, storageAccount, environment, ContainerOptions{})
+}
+
+func NewSDKContainerWithOptions(name string, storageAccount StorageAccount, environment Environment, options ContainerOptions) (SDKContainer, error) {
+	limiter := retry.NewLimiter(options.MaxRequestsPerSecond)
 
-	serviceClient, err := buildServiceClient(storageAccount, environment)
+	serviceClient, err := buildServiceClient(storageAccount, environment, limiter)
 	if err != nil {
 		return SDKContainer{}, err
 	}
@@ -73,6 +128,8 @@ func NewSDKContainer(name string, storageAccount StorageAccount, environment Env
 		containerClient: containerClient,
 		serviceClient:   serviceClient,
 		environment:     environment,
+		retryPolicy:     options.Retry,
+		limiter:         limiter,
 	}, nil
 }
 
@@ -91,7 +148,7 @@ func (c SDKContainer) CopyBlobsFromSameStorageAccount(sourceContainerName string
 }
 
 func (c SDKContainer) CopyBlobsFromDifferentStorageAccount(sourceStorageAccount StorageAccount, sourceContainerName string, blobIds []BlobId) error {
-	sourceServiceClient, err := buildServiceClient(sourceStorageAccount, c.environment)
+	sourceServiceClient, err := buildServiceClient(sourceStorageAccount, c.environment, c.limiter)
 	if err != nil {
 		return err
 	}
@@ -113,7 +170,9 @@ func (c SDKContainer) CopyBlobsFromDifferentStorageAccount(sourceStorageAccount
 		return err
 	}
 
-	sourceContainerClientWithSAS, err := container.NewClientWithNoCredential(sourceContainerSASURL, nil)
+	sourceContainerClientWithSAS, err := container.NewClientWithNoCredential(sourceContainerSASURL, &container.ClientOptions{
+		ClientOptions: clientOptions(c.limiter),
+	})
 	if err != nil {
 		return err
 	}
@@ -139,7 +198,10 @@ func (c SDKContainer) copyBlobs(sourceContainerClient *container.Client, blobIds
 		}
 
 		go func(blob *container.BlobItem) {
-			errs <- c.copyBlob(sourceContainerClient, blob)
+			var copyID string
+			errs <- c.retryPolicy.Do(isRetryable, func() error {
+				return c.copyBlob(sourceContainerClient, blob, &copyID)
+			})
 		}(sourceBlob)
 	}
 
@@ -161,13 +223,29 @@ func (c SDKContainer) copyBlobs(sourceContainerClient *container.Client, blobIds
 	return nil
 }
 
-func (c SDKContainer) copyBlob(sourceContainerClient *container.Client, blobItem *container.BlobItem) error {
+// copyBlob copies a blob and waits for the copy to finish. copyID holds the ID
+// of the copy which an earlier attempt started, if any: while that copy is
+// pending or has succeeded it is waited for rather than started again, which
+// Azure would reject as a PendingCopyOperation conflict.
+func (c SDKContainer) copyBlob(sourceContainerClient *container.Client, blobItem *container.BlobItem, copyID *string) error {
 
 	ctx := context.Background()
 
 	destinationBlobClient := c.containerClient.NewBlobClient(*blobItem.Name)
 	sourceBlobClient := sourceContainerClient.NewBlobClient(*blobItem.Name)
 
+	if *copyID != "" {
+		properties, err := destinationBlobClient.GetProperties(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if properties.CopyID != nil && *properties.CopyID == *copyID && properties.CopyStatus != nil &&
+			(*properties.CopyStatus == blob.CopyStatusTypePending || *properties.CopyStatus == blob.CopyStatusTypeSuccess) {
+			return c.waitForCopy(ctx, destinationBlobClient, sourceContainerClient, blobItem, *properties.CopyStatus)
+		}
+	}
+
 	_, err := sourceBlobClient.Undelete(ctx, nil) // UndeleteOptions
 	if err != nil {
 		return err
@@ -189,8 +267,14 @@ func (c SDKContainer) copyBlob(sourceContainerClient *container.Client, blobItem
 		return err
 	}
 
-	copyStatus := *resp.CopyStatus
+	if resp.CopyID != nil {
+		*copyID = *resp.CopyID
+	}
+
+	return c.waitForCopy(ctx, destinationBlobClient, sourceContainerClient, blobItem, *resp.CopyStatus)
+}
 
+func (c SDKContainer) waitForCopy(ctx context.Context, destinationBlobClient *blob.Client, sourceContainerClient *container.Client, blobItem *container.BlobItem, copyStatus blob.CopyStatusType) error {
 	for copyStatus == blob.CopyStatusTypePending {
 		time.Sleep(time.Second * 2)
 		getMetadata, err := destinationBlobClient.GetProperties(ctx, nil)
@@ -202,7 +286,7 @@ func (c SDKContainer) copyBlob(sourceContainerClient *container.Client, blobItem
 	}
 
 	if copyStatus != blob.CopyStatusTypeSuccess {
-		return fmt.Errorf("copy of blob '%s' from container '%s' to container '%s' failed with status '%s'", *blobItem.Name, containerURL.Sanitise(sourceContainerClient.URL()), containerURL.Sanitise(c.URL()), copyStatus)
+		return copyFailedError{message: fmt.Sprintf("copy of blob '%s' from container '%s' to container '%s' failed with status '%s'", *blobItem.Name, containerURL.Sanitise(sourceContainerClient.URL()), containerURL.Sanitise(c.URL()), copyStatus)}
 	}
 
 	return nil
@@ -219,7 +303,12 @@ func (c SDKContainer) fetchBlobs(sourceContainerClient *container.Client) (map[B
 	})
 
 	for pager.More() {
-		page, err := pager.NextPage(context.Background())
+		var page container.ListBlobsFlatResponse
+		err := c.retryPolicy.Do(isRetryable, func() error {
+			var err error
+			page, err = pager.NextPage(context.Background())
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed listing blobs in container '%s': %s", c.name, err)
 		}
@@ -233,7 +322,12 @@ func (c SDKContainer) fetchBlobs(sourceContainerClient *container.Client) (map[B
 }
 
 func (c SDKContainer) SoftDeleteEnabled() (bool, error) {
-	properties, err := c.serviceClient.GetProperties(context.Background(), nil)
+	var properties service.GetPropertiesResponse
+	err := c.retryPolicy.Do(isRetryable, func() error {
+		var err error
+		properties, err = c.serviceClient.GetProperties(context.Background(), nil)
+		return err
+	})
 	if err != nil {
 		return false, fmt.Errorf("failed fetching properties for storage account: '%s'", err)
 	}
@@ -251,7 +345,12 @@ func (c SDKContainer) ListBlobs() ([]BlobId, error) {
 	pager := c.containerClient.NewListBlobsFlatPager(nil)
 
 	for pager.More() {
-		page, err := pager.NextPage(context.Background())
+		var page container.ListBlobsFlatResponse
+		err := c.retryPolicy.Do(isRetryable, func() error {
+			var err error
+			page, err = pager.NextPage(context.Background())
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed listing blobs in container '%s': %s", c.name, err)
 		}
@@ -264,6 +363,25 @@ func (c SDKContainer) ListBlobs() ([]BlobId, error) {
 	return blobs, nil
 }
 
+// isRetryable reports whether a request to Azure failed in a way which is
+// likely to be transient: it was throttled, Azure had a server error, the
+// request did not reach Azure, or Azure failed a blob copy it had started.
+func isRetryable(err error) bool {
+	var responseError *azcore.ResponseError
+	if errors.As(err, &responseError) {
+		switch responseError.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netError net.Error
+	var copyFailed copyFailedError
+	return errors.As(err, &netError) || errors.As(err, &copyFailed) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
 func buildCredential(storageAccount StorageAccount) (*azblob.SharedKeyCredential, error) {
 	_, err := base64.StdEncoding.DecodeString(storageAccount.Key)
 	if err != nil {
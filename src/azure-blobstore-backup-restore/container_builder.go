@@ -12,13 +12,17 @@ func (c ContainerBuilder) Containers() (map[string]Container, error) {
 	var containers = make(map[string]Container)
 
 	for containerId, containerConfig := range c.config {
-		container, err := NewSDKContainer(
+		container, err := NewSDKContainerWithOptions(
 			containerConfig.Name,
 			StorageAccount{
 				Name: containerConfig.StorageAccount,
 				Key:  containerConfig.StorageKey,
 			},
 			containerConfig.Environment,
+			ContainerOptions{
+				Retry:                containerConfig.Retry,
+				MaxRequestsPerSecond: containerConfig.MaxRequestsPerSecond,
+			},
 		)
 		if err != nil {
 			return nil, err
@@ -3,6 +3,8 @@ module azure-blobstore-backup-restore
 go 1.25.0
 
 require (
+	common v0.0.0
+	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.23.0
 	github.com/Azure/azure-sdk-for-go/sdk/storage/azblob v1.8.0
 	github.com/maxbrunsfeld/counterfeiter/v6 v6.12.2
 	github.com/onsi/ginkgo/v2 v2.32.1
@@ -11,7 +13,6 @@ require (
 )
 
 require (
-	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.23.0 // indirect
 	github.com/Azure/azure-sdk-for-go/sdk/internal v1.12.0 // indirect
 	github.com/Masterminds/semver/v3 v3.5.0 // indirect
 	github.com/go-logr/logr v1.4.4 // indirect
@@ -30,3 +31,5 @@ require (
 replace system-tests => ../system-tests
 
 replace s3-blobstore-backup-restore => ../s3-blobstore-backup-restore
+
+replace common => ../common
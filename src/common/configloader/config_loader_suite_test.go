@@ -0,0 +1,13 @@
+package configloader_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestConfigLoader(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Config Loader Suite")
+}
@@ -0,0 +1,181 @@
+// Package configloader reads the config files of the backup and restore
+// tools. Config files may be JSON or YAML, may set a string to an environment
+// variable as ${NAME}, and must not contain keys the tool does not know about.
+package configloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// referencePattern matches a string which is wholly a reference to an
+// environment variable, ${NAME}, or such a reference escaped with more dollars.
+var referencePattern = regexp.MustCompile(`^\$(\$*)\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// Load reads the config file at path and decodes it into target, failing if
+// the file has keys which target has no field for.
+func Load(path string, target interface{}) error {
+	contents, err := Read(path)
+	if err != nil {
+		return err
+	}
+
+	return Unmarshal(contents, target)
+}
+
+// Read reads the config file at path and returns it as JSON, with the
+// environment variable references replaced by their values. Files ending in
+// .yml or .yaml are parsed as YAML, and all others as JSON.
+func Read(path string) ([]byte, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		contents, err = yamlToJSON(contents)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		// Malformed JSON is returned as it is, for Unmarshal to report as a
+		// config which cannot be parsed rather than one which cannot be read.
+		if !json.Valid(contents) {
+			return contents, nil
+		}
+	}
+
+	return Interpolate(contents)
+}
+
+// Interpolate replaces each string in the JSON config contents which is wholly
+// ${NAME} with the value of the environment variable NAME, failing if any of
+// them are not set. The value is always a string, however it looks, and
+// strings which merely contain ${ are left as they are. A string which is
+// wholly $${NAME} is left as the literal ${NAME}.
+func Interpolate(contents []byte) ([]byte, error) {
+	// json.Unmarshal reports malformed config, such as an empty file or
+	// content after the end of the config, more clearly than a json.Decoder.
+	var document interface{}
+	if err := json.Unmarshal(contents, &document); err != nil {
+		return nil, err
+	}
+
+	// The config is decoded again keeping its numbers as they were written, so
+	// that large integers are not rounded when it is encoded.
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	document = interpolateValue(document, &missing)
+	if len(missing) != 0 {
+		return nil, fmt.Errorf("config refers to environment variables which are not set: %s", strings.Join(unique(missing), ", "))
+	}
+
+	return json.Marshal(document)
+}
+
+func interpolateValue(value interface{}, missing *[]string) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, element := range typed {
+			typed[key] = interpolateValue(element, missing)
+		}
+		return typed
+	case []interface{}:
+		for i, element := range typed {
+			typed[i] = interpolateValue(element, missing)
+		}
+		return typed
+	case string:
+		reference := referencePattern.FindStringSubmatch(typed)
+		if reference == nil {
+			return typed
+		}
+		if reference[1] != "" {
+			return typed[1:]
+		}
+
+		environmentValue, ok := os.LookupEnv(reference[2])
+		if !ok {
+			*missing = append(*missing, reference[2])
+		}
+		return environmentValue
+	default:
+		return value
+	}
+}
+
+// Unmarshal decodes JSON config into target, failing if it has keys which
+// target has no field for.
+func Unmarshal(contents []byte, target interface{}) error {
+	// json.Unmarshal reports malformed config, such as an empty file or
+	// content after the end of the config, more clearly than a json.Decoder.
+	var document interface{}
+	if err := json.Unmarshal(contents, &document); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(target)
+}
+
+func yamlToJSON(contents []byte) ([]byte, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(convertYAMLValue(document))
+}
+
+// convertYAMLValue turns the maps with non-string keys which the YAML decoder
+// can produce into maps that JSON can represent.
+func convertYAMLValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, element := range typed {
+			typed[key] = convertYAMLValue(element)
+		}
+		return typed
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, element := range typed {
+			converted[fmt.Sprint(key)] = convertYAMLValue(element)
+		}
+		return converted
+	case []interface{}:
+		for i, element := range typed {
+			typed[i] = convertYAMLValue(element)
+		}
+		return typed
+	default:
+		return value
+	}
+}
+
+func unique(values []string) []string {
+	seen := map[string]bool{}
+	var uniqueValues []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			uniqueValues = append(uniqueValues, value)
+		}
+	}
+	sort.Strings(uniqueValues)
+	return uniqueValues
+}
@@ -0,0 +1,192 @@
+package configloader_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"common/configloader"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type bucketConfig struct {
+	Name           string `json:"name"`
+	Region         string `json:"region"`
+	SecretKey      string `json:"secret_key"`
+	ForcePathStyle bool   `json:"force_path_style"`
+	Port           int    `json:"port"`
+}
+
+var _ = Describe("Load", func() {
+	var configDirectory string
+
+	BeforeEach(func() {
+		var err error
+		configDirectory, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		os.Setenv("CONFIG_LOADER_TEST_SECRET", "s3cr3t")
+		os.Setenv("CONFIG_LOADER_TEST_PORT", "9000")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(configDirectory)
+		os.Unsetenv("CONFIG_LOADER_TEST_SECRET")
+		os.Unsetenv("CONFIG_LOADER_TEST_PORT")
+	})
+
+	writeConfig := func(name, contents string) string {
+		path := filepath.Join(configDirectory, name)
+		Expect(os.WriteFile(path, []byte(contents), 0600)).To(Succeed())
+		return path
+	}
+
+	It("loads JSON", func() {
+		path := writeConfig("buckets.json", `{"droplets": {"name": "droplets", "region": "eu-west-1", "force_path_style": true}}`)
+
+		var config map[string]bucketConfig
+		Expect(configloader.Load(path, &config)).To(Succeed())
+		Expect(config).To(Equal(map[string]bucketConfig{
+			"droplets": {Name: "droplets", Region: "eu-west-1", ForcePathStyle: true},
+		}))
+	})
+
+	It("loads YAML", func() {
+		path := writeConfig("buckets.yml", `
+droplets:
+  name: droplets
+  region: eu-west-1
+  force_path_style: true
+  port: 443
+`)
+
+		var config map[string]bucketConfig
+		Expect(configloader.Load(path, &config)).To(Succeed())
+		Expect(config).To(Equal(map[string]bucketConfig{
+			"droplets": {Name: "droplets", Region: "eu-west-1", ForcePathStyle: true, Port: 443},
+		}))
+	})
+
+	It("replaces strings which refer to environment variables", func() {
+		path := writeConfig("buckets.yaml", `
+droplets:
+  name: droplets
+  secret_key: ${CONFIG_LOADER_TEST_SECRET}
+  region: $${NOT_A_REFERENCE}
+  port: 443
+`)
+
+		var config map[string]bucketConfig
+		Expect(configloader.Load(path, &config)).To(Succeed())
+		Expect(config["droplets"].SecretKey).To(Equal("s3cr3t"))
+		Expect(config["droplets"].Region).To(Equal("${NOT_A_REFERENCE}"))
+		Expect(config["droplets"].Port).To(Equal(443))
+	})
+
+	It("leaves strings which only contain a reference as they are", func() {
+		path := writeConfig("buckets.json", `{"droplets": {"name": "a-${CONFIG_LOADER_TEST_SECRET}", "secret_key": "p4$${x}"}}`)
+
+		var config map[string]bucketConfig
+		Expect(configloader.Load(path, &config)).To(Succeed())
+		Expect(config["droplets"].Name).To(Equal("a-${CONFIG_LOADER_TEST_SECRET}"))
+		Expect(config["droplets"].SecretKey).To(Equal("p4$${x}"))
+	})
+
+	It("keeps a referenced value as a string, however it looks", func() {
+		path := writeConfig("buckets.yml", `
+droplets:
+  name: ${CONFIG_LOADER_TEST_SECRET}
+  region: ${CONFIG_LOADER_TEST_PORT}
+`)
+		os.Setenv("CONFIG_LOADER_TEST_SECRET", "yes")
+		os.Setenv("CONFIG_LOADER_TEST_PORT", "0123")
+
+		var config map[string]bucketConfig
+		Expect(configloader.Load(path, &config)).To(Succeed())
+		Expect(config["droplets"].Name).To(Equal("yes"))
+		Expect(config["droplets"].Region).To(Equal("0123"))
+	})
+
+	It("does not let a referenced number set a number", func() {
+		path := writeConfig("buckets.yml", "droplets:\n  port: ${CONFIG_LOADER_TEST_PORT}\n")
+
+		var config map[string]bucketConfig
+		Expect(configloader.Load(path, &config)).To(MatchError(ContainSubstring("cannot unmarshal string")))
+	})
+
+	DescribeTable("inserts referenced values with special characters unchanged",
+		func(name, contents string) {
+			secret := "a \"quoted\", back\\slashed\nmulti-line: value, \"injected_key\": true}"
+			os.Setenv("CONFIG_LOADER_TEST_SECRET", secret)
+			path := writeConfig(name, contents)
+
+			var config map[string]bucketConfig
+			Expect(configloader.Load(path, &config)).To(Succeed())
+			Expect(config).To(Equal(map[string]bucketConfig{"droplets": {SecretKey: secret}}))
+		},
+		Entry("in JSON", "buckets.json", `{"droplets": {"secret_key": "${CONFIG_LOADER_TEST_SECRET}"}}`),
+		Entry("in YAML", "buckets.yml", "droplets:\n  secret_key: ${CONFIG_LOADER_TEST_SECRET}\n"),
+	)
+
+	It("keeps large integers as they were written", func() {
+		path := writeConfig("sizes.json", `{"size": 9007199254740993}`)
+
+		var config map[string]int64
+		Expect(configloader.Load(path, &config)).To(Succeed())
+		Expect(config["size"]).To(Equal(int64(9007199254740993)))
+	})
+
+	It("fails when a referenced environment variable is not set", func() {
+		path := writeConfig("buckets.json", `{"droplets": {"secret_key": "${CONFIG_LOADER_TEST_MISSING}"}}`)
+
+		var config map[string]bucketConfig
+		err := configloader.Load(path, &config)
+		Expect(err).To(MatchError(ContainSubstring("CONFIG_LOADER_TEST_MISSING")))
+	})
+
+	It("fails when the config has an unknown key", func() {
+		path := writeConfig("buckets.json", `{"droplets": {"name": "droplets", "force_pathstyle": true}}`)
+
+		var config map[string]bucketConfig
+		err := configloader.Load(path, &config)
+		Expect(err).To(MatchError(ContainSubstring(`unknown field "force_pathstyle"`)))
+	})
+
+	It("fails when a YAML config has an unknown key", func() {
+		path := writeConfig("buckets.yml", "droplets:\n  force_pathstyle: true\n")
+
+		var config map[string]bucketConfig
+		err := configloader.Load(path, &config)
+		Expect(err).To(MatchError(ContainSubstring(`unknown field "force_pathstyle"`)))
+	})
+
+	It("fails when the config is not valid", func() {
+		path := writeConfig("buckets.yml", "droplets: [")
+
+		var config map[string]bucketConfig
+		Expect(configloader.Load(path, &config)).NotTo(Succeed())
+	})
+
+	It("fails when the JSON config is empty", func() {
+		path := writeConfig("buckets.json", "")
+
+		var config map[string]bucketConfig
+		err := configloader.Load(path, &config)
+		Expect(err).To(MatchError(ContainSubstring("unexpected end of JSON input")))
+	})
+
+	It("fails when there is content after the end of the JSON config", func() {
+		path := writeConfig("buckets.json", `{"droplets": {"name": "a-bucket"}} {}`)
+
+		var config map[string]bucketConfig
+		err := configloader.Load(path, &config)
+		Expect(err).To(MatchError(ContainSubstring("after top-level value")))
+	})
+
+	It("fails when the config file does not exist", func() {
+		var config map[string]bucketConfig
+		err := configloader.Load(filepath.Join(configDirectory, "missing.json"), &config)
+		Expect(err).To(MatchError(ContainSubstring("no such file")))
+	})
+})
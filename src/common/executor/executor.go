@@ -0,0 +1,15 @@
+// Package executor runs the blob copies of the blobstore backup and restore
+// tools.
+package executor
+
+// DefaultMaxInFlight is the number of executables a ParallelExecutor runs at
+// once when it is not given a positive limit.
+const DefaultMaxInFlight = 200
+
+type Executor interface {
+	Run([][]Executable) []error
+}
+
+type Executable interface {
+	Execute() error
+}
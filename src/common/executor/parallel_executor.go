@@ -0,0 +1,47 @@
+package executor
+
+// ParallelExecutor runs each group of executables in turn, running the
+// executables within a group concurrently with no more than maxInFlight of
+// them at once.
+type ParallelExecutor struct {
+	maxInFlight int
+}
+
+// NewParallelExecutor builds a ParallelExecutor which runs at most
+// maxInFlight executables at once, or DefaultMaxInFlight when maxInFlight is
+// not positive.
+func NewParallelExecutor(maxInFlight int) ParallelExecutor {
+	if maxInFlight < 1 {
+		maxInFlight = DefaultMaxInFlight
+	}
+
+	return ParallelExecutor{maxInFlight: maxInFlight}
+}
+
+func (e ParallelExecutor) MaxInFlight() int {
+	return e.maxInFlight
+}
+
+func (e ParallelExecutor) Run(executablesList [][]Executable) []error {
+	var errors []error
+	for _, executables := range executablesList {
+		guard := make(chan struct{}, e.maxInFlight)
+		errs := make(chan error, len(executables))
+
+		for _, executable := range executables {
+			guard <- struct{}{}
+			go func(executable Executable) {
+				defer func() { <-guard }()
+				errs <- executable.Execute()
+			}(executable)
+		}
+
+		for range executables {
+			if err := <-errs; err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	return errors
+}
@@ -0,0 +1,95 @@
+package executor_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"common/executor"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type executableFunc func() error
+
+func (f executableFunc) Execute() error {
+	return f()
+}
+
+var _ = Describe("ParallelExecutor", func() {
+	var inFlight, maxObservedInFlight, executed int32
+
+	trackingExecutable := func(err error) executor.Executable {
+		return executableFunc(func() error {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+
+			for {
+				observed := atomic.LoadInt32(&maxObservedInFlight)
+				if current <= observed || atomic.CompareAndSwapInt32(&maxObservedInFlight, observed, current) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			atomic.AddInt32(&executed, 1)
+			return err
+		})
+	}
+
+	trackingExecutables := func(count int) []executor.Executable {
+		var executables []executor.Executable
+		for i := 0; i < count; i++ {
+			executables = append(executables, trackingExecutable(nil))
+		}
+		return executables
+	}
+
+	BeforeEach(func() {
+		inFlight, maxObservedInFlight, executed = 0, 0, 0
+	})
+
+	It("runs no more than max in flight executables at once", func() {
+		errs := executor.NewParallelExecutor(3).Run([][]executor.Executable{trackingExecutables(30)})
+
+		Expect(errs).To(BeEmpty())
+		Expect(executed).To(Equal(int32(30)))
+		Expect(maxObservedInFlight).To(BeNumerically("<=", 3))
+		Expect(maxObservedInFlight).To(BeNumerically(">", 1))
+	})
+
+	It("runs more than the previous limit of 10 executables at once when allowed to", func() {
+		errs := executor.NewParallelExecutor(50).Run([][]executor.Executable{trackingExecutables(50)})
+
+		Expect(errs).To(BeEmpty())
+		Expect(maxObservedInFlight).To(BeNumerically(">", 10))
+	})
+
+	It("runs each group of executables after the previous one", func() {
+		errs := executor.NewParallelExecutor(10).Run([][]executor.Executable{
+			trackingExecutables(5),
+			trackingExecutables(5),
+		})
+
+		Expect(errs).To(BeEmpty())
+		Expect(executed).To(Equal(int32(10)))
+		Expect(maxObservedInFlight).To(BeNumerically("<=", 5))
+	})
+
+	It("runs every executable and returns the errors of those which fail", func() {
+		errs := executor.NewParallelExecutor(2).Run([][]executor.Executable{{
+			trackingExecutable(errors.New("first failure")),
+			trackingExecutable(nil),
+			trackingExecutable(errors.New("second failure")),
+		}})
+
+		Expect(executed).To(Equal(int32(3)))
+		Expect(errs).To(ConsistOf(MatchError("first failure"), MatchError("second failure")))
+	})
+
+	It("uses the default max in flight when the limit is not positive", func() {
+		Expect(executor.NewParallelExecutor(0).MaxInFlight()).To(Equal(executor.DefaultMaxInFlight))
+		Expect(executor.NewParallelExecutor(-1).MaxInFlight()).To(Equal(executor.DefaultMaxInFlight))
+		Expect(executor.NewParallelExecutor(7).MaxInFlight()).To(Equal(7))
+	})
+})
@@ -0,0 +1,52 @@
+package retry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limiter spaces requests out evenly so that no more than a given number are
+// made each second. A nil Limiter does not limit requests.
+type Limiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewLimiter returns a Limiter allowing requestsPerSecond requests each
+// second, or nil when requestsPerSecond is zero.
+func NewLimiter(requestsPerSecond float64) *Limiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+
+	return &Limiter{interval: time.Duration(float64(time.Second) / requestsPerSecond)}
+}
+
+// ValidateRequestsPerSecond checks a max_requests_per_second config value,
+// where zero means requests are not limited.
+func ValidateRequestsPerSecond(requestsPerSecond float64) error {
+	if requestsPerSecond < 0 {
+		return fmt.Errorf("max_requests_per_second must not be negative")
+	}
+	return nil
+}
+
+// Wait blocks until the next request may be made.
+func (l *Limiter) Wait() {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mutex.Unlock()
+
+	time.Sleep(wait)
+}
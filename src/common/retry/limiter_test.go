@@ -0,0 +1,46 @@
+package retry_test
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"common/retry"
+)
+
+var _ = Describe("Limiter", func() {
+	It("spaces requests out to the given rate", func() {
+		limiter := retry.NewLimiter(50)
+
+		start := time.Now()
+		var waitGroup sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				limiter.Wait()
+			}()
+		}
+		waitGroup.Wait()
+
+		Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+	})
+
+	It("does not limit requests when the rate is zero", func() {
+		limiter := retry.NewLimiter(0)
+		Expect(limiter).To(BeNil())
+
+		start := time.Now()
+		for i := 0; i < 1000; i++ {
+			limiter.Wait()
+		}
+		Expect(time.Since(start)).To(BeNumerically("<", 100*time.Millisecond))
+	})
+
+	It("rejects a negative rate", func() {
+		Expect(retry.ValidateRequestsPerSecond(-1)).To(MatchError("max_requests_per_second must not be negative"))
+		Expect(retry.ValidateRequestsPerSecond(0)).To(Succeed())
+	})
+})
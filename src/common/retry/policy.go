@@ -0,0 +1,101 @@
+// Package retry retries the requests which the blobstore backup and restore
+// tools make, and limits how often they make them, so that transient errors
+// and throttling do not fail a whole backup or restore.
+package retry
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 5
+	DefaultBaseDelayMS = 500
+	DefaultJitter      = 0.5
+
+	// MaxDelay caps the delay between two attempts, however many attempts
+	// have failed.
+	MaxDelay = 30 * time.Second
+)
+
+// Policy controls how failed requests are retried. Zero values mean the
+// defaults.
+type Policy struct {
+	// MaxAttempts is the number of times a request is made before its error
+	// is returned, including the first.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+	// BaseDelayMS is the delay after the first failed attempt, which doubles
+	// after each further failed attempt.
+	BaseDelayMS int `json:"base_delay_ms,omitempty"`
+	// Jitter is the fraction of each delay, between 0 and 1, by which it is
+	// randomly shortened so that concurrent requests do not retry in step.
+	Jitter *float64 `json:"jitter,omitempty"`
+}
+
+func (p Policy) Validate() error {
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("retry.max_attempts must be at least 1")
+	}
+
+	if p.BaseDelayMS < 0 {
+		return fmt.Errorf("retry.base_delay_ms must be at least 1")
+	}
+
+	if p.Jitter != nil && (*p.Jitter < 0 || *p.Jitter > 1) {
+		return fmt.Errorf("retry.jitter must be between 0 and 1")
+	}
+
+	return nil
+}
+
+func (p Policy) Attempts() int {
+	if p.MaxAttempts == 0 {
+		return DefaultMaxAttempts
+	}
+	return p.MaxAttempts
+}
+
+// Delay returns how long to wait after the given attempt, counting from 1,
+// fails before making the next one.
+func (p Policy) Delay(attempt int) time.Duration {
+	baseDelayMS := p.BaseDelayMS
+	if baseDelayMS == 0 {
+		baseDelayMS = DefaultBaseDelayMS
+	}
+
+	delay := time.Duration(baseDelayMS) * time.Millisecond
+	for i := 1; i < attempt && delay < MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > MaxDelay {
+		delay = MaxDelay
+	}
+
+	return delay - time.Duration(p.jitter()*rand.Float64()*float64(delay))
+}
+
+// Do calls operation until it succeeds, fails with an error which isRetryable
+// rejects, or has been attempted Attempts times, and returns its last error.
+func (p Policy) Do(isRetryable func(error) bool, operation func() error) error {
+	var err error
+	for attempt := 1; attempt <= p.Attempts(); attempt++ {
+		if attempt > 1 {
+			time.Sleep(p.Delay(attempt - 1))
+		}
+
+		err = operation()
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (p Policy) jitter() float64 {
+	if p.Jitter == nil {
+		return DefaultJitter
+	}
+	return *p.Jitter
+}
@@ -0,0 +1,123 @@
+package retry_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"common/retry"
+)
+
+var _ = Describe("Policy", func() {
+	noJitter := 0.0
+	halfJitter := 0.5
+
+	Describe("Delay", func() {
+		It("doubles the base delay after each failed attempt", func() {
+			policy := retry.Policy{BaseDelayMS: 100, Jitter: &noJitter}
+
+			Expect(policy.Delay(1)).To(Equal(100 * time.Millisecond))
+			Expect(policy.Delay(2)).To(Equal(200 * time.Millisecond))
+			Expect(policy.Delay(4)).To(Equal(800 * time.Millisecond))
+		})
+
+		It("uses the default base delay", func() {
+			Expect(retry.Policy{Jitter: &noJitter}.Delay(1)).To(Equal(retry.DefaultBaseDelayMS * time.Millisecond))
+		})
+
+		It("never waits longer than the max delay", func() {
+			policy := retry.Policy{BaseDelayMS: 1000, Jitter: &noJitter}
+
+			Expect(policy.Delay(6)).To(Equal(retry.MaxDelay))
+			Expect(policy.Delay(1000)).To(Equal(retry.MaxDelay))
+		})
+
+		It("shortens the delay by up to the jitter fraction of it", func() {
+			policy := retry.Policy{BaseDelayMS: 1000, Jitter: &halfJitter}
+
+			for i := 0; i < 100; i++ {
+				Expect(policy.Delay(1)).To(BeNumerically(">", 500*time.Millisecond))
+				Expect(policy.Delay(1)).To(BeNumerically("<=", time.Second))
+			}
+		})
+	})
+
+	Describe("Do", func() {
+		var policy retry.Policy
+		var attempts int
+		var transientErr, permanentErr error
+		isRetryable := func(err error) bool {
+			return err == transientErr
+		}
+
+		BeforeEach(func() {
+			policy = retry.Policy{MaxAttempts: 3, BaseDelayMS: 1}
+			attempts = 0
+			transientErr = errors.New("slow down")
+			permanentErr = errors.New("access denied")
+		})
+
+		It("retries retryable errors until the operation succeeds", func() {
+			err := policy.Do(isRetryable, func() error {
+				attempts++
+				if attempts < 3 {
+					return transientErr
+				}
+				return nil
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attempts).To(Equal(3))
+		})
+
+		It("returns the last error after the max attempts", func() {
+			err := policy.Do(isRetryable, func() error {
+				attempts++
+				return transientErr
+			})
+
+			Expect(err).To(Equal(transientErr))
+			Expect(attempts).To(Equal(3))
+		})
+
+		It("does not retry errors which are not retryable", func() {
+			err := policy.Do(isRetryable, func() error {
+				attempts++
+				return permanentErr
+			})
+
+			Expect(err).To(Equal(permanentErr))
+			Expect(attempts).To(Equal(1))
+		})
+
+		It("makes the default number of attempts", func() {
+			policy.MaxAttempts = 0
+
+			policy.Do(isRetryable, func() error {
+				attempts++
+				return transientErr
+			})
+
+			Expect(attempts).To(Equal(retry.DefaultMaxAttempts))
+		})
+	})
+
+	DescribeTable("Validate",
+		func(policy retry.Policy, expectedError string) {
+			err := policy.Validate()
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("the defaults", retry.Policy{}, ""),
+		Entry("a valid policy", retry.Policy{MaxAttempts: 10, BaseDelayMS: 100, Jitter: &halfJitter}, ""),
+		Entry("no jitter", retry.Policy{Jitter: &noJitter}, ""),
+		Entry("negative max attempts", retry.Policy{MaxAttempts: -1}, "retry.max_attempts must be at least 1"),
+		Entry("a negative base delay", retry.Policy{BaseDelayMS: -1}, "retry.base_delay_ms must be at least 1"),
+		Entry("a jitter above 1", retry.Policy{Jitter: func() *float64 { j := 1.5; return &j }()}, "retry.jitter must be between 0 and 1"),
+	)
+})
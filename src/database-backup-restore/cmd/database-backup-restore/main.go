@@ -17,61 +17,158 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"database-backup-restore/config"
-	"database-backup-restore/database"
-	"database-backup-restore/mysql"
-	"database-backup-restore/postgres"
+	"database-backup-restore/events"
+	"database-backup-restore/sdk"
 )
 
 func main() {
 	flags, err := config.ParseFlags()
 	if err != nil {
 		log.Fatalf("%s\nUsage: database-backup-restorer [--backup|--restore] --config <config-file> "+
-			"--artifact-file <artifact-file>\n", err)
+			"[--artifact-file <artifact-file>|--artifact-dir <artifact-dir>] [--dry-run] [--log-format text|json] [--temp-dir <temp-dir>]\n", err)
 	}
 
-	connectionConfig, err := config.ParseAndValidateConnectionConfig(flags.ConfigPath)
+	databasesConfig, err := config.ParseAndValidateDatabasesConfig(flags.ConfigPath)
 	if err != nil {
 		log.Fatalf("%v", err)
 	}
 
-	utilitiesConfig := config.GetUtilitiesConfigFromEnv()
-
-	tempFolderManager, err := config.NewTempFolderManager()
-	if err != nil {
+	if err := flags.ValidateArtifactLocation(databasesConfig); err != nil {
 		log.Fatalf("%v", err)
 	}
-	defer tempFolderManager.Cleanup()
 
-	interactor, err := makeInteractor(flags.IsRestore, utilitiesConfig, connectionConfig, tempFolderManager)
-	if err != nil {
-		log.Fatalf("%v", err)
+	options := sdk.Options{
+		Utilities:     config.GetUtilitiesConfigFromEnv(),
+		TempDirectory: flags.TempDirectory,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if flags.LogFormat == config.LogFormatJSON {
+		sink := events.NewJSONSink(os.Stdout)
+		log.SetFlags(0)
+		log.SetOutput(events.NewLogWriter(sink))
+		ctx = events.WithSink(ctx, sink)
+	}
+
+	if databasesConfig.IsMultiple {
+		runMultiple(ctx, flags, databasesConfig, options)
+		return
+	}
+
+	connectionConfig := databasesConfig.Databases[0].ConnectionConfig
+
+	if flags.IsDryRun {
+		err = printPlan(ctx, flags.IsRestore, connectionConfig, flags.ArtifactFilePath, options)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
+	if flags.IsRestore {
+		err = sdk.RestoreFile(ctx, connectionConfig, flags.ArtifactFilePath, options)
+	} else {
+		err = sdk.BackupFile(ctx, connectionConfig, flags.ArtifactFilePath, options)
 	}
 
-	err = interactor.Action(flags.ArtifactFilePath)
 	if err != nil {
-		log.Fatalf(
-			"You may need to delete the artifact-file that was created before re-running.\n%s\n", err)
+		log.Fatalf("%s\n", err)
 	}
 }
 
-func makeInteractor(isRestoreAction bool, utilitiesConfig config.UtilitiesConfig,
-	connectionConfig config.ConnectionConfig, tempFolderManager config.TempFolderManager) (database.Interactor, error) {
+func runMultiple(ctx context.Context, flags config.CommandFlags, databasesConfig config.DatabasesConfig, options sdk.Options) {
+	if flags.IsDryRun {
+		for _, database := range databasesConfig.Databases {
+			if flags.LogFormat == config.LogFormatText {
+				fmt.Printf("Database %s:\n", database.ID)
+			}
+			artifactFilePath := filepath.Join(flags.ArtifactDirectory, database.ID)
+			if err := printPlan(events.WithDatabase(ctx, database.ID), flags.IsRestore, database.ConnectionConfig, artifactFilePath, options); err != nil {
+				log.Fatalf("%s: %v", database.ID, err)
+			}
+		}
+		return
+	}
+
+	var results []sdk.DatabaseResult
+	if flags.IsRestore {
+		results = sdk.RestoreAll(ctx, databasesConfig.Databases, flags.ArtifactDirectory, databasesConfig.Concurrency, options)
+	} else {
+		if err := os.MkdirAll(flags.ArtifactDirectory, 0700); err != nil {
+			log.Fatalf("%s\n", err)
+		}
+		results = sdk.BackupAll(ctx, databasesConfig.Databases, flags.ArtifactDirectory, databasesConfig.Concurrency, options)
+	}
+
+	if flags.LogFormat == config.LogFormatText {
+		printSummary(flags.IsRestore, results)
+	}
 
-	postgresServerVersionDetector := postgres.NewServerVersionDetector(utilitiesConfig.Postgres13.Client)
-	mysqlServerVersionDetector := mysql.NewServerVersionDetector(utilitiesConfig.Mysql80.Client)
-	interactorFactory := database.NewInteractorFactory(utilitiesConfig, postgresServerVersionDetector, mysqlServerVersionDetector, tempFolderManager)
-	return interactorFactory.Make(actionLabel(isRestoreAction), connectionConfig)
+	failures := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failures++
+		}
+	}
+	if failures > 0 {
+		log.Fatalf("%d of %d database %ss failed\n", failures, len(results), actionLabel(flags.IsRestore))
+	}
 }
 
-func actionLabel(isRestoreAction bool) database.Action {
-	var action database.Action
+func printSummary(isRestoreAction bool, results []sdk.DatabaseResult) {
+	fmt.Printf("Summary of the %s:\n", actionLabel(isRestoreAction))
+	for _, result := range results {
+		duration := result.Duration.Round(time.Millisecond)
+		if result.Err != nil {
+			fmt.Printf("  %s: failed after %s: %s\n", result.ID, duration, strings.TrimSpace(result.Err.Error()))
+		} else {
+			fmt.Printf("  %s: succeeded in %s\n", result.ID, duration)
+		}
+	}
+}
+
+// printPlan prints the steps of the backup or restore, or emits them as a plan
+// event when events are printed instead of text.
+func printPlan(ctx context.Context, isRestoreAction bool, connectionConfig config.ConnectionConfig, artifactFilePath string, options sdk.Options) error {
+	var plan []string
+	var err error
 	if isRestoreAction {
-		action = "restore"
+		plan, err = sdk.PlanRestore(ctx, connectionConfig, artifactFilePath, options)
 	} else {
-		action = "backup"
+		plan, err = sdk.PlanBackup(ctx, connectionConfig, artifactFilePath, options)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, ok := events.SinkFrom(ctx); ok {
+		events.Emit(ctx, events.Event{Event: events.Plan, Phase: actionLabel(isRestoreAction), Steps: plan})
+		return nil
+	}
+
+	fmt.Printf("Dry run: the %s would run the following steps\n", actionLabel(isRestoreAction))
+	for _, step := range plan {
+		fmt.Println(step)
+	}
+	return nil
+}
+
+func actionLabel(isRestoreAction bool) string {
+	if isRestoreAction {
+		return "restore"
 	}
-	return action
+	return "backup"
 }
@@ -1,22 +1,50 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"regexp"
+
+	"common/configloader"
 )
 
 type ConnectionConfig struct {
-	Username string     `json:"username"`
-	Password string     `json:"password"`
-	Port     int        `json:"port"`
-	Adapter  string     `json:"adapter"`
-	Host     string     `json:"host"`
-	Database string     `json:"database"`
-	Tables   []string   `json:"tables"`
-	Tls      *TlsConfig `json:"tls"`
+	Username string        `json:"username"`
+	Password string        `json:"password"`
+	Port     int           `json:"port"`
+	Adapter  string        `json:"adapter"`
+	Host     string        `json:"host"`
+	Database string        `json:"database"`
+	Tables   []string      `json:"tables"`
+	Tls      *TlsConfig    `json:"tls"`
+	Restore  RestoreConfig `json:"restore"`
+
+	CheckDiskSpace bool `json:"check_disk_space"`
+}
+
+type RestoreConfig struct {
+	Mode           string             `json:"mode"`
+	SafetyDumpPath string             `json:"safety_dump_path"`
+	MySQL          MySQLRestoreConfig `json:"mysql"`
 }
 
+// MySQLRestoreConfig holds the settings which trade the safety checks of a
+// MySQL restore session for speed. They are all off by default.
+type MySQLRestoreConfig struct {
+	DisableForeignKeyChecks bool   `json:"disable_foreign_key_checks"`
+	DisableUniqueChecks     bool   `json:"disable_unique_checks"`
+	DisableBinaryLog        bool   `json:"disable_binary_log"`
+	MaxAllowedPacket        string `json:"max_allowed_packet"`
+	InitCommand             string `json:"init_command"`
+	RecreateDatabase        bool   `json:"recreate_database"`
+}
+
+const (
+	RestoreModeClean      = ""
+	RestoreModeDataOnly   = "data-only"
+	RestoreModeSchemaOnly = "schema-only"
+	RestoreModeNoClean    = "no-clean"
+)
+
 type TlsConfig struct {
 	SkipHostVerify bool          `json:"skip_host_verify"`
 	Cert           CertTlsConfig `json:"cert"`
@@ -29,39 +57,77 @@ type CertTlsConfig struct {
 }
 
 func ParseAndValidateConnectionConfig(configPath string) (ConnectionConfig, error) {
-	configString, err := os.ReadFile(configPath)
+	configString, err := configloader.Read(configPath)
 	if err != nil {
 		return ConnectionConfig{}, fmt.Errorf("Fail reading config file: %s\n", err)
 	}
 
 	var connectionConfig ConnectionConfig
-	if err := json.Unmarshal(configString, &connectionConfig); err != nil {
+	if err := configloader.Unmarshal(configString, &connectionConfig); err != nil {
 		return ConnectionConfig{}, fmt.Errorf("Could not parse config json: %s\n", err)
 	}
 
-	if !isSupported(connectionConfig.Adapter) {
-		return ConnectionConfig{}, fmt.Errorf("Unsupported adapter %s\n", connectionConfig.Adapter)
+	if err := connectionConfig.Validate(); err != nil {
+		return ConnectionConfig{}, err
 	}
 
-	if connectionConfig.Tables != nil && len(connectionConfig.Tables) == 0 {
-		return ConnectionConfig{}, fmt.Errorf("Tables specified but empty\n")
+	return connectionConfig, nil
+}
+
+func (c ConnectionConfig) Validate() error {
+	if !isSupported(c.Adapter) {
+		return fmt.Errorf("Unsupported adapter %s\n", c.Adapter)
 	}
 
-	if connectionConfig.Tls != nil {
-		if connectionConfig.Tls.Cert.Ca == "" {
-			return ConnectionConfig{}, fmt.Errorf("TLS block specified without tls.cert.ca\n")
+	if c.Tables != nil && len(c.Tables) == 0 {
+		return fmt.Errorf("Tables specified but empty\n")
+	}
+
+	if c.Tls != nil {
+		if c.Tls.Cert.Ca == "" {
+			return fmt.Errorf("TLS block specified without tls.cert.ca\n")
 		}
 
-		if connectionConfig.Tls.Cert.Certificate != "" && connectionConfig.Tls.Cert.PrivateKey == "" {
-			return ConnectionConfig{}, fmt.Errorf("tls.cert.certificate specified but not tls.cert.private_key\n")
+		if c.Tls.Cert.Certificate != "" && c.Tls.Cert.PrivateKey == "" {
+			return fmt.Errorf("tls.cert.certificate specified but not tls.cert.private_key\n")
 		}
 
-		if connectionConfig.Tls.Cert.Certificate == "" && connectionConfig.Tls.Cert.PrivateKey != "" {
-			return ConnectionConfig{}, fmt.Errorf("tls.cert.private_key specified but not tls.cert.certificate\n")
+		if c.Tls.Cert.Certificate == "" && c.Tls.Cert.PrivateKey != "" {
+			return fmt.Errorf("tls.cert.private_key specified but not tls.cert.certificate\n")
 		}
 	}
 
-	return connectionConfig, nil
+	if !isSupportedRestoreMode(c.Restore.Mode) {
+		return fmt.Errorf("Unsupported restore.mode %s\n", c.Restore.Mode)
+	}
+
+	if c.Restore.MySQL != (MySQLRestoreConfig{}) && c.Adapter != "mysql" {
+		return fmt.Errorf("restore.mysql specified for a %s database\n", c.Adapter)
+	}
+
+	if c.Restore.MySQL.MaxAllowedPacket != "" && !maxAllowedPacketPattern.MatchString(c.Restore.MySQL.MaxAllowedPacket) {
+		return fmt.Errorf("Invalid restore.mysql.max_allowed_packet %s: must be a number of bytes, optionally followed by K, M or G\n",
+			c.Restore.MySQL.MaxAllowedPacket)
+	}
+
+	if c.Restore.MySQL.RecreateDatabase && (c.Tables != nil || c.Restore.Mode != RestoreModeClean) {
+		return fmt.Errorf("restore.mysql.recreate_database cannot be used with tables or a restore.mode\n")
+	}
+
+	return nil
+}
+
+var maxAllowedPacketPattern = regexp.MustCompile(`^[0-9]+[KMG]?$`)
+
+var supportedRestoreModes = []string{RestoreModeClean, RestoreModeDataOnly, RestoreModeSchemaOnly, RestoreModeNoClean}
+
+func isSupportedRestoreMode(mode string) bool {
+	for _, el := range supportedRestoreModes {
+		if el == mode {
+			return true
+		}
+	}
+	return false
 }
 
 var supportedAdapters = []string{"postgres", "mysql"}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"common/configloader"
+)
+
+const DefaultConcurrency = 4
+
+// DatabasesConfig holds every database named in a config file. A config file
+// holding a single connection config parses to one database with an empty ID
+// and IsMultiple set to false.
+type DatabasesConfig struct {
+	Concurrency int
+	Databases   []NamedConnectionConfig
+	IsMultiple  bool
+}
+
+type NamedConnectionConfig struct {
+	ID string `json:"id"`
+	ConnectionConfig
+}
+
+type multipleDatabasesConfig struct {
+	Concurrency int             `json:"concurrency"`
+	Databases   json.RawMessage `json:"databases"`
+}
+
+var databaseIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ParseAndValidateDatabasesConfig reads a JSON or YAML config file holding either a single
+// connection config, an array of connection configs each with an "id", or an
+// object with a "databases" array or map keyed by ID and an optional
+// "concurrency".
+func ParseAndValidateDatabasesConfig(configPath string) (DatabasesConfig, error) {
+	configString, err := configloader.Read(configPath)
+	if err != nil {
+		return DatabasesConfig{}, fmt.Errorf("Fail reading config file: %s\n", err)
+	}
+
+	databasesConfig, err := parseDatabasesConfig(configString)
+	if err != nil {
+		return DatabasesConfig{}, fmt.Errorf("Could not parse config json: %s\n", err)
+	}
+
+	if err := databasesConfig.Validate(); err != nil {
+		return DatabasesConfig{}, err
+	}
+
+	return databasesConfig, nil
+}
+
+func parseDatabasesConfig(configString []byte) (DatabasesConfig, error) {
+	trimmed := bytes.TrimSpace(configString)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		databases, err := parseDatabases(trimmed)
+		if err != nil {
+			return DatabasesConfig{}, err
+		}
+		return DatabasesConfig{Concurrency: DefaultConcurrency, Databases: databases, IsMultiple: true}, nil
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(configString, &keys); err != nil {
+		return DatabasesConfig{}, err
+	}
+
+	if _, ok := keys["databases"]; !ok {
+		var connectionConfig ConnectionConfig
+		if err := configloader.Unmarshal(configString, &connectionConfig); err != nil {
+			return DatabasesConfig{}, err
+		}
+		return DatabasesConfig{
+			Concurrency: 1,
+			Databases:   []NamedConnectionConfig{{ConnectionConfig: connectionConfig}},
+		}, nil
+	}
+
+	var multipleConfig multipleDatabasesConfig
+	if err := configloader.Unmarshal(configString, &multipleConfig); err != nil {
+		return DatabasesConfig{}, err
+	}
+
+	databases, err := parseDatabases(bytes.TrimSpace(multipleConfig.Databases))
+	if err != nil {
+		return DatabasesConfig{}, err
+	}
+
+	concurrency := multipleConfig.Concurrency
+	if _, ok := keys["concurrency"]; !ok {
+		concurrency = DefaultConcurrency
+	}
+
+	return DatabasesConfig{Concurrency: concurrency, Databases: databases, IsMultiple: true}, nil
+}
+
+func parseDatabases(databasesString []byte) ([]NamedConnectionConfig, error) {
+	if len(databasesString) > 0 && databasesString[0] == '[' {
+		var databases []NamedConnectionConfig
+		if err := configloader.Unmarshal(databasesString, &databases); err != nil {
+			return nil, err
+		}
+		return withSafetyDumpPathPerDatabase(databases), nil
+	}
+
+	var databasesByID map[string]ConnectionConfig
+	if err := configloader.Unmarshal(databasesString, &databasesByID); err != nil {
+		return nil, err
+	}
+
+	var databases []NamedConnectionConfig
+	for id, connectionConfig := range databasesByID {
+		databases = append(databases, NamedConnectionConfig{ID: id, ConnectionConfig: connectionConfig})
+	}
+	sort.Slice(databases, func(i, j int) bool {
+		return databases[i].ID < databases[j].ID
+	})
+	return withSafetyDumpPathPerDatabase(databases), nil
+}
+
+// withSafetyDumpPathPerDatabase suffixes each safety dump path with the ID of
+// its database, so that databases which share a restore config, such as
+// through a YAML anchor, do not overwrite each other's safety dumps.
+func withSafetyDumpPathPerDatabase(databases []NamedConnectionConfig) []NamedConnectionConfig {
+	for i := range databases {
+		if databases[i].Restore.SafetyDumpPath != "" {
+			databases[i].Restore.SafetyDumpPath += "." + databases[i].ID
+		}
+	}
+	return databases
+}
+
+func (c DatabasesConfig) Validate() error {
+	if !c.IsMultiple {
+		return c.Databases[0].Validate()
+	}
+
+	if len(c.Databases) == 0 {
+		return fmt.Errorf("No databases specified\n")
+	}
+
+	if c.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1\n")
+	}
+
+	seenIDs := map[string]bool{}
+	for _, database := range c.Databases {
+		if !databaseIDPattern.MatchString(database.ID) || database.ID == "." || database.ID == ".." {
+			return fmt.Errorf("Invalid database id %q: ids may only contain letters, digits, '_', '-' and '.'\n", database.ID)
+		}
+
+		if seenIDs[database.ID] {
+			return fmt.Errorf("Duplicate database id %s\n", database.ID)
+		}
+		seenIDs[database.ID] = true
+
+		if err := database.Validate(); err != nil {
+			return fmt.Errorf("Invalid config for database %s: %s", database.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package config_test
+
+import (
+	. "database-backup-restore/config"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"os"
+)
+
+var _ = Describe("ParseAndValidateDatabasesConfig", func() {
+	var configPath string
+
+	writeConfigWithPattern := func(pattern, contents string) {
+		configFile, err := os.CreateTemp("", pattern)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = configFile.WriteString(contents)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(configFile.Close()).To(Succeed())
+		configPath = configFile.Name()
+	}
+
+	writeConfig := func(contents string) {
+		writeConfigWithPattern("", contents)
+	}
+
+	AfterEach(func() {
+		os.Remove(configPath)
+	})
+
+	Context("when the config file holds a single connection config", func() {
+		It("returns one database without an id", func() {
+			writeConfig(`{"adapter": "postgres", "database": "uaa"}`)
+
+			databasesConfig, err := ParseAndValidateDatabasesConfig(configPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databasesConfig.IsMultiple).To(BeFalse())
+			Expect(databasesConfig.Databases).To(Equal([]NamedConnectionConfig{
+				{ConnectionConfig: ConnectionConfig{Adapter: "postgres", Database: "uaa"}},
+			}))
+		})
+	})
+
+	Context("when the config file holds an array of connection configs", func() {
+		It("returns every database with the default concurrency", func() {
+			writeConfig(`[
+				{"id": "uaa", "adapter": "postgres", "database": "uaa"},
+				{"id": "ccdb", "adapter": "mysql", "database": "cloud_controller"}
+			]`)
+
+			databasesConfig, err := ParseAndValidateDatabasesConfig(configPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databasesConfig.IsMultiple).To(BeTrue())
+			Expect(databasesConfig.Concurrency).To(Equal(DefaultConcurrency))
+			Expect(databasesConfig.Databases).To(Equal([]NamedConnectionConfig{
+				{ID: "uaa", ConnectionConfig: ConnectionConfig{Adapter: "postgres", Database: "uaa"}},
+				{ID: "ccdb", ConnectionConfig: ConnectionConfig{Adapter: "mysql", Database: "cloud_controller"}},
+			}))
+		})
+	})
+
+	Context("when the config file holds a map of connection configs keyed by id", func() {
+		It("returns every database sorted by id, with the given concurrency", func() {
+			writeConfig(`{
+				"concurrency": 2,
+				"databases": {
+					"uaa": {"adapter": "postgres", "database": "uaa"},
+					"ccdb": {"adapter": "mysql", "database": "cloud_controller"}
+				}
+			}`)
+
+			databasesConfig, err := ParseAndValidateDatabasesConfig(configPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databasesConfig.IsMultiple).To(BeTrue())
+			Expect(databasesConfig.Concurrency).To(Equal(2))
+			Expect(databasesConfig.Databases).To(Equal([]NamedConnectionConfig{
+				{ID: "ccdb", ConnectionConfig: ConnectionConfig{Adapter: "mysql", Database: "cloud_controller"}},
+				{ID: "uaa", ConnectionConfig: ConnectionConfig{Adapter: "postgres", Database: "uaa"}},
+			}))
+		})
+	})
+
+	Context("when the databases have a safety dump path", func() {
+		It("suffixes each safety dump path with the id of its database", func() {
+			writeConfig(`{
+				"databases": {
+					"uaa": {"adapter": "postgres", "database": "uaa", "restore": {"safety_dump_path": "/tmp/safety-dump"}},
+					"ccdb": {"adapter": "mysql", "database": "cloud_controller", "restore": {"safety_dump_path": "/tmp/safety-dump"}},
+					"credhub": {"adapter": "mysql", "database": "credhub"}
+				}
+			}`)
+
+			databasesConfig, err := ParseAndValidateDatabasesConfig(configPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databasesConfig.Databases[0].Restore.SafetyDumpPath).To(Equal("/tmp/safety-dump.ccdb"))
+			Expect(databasesConfig.Databases[1].Restore.SafetyDumpPath).To(BeEmpty())
+			Expect(databasesConfig.Databases[2].Restore.SafetyDumpPath).To(Equal("/tmp/safety-dump.uaa"))
+		})
+	})
+
+	Context("when the config file is YAML and refers to environment variables", func() {
+		BeforeEach(func() {
+			os.Setenv("DBR_TEST_PASSWORD", "s3cr3t")
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("DBR_TEST_PASSWORD")
+		})
+
+		It("returns every database with the variables replaced", func() {
+			writeConfigWithPattern("*.yml", `
+concurrency: 2
+databases:
+  uaa:
+    adapter: postgres
+    database: uaa
+    password: ${DBR_TEST_PASSWORD}
+    port: 5432
+`)
+
+			databasesConfig, err := ParseAndValidateDatabasesConfig(configPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databasesConfig.Concurrency).To(Equal(2))
+			Expect(databasesConfig.Databases).To(Equal([]NamedConnectionConfig{
+				{ID: "uaa", ConnectionConfig: ConnectionConfig{
+					Adapter:  "postgres",
+					Database: "uaa",
+					Password: "s3cr3t",
+					Port:     5432,
+				}},
+			}))
+		})
+	})
+
+	DescribeTable("rejects invalid configs",
+		func(contents, expectedError string) {
+			writeConfig(contents)
+
+			_, err := ParseAndValidateDatabasesConfig(configPath)
+			Expect(err).To(MatchError(ContainSubstring(expectedError)))
+		},
+		Entry("invalid json", `foo!`, "Could not parse config json"),
+		Entry("an unknown key", `{"adapter": "postgres", "pasword": "foo"}`,
+			`Could not parse config json: json: unknown field "pasword"`),
+		Entry("an unknown key in a database", `{"databases": {"uaa": {"adapter": "postgres", "hots": "foo"}}}`,
+			`unknown field "hots"`),
+		Entry("an unknown top level key", `{"concurency": 2, "databases": {"uaa": {"adapter": "postgres"}}}`,
+			`unknown field "concurency"`),
+		Entry("an unset environment variable", `{"adapter": "postgres", "password": "${DBR_TEST_UNSET}"}`,
+			"config refers to environment variables which are not set: DBR_TEST_UNSET"),
+		Entry("no databases", `{"databases": []}`, "No databases specified"),
+		Entry("concurrency below one", `{"concurrency": 0, "databases": {"uaa": {"adapter": "postgres"}}}`,
+			"concurrency must be at least 1"),
+		Entry("a missing id", `[{"adapter": "postgres"}]`, `Invalid database id ""`),
+		Entry("an id which is not a file name", `{"databases": {"../uaa": {"adapter": "postgres"}}}`,
+			`Invalid database id "../uaa"`),
+		Entry("a duplicate id", `[{"id": "uaa", "adapter": "postgres"}, {"id": "uaa", "adapter": "postgres"}]`,
+			"Duplicate database id uaa"),
+		Entry("an invalid database", `{"databases": {"uaa": {"adapter": "foo-server"}}}`,
+			"Invalid config for database uaa: Unsupported adapter foo-server"),
+	)
+})
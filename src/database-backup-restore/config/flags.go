@@ -3,19 +3,33 @@ package config
 import (
 	"errors"
 	"flag"
+	"fmt"
 )
 
 type CommandFlags struct {
-	ConfigPath       string
-	IsRestore        bool
-	ArtifactFilePath string
+	ConfigPath        string
+	IsRestore         bool
+	ArtifactFilePath  string
+	ArtifactDirectory string
+	IsDryRun          bool
+	LogFormat         string
+	TempDirectory     string
 }
 
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
 func ParseFlags() (CommandFlags, error) {
 	var configPath = flag.String("config", "", "Path to JSON config file")
 	var backupAction = flag.Bool("backup", false, "Run database backup")
 	var restoreAction = flag.Bool("restore", false, "Run database restore")
 	var artifactFilePath = flag.String("artifact-file", "", "Path to output file")
+	var artifactDirectory = flag.String("artifact-dir", "", "Path to the directory holding one artifact per database, when the config file holds several databases")
+	var dryRun = flag.Bool("dry-run", false, "Detect the server version and print the commands that would be run, without running them")
+	var tempDirectory = flag.String("temp-dir", "", "Directory in which to create the temporary folder for TLS certificates and keys, such as a tmpfs mount")
+	var logFormat = flag.String("log-format", LogFormatText, "Format of the output: text or json")
 
 	flag.Parse()
 
@@ -31,13 +45,35 @@ func ParseFlags() (CommandFlags, error) {
 		return CommandFlags{}, errors.New("Missing --config flag")
 	}
 
-	if *artifactFilePath == "" {
-		return CommandFlags{}, errors.New("Missing --artifact-file flag")
+	if *artifactFilePath != "" && *artifactDirectory != "" {
+		return CommandFlags{}, errors.New("Only one of: --artifact-file or --artifact-dir can be provided")
+	}
+
+	if *logFormat != LogFormatText && *logFormat != LogFormatJSON {
+		return CommandFlags{}, fmt.Errorf("Unsupported --log-format %s: must be text or json", *logFormat)
 	}
 
 	return CommandFlags{
-		ConfigPath:       *configPath,
-		IsRestore:        *restoreAction,
-		ArtifactFilePath: *artifactFilePath,
+		ConfigPath:        *configPath,
+		IsRestore:         *restoreAction,
+		ArtifactFilePath:  *artifactFilePath,
+		ArtifactDirectory: *artifactDirectory,
+		IsDryRun:          *dryRun,
+		LogFormat:         *logFormat,
+		TempDirectory:     *tempDirectory,
 	}, nil
 }
+
+// ValidateArtifactLocation checks that --artifact-file was given for a config
+// file holding a single database, and --artifact-dir for one holding several.
+func (f CommandFlags) ValidateArtifactLocation(databasesConfig DatabasesConfig) error {
+	if databasesConfig.IsMultiple && f.ArtifactDirectory == "" {
+		return errors.New("Missing --artifact-dir flag: the config file holds several databases")
+	}
+
+	if !databasesConfig.IsMultiple && f.ArtifactFilePath == "" {
+		return errors.New("Missing --artifact-file flag")
+	}
+
+	return nil
+}
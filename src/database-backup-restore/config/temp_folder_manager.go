@@ -4,15 +4,29 @@ import (
 	"os"
 )
 
+// TempFolderManager keeps the files handed to the database utilities, such as
+// TLS certificates and keys, in a folder only the current user can read.
 type TempFolderManager struct {
 	folderPath string
 }
 
 func NewTempFolderManager() (TempFolderManager, error) {
-	folderPath, err := os.MkdirTemp("", "")
+	return NewTempFolderManagerIn("")
+}
+
+// NewTempFolderManagerIn creates the temp folder inside baseDirectory, for
+// example a tmpfs mount, or inside the default temp directory when it is empty.
+func NewTempFolderManagerIn(baseDirectory string) (TempFolderManager, error) {
+	folderPath, err := os.MkdirTemp(baseDirectory, "database-backup-restore")
 	if err != nil {
 		return TempFolderManager{}, err
 	}
+
+	if err := os.Chmod(folderPath, 0700); err != nil {
+		os.RemoveAll(folderPath)
+		return TempFolderManager{}, err
+	}
+
 	return TempFolderManager{folderPath: folderPath}, nil
 }
 
@@ -21,13 +35,17 @@ func (m TempFolderManager) WriteTempFile(contents string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	defer file.Close()
 
-	err = os.WriteFile(file.Name(), []byte(contents), 0777)
-	if err != nil {
+	if err := file.Chmod(0600); err != nil {
+		return "", err
+	}
+
+	if _, err := file.WriteString(contents); err != nil {
 		return "", err
 	}
 
-	return file.Name(), nil
+	return file.Name(), file.Close()
 }
 
 func (m TempFolderManager) Cleanup() error {
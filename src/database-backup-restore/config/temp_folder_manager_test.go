@@ -7,6 +7,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"os"
+	"path/filepath"
 )
 
 var _ = Describe("TempFolderManager", func() {
@@ -31,6 +32,49 @@ var _ = Describe("TempFolderManager", func() {
 			Expect(filePath).To(BeAnExistingFile())
 			Expect(os.ReadFile(filePath)).To(Equal([]byte("test contents")))
 		})
+
+		It("only lets the current user read the file and its folder", func() {
+			filePath, err := tempFolderManager.WriteTempFile("private key")
+			Expect(err).NotTo(HaveOccurred())
+
+			fileInfo, err := os.Stat(filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileInfo.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+			folderInfo, err := os.Stat(filepath.Dir(filePath))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(folderInfo.Mode().Perm()).To(Equal(os.FileMode(0700)))
+		})
+
+		It("fails when the folder has been removed", func() {
+			Expect(tempFolderManager.Cleanup()).To(Succeed())
+
+			_, err := tempFolderManager.WriteTempFile("test contents")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("NewTempFolderManagerIn", func() {
+		It("creates the folder inside the given directory", func() {
+			baseDirectory, err := os.MkdirTemp("", "")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(baseDirectory)
+
+			tempFolderManager, err := NewTempFolderManagerIn(baseDirectory)
+			Expect(err).NotTo(HaveOccurred())
+
+			filePath, err := tempFolderManager.WriteTempFile("test contents")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filepath.Dir(filepath.Dir(filePath))).To(Equal(baseDirectory))
+
+			Expect(tempFolderManager.Cleanup()).To(Succeed())
+			Expect(os.ReadDir(baseDirectory)).To(BeEmpty())
+		})
+
+		It("fails when the directory does not exist", func() {
+			_, err := NewTempFolderManagerIn("/non/existent/directory")
+			Expect(err).To(HaveOccurred())
+		})
 	})
 
 	Describe("Cleanup", func() {
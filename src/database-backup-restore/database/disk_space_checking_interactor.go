@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"syscall"
+)
+
+//counterfeiter:generate -o fakes/fake_size_estimator.go . SizeEstimator
+type SizeEstimator interface {
+	EstimateSize(ctx context.Context) (uint64, error)
+}
+
+//counterfeiter:generate -o fakes/fake_disk_space_reporter.go . DiskSpaceReporter
+type DiskSpaceReporter interface {
+	FreeSpace(path string) (uint64, error)
+}
+
+// InsufficientDiskSpaceError is returned when the filesystem the artifact is
+// written to has less free space than the database reports it is using.
+type InsufficientDiskSpaceError struct {
+	Path          string
+	EstimatedSize uint64
+	FreeSpace     uint64
+}
+
+func (e InsufficientDiskSpaceError) Error() string {
+	return fmt.Sprintf("not enough disk space to back up the database: %s has %d bytes free but the database is using %d bytes",
+		e.Path, e.FreeSpace, e.EstimatedSize)
+}
+
+type DiskSpaceCheckingInteractor struct {
+	sizeEstimator     SizeEstimator
+	diskSpaceReporter DiskSpaceReporter
+	interactor        Interactor
+}
+
+func NewDiskSpaceCheckingInteractor(
+	sizeEstimator SizeEstimator,
+	diskSpaceReporter DiskSpaceReporter,
+	interactor Interactor) DiskSpaceCheckingInteractor {
+
+	return DiskSpaceCheckingInteractor{
+		sizeEstimator:     sizeEstimator,
+		diskSpaceReporter: diskSpaceReporter,
+		interactor:        interactor,
+	}
+}
+
+func (i DiskSpaceCheckingInteractor) Action(ctx context.Context, artifactFilePath string) error {
+	estimatedSize, err := i.sizeEstimator.EstimateSize(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to estimate the size of the database: %w", err)
+	}
+
+	artifactDirectory := filepath.Dir(artifactFilePath)
+	freeSpace, err := i.diskSpaceReporter.FreeSpace(artifactDirectory)
+	if err != nil {
+		return fmt.Errorf("failed to check free disk space in %s: %w", artifactDirectory, err)
+	}
+
+	log.Printf("Database is using %d bytes, %s has %d bytes free\n", estimatedSize, artifactDirectory, freeSpace)
+
+	if freeSpace < estimatedSize {
+		return InsufficientDiskSpaceError{Path: artifactDirectory, EstimatedSize: estimatedSize, FreeSpace: freeSpace}
+	}
+
+	return i.interactor.Action(ctx, artifactFilePath)
+}
+
+func (i DiskSpaceCheckingInteractor) Plan(artifactFilePath string) ([]string, error) {
+	interactorPlan, err := i.interactor.Plan(artifactFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(
+		[]string{"check that " + filepath.Dir(artifactFilePath) + " has enough free space for the database"},
+		interactorPlan...,
+	), nil
+}
+
+type StatfsDiskSpaceReporter struct{}
+
+func NewStatfsDiskSpaceReporter() StatfsDiskSpaceReporter {
+	return StatfsDiskSpaceReporter{}
+}
+
+func (r StatfsDiskSpaceReporter) FreeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
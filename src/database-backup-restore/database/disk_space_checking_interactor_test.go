@@ -0,0 +1,126 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+
+	. "github.com/onsi/gomega"
+
+	"database-backup-restore/database"
+	"database-backup-restore/database/fakes"
+)
+
+var _ = Describe("DiskSpaceCheckingInteractor", func() {
+	var (
+		sizeEstimator               *fakes.FakeSizeEstimator
+		diskSpaceReporter           *fakes.FakeDiskSpaceReporter
+		interactor                  *fakes.FakeInteractor
+		diskSpaceCheckingInteractor database.DiskSpaceCheckingInteractor
+		returnError                 error
+		artifactPath                = "/artifact/file/path"
+	)
+
+	BeforeEach(func() {
+		sizeEstimator = new(fakes.FakeSizeEstimator)
+		diskSpaceReporter = new(fakes.FakeDiskSpaceReporter)
+		interactor = new(fakes.FakeInteractor)
+	})
+
+	JustBeforeEach(func() {
+		diskSpaceCheckingInteractor = database.NewDiskSpaceCheckingInteractor(sizeEstimator, diskSpaceReporter, interactor)
+		returnError = diskSpaceCheckingInteractor.Action(context.Background(), artifactPath)
+	})
+
+	Context("when there is enough free space", func() {
+		BeforeEach(func() {
+			sizeEstimator.EstimateSizeReturns(100, nil)
+			diskSpaceReporter.FreeSpaceReturns(200, nil)
+			interactor.ActionReturns(fmt.Errorf("test error"))
+		})
+
+		It("delegates to the wrapped interactor", func() {
+			By("only checking the free space in the artifact directory", func() {
+				Expect(diskSpaceReporter.FreeSpaceCallCount()).To(Equal(1))
+				Expect(diskSpaceReporter.FreeSpaceArgsForCall(0)).To(Equal("/artifact/file"))
+			})
+
+			By("calling its Action method", func() {
+				Expect(interactor.ActionCallCount()).To(Equal(1))
+				_, actionArtifactPath := interactor.ActionArgsForCall(0)
+				Expect(actionArtifactPath).To(Equal(artifactPath))
+			})
+
+			By("returning its return value", func() {
+				Expect(returnError).To(MatchError("test error"))
+			})
+		})
+	})
+
+	Context("when there is not enough free space", func() {
+		BeforeEach(func() {
+			sizeEstimator.EstimateSizeReturns(300, nil)
+			diskSpaceReporter.FreeSpaceReturns(200, nil)
+		})
+
+		It("fails without calling the wrapped interactor", func() {
+			Expect(interactor.ActionCallCount()).To(Equal(0))
+			Expect(returnError).To(Equal(database.InsufficientDiskSpaceError{
+				Path:          "/artifact/file",
+				EstimatedSize: 300,
+				FreeSpace:     200,
+			}))
+			Expect(returnError).To(MatchError(
+				"not enough disk space to back up the database: /artifact/file has 200 bytes free but the database is using 300 bytes"))
+		})
+	})
+
+	Describe("Plan", func() {
+		BeforeEach(func() {
+			interactor.PlanReturns([]string{"dump"}, nil)
+		})
+
+		It("plans the check of the artifact directory before the wrapped interactor's steps", func() {
+			Expect(diskSpaceCheckingInteractor.Plan(artifactPath)).To(Equal([]string{
+				"check that /artifact/file has enough free space for the database",
+				"dump",
+			}))
+		})
+	})
+
+	Context("when the size of the database cannot be estimated", func() {
+		BeforeEach(func() {
+			sizeEstimator.EstimateSizeReturns(0, fmt.Errorf("size test error"))
+		})
+
+		It("fails without calling the wrapped interactor", func() {
+			Expect(interactor.ActionCallCount()).To(Equal(0))
+			Expect(returnError).To(MatchError("failed to estimate the size of the database: size test error"))
+		})
+	})
+
+	Context("when the free space cannot be checked", func() {
+		BeforeEach(func() {
+			diskSpaceReporter.FreeSpaceReturns(0, fmt.Errorf("statfs test error"))
+		})
+
+		It("fails without calling the wrapped interactor", func() {
+			Expect(interactor.ActionCallCount()).To(Equal(0))
+			Expect(returnError).To(MatchError("failed to check free disk space in /artifact/file: statfs test error"))
+		})
+	})
+})
+
+var _ = Describe("StatfsDiskSpaceReporter", func() {
+	It("reports the free space of an existing directory", func() {
+		freeSpace, err := database.NewStatfsDiskSpaceReporter().FreeSpace(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(freeSpace).To(BeNumerically(">", 0))
+	})
+
+	It("fails for a directory which does not exist", func() {
+		_, err := database.NewStatfsDiskSpaceReporter().FreeSpace("/non/existent/directory")
+		Expect(err).To(HaveOccurred())
+	})
+})
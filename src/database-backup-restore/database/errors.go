@@ -0,0 +1,55 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConnectionError is returned when the database server could not be reached
+// to detect its version.
+type ConnectionError struct {
+	Err error
+}
+
+func (e ConnectionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedVersionError is returned when there are no utilities for the
+// detected version of the database server.
+type UnsupportedVersionError struct {
+	Implementation string
+	Major          string
+	Minor          string
+}
+
+func (e UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("unsupported version of %s: %s.%s", e.Implementation, e.Major, e.Minor)
+}
+
+// MissingTablesError is returned when tables listed in the config do not
+// exist in the database.
+type MissingTablesError struct {
+	Tables []string
+}
+
+func (e MissingTablesError) Error() string {
+	return fmt.Sprintf("can't find specified table(s): %s", strings.Join(e.Tables, ", "))
+}
+
+// UtilityError is returned when a dump or restore utility fails.
+type UtilityError struct {
+	Err error
+}
+
+func (e UtilityError) Error() string {
+	return e.Err.Error()
+}
+
+func (e UtilityError) Unwrap() error {
+	return e.Err
+}
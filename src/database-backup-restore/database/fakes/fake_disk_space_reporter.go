@@ -0,0 +1,113 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"database-backup-restore/database"
+	"sync"
+)
+
+type FakeDiskSpaceReporter struct {
+	FreeSpaceStub        func(string) (uint64, error)
+	freeSpaceMutex       sync.RWMutex
+	freeSpaceArgsForCall []struct {
+		arg1 string
+	}
+	freeSpaceReturns struct {
+		result1 uint64
+		result2 error
+	}
+	freeSpaceReturnsOnCall map[int]struct {
+		result1 uint64
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeDiskSpaceReporter) FreeSpace(arg1 string) (uint64, error) {
+	fake.freeSpaceMutex.Lock()
+	ret, specificReturn := fake.freeSpaceReturnsOnCall[len(fake.freeSpaceArgsForCall)]
+	fake.freeSpaceArgsForCall = append(fake.freeSpaceArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.FreeSpaceStub
+	fakeReturns := fake.freeSpaceReturns
+	fake.recordInvocation("FreeSpace", []interface{}{arg1})
+	fake.freeSpaceMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeDiskSpaceReporter) FreeSpaceCallCount() int {
+	fake.freeSpaceMutex.RLock()
+	defer fake.freeSpaceMutex.RUnlock()
+	return len(fake.freeSpaceArgsForCall)
+}
+
+func (fake *FakeDiskSpaceReporter) FreeSpaceCalls(stub func(string) (uint64, error)) {
+	fake.freeSpaceMutex.Lock()
+	defer fake.freeSpaceMutex.Unlock()
+	fake.FreeSpaceStub = stub
+}
+
+func (fake *FakeDiskSpaceReporter) FreeSpaceArgsForCall(i int) string {
+	fake.freeSpaceMutex.RLock()
+	defer fake.freeSpaceMutex.RUnlock()
+	argsForCall := fake.freeSpaceArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDiskSpaceReporter) FreeSpaceReturns(result1 uint64, result2 error) {
+	fake.freeSpaceMutex.Lock()
+	defer fake.freeSpaceMutex.Unlock()
+	fake.FreeSpaceStub = nil
+	fake.freeSpaceReturns = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskSpaceReporter) FreeSpaceReturnsOnCall(i int, result1 uint64, result2 error) {
+	fake.freeSpaceMutex.Lock()
+	defer fake.freeSpaceMutex.Unlock()
+	fake.FreeSpaceStub = nil
+	if fake.freeSpaceReturnsOnCall == nil {
+		fake.freeSpaceReturnsOnCall = make(map[int]struct {
+			result1 uint64
+			result2 error
+		})
+	}
+	fake.freeSpaceReturnsOnCall[i] = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskSpaceReporter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeDiskSpaceReporter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ database.DiskSpaceReporter = new(FakeDiskSpaceReporter)
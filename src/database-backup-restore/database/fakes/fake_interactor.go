@@ -2,15 +2,17 @@
 package fakes
 
 import (
+	"context"
 	"database-backup-restore/database"
 	"sync"
 )
 
 type FakeInteractor struct {
-	ActionStub        func(string) error
+	ActionStub        func(context.Context, string) error
 	actionMutex       sync.RWMutex
 	actionArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
+		arg2 string
 	}
 	actionReturns struct {
 		result1 error
@@ -18,22 +20,36 @@ type FakeInteractor struct {
 	actionReturnsOnCall map[int]struct {
 		result1 error
 	}
+	PlanStub        func(string) ([]string, error)
+	planMutex       sync.RWMutex
+	planArgsForCall []struct {
+		arg1 string
+	}
+	planReturns struct {
+		result1 []string
+		result2 error
+	}
+	planReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeInteractor) Action(arg1 string) error {
+func (fake *FakeInteractor) Action(arg1 context.Context, arg2 string) error {
 	fake.actionMutex.Lock()
 	ret, specificReturn := fake.actionReturnsOnCall[len(fake.actionArgsForCall)]
 	fake.actionArgsForCall = append(fake.actionArgsForCall, struct {
-		arg1 string
-	}{arg1})
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
 	stub := fake.ActionStub
 	fakeReturns := fake.actionReturns
-	fake.recordInvocation("Action", []interface{}{arg1})
+	fake.recordInvocation("Action", []interface{}{arg1, arg2})
 	fake.actionMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -47,17 +63,17 @@ func (fake *FakeInteractor) ActionCallCount() int {
 	return len(fake.actionArgsForCall)
 }
 
-func (fake *FakeInteractor) ActionCalls(stub func(string) error) {
+func (fake *FakeInteractor) ActionCalls(stub func(context.Context, string) error) {
 	fake.actionMutex.Lock()
 	defer fake.actionMutex.Unlock()
 	fake.ActionStub = stub
 }
 
-func (fake *FakeInteractor) ActionArgsForCall(i int) string {
+func (fake *FakeInteractor) ActionArgsForCall(i int) (context.Context, string) {
 	fake.actionMutex.RLock()
 	defer fake.actionMutex.RUnlock()
 	argsForCall := fake.actionArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeInteractor) ActionReturns(result1 error) {
@@ -83,11 +99,73 @@ func (fake *FakeInteractor) ActionReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeInteractor) Plan(arg1 string) ([]string, error) {
+	fake.planMutex.Lock()
+	ret, specificReturn := fake.planReturnsOnCall[len(fake.planArgsForCall)]
+	fake.planArgsForCall = append(fake.planArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.PlanStub
+	fakeReturns := fake.planReturns
+	fake.recordInvocation("Plan", []interface{}{arg1})
+	fake.planMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeInteractor) PlanCallCount() int {
+	fake.planMutex.RLock()
+	defer fake.planMutex.RUnlock()
+	return len(fake.planArgsForCall)
+}
+
+func (fake *FakeInteractor) PlanCalls(stub func(string) ([]string, error)) {
+	fake.planMutex.Lock()
+	defer fake.planMutex.Unlock()
+	fake.PlanStub = stub
+}
+
+func (fake *FakeInteractor) PlanArgsForCall(i int) string {
+	fake.planMutex.RLock()
+	defer fake.planMutex.RUnlock()
+	argsForCall := fake.planArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeInteractor) PlanReturns(result1 []string, result2 error) {
+	fake.planMutex.Lock()
+	defer fake.planMutex.Unlock()
+	fake.PlanStub = nil
+	fake.planReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeInteractor) PlanReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.planMutex.Lock()
+	defer fake.planMutex.Unlock()
+	fake.PlanStub = nil
+	if fake.planReturnsOnCall == nil {
+		fake.planReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.planReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeInteractor) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.actionMutex.RLock()
-	defer fake.actionMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
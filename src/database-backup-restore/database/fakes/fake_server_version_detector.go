@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"context"
 	"database-backup-restore/config"
 	"database-backup-restore/database"
 	"database-backup-restore/version"
@@ -9,11 +10,12 @@ import (
 )
 
 type FakeServerVersionDetector struct {
-	GetVersionStub        func(config.ConnectionConfig, config.TempFolderManager) (version.DatabaseServerVersion, error)
+	GetVersionStub        func(context.Context, config.ConnectionConfig, config.TempFolderManager) (version.DatabaseServerVersion, error)
 	getVersionMutex       sync.RWMutex
 	getVersionArgsForCall []struct {
-		arg1 config.ConnectionConfig
-		arg2 config.TempFolderManager
+		arg1 context.Context
+		arg2 config.ConnectionConfig
+		arg3 config.TempFolderManager
 	}
 	getVersionReturns struct {
 		result1 version.DatabaseServerVersion
@@ -27,19 +29,20 @@ type FakeServerVersionDetector struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeServerVersionDetector) GetVersion(arg1 config.ConnectionConfig, arg2 config.TempFolderManager) (version.DatabaseServerVersion, error) {
+func (fake *FakeServerVersionDetector) GetVersion(arg1 context.Context, arg2 config.ConnectionConfig, arg3 config.TempFolderManager) (version.DatabaseServerVersion, error) {
 	fake.getVersionMutex.Lock()
 	ret, specificReturn := fake.getVersionReturnsOnCall[len(fake.getVersionArgsForCall)]
 	fake.getVersionArgsForCall = append(fake.getVersionArgsForCall, struct {
-		arg1 config.ConnectionConfig
-		arg2 config.TempFolderManager
-	}{arg1, arg2})
+		arg1 context.Context
+		arg2 config.ConnectionConfig
+		arg3 config.TempFolderManager
+	}{arg1, arg2, arg3})
 	stub := fake.GetVersionStub
 	fakeReturns := fake.getVersionReturns
-	fake.recordInvocation("GetVersion", []interface{}{arg1, arg2})
+	fake.recordInvocation("GetVersion", []interface{}{arg1, arg2, arg3})
 	fake.getVersionMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -53,17 +56,17 @@ func (fake *FakeServerVersionDetector) GetVersionCallCount() int {
 	return len(fake.getVersionArgsForCall)
 }
 
-func (fake *FakeServerVersionDetector) GetVersionCalls(stub func(config.ConnectionConfig, config.TempFolderManager) (version.DatabaseServerVersion, error)) {
+func (fake *FakeServerVersionDetector) GetVersionCalls(stub func(context.Context, config.ConnectionConfig, config.TempFolderManager) (version.DatabaseServerVersion, error)) {
 	fake.getVersionMutex.Lock()
 	defer fake.getVersionMutex.Unlock()
 	fake.GetVersionStub = stub
 }
 
-func (fake *FakeServerVersionDetector) GetVersionArgsForCall(i int) (config.ConnectionConfig, config.TempFolderManager) {
+func (fake *FakeServerVersionDetector) GetVersionArgsForCall(i int) (context.Context, config.ConnectionConfig, config.TempFolderManager) {
 	fake.getVersionMutex.RLock()
 	defer fake.getVersionMutex.RUnlock()
 	argsForCall := fake.getVersionArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeServerVersionDetector) GetVersionReturns(result1 version.DatabaseServerVersion, result2 error) {
@@ -95,8 +98,6 @@ func (fake *FakeServerVersionDetector) GetVersionReturnsOnCall(i int, result1 ve
 func (fake *FakeServerVersionDetector) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.getVersionMutex.RLock()
-	defer fake.getVersionMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,114 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"context"
+	"database-backup-restore/database"
+	"sync"
+)
+
+type FakeSizeEstimator struct {
+	EstimateSizeStub        func(context.Context) (uint64, error)
+	estimateSizeMutex       sync.RWMutex
+	estimateSizeArgsForCall []struct {
+		arg1 context.Context
+	}
+	estimateSizeReturns struct {
+		result1 uint64
+		result2 error
+	}
+	estimateSizeReturnsOnCall map[int]struct {
+		result1 uint64
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSizeEstimator) EstimateSize(arg1 context.Context) (uint64, error) {
+	fake.estimateSizeMutex.Lock()
+	ret, specificReturn := fake.estimateSizeReturnsOnCall[len(fake.estimateSizeArgsForCall)]
+	fake.estimateSizeArgsForCall = append(fake.estimateSizeArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.EstimateSizeStub
+	fakeReturns := fake.estimateSizeReturns
+	fake.recordInvocation("EstimateSize", []interface{}{arg1})
+	fake.estimateSizeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSizeEstimator) EstimateSizeCallCount() int {
+	fake.estimateSizeMutex.RLock()
+	defer fake.estimateSizeMutex.RUnlock()
+	return len(fake.estimateSizeArgsForCall)
+}
+
+func (fake *FakeSizeEstimator) EstimateSizeCalls(stub func(context.Context) (uint64, error)) {
+	fake.estimateSizeMutex.Lock()
+	defer fake.estimateSizeMutex.Unlock()
+	fake.EstimateSizeStub = stub
+}
+
+func (fake *FakeSizeEstimator) EstimateSizeArgsForCall(i int) context.Context {
+	fake.estimateSizeMutex.RLock()
+	defer fake.estimateSizeMutex.RUnlock()
+	argsForCall := fake.estimateSizeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSizeEstimator) EstimateSizeReturns(result1 uint64, result2 error) {
+	fake.estimateSizeMutex.Lock()
+	defer fake.estimateSizeMutex.Unlock()
+	fake.EstimateSizeStub = nil
+	fake.estimateSizeReturns = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSizeEstimator) EstimateSizeReturnsOnCall(i int, result1 uint64, result2 error) {
+	fake.estimateSizeMutex.Lock()
+	defer fake.estimateSizeMutex.Unlock()
+	fake.EstimateSizeStub = nil
+	if fake.estimateSizeReturnsOnCall == nil {
+		fake.estimateSizeReturnsOnCall = make(map[int]struct {
+			result1 uint64
+			result2 error
+		})
+	}
+	fake.estimateSizeReturnsOnCall[i] = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSizeEstimator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSizeEstimator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ database.SizeEstimator = new(FakeSizeEstimator)
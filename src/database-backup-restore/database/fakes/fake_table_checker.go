@@ -2,15 +2,17 @@
 package fakes
 
 import (
+	"context"
 	"database-backup-restore/database"
 	"sync"
 )
 
 type FakeTableChecker struct {
-	FindMissingTablesStub        func([]string) ([]string, error)
+	FindMissingTablesStub        func(context.Context, []string) ([]string, error)
 	findMissingTablesMutex       sync.RWMutex
 	findMissingTablesArgsForCall []struct {
-		arg1 []string
+		arg1 context.Context
+		arg2 []string
 	}
 	findMissingTablesReturns struct {
 		result1 []string
@@ -24,23 +26,24 @@ type FakeTableChecker struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeTableChecker) FindMissingTables(arg1 []string) ([]string, error) {
-	var arg1Copy []string
-	if arg1 != nil {
-		arg1Copy = make([]string, len(arg1))
-		copy(arg1Copy, arg1)
+func (fake *FakeTableChecker) FindMissingTables(arg1 context.Context, arg2 []string) ([]string, error) {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
 	}
 	fake.findMissingTablesMutex.Lock()
 	ret, specificReturn := fake.findMissingTablesReturnsOnCall[len(fake.findMissingTablesArgsForCall)]
 	fake.findMissingTablesArgsForCall = append(fake.findMissingTablesArgsForCall, struct {
-		arg1 []string
-	}{arg1Copy})
+		arg1 context.Context
+		arg2 []string
+	}{arg1, arg2Copy})
 	stub := fake.FindMissingTablesStub
 	fakeReturns := fake.findMissingTablesReturns
-	fake.recordInvocation("FindMissingTables", []interface{}{arg1Copy})
+	fake.recordInvocation("FindMissingTables", []interface{}{arg1, arg2Copy})
 	fake.findMissingTablesMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -54,17 +57,17 @@ func (fake *FakeTableChecker) FindMissingTablesCallCount() int {
 	return len(fake.findMissingTablesArgsForCall)
 }
 
-func (fake *FakeTableChecker) FindMissingTablesCalls(stub func([]string) ([]string, error)) {
+func (fake *FakeTableChecker) FindMissingTablesCalls(stub func(context.Context, []string) ([]string, error)) {
 	fake.findMissingTablesMutex.Lock()
 	defer fake.findMissingTablesMutex.Unlock()
 	fake.FindMissingTablesStub = stub
 }
 
-func (fake *FakeTableChecker) FindMissingTablesArgsForCall(i int) []string {
+func (fake *FakeTableChecker) FindMissingTablesArgsForCall(i int) (context.Context, []string) {
 	fake.findMissingTablesMutex.RLock()
 	defer fake.findMissingTablesMutex.RUnlock()
 	argsForCall := fake.findMissingTablesArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeTableChecker) FindMissingTablesReturns(result1 []string, result2 error) {
@@ -96,8 +99,6 @@ func (fake *FakeTableChecker) FindMissingTablesReturnsOnCall(i int, result1 []st
 func (fake *FakeTableChecker) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.findMissingTablesMutex.RLock()
-	defer fake.findMissingTablesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+
 	"database-backup-restore/config"
 	"database-backup-restore/version"
 )
@@ -8,12 +10,13 @@ import (
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate -o fakes/fake_interactor.go . Interactor
 type Interactor interface {
-	Action(artifactFilePath string) error
+	Action(ctx context.Context, artifactFilePath string) error
+	Plan(artifactFilePath string) ([]string, error)
 }
 
 //counterfeiter:generate -o fakes/fake_server_version_detector.go . ServerVersionDetector
 type ServerVersionDetector interface {
-	GetVersion(config.ConnectionConfig, config.TempFolderManager) (version.DatabaseServerVersion, error)
+	GetVersion(context.Context, config.ConnectionConfig, config.TempFolderManager) (version.DatabaseServerVersion, error)
 }
 
 //counterfeiter:generate -o fakes/fake_dump_utility_version_detector.go . DumpUtilityVersionDetector
@@ -22,7 +25,7 @@ type DumpUtilityVersionDetector interface {
 }
 
 type Factory interface {
-	Make(Action, config.ConnectionConfig) Interactor
+	Make(context.Context, Action, config.ConnectionConfig) (Interactor, error)
 }
 
 type Action string
@@ -1,9 +1,11 @@
 package database
 
 import (
+	"context"
 	"fmt"
 
 	"database-backup-restore/config"
+	"database-backup-restore/events"
 	"database-backup-restore/mysql"
 	"database-backup-restore/postgres"
 	"database-backup-restore/version"
@@ -30,56 +32,73 @@ func NewInteractorFactory(
 	}
 }
 
-func (f InteractorFactory) Make(action Action, connectionConfig config.ConnectionConfig) (Interactor, error) {
+func (f InteractorFactory) Make(ctx context.Context, action Action, connectionConfig config.ConnectionConfig) (Interactor, error) {
 	switch {
 	case connectionConfig.Adapter == "postgres" && action == "backup":
-		return f.makePostgresBackuper(connectionConfig)
+		return f.makePostgresBackuper(ctx, connectionConfig)
 	case connectionConfig.Adapter == "mysql" && action == "backup":
-		return f.makeMysqlBackuper(connectionConfig)
+		return f.makeMysqlBackuper(ctx, connectionConfig)
 	case connectionConfig.Adapter == "postgres" && action == "restore":
-		return f.makePostgresRestorer(connectionConfig)
+		return f.makePostgresRestorer(ctx, connectionConfig)
 	case connectionConfig.Adapter == "mysql" && action == "restore":
-		return f.makeMysqlRestorer(connectionConfig)
+		return f.makeMysqlRestorer(ctx, connectionConfig)
 	}
 
 	return nil, fmt.Errorf("unsupported adapter/action combination: %s/%s", connectionConfig.Adapter, action)
 }
 
-func (f InteractorFactory) makeMysqlBackuper(config config.ConnectionConfig) (Interactor, error) {
-	mysqldbVersion, err := f.mysqlServerVersionDetector.GetVersion(config, f.tempFolderManager)
+func (f InteractorFactory) makeMysqlBackuper(ctx context.Context, config config.ConnectionConfig) (Interactor, error) {
+	mysqldbVersion, err := f.detectVersion(ctx, f.mysqlServerVersionDetector, config)
 	if err != nil {
 		return nil, err
 	}
 
-	mysqlDumpPath, _, err := f.getUtilitiesForMySQL(mysqldbVersion)
+	mysqlDumpPath, mysqlClientPath, err := f.getUtilitiesForMySQL(mysqldbVersion)
 	if err != nil {
 		return nil, err
 	}
 
 	mysqlSSLProvider := f.getSSLCommandProvider(mysqldbVersion)
+	emitUtilityChosen(ctx, mysqlDumpPath, mysqlSSLProvider)
+
 	mysqlAdditionalOptionsProvider := f.getAdditionalOptionsProvider(mysqldbVersion)
+	mysqlBackuper := mysql.NewBackuper(config, mysqlDumpPath, mysqlSSLProvider, mysqlAdditionalOptionsProvider)
+
+	if !config.CheckDiskSpace {
+		return mysqlBackuper, nil
+	}
 
-	return mysql.NewBackuper(config, mysqlDumpPath, mysqlSSLProvider, mysqlAdditionalOptionsProvider), nil
+	sizeEstimator := mysql.NewSizeEstimator(config, mysqlClientPath, mysqlSSLProvider)
+	return NewDiskSpaceCheckingInteractor(sizeEstimator, NewStatfsDiskSpaceReporter(), mysqlBackuper), nil
 }
 
-func (f InteractorFactory) makeMysqlRestorer(config config.ConnectionConfig) (Interactor, error) {
-	mysqldbVersion, err := f.mysqlServerVersionDetector.GetVersion(config, f.tempFolderManager)
+func (f InteractorFactory) makeMysqlRestorer(ctx context.Context, config config.ConnectionConfig) (Interactor, error) {
+	mysqldbVersion, err := f.detectVersion(ctx, f.mysqlServerVersionDetector, config)
 	if err != nil {
 		return nil, err
 	}
 
-	_, mysqlRestorePath, err := f.getUtilitiesForMySQL(mysqldbVersion)
+	mysqlDumpPath, mysqlRestorePath, err := f.getUtilitiesForMySQL(mysqldbVersion)
 	if err != nil {
 		return nil, err
 	}
 
 	mysqlSSLProvider := f.getSSLCommandProvider(mysqldbVersion)
+	emitUtilityChosen(ctx, mysqlRestorePath, mysqlSSLProvider)
+
+	mysqlRestorer := mysql.NewRestorer(config, mysqlRestorePath, mysqlSSLProvider)
 
-	return mysql.NewRestorer(config, mysqlRestorePath, mysqlSSLProvider), nil
+	if config.Restore.SafetyDumpPath == "" {
+		return mysqlRestorer, nil
+	}
+
+	mysqlAdditionalOptionsProvider := f.getAdditionalOptionsProvider(mysqldbVersion)
+	mysqlBackuper := mysql.NewBackuper(config, mysqlDumpPath, mysqlSSLProvider, mysqlAdditionalOptionsProvider)
+	return NewSafetyDumpInteractor(config.Restore.SafetyDumpPath, mysqlBackuper, mysqlRestorer), nil
 }
 
-func (f InteractorFactory) makePostgresBackuper(config config.ConnectionConfig) (Interactor, error) {
-	postgresVersion, err := f.postgresServerVersionDetector.GetVersion(config, f.tempFolderManager)
+func (f InteractorFactory) makePostgresBackuper(ctx context.Context, config config.ConnectionConfig) (Interactor, error) {
+	postgresVersion, err := f.detectVersion(ctx, f.postgresServerVersionDetector, config)
 	if err != nil {
 		return nil, err
 	}
@@ -89,23 +108,62 @@ func (f InteractorFactory) makePostgresBackuper(config config.ConnectionConfig)
 		return nil, err
 	}
 
-	postgresBackuper := postgres.NewBackuper(config, f.tempFolderManager, pgDumpPath)
+	emitUtilityChosen(ctx, pgDumpPath, nil)
+
+	var postgresBackuper Interactor = postgres.NewBackuper(config, f.tempFolderManager, pgDumpPath)
+	if config.CheckDiskSpace {
+		sizeEstimator := postgres.NewSizeEstimator(config, f.tempFolderManager, psqlPath)
+		postgresBackuper = NewDiskSpaceCheckingInteractor(sizeEstimator, NewStatfsDiskSpaceReporter(), postgresBackuper)
+	}
+
 	tableChecker := postgres.NewTableChecker(config, psqlPath)
 	return NewTableCheckingInteractor(config, tableChecker, postgresBackuper), nil
 }
 
-func (f InteractorFactory) makePostgresRestorer(config config.ConnectionConfig) (Interactor, error) {
-	postgresVersion, err := f.postgresServerVersionDetector.GetVersion(config, f.tempFolderManager)
+func (f InteractorFactory) makePostgresRestorer(ctx context.Context, config config.ConnectionConfig) (Interactor, error) {
+	postgresVersion, err := f.detectVersion(ctx, f.postgresServerVersionDetector, config)
 	if err != nil {
 		return nil, err
 	}
 
-	_, _, pgRestorePath, err := f.getUtilitiesForPostgres(postgresVersion)
+	_, pgDumpPath, pgRestorePath, err := f.getUtilitiesForPostgres(postgresVersion)
 	if err != nil {
 		return nil, err
 	}
 
-	return postgres.NewRestorer(config, f.tempFolderManager, pgRestorePath), nil
+	emitUtilityChosen(ctx, pgRestorePath, nil)
+
+	postgresRestorer := postgres.NewRestorer(config, f.tempFolderManager, pgRestorePath)
+
+	if config.Restore.SafetyDumpPath == "" {
+		return postgresRestorer, nil
+	}
+
+	postgresBackuper := postgres.NewBackuper(config, f.tempFolderManager, pgDumpPath)
+	return NewSafetyDumpInteractor(config.Restore.SafetyDumpPath, postgresBackuper, postgresRestorer), nil
+}
+
+func (f InteractorFactory) detectVersion(ctx context.Context, detector ServerVersionDetector, config config.ConnectionConfig) (version.DatabaseServerVersion, error) {
+	serverVersion, err := detector.GetVersion(ctx, config, f.tempFolderManager)
+	if err != nil {
+		return version.DatabaseServerVersion{}, ConnectionError{Err: err}
+	}
+
+	events.Emit(ctx, events.Event{
+		Event:          events.VersionDetected,
+		Implementation: serverVersion.Implementation,
+		Version:        serverVersion.SemanticVersion.String(),
+	})
+
+	return serverVersion, nil
+}
+
+func emitUtilityChosen(ctx context.Context, utilityPath string, sslOptionsProvider mysql.SSLOptionsProvider) {
+	event := events.Event{Event: events.UtilityChosen, Utility: utilityPath}
+	if sslOptionsProvider != nil {
+		event.SSLProvider = sslOptionsProvider.Name()
+	}
+	events.Emit(ctx, event)
 }
 
 func (f InteractorFactory) getUtilitiesForMySQL(mysqlVersion version.DatabaseServerVersion) (string, string, error) {
@@ -123,7 +181,7 @@ func (f InteractorFactory) getUtilitiesForMySQL(mysqlVersion version.DatabaseSer
 		}
 	}
 
-	return "", "", fmt.Errorf("unsupported version of %s: %s.%s", implementation, semVer.Major, semVer.Minor)
+	return "", "", UnsupportedVersionError{Implementation: implementation, Major: semVer.Major, Minor: semVer.Minor}
 }
 
 func (f InteractorFactory) getSSLCommandProvider(mysqlVersion version.DatabaseServerVersion) mysql.SSLOptionsProvider {
@@ -167,5 +225,5 @@ func (f InteractorFactory) getUtilitiesForPostgres(postgresVersion version.Datab
 			nil
 	}
 
-	return "", "", "", fmt.Errorf("unsupported version of postgresql: %s.%s", semVer.Major, semVer.Minor)
+	return "", "", "", UnsupportedVersionError{Implementation: "postgresql", Major: semVer.Major, Minor: semVer.Minor}
 }
@@ -1,6 +1,7 @@
 package database_test
 
 import (
+	"context"
 	"database-backup-restore/config"
 	"database-backup-restore/database"
 	"database-backup-restore/database/fakes"
@@ -34,7 +35,7 @@ var _ = Describe("InteractorFactory", func() {
 			mysqlServerVersionDetector,
 			tempFolderManager)
 
-		interactor, factoryError = interactorFactory.Make(action, connectionConfig)
+		interactor, factoryError = interactorFactory.Make(context.Background(), action, connectionConfig)
 	})
 
 	BeforeEach(func() {
@@ -155,6 +156,29 @@ var _ = Describe("InteractorFactory", func() {
 					Expect(factoryError).To(MatchError(ContainSubstring("unsupported version of postgres")))
 				})
 			})
+
+			Context("when the disk space check is enabled", func() {
+				BeforeEach(func() {
+					connectionConfig = config.ConnectionConfig{Adapter: "postgres", CheckDiskSpace: true}
+					postgresServerVersionDetector.GetVersionReturns(
+						version.DatabaseServerVersion{Implementation: "postgres", SemanticVersion: version.SemanticVersion{Major: "13", Minor: "2", Patch: "1"}},
+						nil)
+				})
+
+				It("builds a database.TableCheckingInteractor around a database.DiskSpaceCheckingInteractor", func() {
+					Expect(factoryError).NotTo(HaveOccurred())
+					Expect(interactor).To(Equal(
+						database.NewTableCheckingInteractor(connectionConfig,
+							postgres.NewTableChecker(connectionConfig, "pg_p_13_client"),
+							database.NewDiskSpaceCheckingInteractor(
+								postgres.NewSizeEstimator(connectionConfig, tempFolderManager, "pg_p_13_client"),
+								database.NewStatfsDiskSpaceReporter(),
+								postgres.NewBackuper(connectionConfig, tempFolderManager, "pg_p_13_dump"),
+							),
+						),
+					))
+				})
+			})
 		})
 
 		Context("when the action is 'restore'", func() {
@@ -228,6 +252,29 @@ var _ = Describe("InteractorFactory", func() {
 				})
 			})
 
+			Context("when a safety dump path is configured", func() {
+				BeforeEach(func() {
+					connectionConfig = config.ConnectionConfig{
+						Adapter: "postgres",
+						Restore: config.RestoreConfig{SafetyDumpPath: "/safety/dump"},
+					}
+					postgresServerVersionDetector.GetVersionReturns(
+						version.DatabaseServerVersion{Implementation: "postgres", SemanticVersion: version.SemanticVersion{Major: "16", Minor: "3", Patch: "0"}},
+						nil)
+				})
+
+				It("builds a database.SafetyDumpInteractor", func() {
+					Expect(factoryError).NotTo(HaveOccurred())
+					Expect(interactor).To(Equal(
+						database.NewSafetyDumpInteractor(
+							"/safety/dump",
+							postgres.NewBackuper(connectionConfig, tempFolderManager, "pg_p_16_dump"),
+							postgres.NewRestorer(connectionConfig, tempFolderManager, "pg_p_16_restore"),
+						),
+					))
+				})
+			})
+
 		})
 
 		Context("when the server version detection fails", func() {
@@ -242,6 +289,7 @@ var _ = Describe("InteractorFactory", func() {
 			It("fails", func() {
 				Expect(interactor).To(BeNil())
 				Expect(factoryError).To(MatchError("server version detection test error"))
+				Expect(factoryError).To(BeAssignableToTypeOf(database.ConnectionError{}))
 			})
 		})
 	})
@@ -353,6 +401,7 @@ var _ = Describe("InteractorFactory", func() {
 
 				It("errors", func() {
 					Expect(factoryError).To(MatchError("unsupported version of mariadb: 5.5"))
+					Expect(factoryError).To(Equal(database.UnsupportedVersionError{Implementation: "mariadb", Major: "5", Minor: "5"}))
 				})
 			})
 		})
@@ -379,6 +428,37 @@ var _ = Describe("InteractorFactory", func() {
 					))
 				})
 			})
+
+			Context("when a safety dump path is configured", func() {
+				BeforeEach(func() {
+					connectionConfig = config.ConnectionConfig{
+						Adapter: "mysql",
+						Restore: config.RestoreConfig{SafetyDumpPath: "/safety/dump"},
+					}
+					mysqlServerVersionDetector.GetVersionReturns(
+						version.DatabaseServerVersion{
+							Implementation:  "mariadb",
+							SemanticVersion: version.SemanticVersion{Major: "10", Minor: "3"}}, nil)
+				})
+
+				It("builds a database.SafetyDumpInteractor", func() {
+					Expect(factoryError).NotTo(HaveOccurred())
+					Expect(interactor).To(Equal(
+						database.NewSafetyDumpInteractor(
+							"/safety/dump",
+							mysql.NewBackuper(
+								connectionConfig,
+								"mariadb_dump",
+								mysql.NewLegacySSLOptionsProvider(tempFolderManager),
+								mysql.NewEmptyAdditionalOptionsProvider()),
+							mysql.NewRestorer(
+								connectionConfig,
+								"mariadb_restore",
+								mysql.NewLegacySSLOptionsProvider(tempFolderManager)),
+						),
+					))
+				})
+			})
 			Context("when the version is detected as MySQL 8.0.27", func() {
 				BeforeEach(func() {
 					mysqlServerVersionDetector.GetVersionReturns(
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+type SafetyDumpInteractor struct {
+	safetyDumpPath string
+	backuper       Interactor
+	restorer       Interactor
+}
+
+func NewSafetyDumpInteractor(safetyDumpPath string, backuper Interactor, restorer Interactor) SafetyDumpInteractor {
+	return SafetyDumpInteractor{
+		safetyDumpPath: safetyDumpPath,
+		backuper:       backuper,
+		restorer:       restorer,
+	}
+}
+
+func (i SafetyDumpInteractor) Action(ctx context.Context, artifactFilePath string) error {
+	log.Printf("Backing up the current state of the database to %s before restoring\n", i.safetyDumpPath)
+
+	err := i.backuper.Action(ctx, i.safetyDumpPath)
+	if err != nil {
+		return fmt.Errorf("failed to take safety dump before restore: %w", err)
+	}
+
+	err = i.restorer.Action(ctx, artifactFilePath)
+	if err != nil {
+		return fmt.Errorf("%w\nThe database state from before the restore can be restored from the safety dump at %s", err, i.safetyDumpPath)
+	}
+
+	return nil
+}
+
+func (i SafetyDumpInteractor) Plan(artifactFilePath string) ([]string, error) {
+	backupPlan, err := i.backuper.Plan(i.safetyDumpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	restorePlan, err := i.restorer.Plan(artifactFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(backupPlan, restorePlan...), nil
+}
@@ -0,0 +1,89 @@
+package database_test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+
+	. "github.com/onsi/gomega"
+
+	"database-backup-restore/database"
+	"database-backup-restore/database/fakes"
+)
+
+var _ = Describe("SafetyDumpInteractor", func() {
+	var (
+		backuper             *fakes.FakeInteractor
+		restorer             *fakes.FakeInteractor
+		safetyDumpInteractor database.SafetyDumpInteractor
+		returnError          error
+		safetyDumpPath       = "/safety/dump/path"
+		artifactPath         = "/artifact/file/path"
+	)
+
+	BeforeEach(func() {
+		backuper = new(fakes.FakeInteractor)
+		restorer = new(fakes.FakeInteractor)
+	})
+
+	JustBeforeEach(func() {
+		safetyDumpInteractor = database.NewSafetyDumpInteractor(safetyDumpPath, backuper, restorer)
+		returnError = safetyDumpInteractor.Action(context.Background(), artifactPath)
+	})
+
+	Context("when the safety dump and the restore succeed", func() {
+		It("backs up the database before restoring it", func() {
+			By("dumping the database to the safety dump path", func() {
+				Expect(backuper.ActionCallCount()).To(Equal(1))
+				_, actionArtifactPath := backuper.ActionArgsForCall(0)
+				Expect(actionArtifactPath).To(Equal(safetyDumpPath))
+			})
+
+			By("restoring the artifact", func() {
+				Expect(restorer.ActionCallCount()).To(Equal(1))
+				_, actionArtifactPath := restorer.ActionArgsForCall(0)
+				Expect(actionArtifactPath).To(Equal(artifactPath))
+			})
+
+			By("succeeding", func() {
+				Expect(returnError).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when the safety dump fails", func() {
+		BeforeEach(func() {
+			backuper.ActionReturns(fmt.Errorf("dump test error"))
+		})
+
+		It("fails without restoring", func() {
+			Expect(restorer.ActionCallCount()).To(Equal(0))
+			Expect(returnError).To(MatchError("failed to take safety dump before restore: dump test error"))
+		})
+	})
+
+	Context("when planning", func() {
+		BeforeEach(func() {
+			backuper.PlanReturns([]string{"dump"}, nil)
+			restorer.PlanReturns([]string{"restore"}, nil)
+		})
+
+		It("plans the safety dump before the restore", func() {
+			Expect(safetyDumpInteractor.Plan(artifactPath)).To(Equal([]string{"dump", "restore"}))
+			Expect(backuper.PlanArgsForCall(0)).To(Equal(safetyDumpPath))
+			Expect(restorer.PlanArgsForCall(0)).To(Equal(artifactPath))
+		})
+	})
+
+	Context("when the restore fails", func() {
+		BeforeEach(func() {
+			restorer.ActionReturns(fmt.Errorf("restore test error"))
+		})
+
+		It("returns an error which points at the safety dump", func() {
+			Expect(returnError).To(MatchError(ContainSubstring("restore test error")))
+			Expect(returnError).To(MatchError(ContainSubstring(safetyDumpPath)))
+		})
+	})
+})
@@ -1,8 +1,7 @@
 package database
 
 import (
-	"fmt"
-
+	"context"
 	"strings"
 
 	"database-backup-restore/config"
@@ -11,7 +10,7 @@ import (
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate -o fakes/fake_table_checker.go . TableChecker
 type TableChecker interface {
-	FindMissingTables(tableNames []string) ([]string, error)
+	FindMissingTables(ctx context.Context, tableNames []string) ([]string, error)
 }
 
 type TableCheckingInteractor struct {
@@ -32,16 +31,28 @@ func NewTableCheckingInteractor(
 	}
 }
 
-func (i TableCheckingInteractor) Action(artifactFilePath string) error {
+func (i TableCheckingInteractor) Action(ctx context.Context, artifactFilePath string) error {
 	if i.config.Tables != nil {
-		missingTables, err := i.tableChecker.FindMissingTables(i.config.Tables)
+		missingTables, err := i.tableChecker.FindMissingTables(ctx, i.config.Tables)
 		if err != nil {
 			return err
 		}
 		if len(missingTables) != 0 {
-			return fmt.Errorf("can't find specified table(s): %s",
-				strings.Join(missingTables, ", "))
+			return MissingTablesError{Tables: missingTables}
 		}
 	}
-	return i.interactor.Action(artifactFilePath)
+	return i.interactor.Action(ctx, artifactFilePath)
+}
+
+func (i TableCheckingInteractor) Plan(artifactFilePath string) ([]string, error) {
+	interactorPlan, err := i.interactor.Plan(artifactFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []string
+	if i.config.Tables != nil {
+		plan = append(plan, "check that the tables exist: "+strings.Join(i.config.Tables, ", "))
+	}
+	return append(plan, interactorPlan...), nil
 }
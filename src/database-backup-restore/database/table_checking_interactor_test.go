@@ -1,6 +1,7 @@
 package database_test
 
 import (
+	"context"
 	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -30,7 +31,7 @@ var _ = Describe("TableCheckingInteractor", func() {
 	JustBeforeEach(func() {
 		tableCheckingInteractor =
 			database.NewTableCheckingInteractor(cfg, tableChecker, interactor)
-		returnError = tableCheckingInteractor.Action(artifactPath)
+		returnError = tableCheckingInteractor.Action(context.Background(), artifactPath)
 	})
 
 	Context("when tables are specified", func() {
@@ -48,12 +49,14 @@ var _ = Describe("TableCheckingInteractor", func() {
 
 			It("delegates to the wrapped interactor", func() {
 				By("passing the right tables to the TableChecker", func() {
-					Expect(tableChecker.FindMissingTablesArgsForCall(0)).To(Equal(cfg.Tables))
+					_, tableNames := tableChecker.FindMissingTablesArgsForCall(0)
+					Expect(tableNames).To(Equal(cfg.Tables))
 				})
 
 				By("calling its Action method", func() {
 					Expect(interactor.ActionCallCount()).To(Equal(1))
-					Expect(interactor.ActionArgsForCall(0)).To(Equal(artifactPath))
+					_, actionArtifactPath := interactor.ActionArgsForCall(0)
+					Expect(actionArtifactPath).To(Equal(artifactPath))
 				})
 
 				By("returning its return value", func() {
@@ -62,6 +65,21 @@ var _ = Describe("TableCheckingInteractor", func() {
 			})
 		})
 
+		Context("when planning", func() {
+			BeforeEach(func() {
+				tableChecker.FindMissingTablesReturns([]string{}, nil)
+				interactor.PlanReturns([]string{"dump"}, nil)
+			})
+
+			It("plans the table check before the wrapped interactor's steps", func() {
+				Expect(tableCheckingInteractor.Plan(artifactPath)).To(Equal([]string{
+					"check that the tables exist: table1, table2, table3",
+					"dump",
+				}))
+				Expect(interactor.PlanArgsForCall(0)).To(Equal(artifactPath))
+			})
+		})
+
 		Context("when some tables don't exist", func() {
 			BeforeEach(func() {
 				tableChecker.FindMissingTablesReturns([]string{"table2", "table3"}, nil)
@@ -69,7 +87,8 @@ var _ = Describe("TableCheckingInteractor", func() {
 
 			It("fails", func() {
 				By("passing the right tables to the TableChecker", func() {
-					Expect(tableChecker.FindMissingTablesArgsForCall(0)).To(Equal(cfg.Tables))
+					_, tableNames := tableChecker.FindMissingTablesArgsForCall(0)
+					Expect(tableNames).To(Equal(cfg.Tables))
 				})
 
 				By("not calling its Action method", func() {
@@ -78,6 +97,7 @@ var _ = Describe("TableCheckingInteractor", func() {
 
 				By("returning an informative error", func() {
 					Expect(returnError).To(MatchError("can't find specified table(s): table2, table3"))
+					Expect(returnError).To(BeAssignableToTypeOf(database.MissingTablesError{}))
 				})
 			})
 		})
@@ -89,7 +109,8 @@ var _ = Describe("TableCheckingInteractor", func() {
 
 			It("fails", func() {
 				By("passing the right tables to the TableChecker", func() {
-					Expect(tableChecker.FindMissingTablesArgsForCall(0)).To(Equal(cfg.Tables))
+					_, tableNames := tableChecker.FindMissingTablesArgsForCall(0)
+					Expect(tableNames).To(Equal(cfg.Tables))
 				})
 
 				By("not calling its Action method", func() {
@@ -118,7 +139,8 @@ var _ = Describe("TableCheckingInteractor", func() {
 
 			By("calling its Action method", func() {
 				Expect(interactor.ActionCallCount()).To(Equal(1))
-				Expect(interactor.ActionArgsForCall(0)).To(Equal(artifactPath))
+				_, actionArtifactPath := interactor.ActionArgsForCall(0)
+				Expect(actionArtifactPath).To(Equal(artifactPath))
 			})
 
 			By("returning its return value", func() {
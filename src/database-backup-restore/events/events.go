@@ -0,0 +1,111 @@
+// Package events reports the progress of backups and restores as structured
+// events, for callers which need to parse it rather than read it.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	PhaseStarted    = "phase_started"
+	PhaseFinished   = "phase_finished"
+	VersionDetected = "version_detected"
+	UtilityChosen   = "utility_chosen"
+	TableProgress   = "table_progress"
+	UtilityOutput   = "utility_output"
+	Log             = "log"
+	Plan            = "plan"
+
+	DatabaseFinished = "database_finished"
+)
+
+type Event struct {
+	Time            time.Time `json:"time"`
+	Event           string    `json:"event"`
+	Database        string    `json:"database,omitempty"`
+	Phase           string    `json:"phase,omitempty"`
+	Implementation  string    `json:"implementation,omitempty"`
+	Version         string    `json:"version,omitempty"`
+	Utility         string    `json:"utility,omitempty"`
+	SSLProvider     string    `json:"ssl_provider,omitempty"`
+	Table           string    `json:"table,omitempty"`
+	Message         string    `json:"message,omitempty"`
+	Steps           []string  `json:"steps,omitempty"`
+	BytesWritten    int64     `json:"bytes_written,omitempty"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+	ErrorClass      string    `json:"error_class,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+type Sink interface {
+	Emit(Event)
+}
+
+// JSONSink writes each event as a single line of JSON.
+type JSONSink struct {
+	mutex  *sync.Mutex
+	writer io.Writer
+}
+
+func NewJSONSink(writer io.Writer) JSONSink {
+	return JSONSink{mutex: new(sync.Mutex), writer: writer}
+}
+
+func (s JSONSink) Emit(event Event) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.writer.Write(append(line, '\n'))
+}
+
+type sinkKey struct{}
+
+func WithSink(ctx context.Context, sink Sink) context.Context {
+	return context.WithValue(ctx, sinkKey{}, sink)
+}
+
+func SinkFrom(ctx context.Context) (Sink, bool) {
+	sink, ok := ctx.Value(sinkKey{}).(Sink)
+	return sink, ok
+}
+
+// Emit sends event to the sink attached to ctx, if there is one.
+func Emit(ctx context.Context, event Event) {
+	sink, ok := SinkFrom(ctx)
+	if !ok {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	sink.Emit(event)
+}
+
+// WithDatabase labels the events sent through the returned context with the
+// ID of the database they are about, when one config file holds several.
+func WithDatabase(ctx context.Context, id string) context.Context {
+	sink, ok := SinkFrom(ctx)
+	if !ok {
+		return ctx
+	}
+	return WithSink(ctx, databaseSink{sink: sink, id: id})
+}
+
+type databaseSink struct {
+	sink Sink
+	id   string
+}
+
+func (s databaseSink) Emit(event Event) {
+	event.Database = s.id
+	s.sink.Emit(event)
+}
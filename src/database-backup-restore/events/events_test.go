@@ -0,0 +1,109 @@
+package events_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"database-backup-restore/events"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type recordingSink struct {
+	events *[]events.Event
+}
+
+func (s recordingSink) Emit(event events.Event) {
+	*s.events = append(*s.events, event)
+}
+
+var _ = Describe("Emit", func() {
+	It("writes one line of JSON per event to the sink on the context", func() {
+		output := new(bytes.Buffer)
+		ctx := events.WithSink(context.Background(), events.NewJSONSink(output))
+
+		events.Emit(ctx, events.Event{Event: events.PhaseStarted, Phase: "backup"})
+		events.Emit(ctx, events.Event{Event: events.PhaseFinished, Phase: "backup", BytesWritten: 42})
+
+		lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+		Expect(lines).To(HaveLen(2))
+
+		var finished map[string]interface{}
+		Expect(json.Unmarshal([]byte(lines[1]), &finished)).To(Succeed())
+		Expect(finished).To(HaveKeyWithValue("event", "phase_finished"))
+		Expect(finished).To(HaveKeyWithValue("phase", "backup"))
+		Expect(finished).To(HaveKeyWithValue("bytes_written", BeNumerically("==", 42)))
+		Expect(finished).To(HaveKey("time"))
+		Expect(finished).NotTo(HaveKey("error"))
+	})
+
+	It("does nothing when there is no sink on the context", func() {
+		Expect(func() {
+			events.Emit(context.Background(), events.Event{Event: events.Log})
+		}).NotTo(Panic())
+	})
+})
+
+var _ = Describe("UtilityOutputWriter", func() {
+	var emitted []events.Event
+	var writer events.UtilityOutputWriter
+
+	BeforeEach(func() {
+		emitted = nil
+		writer = events.NewUtilityOutputWriter(recordingSink{events: &emitted}, "/usr/bin/pg_dump")
+	})
+
+	It("emits an event per line, including lines split across writes", func() {
+		writer.Write([]byte("pg_dump: reading sch"))
+		writer.Write([]byte("emas\npg_dump: dumping contents of table \"public.people\"\n"))
+
+		Expect(emitted).To(HaveLen(3))
+		Expect(emitted[0]).To(matchEvent(events.UtilityOutput, "pg_dump", "pg_dump: reading schemas", ""))
+		Expect(emitted[1]).To(matchEvent(events.UtilityOutput, "pg_dump", `pg_dump: dumping contents of table "public.people"`, ""))
+		Expect(emitted[2]).To(matchEvent(events.TableProgress, "pg_dump", "", "public.people"))
+	})
+
+	It("emits the last line on flush when it has no newline", func() {
+		writer.Write([]byte("pg_dump: finished"))
+		Expect(emitted).To(BeEmpty())
+
+		writer.Flush()
+		Expect(emitted).To(HaveLen(1))
+		Expect(emitted[0].Message).To(Equal("pg_dump: finished"))
+	})
+})
+
+var _ = Describe("LogWriter", func() {
+	It("emits each log line as a log event", func() {
+		var emitted []events.Event
+		events.NewLogWriter(recordingSink{events: &emitted}).Write([]byte("Postgres server version 13.1\n"))
+
+		Expect(emitted).To(HaveLen(1))
+		Expect(emitted[0].Event).To(Equal(events.Log))
+		Expect(emitted[0].Message).To(Equal("Postgres server version 13.1"))
+	})
+})
+
+var _ = DescribeTable("ParseTableProgress",
+	func(line, expectedTable string, expectedOk bool) {
+		table, ok := events.ParseTableProgress(line)
+		Expect(ok).To(Equal(expectedOk))
+		Expect(table).To(Equal(expectedTable))
+	},
+	Entry("pg_dump", `pg_dump: dumping contents of table "public.people"`, "public.people", true),
+	Entry("pg_restore", `pg_restore: processing data for table "public.people"`, "public.people", true),
+	Entry("mysqldump", "-- Retrieving table structure for table `people`...", "people", true),
+	Entry("other output", "pg_dump: reading extensions", "", false),
+)
+
+func matchEvent(event, utility, message, table string) OmegaMatcher {
+	return SatisfyAll(
+		WithTransform(func(e events.Event) string { return e.Event }, Equal(event)),
+		WithTransform(func(e events.Event) string { return e.Utility }, Equal(utility)),
+		WithTransform(func(e events.Event) string { return e.Message }, Equal(message)),
+		WithTransform(func(e events.Event) string { return e.Table }, Equal(table)),
+	)
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogWriter turns the lines written by the standard logger into log events.
+type LogWriter struct {
+	sink Sink
+}
+
+func NewLogWriter(sink Sink) LogWriter {
+	return LogWriter{sink: sink}
+}
+
+func (w LogWriter) Write(p []byte) (int, error) {
+	w.sink.Emit(Event{
+		Time:    time.Now().UTC(),
+		Event:   Log,
+		Message: strings.TrimRight(string(p), "\n"),
+	})
+	return len(p), nil
+}
+
+// UtilityOutputWriter turns the lines a dump or restore utility writes into
+// utility output events, and into table progress events when a line says
+// which table the utility has moved on to.
+type UtilityOutputWriter struct {
+	sink    Sink
+	utility string
+	mutex   *sync.Mutex
+	buffer  *bytes.Buffer
+}
+
+func NewUtilityOutputWriter(sink Sink, utilityPath string) UtilityOutputWriter {
+	return UtilityOutputWriter{
+		sink:    sink,
+		utility: filepath.Base(utilityPath),
+		mutex:   new(sync.Mutex),
+		buffer:  new(bytes.Buffer),
+	}
+}
+
+func (w UtilityOutputWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buffer.Write(p)
+	for {
+		line, err := w.buffer.ReadString('\n')
+		if err != nil {
+			w.buffer.WriteString(line)
+			break
+		}
+		w.emitLine(strings.TrimRight(line, "\r\n"))
+	}
+	return len(p), nil
+}
+
+// Flush emits any last line which did not end in a newline.
+func (w UtilityOutputWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.buffer.Len() > 0 {
+		w.emitLine(w.buffer.String())
+		w.buffer.Reset()
+	}
+}
+
+func (w UtilityOutputWriter) emitLine(line string) {
+	if line == "" {
+		return
+	}
+
+	now := time.Now().UTC()
+	w.sink.Emit(Event{Time: now, Event: UtilityOutput, Utility: w.utility, Message: line})
+
+	if table, ok := ParseTableProgress(line); ok {
+		w.sink.Emit(Event{Time: now, Event: TableProgress, Utility: w.utility, Table: table})
+	}
+}
+
+var tableProgressPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`dumping contents of table "?([^"\s]+)"?`),
+	regexp.MustCompile(`processing data for table "?([^"\s]+)"?`),
+	regexp.MustCompile("Retrieving table structure for table `?([^`\\s]+?)`?\\.\\.\\.$"),
+}
+
+// ParseTableProgress finds the table named in a line of pg_dump --verbose,
+// pg_restore --verbose or mysqldump -v output.
+func ParseTableProgress(line string) (string, bool) {
+	for _, pattern := range tableProgressPatterns {
+		if matches := pattern.FindStringSubmatch(line); matches != nil {
+			return matches[1], true
+		}
+	}
+	return "", false
+}
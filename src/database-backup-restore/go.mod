@@ -1,6 +1,7 @@
 module database-backup-restore
 
 require (
+	common v0.0.0
 	github.com/go-sql-driver/mysql v1.8.1
 	github.com/lib/pq v1.10.9
 	github.com/maxbrunsfeld/counterfeiter/v6 v6.12.2
@@ -28,3 +29,5 @@ require (
 )
 
 go 1.25.0
+
+replace common => ../common
@@ -86,6 +86,15 @@ var _ = Describe("Backup and Restore DB Utility", func() {
 					configGenerator: validPgConfig,
 					expectedOutput:  "Missing --artifact-file flag",
 				}),
+				Entry("both the artifact-file and artifact-dir are provided", TestEntry{
+					arguments:      "--backup --artifact-file /foo --artifact-dir /bar --config foo",
+					expectedOutput: "Only one of: --artifact-file or --artifact-dir can be provided",
+				}),
+				Entry("the artifact-dir is not provided for several databases", TestEntry{
+					arguments:       "--backup --artifact-file /foo --config %s",
+					configGenerator: multiplePgConfig,
+					expectedOutput:  "Missing --artifact-dir flag",
+				}),
 				Entry("is not a valid json", TestEntry{
 					arguments:       "--backup --artifact-file /foo --config %s",
 					configGenerator: invalidConfig,
@@ -116,6 +125,21 @@ var _ = Describe("Backup and Restore DB Utility", func() {
 					configGenerator: missingClientCertConfig,
 					expectedOutput:  "tls.cert.private_key specified but not tls.cert.certificate",
 				}),
+				Entry("unsupported restore mode", TestEntry{
+					arguments:       "--restore --artifact-file /foo --config %s",
+					configGenerator: invalidRestoreModeConfig,
+					expectedOutput:  "Unsupported restore.mode everything",
+				}),
+				Entry("mysql restore settings for a postgres database", TestEntry{
+					arguments:       "--restore --artifact-file /foo --config %s",
+					configGenerator: postgresWithMySQLRestoreConfig,
+					expectedOutput:  "restore.mysql specified for a postgres database",
+				}),
+				Entry("recreating the database when restoring only some tables", TestEntry{
+					arguments:       "--restore --artifact-file /foo --config %s",
+					configGenerator: recreateDatabaseWithTablesConfig,
+					expectedOutput:  "restore.mysql.recreate_database cannot be used with tables or a restore.mode",
+				}),
 			},
 		)
 	})
@@ -286,6 +310,29 @@ func missingClientCertConfig() (string, error) {
 	return validConfig.Name(), nil
 }
 
+func invalidRestoreModeConfig() (string, error) {
+	validConfig, err := os.CreateTemp(os.TempDir(), "")
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprint(validConfig,
+		`
+			{
+			  "username":"testuser",
+			  "password":"password",
+			  "host":"127.0.0.1",
+			  "port":1234,
+			  "database":"mycooldb",
+			  "adapter":"postgres",
+			  "restore": {
+					"mode": "everything"
+				}
+			}`,
+	)
+	return validConfig.Name(), nil
+}
+
 func validPgConfig() (string, error) {
 	validConfig, err := os.CreateTemp(os.TempDir(), "")
 	if err != nil {
@@ -306,6 +353,33 @@ func validPgConfig() (string, error) {
 	return validConfig.Name(), nil
 }
 
+func postgresWithMySQLRestoreConfig() (string, error) {
+	postgresConfig, err := os.CreateTemp(os.TempDir(), "")
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(postgresConfig, `{"adapter": "postgres", "restore": {"mysql": {"disable_unique_checks": true}}}`)
+	return postgresConfig.Name(), nil
+}
+
+func recreateDatabaseWithTablesConfig() (string, error) {
+	mysqlConfig, err := os.CreateTemp(os.TempDir(), "")
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(mysqlConfig, `{"adapter": "mysql", "tables": ["people"], "restore": {"mysql": {"recreate_database": true}}}`)
+	return mysqlConfig.Name(), nil
+}
+
+func multiplePgConfig() (string, error) {
+	multipleConfig, err := os.CreateTemp(os.TempDir(), "")
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(multipleConfig, `{"databases": {"uaa": {"adapter": "postgres", "database": "uaa"}}}`)
+	return multipleConfig.Name(), nil
+}
+
 func saveFile(content string) *os.File {
 	configFile, err := os.CreateTemp(os.TempDir(), time.Now().String())
 	Expect(err).NotTo(HaveOccurred())
@@ -606,6 +606,110 @@ var _ = Describe("MySQL", func() {
 						})
 					})
 				})
+
+				Context("when the restore mode is data-only", func() {
+					BeforeEach(func() {
+						configFile = saveFile(fmt.Sprintf(`{
+							"adapter":  "mysql",
+							"username": "%s",
+							"password": "%s",
+							"host":     "%s",
+							"port":     %d,
+							"database": "%s",
+							"restore":  {"mode": "data-only"}
+						}`,
+							username,
+							password,
+							host,
+							port,
+							databaseName))
+					})
+
+					It("streams the filtered dump to mysql", func() {
+						Expect(fakeMysqlClient80.Invocations()).To(HaveLen(2))
+						Expect(fakeMysqlClient80.Invocations()[1].Stdin()).Should(ConsistOf("SOME BACKUP SQL"))
+						Expect(session).Should(gexec.Exit(0))
+					})
+				})
+
+				Context("when the fast-restore settings are configured", func() {
+					BeforeEach(func() {
+						configFile = saveFile(fmt.Sprintf(`{
+							"adapter":  "mysql",
+							"username": "%s",
+							"password": "%s",
+							"host":     "%s",
+							"port":     %d,
+							"database": "%s",
+							"restore":  {
+								"mysql": {
+									"disable_foreign_key_checks": true,
+									"disable_unique_checks":      true,
+									"disable_binary_log":         true,
+									"max_allowed_packet":         "1G",
+									"init_command":               "SET SESSION innodb_lock_wait_timeout = 600"
+								}
+							}
+						}`,
+							username,
+							password,
+							host,
+							port,
+							databaseName))
+					})
+
+					It("applies the settings to the restore session", func() {
+						Expect(fakeMysqlClient80.Invocations()).To(HaveLen(2))
+						Expect(fakeMysqlClient80.Invocations()[1].Args()).Should(ConsistOf(
+							fmt.Sprintf("--user=%s", username),
+							fmt.Sprintf("--host=%s", host),
+							fmt.Sprintf("--port=%d", port),
+							"--max-allowed-packet=1G",
+							"--init-command=SET SESSION innodb_lock_wait_timeout = 600",
+							"-v",
+							databaseName,
+						))
+						Expect(fakeMysqlClient80.Invocations()[1].Stdin()).Should(Equal([]string{
+							"SET SESSION foreign_key_checks = 0, SESSION unique_checks = 0, SESSION sql_log_bin = 0;",
+							"SOME BACKUP SQL",
+						}))
+						Expect(session).Should(gexec.Exit(0))
+					})
+				})
+
+				Context("when the database should be recreated first", func() {
+					BeforeEach(func() {
+						configFile = saveFile(fmt.Sprintf(`{
+							"adapter":  "mysql",
+							"username": "%s",
+							"password": "%s",
+							"host":     "%s",
+							"port":     %d,
+							"database": "%s",
+							"restore":  {"mysql": {"recreate_database": true}}
+						}`,
+							username,
+							password,
+							host,
+							port,
+							databaseName))
+
+						fakeMysqlClient80.WhenCalled().WillExitWith(0)
+					})
+
+					It("drops and creates the database before restoring it", func() {
+						Expect(fakeMysqlClient80.Invocations()).To(HaveLen(3))
+						Expect(fakeMysqlClient80.Invocations()[1].Args()).Should(ConsistOf(
+							fmt.Sprintf("--user=%s", username),
+							fmt.Sprintf("--host=%s", host),
+							fmt.Sprintf("--port=%d", port),
+							fmt.Sprintf("--execute=DROP DATABASE IF EXISTS `%[1]s`; CREATE DATABASE `%[1]s`", databaseName),
+						))
+						Expect(fakeMysqlClient80.Invocations()[2].Args()).Should(ContainElement(databaseName))
+						Expect(fakeMysqlClient80.Invocations()[2].Stdin()).Should(ConsistOf("SOME BACKUP SQL"))
+						Expect(session).Should(gexec.Exit(0))
+					})
+				})
 			})
 
 			Context("when mysql fails", func() {
@@ -17,9 +17,12 @@
 package integration_tests
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 
@@ -263,8 +266,62 @@ var _ = Describe("Postgres", func() {
 					fakePgDump13.WhenCalled().WillExitWith(1)
 				})
 
-				It("also fails", func() {
+				It("also fails and removes the partial artifact", func() {
 					Eventually(session).Should(gexec.Exit(1))
+					Expect(artifactFile).NotTo(BeAnExistingFile())
+				})
+			})
+
+			Context("when the disk space check is enabled", func() {
+				BeforeEach(func() {
+					configFile = saveFile(fmt.Sprintf(`{
+								"adapter":  "postgres",
+								"username": "%s",
+								"password": "%s",
+								"host":     "%s",
+								"port":     %d,
+								"database": "%s",
+								"check_disk_space": true
+							}`,
+						username,
+						password,
+						host,
+						port,
+						databaseName))
+				})
+
+				Context("and there is enough free space", func() {
+					BeforeEach(func() {
+						fakePgClient.WhenCalled().WillPrintToStdOut(" 1024 \n\n").WillExitWith(0)
+						fakePgDump13.WhenCalled().WillExitWith(0)
+					})
+
+					It("queries the size of the database before taking a backup", func() {
+						Expect(fakePgClient.Invocations()).To(HaveLen(2))
+						Expect(fakePgClient.Invocations()[1].Args()).Should(ConsistOf(
+							"--tuples-only",
+							fmt.Sprintf("--username=%s", username),
+							fmt.Sprintf("--host=%s", host),
+							fmt.Sprintf("--port=%d", port),
+							databaseName,
+							"--command=SELECT pg_database_size(current_database());",
+						))
+						Expect(fakePgDump13.Invocations()).To(HaveLen(1))
+						Expect(session).Should(gexec.Exit(0))
+					})
+				})
+
+				Context("and there is not enough free space", func() {
+					BeforeEach(func() {
+						fakePgClient.WhenCalled().WillPrintToStdOut(" 18446744073709551615 \n\n").WillExitWith(0)
+					})
+
+					It("fails fast without taking a backup", func() {
+						Expect(session).Should(gexec.Exit(1))
+						Expect(session.Err).To(gbytes.Say("not enough disk space to back up the database"))
+						Expect(fakePgDump13.Invocations()).To(HaveLen(0))
+						Expect(artifactFile).NotTo(BeAnExistingFile())
+					})
 				})
 			})
 
@@ -898,6 +955,125 @@ var _ = Describe("Postgres", func() {
 					Eventually(session).Should(gexec.Exit(1))
 				})
 			})
+
+			Context("when the restore mode is data-only", func() {
+				BeforeEach(func() {
+					configFile = saveFile(fmt.Sprintf(`{
+							"adapter":  "postgres",
+							"username": "%s",
+							"password": "%s",
+							"host":     "%s",
+							"port":     %d,
+							"database": "%s",
+							"restore":  {"mode": "data-only"}
+						}`,
+						username,
+						password,
+						host,
+						port,
+						databaseName))
+					fakePgRestore13.WhenCalled().WillExitWith(0)
+					fakePgRestore13.WhenCalled().WillExitWith(0)
+				})
+
+				It("calls pg_restore without cleaning the database", func() {
+					expectedArgs := []interface{}{
+						"--verbose",
+						fmt.Sprintf("--username=%s", username),
+						fmt.Sprintf("--host=%s", host),
+						fmt.Sprintf("--port=%d", port),
+						"--format=custom",
+						fmt.Sprintf("--dbname=%s", databaseName),
+						"--data-only",
+						"--single-transaction",
+						"--exit-on-error",
+						HavePrefix("--use-list="),
+						artifactFile,
+					}
+
+					Expect(fakePgRestore13.Invocations()).To(HaveLen(2))
+					Expect(fakePgRestore13.Invocations()[1].Args()).Should(ConsistOf(expectedArgs))
+					Expect(session).Should(gexec.Exit(0))
+				})
+			})
+
+			Context("when the restore mode is no-clean", func() {
+				BeforeEach(func() {
+					configFile = saveFile(fmt.Sprintf(`{
+							"adapter":  "postgres",
+							"username": "%s",
+							"password": "%s",
+							"host":     "%s",
+							"port":     %d,
+							"database": "%s",
+							"restore":  {"mode": "no-clean"}
+						}`,
+						username,
+						password,
+						host,
+						port,
+						databaseName))
+					fakePgRestore13.WhenCalled().WillExitWith(0)
+					fakePgRestore13.WhenCalled().WillExitWith(0)
+				})
+
+				It("calls pg_restore without --clean", func() {
+					Expect(fakePgRestore13.Invocations()).To(HaveLen(2))
+					Expect(fakePgRestore13.Invocations()[1].Args()).NotTo(ContainElement("--clean"))
+					Expect(fakePgRestore13.Invocations()[1].Args()).To(ContainElement("--single-transaction"))
+					Expect(session).Should(gexec.Exit(0))
+				})
+			})
+
+			Context("when a safety dump path is configured", func() {
+				var safetyDumpPath string
+
+				BeforeEach(func() {
+					safetyDumpPath = tempFilePath()
+					configFile = saveFile(fmt.Sprintf(`{
+							"adapter":  "postgres",
+							"username": "%s",
+							"password": "%s",
+							"host":     "%s",
+							"port":     %d,
+							"database": "%s",
+							"restore":  {"safety_dump_path": "%s"}
+						}`,
+						username,
+						password,
+						host,
+						port,
+						databaseName,
+						safetyDumpPath))
+				})
+
+				Context("and pg_dump and pg_restore succeed", func() {
+					BeforeEach(func() {
+						fakePgDump13.WhenCalled().WillExitWith(0)
+						fakePgRestore13.WhenCalled().WillExitWith(0)
+						fakePgRestore13.WhenCalled().WillExitWith(0)
+					})
+
+					It("dumps the database to the safety dump path before restoring", func() {
+						Expect(fakePgDump13.Invocations()).To(HaveLen(1))
+						Expect(fakePgDump13.Invocations()[0].Args()).To(ContainElement(fmt.Sprintf("--file=%s", safetyDumpPath)))
+						Expect(fakePgRestore13.Invocations()).To(HaveLen(2))
+						Expect(session).Should(gexec.Exit(0))
+					})
+				})
+
+				Context("and the safety dump fails", func() {
+					BeforeEach(func() {
+						fakePgDump13.WhenCalled().WillExitWith(1)
+					})
+
+					It("fails without restoring", func() {
+						Eventually(session).Should(gexec.Exit(1))
+						Expect(session.Err).To(gbytes.Say("failed to take safety dump before restore"))
+						Expect(fakePgRestore13.Invocations()).To(HaveLen(0))
+					})
+				})
+			})
 		})
 
 		Context("Postgres database server is version 15", func() {
@@ -1095,6 +1271,282 @@ var _ = Describe("Postgres", func() {
 			})
 		})
 	})
+
+	Context("dry run", func() {
+		BeforeEach(func() {
+			fakePgClient.WhenCalled().WillPrintToStdOut(
+				" PostgreSQL 13.2 on x86_64-pc-linux-gnu, compiled by gcc " +
+					"(Ubuntu 5.4.0-6ubuntu1~16.04.12) 5.4.0 20160609, 64-bit").
+				WillExitWith(0)
+		})
+
+		Context("backup", func() {
+			JustBeforeEach(func() {
+				session = run(compiledSDKPath, envVars,
+					"--artifact-file", artifactFile,
+					"--config", configFile.Name(),
+					"--backup",
+					"--dry-run",
+				)
+			})
+
+			It("prints the pg_dump command without running it", func() {
+				Expect(session).Should(gexec.Exit(0))
+
+				Expect(fakePgClient.Invocations()).To(HaveLen(1))
+				Expect(fakePgDump13.Invocations()).To(HaveLen(0))
+
+				Expect(session.Err).To(gbytes.Say("Postgres server version 13.2.0"))
+				Expect(session.Out).To(gbytes.Say("Dry run: the backup would run the following steps"))
+				Expect(session.Out).To(gbytes.Say("PGPASSWORD=<redacted> " + fakePgDump13.Path))
+				Expect(session.Out).To(gbytes.Say(fmt.Sprintf("--file=%s %s", artifactFile, databaseName)))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring(password))
+			})
+		})
+
+		Context("restore", func() {
+			JustBeforeEach(func() {
+				session = run(compiledSDKPath, envVars,
+					"--artifact-file", artifactFile,
+					"--config", configFile.Name(),
+					"--restore",
+					"--dry-run",
+				)
+			})
+
+			It("prints the pg_restore commands without running them", func() {
+				Expect(session).Should(gexec.Exit(0))
+
+				Expect(fakePgRestore13.Invocations()).To(HaveLen(0))
+
+				Expect(session.Out).To(gbytes.Say("Dry run: the restore would run the following steps"))
+				Expect(session.Out).To(gbytes.Say(fakePgRestore13.Path + " --list " + artifactFile))
+				Expect(session.Out).To(gbytes.Say("PGPASSWORD=<redacted> " + fakePgRestore13.Path))
+				Expect(session.Out).To(gbytes.Say("--clean --if-exists --single-transaction"))
+			})
+		})
+
+		Context("with the json log format", func() {
+			JustBeforeEach(func() {
+				session = run(compiledSDKPath, envVars,
+					"--artifact-file", artifactFile,
+					"--config", configFile.Name(),
+					"--backup",
+					"--dry-run",
+					"--log-format", "json",
+				)
+			})
+
+			It("prints the plan as a line of JSON", func() {
+				Expect(session).Should(gexec.Exit(0))
+
+				var emitted []map[string]interface{}
+				for _, line := range strings.Split(strings.TrimSpace(string(session.Out.Contents())), "\n") {
+					var event map[string]interface{}
+					Expect(json.Unmarshal([]byte(line), &event)).To(Succeed(), line)
+					emitted = append(emitted, event)
+				}
+
+				Expect(emitted).To(ContainElement(SatisfyAll(
+					HaveKeyWithValue("event", "plan"),
+					HaveKeyWithValue("phase", "backup"),
+					HaveKeyWithValue("steps", ContainElement(ContainSubstring("PGPASSWORD=<redacted> "+fakePgDump13.Path))),
+				)))
+			})
+		})
+	})
+
+	Context("several databases", func() {
+		var artifactDirectory string
+
+		BeforeEach(func() {
+			var err error
+			artifactDirectory, err = os.MkdirTemp("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			configFile = saveFile(fmt.Sprintf(`{
+				"concurrency": 1,
+				"databases": [
+					{"id": "uaa", "adapter": "postgres", "username": "%[1]s", "password": "%[2]s",
+					 "host": "%[3]s", "port": %[4]d, "database": "uaa"},
+					{"id": "ccdb", "adapter": "postgres", "username": "%[1]s", "password": "%[2]s",
+					 "host": "%[3]s", "port": %[4]d, "database": "ccdb"}
+				]
+			}`, username, password, host, port))
+
+			fakePgClient.WhenCalled().WillPrintToStdOut(
+				" PostgreSQL 13.2 on x86_64-pc-linux-gnu, compiled by gcc " +
+					"(Ubuntu 5.4.0-6ubuntu1~16.04.12) 5.4.0 20160609, 64-bit").
+				WillExitWith(0)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(artifactDirectory)
+		})
+
+		Context("backup", func() {
+			JustBeforeEach(func() {
+				session = run(compiledSDKPath, envVars,
+					"--artifact-dir", artifactDirectory,
+					"--config", configFile.Name(),
+					"--backup",
+				)
+			})
+
+			Context("when every backup succeeds", func() {
+				BeforeEach(func() {
+					fakePgClient.WhenCalled().WillPrintToStdOut(
+						" PostgreSQL 13.2 on x86_64-pc-linux-gnu, compiled by gcc " +
+							"(Ubuntu 5.4.0-6ubuntu1~16.04.12) 5.4.0 20160609, 64-bit").
+						WillExitWith(0)
+					fakePgDump13.WhenCalled().WillExitWith(0)
+					fakePgDump13.WhenCalled().WillExitWith(0)
+				})
+
+				It("backs up each database to its own artifact and prints a summary", func() {
+					Expect(session).Should(gexec.Exit(0))
+
+					Expect(fakePgDump13.Invocations()).To(HaveLen(2))
+					Expect(fakePgDump13.Invocations()[0].Args()).To(ContainElements(
+						"--file="+filepath.Join(artifactDirectory, "uaa"), "uaa"))
+					Expect(fakePgDump13.Invocations()[1].Args()).To(ContainElements(
+						"--file="+filepath.Join(artifactDirectory, "ccdb"), "ccdb"))
+
+					Expect(filepath.Join(artifactDirectory, "uaa")).To(BeAnExistingFile())
+					Expect(filepath.Join(artifactDirectory, "ccdb")).To(BeAnExistingFile())
+
+					Expect(session.Out).To(gbytes.Say("Summary of the backup:"))
+					Expect(session.Out).To(gbytes.Say(`uaa: succeeded in`))
+					Expect(session.Out).To(gbytes.Say(`ccdb: succeeded in`))
+				})
+			})
+
+			Context("when one backup fails", func() {
+				BeforeEach(func() {
+					fakePgDump13.WhenCalled().WillExitWith(0)
+					fakePgClient.WhenCalled().WillExitWith(1)
+				})
+
+				It("backs up the other databases, and fails with a summary", func() {
+					Expect(session).Should(gexec.Exit(1))
+
+					Expect(filepath.Join(artifactDirectory, "uaa")).To(BeAnExistingFile())
+					Expect(filepath.Join(artifactDirectory, "ccdb")).NotTo(BeAnExistingFile())
+
+					Expect(session.Out).To(gbytes.Say(`uaa: succeeded in`))
+					Expect(session.Out).To(gbytes.Say(`ccdb: failed after .*: Unable to check version of Postgres`))
+					Expect(session.Err).To(gbytes.Say("1 of 2 database backups failed"))
+				})
+			})
+		})
+
+		Context("restore", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(filepath.Join(artifactDirectory, "uaa"), []byte("uaa dump"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(artifactDirectory, "ccdb"), []byte("ccdb dump"), 0600)).To(Succeed())
+
+				fakePgClient.WhenCalled().WillPrintToStdOut(
+					" PostgreSQL 13.2 on x86_64-pc-linux-gnu, compiled by gcc " +
+						"(Ubuntu 5.4.0-6ubuntu1~16.04.12) 5.4.0 20160609, 64-bit").
+					WillExitWith(0)
+				for range []string{"uaa", "ccdb"} {
+					fakePgRestore13.WhenCalled().WillExitWith(0)
+					fakePgRestore13.WhenCalled().WillExitWith(0)
+				}
+			})
+
+			JustBeforeEach(func() {
+				session = run(compiledSDKPath, envVars,
+					"--artifact-dir", artifactDirectory,
+					"--config", configFile.Name(),
+					"--restore",
+				)
+			})
+
+			It("restores each database from its own artifact", func() {
+				Expect(session).Should(gexec.Exit(0))
+
+				Expect(fakePgRestore13.Invocations()).To(HaveLen(4))
+				Expect(fakePgRestore13.Invocations()[0].Args()).To(ContainElement(filepath.Join(artifactDirectory, "uaa")))
+				Expect(fakePgRestore13.Invocations()[2].Args()).To(ContainElement(filepath.Join(artifactDirectory, "ccdb")))
+
+				Expect(session.Out).To(gbytes.Say("Summary of the restore:"))
+			})
+		})
+	})
+
+	Context("json log format", func() {
+		BeforeEach(func() {
+			fakePgClient.WhenCalled().WillPrintToStdOut(
+				" PostgreSQL 13.2 on x86_64-pc-linux-gnu, compiled by gcc " +
+					"(Ubuntu 5.4.0-6ubuntu1~16.04.12) 5.4.0 20160609, 64-bit").
+				WillExitWith(0)
+		})
+
+		JustBeforeEach(func() {
+			session = run(compiledSDKPath, envVars,
+				"--artifact-file", artifactFile,
+				"--config", configFile.Name(),
+				"--backup",
+				"--log-format", "json",
+			)
+		})
+
+		Context("and pg_dump succeeds", func() {
+			BeforeEach(func() {
+				fakePgDump13.WhenCalled().
+					WillPrintToStdErr(`pg_dump: dumping contents of table "public.people"`).
+					WillExitWith(0)
+			})
+
+			It("prints each step of the backup as a line of JSON", func() {
+				Expect(session).Should(gexec.Exit(0))
+
+				var emitted []map[string]interface{}
+				for _, line := range strings.Split(strings.TrimSpace(string(session.Out.Contents())), "\n") {
+					var event map[string]interface{}
+					Expect(json.Unmarshal([]byte(line), &event)).To(Succeed(), line)
+					emitted = append(emitted, event)
+				}
+
+				Expect(emitted).To(ContainElement(SatisfyAll(
+					HaveKeyWithValue("event", "phase_started"),
+					HaveKeyWithValue("phase", "detect_version"),
+				)))
+				Expect(emitted).To(ContainElement(SatisfyAll(
+					HaveKeyWithValue("event", "version_detected"),
+					HaveKeyWithValue("implementation", "postgres"),
+					HaveKeyWithValue("version", "13.2.0"),
+				)))
+				Expect(emitted).To(ContainElement(SatisfyAll(
+					HaveKeyWithValue("event", "utility_chosen"),
+					HaveKeyWithValue("utility", fakePgDump13.Path),
+				)))
+				Expect(emitted).To(ContainElement(SatisfyAll(
+					HaveKeyWithValue("event", "table_progress"),
+					HaveKeyWithValue("table", "public.people"),
+				)))
+				Expect(emitted).To(ContainElement(SatisfyAll(
+					HaveKeyWithValue("event", "phase_finished"),
+					HaveKeyWithValue("phase", "backup"),
+					HaveKey("duration_seconds"),
+					Not(HaveKey("error")),
+				)))
+			})
+		})
+
+		Context("and pg_dump fails", func() {
+			BeforeEach(func() {
+				fakePgDump13.WhenCalled().WillExitWith(1)
+			})
+
+			It("reports the class of the error", func() {
+				Expect(session).Should(gexec.Exit(1))
+				Expect(session.Out).To(gbytes.Say(`"event":"phase_finished","phase":"backup"`))
+				Expect(session.Out).To(gbytes.Say(`"error_class":"UtilityError"`))
+			})
+		})
+	})
 })
 
 func run(path string, env map[string]string, args ...string) *gexec.Session {
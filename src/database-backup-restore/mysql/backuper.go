@@ -1,7 +1,10 @@
 package mysql
 
 import (
+	"context"
+
 	"database-backup-restore/config"
+	"database-backup-restore/runner"
 )
 
 type Backuper struct {
@@ -21,7 +24,30 @@ func NewBackuper(config config.ConnectionConfig, backupBinary string, sslOptions
 	}
 }
 
-func (b Backuper) Action(artifactFilePath string) error {
+func (b Backuper) Action(ctx context.Context, artifactFilePath string) error {
+	command, err := b.command(artifactFilePath)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = command.WithContext(ctx).Run()
+
+	return err
+}
+
+func (b Backuper) Plan(artifactFilePath string) ([]string, error) {
+	command, err := b.command(artifactFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{
+		"using the " + b.sslOptionsProvider.Name() + " SSL options",
+		command.String(),
+	}, nil
+}
+
+func (b Backuper) command(artifactFilePath string) (runner.Command, error) {
 	cmdArgs := []string{
 		"-v",
 		"--skip-add-locks",
@@ -33,7 +59,10 @@ func (b Backuper) Action(artifactFilePath string) error {
 	cmdArgs = append(cmdArgs, b.config.Tables...)
 	cmdArgs = append(cmdArgs, b.additionalOptionsProvider.BuildParams()...)
 
-	_, _, err := NewMysqlCommand(b.config, b.backupBinary, b.sslOptionsProvider).WithParams(cmdArgs...).Run()
+	command, err := NewMysqlCommand(b.config, b.backupBinary, b.sslOptionsProvider)
+	if err != nil {
+		return runner.Command{}, err
+	}
 
-	return err
+	return command.WithParams(cmdArgs...), nil
 }
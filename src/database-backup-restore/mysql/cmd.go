@@ -7,14 +7,18 @@ import (
 	"database-backup-restore/runner"
 )
 
-func NewMysqlCommand(config config.ConnectionConfig, cmd string, sslOptionsProvider SSLOptionsProvider) runner.Command {
+func NewMysqlCommand(config config.ConnectionConfig, cmd string, sslOptionsProvider SSLOptionsProvider) (runner.Command, error) {
 	cmdArgs := []string{
 		"--user=" + config.Username,
 		"--host=" + config.Host,
 		fmt.Sprintf("--port=%d", config.Port),
 	}
 
-	cmdArgs = append(cmdArgs, sslOptionsProvider.BuildSSLParams(config.Tls)...)
+	sslParams, err := sslOptionsProvider.BuildSSLParams(config.Tls)
+	if err != nil {
+		return runner.Command{}, err
+	}
+	cmdArgs = append(cmdArgs, sslParams...)
 
-	return runner.NewCommand(cmd).WithParams(cmdArgs...).WithEnv(map[string]string{"MYSQL_PWD": config.Password})
+	return runner.NewCommand(cmd).WithParams(cmdArgs...).WithEnv(map[string]string{"MYSQL_PWD": config.Password}), nil
 }
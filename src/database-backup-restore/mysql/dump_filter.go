@@ -0,0 +1,136 @@
+package mysql
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"database-backup-restore/config"
+)
+
+const (
+	createTablePrefix            = "CREATE TABLE "
+	createTableIfNotExistsPrefix = "CREATE TABLE IF NOT EXISTS "
+	delimiterPrefix              = "DELIMITER "
+	defaultDelimiter             = ";"
+	maxStatementStart            = 1024
+)
+
+// statementKind is what a statement of a dump does, which decides whether a
+// restore mode runs it.
+type statementKind int
+
+const (
+	otherStatement statementKind = iota
+	dropTableStatement
+	createTableStatement
+	insertStatement
+	// dropObjectStatement and createObjectStatement drop and create the
+	// views, triggers, routines and events of the database.
+	dropObjectStatement
+	createObjectStatement
+)
+
+var (
+	// versionCommentPattern matches the markers of the version-specific
+	// comments mysqldump wraps statements in, such as "/*!50001 " and "*/".
+	versionCommentPattern = regexp.MustCompile(`/\*!\d*|\*/`)
+	dropObjectPattern     = regexp.MustCompile(`^DROP (VIEW|TRIGGER|PROCEDURE|FUNCTION|EVENT)\b`)
+	createObjectPattern   = regexp.MustCompile(`^CREATE\b.*?\b(VIEW|TRIGGER|PROCEDURE|FUNCTION|EVENT)\b`)
+)
+
+// FilterDump copies a mysqldump file from reader to writer, leaving out or
+// rewriting the statements which the given restore mode must not run.
+//
+// data-only leaves out the statements which drop or create tables, views,
+// triggers, routines and events. no-clean leaves out those which drop them,
+// creates the tables only if they do not exist, and leaves out the views,
+// triggers, routines and events, which MySQL cannot create only if they do
+// not exist. schema-only leaves out the data.
+func FilterDump(mode string, reader io.Reader, writer io.Writer) error {
+	bufferedReader := bufio.NewReader(reader)
+	delimiter := defaultDelimiter
+	var statement strings.Builder
+
+	for {
+		line, readErr := bufferedReader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case statement.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")):
+			if _, err := io.WriteString(writer, line); err != nil {
+				return err
+			}
+		case statement.Len() == 0 && strings.HasPrefix(strings.ToUpper(trimmed), delimiterPrefix):
+			delimiter = strings.TrimSpace(trimmed[len(delimiterPrefix):])
+			if _, err := io.WriteString(writer, line); err != nil {
+				return err
+			}
+		default:
+			statement.WriteString(line)
+			if strings.HasSuffix(trimmed, delimiter) || readErr == io.EOF {
+				if _, err := io.WriteString(writer, filterStatement(mode, statement.String())); err != nil {
+					return err
+				}
+				statement.Reset()
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+func filterStatement(mode, statement string) string {
+	kind := kindOf(statement)
+
+	switch mode {
+	case config.RestoreModeDataOnly:
+		if kind != otherStatement && kind != insertStatement {
+			return ""
+		}
+	case config.RestoreModeNoClean:
+		switch kind {
+		case dropTableStatement, dropObjectStatement, createObjectStatement:
+			return ""
+		case createTableStatement:
+			if !strings.HasPrefix(statement, createTableIfNotExistsPrefix) {
+				return createTableIfNotExistsPrefix + strings.TrimPrefix(statement, createTablePrefix)
+			}
+		}
+	case config.RestoreModeSchemaOnly:
+		if kind == insertStatement {
+			return ""
+		}
+	}
+
+	return statement
+}
+
+// kindOf classifies a statement by its start, which is long enough to hold
+// the definer and options before the kind of object a CREATE statement
+// creates, without normalizing the whole of a long INSERT statement.
+func kindOf(statement string) statementKind {
+	start := statement[:min(len(statement), maxStatementStart)]
+	normalized := strings.ToUpper(strings.Join(strings.Fields(versionCommentPattern.ReplaceAllString(start, " ")), " "))
+
+	switch {
+	case strings.HasPrefix(normalized, "DROP TABLE "):
+		return dropTableStatement
+	case strings.HasPrefix(normalized, createTablePrefix):
+		return createTableStatement
+	case strings.HasPrefix(normalized, "INSERT INTO "):
+		return insertStatement
+	case dropObjectPattern.MatchString(normalized):
+		return dropObjectStatement
+	case createObjectPattern.MatchString(normalized):
+		return createObjectStatement
+	default:
+		return otherStatement
+	}
+}
@@ -0,0 +1,136 @@
+package mysql_test
+
+import (
+	"bytes"
+	"strings"
+
+	"database-backup-restore/config"
+	"database-backup-restore/mysql"
+
+	. "github.com/onsi/ginkgo/v2"
+
+	. "github.com/onsi/gomega"
+)
+
+var dump = `-- MySQL dump 10.13
+DROP TABLE IF EXISTS ` + "`people`" + `;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+CREATE TABLE ` + "`people`" + ` (
+  ` + "`id`" + ` int NOT NULL,
+  PRIMARY KEY (` + "`id`" + `)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+/*!40101 SET character_set_client = @saved_cs_client */;
+/*!40000 ALTER TABLE ` + "`people`" + ` DISABLE KEYS */;
+INSERT INTO ` + "`people`" + ` VALUES (1),(2);
+/*!40000 ALTER TABLE ` + "`people`" + ` ENABLE KEYS */;
+`
+
+var dumpWithObjects = `-- MySQL dump 10.13
+DROP TABLE IF EXISTS ` + "`people`" + `;
+CREATE TABLE ` + "`people`" + ` (
+  ` + "`id`" + ` int NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+INSERT INTO ` + "`people`" + ` VALUES (1),(2);
+DELIMITER ;;
+/*!50003 CREATE*/ /*!50017 DEFINER=` + "`root`@`%`" + `*/ /*!50003 TRIGGER ` + "`people_ids`" + ` BEFORE INSERT ON ` + "`people`" + ` FOR EACH ROW
+SET NEW.id = NEW.id + 1 */;;
+DELIMITER ;
+--
+-- Final view structure for view ` + "`adults`" + `
+--
+/*!50001 DROP VIEW IF EXISTS ` + "`adults`" + `*/;
+/*!50001 CREATE ALGORITHM=UNDEFINED */
+/*!50013 DEFINER=` + "`root`@`%`" + ` SQL SECURITY DEFINER */
+/*!50001 VIEW ` + "`adults`" + ` AS select ` + "`people`.`id`" + ` from ` + "`people`" + ` */;
+/*!50003 DROP PROCEDURE IF EXISTS ` + "`count_people`" + ` */;
+DELIMITER ;;
+CREATE DEFINER=` + "`root`@`%`" + ` PROCEDURE ` + "`count_people`" + `()
+BEGIN
+  SELECT COUNT(*) FROM ` + "`people`" + `;
+END ;;
+DELIMITER ;
+`
+
+var _ = Describe("FilterDump", func() {
+	var output *bytes.Buffer
+	var mode string
+
+	JustBeforeEach(func() {
+		output = new(bytes.Buffer)
+		Expect(mysql.FilterDump(mode, strings.NewReader(dump), output)).To(Succeed())
+	})
+
+	Context("when the restore mode is data-only", func() {
+		BeforeEach(func() {
+			mode = config.RestoreModeDataOnly
+		})
+
+		It("removes DROP TABLE and CREATE TABLE statements", func() {
+			Expect(output.String()).To(Equal(`-- MySQL dump 10.13
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = @saved_cs_client */;
+/*!40000 ALTER TABLE ` + "`people`" + ` DISABLE KEYS */;
+INSERT INTO ` + "`people`" + ` VALUES (1),(2);
+/*!40000 ALTER TABLE ` + "`people`" + ` ENABLE KEYS */;
+`))
+		})
+	})
+
+	Context("when the restore mode is schema-only", func() {
+		BeforeEach(func() {
+			mode = config.RestoreModeSchemaOnly
+		})
+
+		It("removes INSERT statements", func() {
+			Expect(output.String()).NotTo(ContainSubstring("INSERT INTO"))
+			Expect(output.String()).To(ContainSubstring("DROP TABLE IF EXISTS `people`;"))
+			Expect(output.String()).To(ContainSubstring("CREATE TABLE `people` ("))
+		})
+	})
+
+	Context("when the restore mode is no-clean", func() {
+		BeforeEach(func() {
+			mode = config.RestoreModeNoClean
+		})
+
+		It("keeps existing tables", func() {
+			Expect(output.String()).NotTo(ContainSubstring("DROP TABLE"))
+			Expect(output.String()).To(ContainSubstring("CREATE TABLE IF NOT EXISTS `people` ("))
+			Expect(output.String()).To(ContainSubstring("INSERT INTO `people` VALUES (1),(2);"))
+		})
+	})
+
+	Context("when the dump has views, triggers and routines", func() {
+		filter := func(mode string) string {
+			output := new(bytes.Buffer)
+			Expect(mysql.FilterDump(mode, strings.NewReader(dumpWithObjects), output)).To(Succeed())
+			return output.String()
+		}
+
+		It("leaves them out of a data-only restore, which does not recreate the tables they depend on", func() {
+			Expect(filter(config.RestoreModeDataOnly)).To(Equal(`-- MySQL dump 10.13
+INSERT INTO ` + "`people`" + ` VALUES (1),(2);
+DELIMITER ;;
+DELIMITER ;
+--
+-- Final view structure for view ` + "`adults`" + `
+--
+DELIMITER ;;
+DELIMITER ;
+`))
+		})
+
+		It("leaves them out of a no-clean restore, which cannot create them only if they do not exist", func() {
+			output := filter(config.RestoreModeNoClean)
+			Expect(output).To(ContainSubstring("CREATE TABLE IF NOT EXISTS `people` ("))
+			Expect(output).To(ContainSubstring("INSERT INTO `people` VALUES (1),(2);"))
+			Expect(output).NotTo(ContainSubstring("TRIGGER"))
+			Expect(output).NotTo(ContainSubstring("VIEW"))
+			Expect(output).NotTo(ContainSubstring("PROCEDURE"))
+		})
+
+		It("keeps them in a schema-only restore", func() {
+			Expect(filter(config.RestoreModeSchemaOnly)).To(Equal(strings.Replace(dumpWithObjects, "INSERT INTO `people` VALUES (1),(2);\n", "", 1)))
+		})
+	})
+})
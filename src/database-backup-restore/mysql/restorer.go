@@ -2,10 +2,14 @@ package mysql
 
 import (
 	"bufio"
-	"log"
+	"context"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"database-backup-restore/config"
+	"database-backup-restore/runner"
 )
 
 type Restorer struct {
@@ -25,17 +29,130 @@ func NewRestorer(
 	}
 }
 
-func (r Restorer) Action(artifactFilePath string) error {
+func (r Restorer) Action(ctx context.Context, artifactFilePath string) error {
 	artifactFile, err := os.Open(artifactFilePath)
 	if err != nil {
-		log.Fatalln("Error reading from artifact file,", err)
+		return fmt.Errorf("Error reading from artifact file, %s", err)
 	}
-	artifactReader := bufio.NewReader(artifactFile)
+	defer artifactFile.Close()
 
-	_, _, err = NewMysqlCommand(
-		r.config,
-		r.clientBinary,
-		r.sslOptionsProvider).WithParams("-v", r.config.Database).WithStdin(artifactReader).Run()
+	command, err := r.command()
+	if err != nil {
+		return err
+	}
+
+	if r.config.Restore.MySQL.RecreateDatabase {
+		recreateDatabaseCommand, err := r.recreateDatabaseCommand()
+		if err != nil {
+			return err
+		}
+
+		_, stderr, err := recreateDatabaseCommand.WithContext(ctx).Run()
+		if err != nil {
+			return fmt.Errorf("failed to recreate database %s: %s\n%s", r.config.Database, err, string(stderr))
+		}
+	}
+
+	var artifactReader io.Reader = bufio.NewReader(artifactFile)
+
+	if r.config.Restore.Mode != config.RestoreModeClean {
+		pipeReader, pipeWriter := io.Pipe()
+		defer pipeReader.Close()
+
+		go func(dumpReader io.Reader) {
+			pipeWriter.CloseWithError(FilterDump(r.config.Restore.Mode, dumpReader, pipeWriter))
+		}(artifactReader)
+
+		artifactReader = pipeReader
+	}
+
+	if sessionSettings := r.sessionSettings(); sessionSettings != "" {
+		artifactReader = io.MultiReader(strings.NewReader(sessionSettings+"\n"), artifactReader)
+	}
+
+	_, _, err = command.WithStdin(artifactReader).WithContext(ctx).Run()
 
 	return err
 }
+
+func (r Restorer) Plan(artifactFilePath string) ([]string, error) {
+	plan := []string{"using the " + r.sslOptionsProvider.Name() + " SSL options"}
+
+	if r.config.Restore.MySQL.RecreateDatabase {
+		recreateDatabaseCommand, err := r.recreateDatabaseCommand()
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, recreateDatabaseCommand.String())
+	}
+
+	command, err := r.command()
+	if err != nil {
+		return nil, err
+	}
+	plan = append(plan, command.String()+" < "+artifactFilePath)
+
+	if sessionSettings := r.sessionSettings(); sessionSettings != "" {
+		plan = append(plan, fmt.Sprintf("running %q before the contents of %s", sessionSettings, artifactFilePath))
+	}
+
+	if r.config.Restore.Mode != config.RestoreModeClean {
+		plan = append(plan, fmt.Sprintf("filtering %s for restore mode %s", artifactFilePath, r.config.Restore.Mode))
+	}
+
+	return plan, nil
+}
+
+func (r Restorer) command() (runner.Command, error) {
+	var params []string
+	if r.config.Restore.MySQL.MaxAllowedPacket != "" {
+		params = append(params, "--max-allowed-packet="+r.config.Restore.MySQL.MaxAllowedPacket)
+	}
+	if r.config.Restore.MySQL.InitCommand != "" {
+		params = append(params, "--init-command="+r.config.Restore.MySQL.InitCommand)
+	}
+	params = append(params, "-v", r.config.Database)
+
+	command, err := NewMysqlCommand(r.config, r.clientBinary, r.sslOptionsProvider)
+	if err != nil {
+		return runner.Command{}, err
+	}
+
+	return command.WithParams(params...), nil
+}
+
+func (r Restorer) recreateDatabaseCommand() (runner.Command, error) {
+	command, err := NewMysqlCommand(r.config, r.clientBinary, r.sslOptionsProvider)
+	if err != nil {
+		return runner.Command{}, err
+	}
+
+	database := quoteIdentifier(r.config.Database)
+	return command.WithParams(
+		fmt.Sprintf("--execute=DROP DATABASE IF EXISTS %s; CREATE DATABASE %s", database, database),
+	), nil
+}
+
+// sessionSettings returns the SET statement which is run ahead of the dump,
+// in the same session, to turn off the checks the config asks to skip.
+func (r Restorer) sessionSettings() string {
+	var assignments []string
+	if r.config.Restore.MySQL.DisableForeignKeyChecks {
+		assignments = append(assignments, "SESSION foreign_key_checks = 0")
+	}
+	if r.config.Restore.MySQL.DisableUniqueChecks {
+		assignments = append(assignments, "SESSION unique_checks = 0")
+	}
+	if r.config.Restore.MySQL.DisableBinaryLog {
+		assignments = append(assignments, "SESSION sql_log_bin = 0")
+	}
+
+	if len(assignments) == 0 {
+		return ""
+	}
+	return "SET " + strings.Join(assignments, ", ") + ";"
+}
+
+func quoteIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"database-backup-restore/config"
+)
+
+type SizeEstimator struct {
+	config             config.ConnectionConfig
+	clientBinary       string
+	sslOptionsProvider SSLOptionsProvider
+}
+
+func NewSizeEstimator(config config.ConnectionConfig, clientBinary string, sslOptionsProvider SSLOptionsProvider) SizeEstimator {
+	return SizeEstimator{config: config, clientBinary: clientBinary, sslOptionsProvider: sslOptionsProvider}
+}
+
+func (e SizeEstimator) EstimateSize(ctx context.Context) (uint64, error) {
+	query := fmt.Sprintf(
+		"SELECT COALESCE(SUM(data_length + index_length), 0) FROM information_schema.tables WHERE table_schema = %s",
+		quoteLiteral(e.config.Database))
+	if e.config.Tables != nil {
+		var tables []string
+		for _, table := range e.config.Tables {
+			tables = append(tables, quoteLiteral(table))
+		}
+		query += fmt.Sprintf(" AND table_name IN (%s)", strings.Join(tables, ","))
+	}
+
+	command, err := NewMysqlCommand(e.config, e.clientBinary, e.sslOptionsProvider)
+	if err != nil {
+		return 0, err
+	}
+
+	stdout, stderr, err := command.WithParams(
+		"--skip-column-names",
+		"--silent",
+		"--execute="+query,
+	).WithContext(ctx).Run()
+	if err != nil {
+		return 0, fmt.Errorf("%s\n%s", err, string(stderr))
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(stdout)), 10, 64)
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(value) + "'"
+}
@@ -1,15 +1,27 @@
 package mysql
 
 import (
+	"fmt"
 	"strings"
 
 	"database-backup-restore/config"
 )
 
 type SSLOptionsProvider interface {
-	BuildSSLParams(*config.TlsConfig) []string
+	BuildSSLParams(*config.TlsConfig) ([]string, error)
+	// Name is how the provider is named in plans and events.
+	Name() string
 }
 
+const (
+	// LegacySSLOptionsName is the name of the provider for servers which
+	// only take --ssl-verify-server-cert, such as MariaDB.
+	LegacySSLOptionsName = "legacy-ssl"
+	// DefaultSSLOptionsName is the name of the provider for servers which
+	// take --ssl-mode.
+	DefaultSSLOptionsName = "ssl-mode"
+)
+
 type LegacySSLOptionsProvider struct {
 	tempFolderManager config.TempFolderManager
 }
@@ -20,26 +32,23 @@ func NewLegacySSLOptionsProvider(tempFolderManager config.TempFolderManager) Leg
 	}
 }
 
-func (p LegacySSLOptionsProvider) BuildSSLParams(config *config.TlsConfig) []string {
-	var cmdArgs []string
-	if config != nil {
-		caFileName, _ := p.tempFolderManager.WriteTempFile(config.Cert.Ca)
-		cmdArgs = append(cmdArgs, "--ssl-ca="+caFileName)
-		if config.Cert.Certificate != "" {
-			certFileName, _ := p.tempFolderManager.WriteTempFile(config.Cert.Certificate)
-			cmdArgs = append(cmdArgs, "--ssl-cert="+certFileName)
-		}
-		if config.Cert.PrivateKey != "" {
-			clientKeyFileName, _ := p.tempFolderManager.WriteTempFile(config.Cert.PrivateKey)
-			cmdArgs = append(cmdArgs, "--ssl-key="+clientKeyFileName)
-		}
-		if !config.SkipHostVerify {
-			cmdArgs = append(cmdArgs, "--ssl-verify-server-cert")
-		}
-	} else {
-		cmdArgs = append(cmdArgs, "--ssl-cipher="+supportedCipherList())
+func (p LegacySSLOptionsProvider) Name() string {
+	return LegacySSLOptionsName
+}
+
+func (p LegacySSLOptionsProvider) BuildSSLParams(config *config.TlsConfig) ([]string, error) {
+	if config == nil {
+		return []string{"--ssl-cipher=" + supportedCipherList()}, nil
+	}
+
+	cmdArgs, err := certificateParams(p.tempFolderManager, config)
+	if err != nil {
+		return nil, err
 	}
-	return cmdArgs
+	if !config.SkipHostVerify {
+		cmdArgs = append(cmdArgs, "--ssl-verify-server-cert")
+	}
+	return cmdArgs, nil
 }
 
 type DefaultSSLOptionsProvider struct {
@@ -52,26 +61,53 @@ func NewDefaultSSLProvider(tempFolderManager config.TempFolderManager) DefaultSS
 	}
 }
 
-func (p DefaultSSLOptionsProvider) BuildSSLParams(config *config.TlsConfig) []string {
-	var cmdArgs []string
-	if config != nil {
-		caFileName, _ := p.tempFolderManager.WriteTempFile(config.Cert.Ca)
-		cmdArgs = append(cmdArgs, "--ssl-ca="+caFileName)
-		if config.Cert.Certificate != "" {
-			clientCertFileName, _ := p.tempFolderManager.WriteTempFile(config.Cert.Certificate)
-			cmdArgs = append(cmdArgs, "--ssl-cert="+clientCertFileName)
-		}
-		if config.Cert.PrivateKey != "" {
-			clientKeyFileName, _ := p.tempFolderManager.WriteTempFile(config.Cert.PrivateKey)
-			cmdArgs = append(cmdArgs, "--ssl-key="+clientKeyFileName)
+func (p DefaultSSLOptionsProvider) Name() string {
+	return DefaultSSLOptionsName
+}
+
+func (p DefaultSSLOptionsProvider) BuildSSLParams(config *config.TlsConfig) ([]string, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	cmdArgs, err := certificateParams(p.tempFolderManager, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.SkipHostVerify {
+		cmdArgs = append(cmdArgs, "--ssl-mode=VERIFY_CA")
+	} else {
+		cmdArgs = append(cmdArgs, "--ssl-mode=VERIFY_IDENTITY")
+	}
+	return cmdArgs, nil
+}
+
+// certificateParams writes the CA, client certificate and client key to temp
+// files and returns the flags which point the mysql utilities at them.
+func certificateParams(tempFolderManager config.TempFolderManager, tlsConfig *config.TlsConfig) ([]string, error) {
+	caFileName, err := tempFolderManager.WriteTempFile(tlsConfig.Cert.Ca)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write tls.cert.ca to a temp file: %s", err)
+	}
+	cmdArgs := []string{"--ssl-ca=" + caFileName}
+
+	if tlsConfig.Cert.Certificate != "" {
+		certFileName, err := tempFolderManager.WriteTempFile(tlsConfig.Cert.Certificate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write tls.cert.certificate to a temp file: %s", err)
 		}
-		if config.SkipHostVerify {
-			cmdArgs = append(cmdArgs, "--ssl-mode=VERIFY_CA")
-		} else {
-			cmdArgs = append(cmdArgs, "--ssl-mode=VERIFY_IDENTITY")
+		cmdArgs = append(cmdArgs, "--ssl-cert="+certFileName)
+	}
+
+	if tlsConfig.Cert.PrivateKey != "" {
+		keyFileName, err := tempFolderManager.WriteTempFile(tlsConfig.Cert.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write tls.cert.private_key to a temp file: %s", err)
 		}
+		cmdArgs = append(cmdArgs, "--ssl-key="+keyFileName)
 	}
-	return cmdArgs
+
+	return cmdArgs, nil
 }
 
 func supportedCipherList() string {
@@ -0,0 +1,60 @@
+package mysql_test
+
+import (
+	"database-backup-restore/config"
+	"database-backup-restore/mysql"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SSL options providers", func() {
+	var tempFolderManager config.TempFolderManager
+	var tlsConfig *config.TlsConfig
+
+	BeforeEach(func() {
+		var err error
+		tempFolderManager, err = config.NewTempFolderManager()
+		Expect(err).NotTo(HaveOccurred())
+
+		tlsConfig = &config.TlsConfig{Cert: config.CertTlsConfig{Ca: "A_CA_CERT"}}
+	})
+
+	AfterEach(func() {
+		tempFolderManager.Cleanup()
+	})
+
+	DescribeTable("writes the CA to a temp file",
+		func(provider func(config.TempFolderManager) mysql.SSLOptionsProvider) {
+			params, err := provider(tempFolderManager).BuildSSLParams(tlsConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(params).To(ContainElement(HavePrefix("--ssl-ca=")))
+		},
+		Entry("default", defaultProvider),
+		Entry("legacy", legacyProvider),
+	)
+
+	It("names the providers", func() {
+		Expect(defaultProvider(tempFolderManager).Name()).To(Equal("ssl-mode"))
+		Expect(legacyProvider(tempFolderManager).Name()).To(Equal("legacy-ssl"))
+	})
+
+	DescribeTable("fails when the CA cannot be written",
+		func(provider func(config.TempFolderManager) mysql.SSLOptionsProvider) {
+			Expect(tempFolderManager.Cleanup()).To(Succeed())
+
+			_, err := provider(tempFolderManager).BuildSSLParams(tlsConfig)
+			Expect(err).To(MatchError(ContainSubstring("failed to write tls.cert.ca to a temp file")))
+		},
+		Entry("default", defaultProvider),
+		Entry("legacy", legacyProvider),
+	)
+})
+
+func defaultProvider(tempFolderManager config.TempFolderManager) mysql.SSLOptionsProvider {
+	return mysql.NewDefaultSSLProvider(tempFolderManager)
+}
+
+func legacyProvider(tempFolderManager config.TempFolderManager) mysql.SSLOptionsProvider {
+	return mysql.NewLegacySSLOptionsProvider(tempFolderManager)
+}
@@ -1,7 +1,8 @@
 package mysql
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
 
 	"strings"
@@ -18,16 +19,21 @@ func NewServerVersionDetector(mysqlPath string) ServerVersionDetector {
 	return ServerVersionDetector{mysqlPath: mysqlPath}
 }
 
-func (d ServerVersionDetector) GetVersion(config config.ConnectionConfig, tempFolderManager config.TempFolderManager) (version.DatabaseServerVersion, error) {
-	stdout, stderr, err := NewMysqlCommand(config, d.mysqlPath, NewDefaultSSLProvider(tempFolderManager)).
+func (d ServerVersionDetector) GetVersion(ctx context.Context, config config.ConnectionConfig, tempFolderManager config.TempFolderManager) (version.DatabaseServerVersion, error) {
+	command, err := NewMysqlCommand(config, d.mysqlPath, NewDefaultSSLProvider(tempFolderManager))
+	if err != nil {
+		return version.DatabaseServerVersion{}, err
+	}
+
+	stdout, stderr, err := command.
 		WithParams(
 			"--skip-column-names",
 			"--silent",
 			"--execute=SELECT VERSION()",
-		).Run()
+		).WithContext(ctx).Run()
 
 	if err != nil {
-		return version.DatabaseServerVersion{}, fmt.Errorf(string(stderr))
+		return version.DatabaseServerVersion{}, errors.New(string(stderr))
 	}
 
 	versionString := string(stdout)
@@ -1,7 +1,10 @@
 package postgres
 
 import (
+	"context"
+
 	"database-backup-restore/config"
+	"database-backup-restore/runner"
 )
 
 type Backuper struct {
@@ -18,7 +21,27 @@ func NewBackuper(config config.ConnectionConfig, tempFolderManager config.TempFo
 	}
 }
 
-func (b Backuper) Action(artifactFilePath string) error {
+func (b Backuper) Action(ctx context.Context, artifactFilePath string) error {
+	command, err := b.command(artifactFilePath)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = command.WithContext(ctx).Run()
+
+	return err
+}
+
+func (b Backuper) Plan(artifactFilePath string) ([]string, error) {
+	command, err := b.command(artifactFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{command.String()}, nil
+}
+
+func (b Backuper) command(artifactFilePath string) (runner.Command, error) {
 	cmdArgs := []string{
 		"--verbose",
 		"--format=custom",
@@ -30,7 +53,10 @@ func (b Backuper) Action(artifactFilePath string) error {
 		cmdArgs = append(cmdArgs, "-t", tableName)
 	}
 
-	_, _, err := NewPostgresCommand(b.config, b.tempFolderManager, b.backupBinary).WithParams(cmdArgs...).Run()
+	command, err := NewPostgresCommand(b.config, b.tempFolderManager, b.backupBinary)
+	if err != nil {
+		return runner.Command{}, err
+	}
 
-	return err
+	return command.WithParams(cmdArgs...), nil
 }
@@ -7,7 +7,7 @@ import (
 	"database-backup-restore/runner"
 )
 
-func NewPostgresCommand(config config.ConnectionConfig, tempFolderManager config.TempFolderManager, cmd string) runner.Command {
+func NewPostgresCommand(config config.ConnectionConfig, tempFolderManager config.TempFolderManager, cmd string) (runner.Command, error) {
 	cmdArgs := []string{
 		fmt.Sprintf("--username=%s", config.Username),
 		fmt.Sprintf("--host=%s", config.Host),
@@ -19,7 +19,10 @@ func NewPostgresCommand(config config.ConnectionConfig, tempFolderManager config
 	}
 
 	if config.Tls != nil {
-		caCertFileName, _ := tempFolderManager.WriteTempFile(config.Tls.Cert.Ca)
+		caCertFileName, err := tempFolderManager.WriteTempFile(config.Tls.Cert.Ca)
+		if err != nil {
+			return runner.Command{}, fmt.Errorf("failed to write tls.cert.ca to a temp file: %s", err)
+		}
 		env["PGSSLROOTCERT"] = caCertFileName
 
 		if config.Tls.SkipHostVerify {
@@ -29,15 +32,21 @@ func NewPostgresCommand(config config.ConnectionConfig, tempFolderManager config
 		}
 
 		if config.Tls.Cert.Certificate != "" {
-			clientCertFileName, _ := tempFolderManager.WriteTempFile(config.Tls.Cert.Certificate)
+			clientCertFileName, err := tempFolderManager.WriteTempFile(config.Tls.Cert.Certificate)
+			if err != nil {
+				return runner.Command{}, fmt.Errorf("failed to write tls.cert.certificate to a temp file: %s", err)
+			}
 			env["PGSSLCERT"] = clientCertFileName
 		}
 
 		if config.Tls.Cert.PrivateKey != "" {
-			clientKeyFileName, _ := tempFolderManager.WriteTempFile(config.Tls.Cert.PrivateKey)
+			clientKeyFileName, err := tempFolderManager.WriteTempFile(config.Tls.Cert.PrivateKey)
+			if err != nil {
+				return runner.Command{}, fmt.Errorf("failed to write tls.cert.private_key to a temp file: %s", err)
+			}
 			env["PGSSLKEY"] = clientKeyFileName
 		}
 	}
 
-	return runner.NewCommand(cmd).WithParams(cmdArgs...).WithEnv(env)
+	return runner.NewCommand(cmd).WithParams(cmdArgs...).WithEnv(env), nil
 }
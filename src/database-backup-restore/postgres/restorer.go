@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -22,35 +23,76 @@ func NewRestorer(config config.ConnectionConfig, tempFolderManager config.TempFo
 	}
 }
 
-func (r Restorer) Action(artifactFilePath string) error {
-	stdout, _, err := runner.NewCommand(r.restoreBinary).WithParams("--list", artifactFilePath).Run()
+func (r Restorer) Action(ctx context.Context, artifactFilePath string) error {
+	stdout, _, err := r.listCommand(artifactFilePath).WithContext(ctx).Run()
 
 	if err != nil {
 		return err
 	}
 
-	listFile, err := os.CreateTemp("", "backup-restore-sdk")
+	listFilePath, err := r.tempFolderManager.WriteTempFile(string(ListFileFilter(stdout)))
 	if err != nil {
 		return err
 	}
-	defer os.Remove(listFile.Name())
+	defer os.Remove(listFilePath)
 
-	listFile.Write(ListFileFilter(stdout))
+	restoreCommand, err := r.restoreCommand(artifactFilePath, listFilePath)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = restoreCommand.WithContext(ctx).Run()
+
+	return err
+}
+
+func (r Restorer) Plan(artifactFilePath string) ([]string, error) {
+	restoreCommand, err := r.restoreCommand(artifactFilePath, "<filtered-list-file>")
+	if err != nil {
+		return nil, err
+	}
 
+	return []string{
+		r.listCommand(artifactFilePath).String(),
+		restoreCommand.String(),
+	}, nil
+}
+
+func (r Restorer) listCommand(artifactFilePath string) runner.Command {
+	return runner.NewCommand(r.restoreBinary).WithParams("--list", artifactFilePath)
+}
+
+func (r Restorer) restoreCommand(artifactFilePath, listFilePath string) (runner.Command, error) {
 	cmdArgs := []string{
 		"--verbose",
 		"--format=custom",
 		"--dbname=" + r.config.Database,
-		"--clean",
-		"--if-exists",
+	}
+	cmdArgs = append(cmdArgs, restoreModeParams(r.config.Restore.Mode)...)
+	cmdArgs = append(cmdArgs,
 		"--single-transaction",
 		"--exit-on-error",
-		fmt.Sprintf("--use-list=%s", listFile.Name()),
+		fmt.Sprintf("--use-list=%s", listFilePath),
 		artifactFilePath,
+	)
+
+	command, err := NewPostgresCommand(r.config, r.tempFolderManager, r.restoreBinary)
+	if err != nil {
+		return runner.Command{}, err
 	}
 
-	_, _, err = NewPostgresCommand(r.config, r.tempFolderManager, r.restoreBinary).
-		WithParams(cmdArgs...).Run()
+	return command.WithParams(cmdArgs...), nil
+}
 
-	return err
+func restoreModeParams(mode string) []string {
+	switch mode {
+	case config.RestoreModeDataOnly:
+		return []string{"--data-only"}
+	case config.RestoreModeSchemaOnly:
+		return []string{"--schema-only", "--clean", "--if-exists"}
+	case config.RestoreModeNoClean:
+		return []string{}
+	default:
+		return []string{"--clean", "--if-exists"}
+	}
 }
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"database-backup-restore/config"
+)
+
+type SizeEstimator struct {
+	config            config.ConnectionConfig
+	tempFolderManager config.TempFolderManager
+	psqlPath          string
+}
+
+func NewSizeEstimator(config config.ConnectionConfig, tempFolderManager config.TempFolderManager, psqlPath string) SizeEstimator {
+	return SizeEstimator{config: config, tempFolderManager: tempFolderManager, psqlPath: psqlPath}
+}
+
+func (e SizeEstimator) EstimateSize(ctx context.Context) (uint64, error) {
+	query := "SELECT pg_database_size(current_database());"
+	if e.config.Tables != nil {
+		query = fmt.Sprintf(
+			"SELECT COALESCE(sum(pg_total_relation_size(quote_ident(table_name))), 0) FROM information_schema.tables "+
+				"WHERE table_type='BASE TABLE' AND table_schema='public' AND table_name IN (%s);",
+			quoteLiterals(e.config.Tables))
+	}
+
+	command, err := NewPostgresCommand(e.config, e.tempFolderManager, e.psqlPath)
+	if err != nil {
+		return 0, err
+	}
+
+	stdout, stderr, err := command.WithParams(
+		"--tuples-only",
+		e.config.Database,
+		"--command="+query,
+	).WithContext(ctx).Run()
+	if err != nil {
+		return 0, fmt.Errorf("%s\n%s", err, string(stderr))
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(stdout)), 10, 64)
+}
+
+func quoteLiterals(values []string) string {
+	var literals []string
+	for _, value := range values {
+		literals = append(literals, "'"+strings.ReplaceAll(value, "'", "''")+"'")
+	}
+	return strings.Join(literals, ",")
+}
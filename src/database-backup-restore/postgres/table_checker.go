@@ -1,8 +1,8 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
-
 	"strings"
 
 	"database-backup-restore/config"
@@ -18,7 +18,7 @@ func NewTableChecker(config config.ConnectionConfig, psqlPath string) TableCheck
 	return TableChecker{config: config, psqlPath: psqlPath}
 }
 
-func (c TableChecker) FindMissingTables(tableNames []string) ([]string, error) {
+func (c TableChecker) FindMissingTables(ctx context.Context, tableNames []string) ([]string, error) {
 	stdout, _, err := runner.NewCommand(c.psqlPath).WithParams(
 		"--tuples-only",
 		fmt.Sprintf("--username=%s", c.config.Username),
@@ -26,7 +26,7 @@ func (c TableChecker) FindMissingTables(tableNames []string) ([]string, error) {
 		fmt.Sprintf("--port=%d", c.config.Port),
 		c.config.Database,
 		`--command=SELECT table_name FROM information_schema.tables WHERE table_type='BASE TABLE' AND table_schema='public';`,
-	).WithEnv(map[string]string{"PGPASSWORD": c.config.Password}).Run()
+	).WithEnv(map[string]string{"PGPASSWORD": c.config.Password}).WithContext(ctx).Run()
 
 	if err != nil {
 		return nil, err
@@ -1,6 +1,8 @@
 package postgres
 
 import (
+	"context"
+	"fmt"
 	"log"
 
 	"database-backup-restore/config"
@@ -15,25 +17,31 @@ func NewServerVersionDetector(psqlPath string) ServerVersionDetector {
 	return ServerVersionDetector{psqlPath: psqlPath}
 }
 
-func (d ServerVersionDetector) GetVersion(config config.ConnectionConfig, tempFolderManager config.TempFolderManager) (version.DatabaseServerVersion, error) {
+func (d ServerVersionDetector) GetVersion(ctx context.Context, config config.ConnectionConfig, tempFolderManager config.TempFolderManager) (version.DatabaseServerVersion, error) {
 	cmdArgs := []string{
 		"--tuples-only",
 		config.Database,
 		`--command=SELECT VERSION()`,
 	}
 
-	stdout, stderr, err := NewPostgresCommand(config, tempFolderManager, d.psqlPath).
-		WithParams(cmdArgs...).Run()
+	command, err := NewPostgresCommand(config, tempFolderManager, d.psqlPath)
+	if err != nil {
+		return version.DatabaseServerVersion{}, err
+	}
+
+	stdout, stderr, err := command.WithParams(cmdArgs...).WithContext(ctx).Run()
 
 	if err != nil {
-		log.Fatalf("Unable to check version of Postgres: %v\n%s\n%s", err, string(stdout), string(stderr))
+		return version.DatabaseServerVersion{}, fmt.Errorf("Unable to check version of Postgres: %v\n%s\n%s", err, string(stdout), string(stderr))
 	}
 
 	semVer, err := ParseVersion(string(stdout))
 	if err != nil {
-		log.Fatalf("Unable to check version of Postgres: %v", err)
+		return version.DatabaseServerVersion{}, fmt.Errorf("Unable to check version of Postgres: %v", err)
 	}
 
+	log.Printf("Postgres server version %v\n", semVer)
+
 	return version.DatabaseServerVersion{
 		Implementation:  "postgres",
 		SemanticVersion: semVer,
@@ -2,11 +2,15 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
+
+	"database-backup-restore/events"
 )
 
 type Command struct {
@@ -14,29 +18,35 @@ type Command struct {
 	params []string
 	env    map[string]string
 	stdin  io.Reader
+	ctx    context.Context
 }
 
 func NewCommand(cmd string) Command {
-	return Command{cmd: cmd}
+	return Command{cmd: cmd, ctx: context.Background()}
 }
 
 func (c Command) WithParams(params ...string) Command {
-	return Command{cmd: c.cmd, params: append(c.params, params...), env: c.env, stdin: c.stdin}
+	return Command{cmd: c.cmd, params: append(c.params, params...), env: c.env, stdin: c.stdin, ctx: c.ctx}
 }
 
 func (c Command) WithEnv(env map[string]string) Command {
-	return Command{cmd: c.cmd, params: c.params, env: env, stdin: c.stdin}
+	return Command{cmd: c.cmd, params: c.params, env: env, stdin: c.stdin, ctx: c.ctx}
 }
 
 func (c Command) WithStdin(stdin io.Reader) Command {
-	return Command{cmd: c.cmd, params: c.params, env: c.env, stdin: stdin}
+	return Command{cmd: c.cmd, params: c.params, env: c.env, stdin: stdin, ctx: c.ctx}
+}
+
+// WithContext makes Run kill the command when ctx is done before it exits.
+func (c Command) WithContext(ctx context.Context) Command {
+	return Command{cmd: c.cmd, params: c.params, env: c.env, stdin: c.stdin, ctx: ctx}
 }
 
 func (c Command) Run() ([]byte, []byte, error) {
 	outb := new(bytes.Buffer)
 	errb := new(bytes.Buffer)
 
-	command := exec.Command(c.cmd, c.params...)
+	command := exec.CommandContext(c.ctx, c.cmd, c.params...)
 
 	command.Env = c.buildEnvStrings()
 
@@ -44,6 +54,14 @@ func (c Command) Run() ([]byte, []byte, error) {
 	command.Stderr = io.MultiWriter(errb, os.Stderr)
 	command.Stdin = c.stdin
 
+	if sink, ok := events.SinkFrom(c.ctx); ok {
+		utilityOutputWriter := events.NewUtilityOutputWriter(sink, c.cmd)
+		defer utilityOutputWriter.Flush()
+
+		command.Stdout = outb
+		command.Stderr = io.MultiWriter(errb, utilityOutputWriter)
+	}
+
 	err := command.Run()
 
 	return outb.Bytes(), errb.Bytes(), err
@@ -57,8 +75,35 @@ func (c Command) buildEnvStrings() []string {
 	return env
 }
 
+func (c Command) buildRedactedEnvStrings() []string {
+	var env []string
+	for key, value := range c.env {
+		if isSensitiveEnvVar(key) {
+			value = redacted
+		}
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// String renders the command as it would be typed into a shell, with
+// passwords in the environment redacted.
 func (c Command) String() string {
-	env := strings.Join(c.buildEnvStrings(), " ")
+	env := strings.Join(c.buildRedactedEnvStrings(), " ")
 	params := strings.Join(c.params, " ")
 	return fmt.Sprintf("%s %s %s", env, c.cmd, params)
 }
+
+const redacted = "<redacted>"
+
+var sensitiveEnvVars = []string{"PGPASSWORD", "MYSQL_PWD"}
+
+func isSensitiveEnvVar(key string) bool {
+	for _, sensitiveEnvVar := range sensitiveEnvVars {
+		if key == sensitiveEnvVar {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"database-backup-restore/config"
+	"database-backup-restore/events"
+)
+
+type NamedConnectionConfig = config.NamedConnectionConfig
+
+// DatabaseResult is the outcome of backing up or restoring one of several
+// databases.
+type DatabaseResult struct {
+	ID       string
+	Duration time.Duration
+	Err      error
+}
+
+// BackupFile backs up the database described by connectionConfig to a new
+// file at artifactFilePath, deleting the file again if the backup fails.
+func BackupFile(ctx context.Context, connectionConfig ConnectionConfig, artifactFilePath string, options Options) error {
+	artifactFile, err := os.Create(artifactFilePath)
+	if err != nil {
+		return err
+	}
+	defer artifactFile.Close()
+
+	err = Backup(ctx, connectionConfig, artifactFile, options)
+	if err != nil {
+		if removeErr := os.Remove(artifactFilePath); removeErr != nil {
+			return fmt.Errorf("%s\nYou may need to delete the artifact-file that was created before re-running: %s", err, removeErr)
+		}
+	}
+	return err
+}
+
+// RestoreFile restores the database described by connectionConfig from the
+// file at artifactFilePath.
+func RestoreFile(ctx context.Context, connectionConfig ConnectionConfig, artifactFilePath string, options Options) error {
+	artifactFile, err := os.Open(artifactFilePath)
+	if err != nil {
+		return err
+	}
+	defer artifactFile.Close()
+
+	return Restore(ctx, connectionConfig, artifactFile, options)
+}
+
+// BackupAll backs up each database to a file named after its ID in
+// artifactDirectory, starting them in order and running at most concurrency
+// backups at once. It returns one result per database, in the same order.
+func BackupAll(ctx context.Context, databases []NamedConnectionConfig, artifactDirectory string,
+	concurrency int, options Options) []DatabaseResult {
+
+	return runAll(ctx, databases, concurrency, func(ctx context.Context, database NamedConnectionConfig) error {
+		return BackupFile(ctx, database.ConnectionConfig, filepath.Join(artifactDirectory, database.ID), options)
+	})
+}
+
+// RestoreAll restores each database from the file named after its ID in
+// artifactDirectory, starting them in order and running at most concurrency
+// restores at once. It returns one result per database, in the same order.
+func RestoreAll(ctx context.Context, databases []NamedConnectionConfig, artifactDirectory string,
+	concurrency int, options Options) []DatabaseResult {
+
+	return runAll(ctx, databases, concurrency, func(ctx context.Context, database NamedConnectionConfig) error {
+		return RestoreFile(ctx, database.ConnectionConfig, filepath.Join(artifactDirectory, database.ID), options)
+	})
+}
+
+func runAll(ctx context.Context, databases []NamedConnectionConfig, concurrency int,
+	run func(context.Context, NamedConnectionConfig) error) []DatabaseResult {
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]DatabaseResult, len(databases))
+	semaphore := make(chan struct{}, concurrency)
+	var waitGroup sync.WaitGroup
+
+	for i, database := range databases {
+		semaphore <- struct{}{}
+		waitGroup.Add(1)
+		go func(i int, database NamedConnectionConfig) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+
+			databaseCtx := events.WithDatabase(ctx, database.ID)
+			start := time.Now()
+
+			err := ctx.Err()
+			if err == nil {
+				err = run(databaseCtx, database)
+			}
+
+			results[i] = DatabaseResult{ID: database.ID, Duration: time.Since(start), Err: err}
+
+			finished := events.Event{Event: events.DatabaseFinished, DurationSeconds: results[i].Duration.Seconds()}
+			if err != nil {
+				finished.ErrorClass = errorClass(err)
+				finished.Error = err.Error()
+			}
+			events.Emit(databaseCtx, finished)
+		}(i, database)
+	}
+
+	waitGroup.Wait()
+	return results
+}
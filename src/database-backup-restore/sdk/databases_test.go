@@ -0,0 +1,84 @@
+package sdk_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"database-backup-restore/config"
+	"database-backup-restore/sdk"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BackupAll", func() {
+	var artifactDirectory string
+	var options sdk.Options
+
+	BeforeEach(func() {
+		var err error
+		artifactDirectory, err = os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		options = sdk.Options{
+			Utilities: config.UtilitiesConfig{
+				Postgres13: config.UtilityPaths{Client: "/non/existent/psql"},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(artifactDirectory)
+	})
+
+	It("returns a result per database in order, and removes the artifacts of failed backups", func() {
+		databases := []sdk.NamedConnectionConfig{
+			{ID: "uaa", ConnectionConfig: sdk.ConnectionConfig{Adapter: "postgres", Database: "uaa"}},
+			{ID: "ccdb", ConnectionConfig: sdk.ConnectionConfig{Adapter: "foo-server"}},
+			{ID: "credhub", ConnectionConfig: sdk.ConnectionConfig{Adapter: "postgres", Database: "credhub"}},
+		}
+
+		results := sdk.BackupAll(context.Background(), databases, artifactDirectory, 2, options)
+
+		Expect(results).To(HaveLen(3))
+		Expect(results[0].ID).To(Equal("uaa"))
+		Expect(results[0].Err).To(BeAssignableToTypeOf(sdk.ConnectionError{}))
+		Expect(results[1].ID).To(Equal("ccdb"))
+		Expect(results[1].Err).To(MatchError(ContainSubstring("Unsupported adapter foo-server")))
+		Expect(results[2].ID).To(Equal("credhub"))
+		Expect(results[2].Err).To(BeAssignableToTypeOf(sdk.ConnectionError{}))
+
+		Expect(filepath.Join(artifactDirectory, "uaa")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(artifactDirectory, "ccdb")).NotTo(BeAnExistingFile())
+	})
+
+	It("does not start any backups once the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		results := sdk.BackupAll(ctx, []sdk.NamedConnectionConfig{
+			{ID: "uaa", ConnectionConfig: sdk.ConnectionConfig{Adapter: "postgres"}},
+		}, artifactDirectory, 1, options)
+
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Err).To(MatchError(context.Canceled))
+		Expect(filepath.Join(artifactDirectory, "uaa")).NotTo(BeAnExistingFile())
+	})
+})
+
+var _ = Describe("RestoreAll", func() {
+	It("fails for a database whose artifact is missing", func() {
+		artifactDirectory, err := os.MkdirTemp("", "")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(artifactDirectory)
+
+		results := sdk.RestoreAll(context.Background(), []sdk.NamedConnectionConfig{
+			{ID: "uaa", ConnectionConfig: sdk.ConnectionConfig{Adapter: "postgres"}},
+		}, artifactDirectory, 1, sdk.Options{})
+
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].ID).To(Equal("uaa"))
+		Expect(results[0].Err).To(MatchError(ContainSubstring("no such file")))
+	})
+})
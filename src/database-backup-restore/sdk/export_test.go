@@ -0,0 +1,3 @@
+package sdk
+
+var ErrorClass = errorClass
@@ -0,0 +1,281 @@
+// Package sdk backs up and restores databases in the same way as the
+// database-backup-restore command, for use from other Go programs.
+package sdk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"database-backup-restore/config"
+	"database-backup-restore/database"
+	"database-backup-restore/events"
+	"database-backup-restore/mysql"
+	"database-backup-restore/postgres"
+)
+
+type ConnectionConfig = config.ConnectionConfig
+
+type (
+	ConnectionError            = database.ConnectionError
+	UnsupportedVersionError    = database.UnsupportedVersionError
+	MissingTablesError         = database.MissingTablesError
+	InsufficientDiskSpaceError = database.InsufficientDiskSpaceError
+	UtilityError               = database.UtilityError
+)
+
+type Options struct {
+	// Utilities holds the paths of the client, dump and restore binaries
+	// for every supported database server version.
+	Utilities config.UtilitiesConfig
+
+	// TempDirectory is where the temporary folder holding TLS certificates,
+	// keys and staged artifacts is created, for example a tmpfs mount. The
+	// default temp directory is used when it is empty.
+	TempDirectory string
+}
+
+const (
+	backupAction  database.Action = "backup"
+	restoreAction database.Action = "restore"
+
+	detectVersionPhase = "detect_version"
+)
+
+// Backup dumps the database described by connectionConfig to artifact.
+// When artifact is a regular file the dump utility writes to it directly,
+// otherwise the dump is staged in a temporary file first.
+func Backup(ctx context.Context, connectionConfig ConnectionConfig, artifact io.Writer, options Options) error {
+	if err := connectionConfig.Validate(); err != nil {
+		return err
+	}
+
+	tempFolderManager, err := config.NewTempFolderManagerIn(options.TempDirectory)
+	if err != nil {
+		return err
+	}
+	defer tempFolderManager.Cleanup()
+
+	interactor, err := makeInteractor(ctx, backupAction, connectionConfig, options, tempFolderManager)
+	if err != nil {
+		return err
+	}
+
+	return runPhase(ctx, string(backupAction), func() (int64, error) {
+		if artifactFilePath, ok := regularFilePath(artifact); ok {
+			if err := interactor.Action(ctx, artifactFilePath); err != nil {
+				return 0, classify(err)
+			}
+			return fileSize(artifactFilePath), nil
+		}
+
+		stagingFilePath, err := tempFolderManager.WriteTempFile("")
+		if err != nil {
+			return 0, err
+		}
+
+		if err := interactor.Action(ctx, stagingFilePath); err != nil {
+			return 0, classify(err)
+		}
+
+		stagingFile, err := os.Open(stagingFilePath)
+		if err != nil {
+			return 0, err
+		}
+		defer stagingFile.Close()
+
+		return io.Copy(artifact, stagingFile)
+	})
+}
+
+// Restore restores the database described by connectionConfig from artifact.
+// When artifact is a regular file the restore utility reads it directly,
+// otherwise it is staged in a temporary file first.
+func Restore(ctx context.Context, connectionConfig ConnectionConfig, artifact io.Reader, options Options) error {
+	if err := connectionConfig.Validate(); err != nil {
+		return err
+	}
+
+	tempFolderManager, err := config.NewTempFolderManagerIn(options.TempDirectory)
+	if err != nil {
+		return err
+	}
+	defer tempFolderManager.Cleanup()
+
+	interactor, err := makeInteractor(ctx, restoreAction, connectionConfig, options, tempFolderManager)
+	if err != nil {
+		return err
+	}
+
+	artifactFilePath, ok := regularFilePath(artifact)
+	if !ok {
+		artifactFilePath, err = stageArtifact(artifact, tempFolderManager)
+		if err != nil {
+			return err
+		}
+	}
+
+	return runPhase(ctx, string(restoreAction), func() (int64, error) {
+		return 0, classify(interactor.Action(ctx, artifactFilePath))
+	})
+}
+
+// PlanBackup detects the database server version and returns the steps that
+// Backup would run to write to artifactFilePath, with passwords redacted.
+func PlanBackup(ctx context.Context, connectionConfig ConnectionConfig, artifactFilePath string, options Options) ([]string, error) {
+	return plan(ctx, backupAction, connectionConfig, artifactFilePath, options)
+}
+
+// PlanRestore detects the database server version and returns the steps that
+// Restore would run to read from artifactFilePath, with passwords redacted.
+func PlanRestore(ctx context.Context, connectionConfig ConnectionConfig, artifactFilePath string, options Options) ([]string, error) {
+	return plan(ctx, restoreAction, connectionConfig, artifactFilePath, options)
+}
+
+func plan(ctx context.Context, action database.Action, connectionConfig ConnectionConfig, artifactFilePath string, options Options) ([]string, error) {
+	if err := connectionConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	tempFolderManager, err := config.NewTempFolderManagerIn(options.TempDirectory)
+	if err != nil {
+		return nil, err
+	}
+	defer tempFolderManager.Cleanup()
+
+	interactor, err := makeInteractor(ctx, action, connectionConfig, options, tempFolderManager)
+	if err != nil {
+		return nil, err
+	}
+
+	return interactor.Plan(artifactFilePath)
+}
+
+func makeInteractor(ctx context.Context, action database.Action, connectionConfig ConnectionConfig,
+	options Options, tempFolderManager config.TempFolderManager) (database.Interactor, error) {
+
+	postgresServerVersionDetector := postgres.NewServerVersionDetector(options.Utilities.Postgres13.Client)
+	mysqlServerVersionDetector := mysql.NewServerVersionDetector(options.Utilities.Mysql80.Client)
+	interactorFactory := database.NewInteractorFactory(options.Utilities, postgresServerVersionDetector, mysqlServerVersionDetector, tempFolderManager)
+
+	var interactor database.Interactor
+	err := runPhase(ctx, detectVersionPhase, func() (int64, error) {
+		var err error
+		interactor, err = interactorFactory.Make(ctx, action, connectionConfig)
+		return 0, err
+	})
+	return interactor, err
+}
+
+// runPhase runs fn between phase_started and phase_finished events. The
+// finished event carries the number of bytes fn reports having written and,
+// when fn fails, the class of the error.
+func runPhase(ctx context.Context, phase string, fn func() (int64, error)) error {
+	events.Emit(ctx, events.Event{Event: events.PhaseStarted, Phase: phase})
+
+	start := time.Now()
+	bytesWritten, err := fn()
+
+	finished := events.Event{
+		Event:           events.PhaseFinished,
+		Phase:           phase,
+		BytesWritten:    bytesWritten,
+		DurationSeconds: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		finished.ErrorClass = errorClass(err)
+		finished.Error = err.Error()
+	}
+	events.Emit(ctx, finished)
+
+	return err
+}
+
+// errorClass names the type of a typed error, which may be wrapped in others.
+func errorClass(err error) string {
+	var connectionError ConnectionError
+	var unsupportedVersionError UnsupportedVersionError
+	var missingTablesError MissingTablesError
+	var insufficientDiskSpaceError InsufficientDiskSpaceError
+	var utilityError UtilityError
+	switch {
+	case errors.As(err, &connectionError):
+		return "ConnectionError"
+	case errors.As(err, &unsupportedVersionError):
+		return "UnsupportedVersionError"
+	case errors.As(err, &missingTablesError):
+		return "MissingTablesError"
+	case errors.As(err, &insufficientDiskSpaceError):
+		return "InsufficientDiskSpaceError"
+	case errors.As(err, &utilityError):
+		return "UtilityError"
+	default:
+		return "Error"
+	}
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func regularFilePath(artifact interface{}) (string, bool) {
+	file, ok := artifact.(*os.File)
+	if !ok {
+		return "", false
+	}
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+
+	return file.Name(), true
+}
+
+func stageArtifact(artifact io.Reader, tempFolderManager config.TempFolderManager) (string, error) {
+	stagingFilePath, err := tempFolderManager.WriteTempFile("")
+	if err != nil {
+		return "", err
+	}
+
+	stagingFile, err := os.OpenFile(stagingFilePath, os.O_WRONLY, 0)
+	if err != nil {
+		return "", err
+	}
+	defer stagingFile.Close()
+
+	if _, err := io.Copy(stagingFile, artifact); err != nil {
+		return "", err
+	}
+
+	return stagingFilePath, stagingFile.Close()
+}
+
+// classify wraps errors from the dump and restore utilities in UtilityError,
+// leaving the errors which are already typed as they are.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var connectionError ConnectionError
+	var unsupportedVersionError UnsupportedVersionError
+	var missingTablesError MissingTablesError
+	var insufficientDiskSpaceError InsufficientDiskSpaceError
+	var utilityError UtilityError
+	if errors.As(err, &connectionError) ||
+		errors.As(err, &unsupportedVersionError) ||
+		errors.As(err, &missingTablesError) ||
+		errors.As(err, &insufficientDiskSpaceError) ||
+		errors.As(err, &utilityError) {
+		return err
+	}
+
+	return UtilityError{Err: err}
+}
@@ -0,0 +1,123 @@
+package sdk_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"database-backup-restore/config"
+	"database-backup-restore/sdk"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backup", func() {
+	var connectionConfig sdk.ConnectionConfig
+	var options sdk.Options
+
+	BeforeEach(func() {
+		connectionConfig = sdk.ConnectionConfig{
+			Adapter:  "postgres",
+			Username: "testuser",
+			Host:     "127.0.0.1",
+			Port:     1234,
+			Database: "mycooldb",
+		}
+		options = sdk.Options{
+			Utilities: config.UtilitiesConfig{
+				Postgres13: config.UtilityPaths{Client: "/non/existent/psql"},
+			},
+		}
+	})
+
+	Context("when the config is invalid", func() {
+		BeforeEach(func() {
+			connectionConfig.Adapter = "foo-server"
+		})
+
+		It("fails", func() {
+			err := sdk.Backup(context.Background(), connectionConfig, new(bytes.Buffer), options)
+			Expect(err).To(MatchError(ContainSubstring("Unsupported adapter foo-server")))
+		})
+	})
+
+	Context("when the database server version cannot be detected", func() {
+		It("returns a ConnectionError", func() {
+			err := sdk.Backup(context.Background(), connectionConfig, new(bytes.Buffer), options)
+			Expect(err).To(BeAssignableToTypeOf(sdk.ConnectionError{}))
+			Expect(err).To(MatchError(ContainSubstring("Unable to check version of Postgres")))
+		})
+	})
+
+	Context("when TLS certificates are configured", func() {
+		var tempDirectory string
+
+		BeforeEach(func() {
+			var err error
+			tempDirectory, err = os.MkdirTemp("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			connectionConfig.Tls = &config.TlsConfig{Cert: config.CertTlsConfig{Ca: "A_CA_CERT", PrivateKey: "A_KEY", Certificate: "A_CERT"}}
+			options.TempDirectory = tempDirectory
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tempDirectory)
+		})
+
+		It("removes them from the temp directory once it has failed", func() {
+			err := sdk.Backup(context.Background(), connectionConfig, new(bytes.Buffer), options)
+			Expect(err).To(HaveOccurred())
+
+			Expect(os.ReadDir(tempDirectory)).To(BeEmpty())
+		})
+
+		It("removes them from the temp directory once it has been cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := sdk.Backup(ctx, connectionConfig, new(bytes.Buffer), options)
+			Expect(err).To(HaveOccurred())
+
+			Expect(os.ReadDir(tempDirectory)).To(BeEmpty())
+		})
+	})
+
+	Context("when the temp directory does not exist", func() {
+		BeforeEach(func() {
+			options.TempDirectory = "/non/existent/directory"
+		})
+
+		It("fails", func() {
+			err := sdk.Backup(context.Background(), connectionConfig, new(bytes.Buffer), options)
+			Expect(err).To(MatchError(ContainSubstring("/non/existent/directory")))
+		})
+	})
+})
+
+var _ = Describe("Restore", func() {
+	It("returns a ConnectionError when the database server version cannot be detected", func() {
+		err := sdk.Restore(
+			context.Background(),
+			sdk.ConnectionConfig{Adapter: "postgres"},
+			bytes.NewBufferString("artifact"),
+			sdk.Options{Utilities: config.UtilitiesConfig{Postgres13: config.UtilityPaths{Client: "/non/existent/psql"}}},
+		)
+		Expect(err).To(BeAssignableToTypeOf(sdk.ConnectionError{}))
+	})
+})
+
+var _ = Describe("ErrorClass", func() {
+	It("names the class of a typed error wrapped in others", func() {
+		err := fmt.Errorf("failed to back up: %w", sdk.InsufficientDiskSpaceError{Path: "/artifacts"})
+
+		Expect(sdk.ErrorClass(err)).To(Equal("InsufficientDiskSpaceError"))
+	})
+
+	It("names errors which are not typed", func() {
+		Expect(sdk.ErrorClass(errors.New("an error"))).To(Equal("Error"))
+	})
+})
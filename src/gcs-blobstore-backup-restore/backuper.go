@@ -5,7 +5,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/cloudfoundry/bosh-backup-and-restore/executor"
+	"common/executor"
 )
 
 const timestampFormat = "2006_01_02_15_04_05"
@@ -3,7 +3,7 @@ package gcs_test
 import (
 	"errors"
 
-	"github.com/cloudfoundry/bosh-backup-and-restore/executor"
+	"common/executor"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -29,8 +29,7 @@ var _ = Describe("Backuper", func() {
 		backupBucket = new(fakes.FakeBucket)
 		backupBucket.NameReturns(BackupBucketName)
 
-		parallelExecutor := executor.NewParallelExecutor()
-		parallelExecutor.SetMaxInFlight(200)
+		parallelExecutor := executor.NewParallelExecutor(200)
 		backuper = gcs.NewBackuper(map[string]gcs.BackupToComplete{
 			bucketId: {
 				BucketPair: gcs.BucketPair{
@@ -103,8 +102,7 @@ var _ = Describe("Backuper", func() {
 				const secondBucketId = "another-bucket"
 
 				BeforeEach(func() {
-					parallelExecutor := executor.NewParallelExecutor()
-					parallelExecutor.SetMaxInFlight(200)
+					parallelExecutor := executor.NewParallelExecutor(200)
 					backuper = gcs.NewBackuper(map[string]gcs.BackupToComplete{
 						bucketId: {
 							BucketPair: gcs.BucketPair{
@@ -10,9 +10,14 @@ import (
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+
+	"common/retry"
 )
 
-const readWriteScope = "https://www.googleapis.com/auth/devstorage.read_write"
+const (
+	readWriteScope = "https://www.googleapis.com/auth/devstorage.read_write"
+	listPageSize   = 1000
+)
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate -o fakes/fake_bucket.go . Bucket
@@ -25,13 +30,28 @@ type Bucket interface {
 }
 
 type SDKBucket struct {
-	name   string
-	handle *storage.BucketHandle
-	ctx    context.Context
-	client *storage.Client
+	name        string
+	handle      *storage.BucketHandle
+	ctx         context.Context
+	client      *storage.Client
+	retryPolicy retry.Policy
+	limiter     *retry.Limiter
+}
+
+// BucketOptions controls how the requests made for a bucket are retried and
+// limited.
+type BucketOptions struct {
+	Retry retry.Policy
+	// MaxRequestsPerSecond limits the requests made for the bucket, or does
+	// not limit them when it is zero.
+	MaxRequestsPerSecond float64
 }
 
 func NewSDKBucket(serviceAccountKeyJson string, name string) (SDKBucket, error) {
+	return NewSDKBucketWithOptions(serviceAccountKeyJson, name, BucketOptions{})
+}
+
+func NewSDKBucketWithOptions(serviceAccountKeyJson string, name string, options BucketOptions) (SDKBucket, error) {
 	ctx := context.Background()
 
 	creds, err := google.CredentialsFromJSON(ctx, []byte(serviceAccountKeyJson), readWriteScope)
@@ -44,9 +64,21 @@ func NewSDKBucket(serviceAccountKeyJson string, name string) (SDKBucket, error)
 		return SDKBucket{}, err
 	}
 
+	// The client only retries requests which GCS documents as idempotent,
+	// which does not include copies, so requests are retried with the
+	// bucket's retry policy instead.
+	client.SetRetry(storage.WithPolicy(storage.RetryNever))
+
 	handle := client.Bucket(name)
 
-	return SDKBucket{name: name, handle: handle, ctx: ctx, client: client}, nil
+	return SDKBucket{
+		name:        name,
+		handle:      handle,
+		ctx:         ctx,
+		client:      client,
+		retryPolicy: options.Retry,
+		limiter:     retry.NewLimiter(options.MaxRequestsPerSecond),
+	}, nil
 }
 
 func (b SDKBucket) Name() string {
@@ -56,22 +88,35 @@ func (b SDKBucket) Name() string {
 func (b SDKBucket) ListBlobs(prefix string) ([]Blob, error) {
 	var blobs []Blob
 
-	query := &storage.Query{
-		Prefix: prefix,
-	}
-	objectsIterator := b.handle.Objects(b.ctx, query)
+	// An object iterator fails for good after an error, so a failed listing
+	// is retried from the start.
+	err := b.retryPolicy.Do(storage.ShouldRetry, func() error {
+		blobs = nil
 
-	for {
-		objectAttributes, err := objectsIterator.Next()
-		if err != nil {
-			if err == iterator.Done {
-				break
+		query := &storage.Query{
+			Prefix: prefix,
+		}
+		pager := iterator.NewPager(b.handle.Objects(b.ctx, query), listPageSize, "")
+
+		for {
+			var objects []*storage.ObjectAttrs
+			b.limiter.Wait()
+			nextPageToken, err := pager.NextPage(&objects)
+			if err != nil {
+				return err
 			}
 
-			return nil, err
-		}
+			for _, objectAttributes := range objects {
+				blobs = append(blobs, NewBlob(objectAttributes.Name))
+			}
 
-		blobs = append(blobs, NewBlob(objectAttributes.Name))
+			if nextPageToken == "" {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return blobs, nil
@@ -84,7 +129,11 @@ func (b SDKBucket) CopyBlobToBucket(dstBucket Bucket, srcBlob, dstBlob string) e
 
 	src := b.client.Bucket(b.Name()).Object(srcBlob)
 	dst := b.client.Bucket(dstBucket.Name()).Object(dstBlob)
-	_, err := dst.CopierFrom(src).Run(b.ctx)
+	err := b.retryPolicy.Do(storage.ShouldRetry, func() error {
+		b.limiter.Wait()
+		_, err := dst.CopierFrom(src).Run(b.ctx)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to copy object: %v", err)
 	}
@@ -115,5 +164,15 @@ func (b SDKBucket) CopyBlobsToBucket(dstBucket Bucket, srcPrefix string) error {
 }
 
 func (b SDKBucket) DeleteBlob(blob string) error {
-	return b.client.Bucket(b.Name()).Object(blob).Delete(b.ctx)
+	attempt := 0
+	return b.retryPolicy.Do(storage.ShouldRetry, func() error {
+		attempt++
+		b.limiter.Wait()
+		err := b.client.Bucket(b.Name()).Object(blob).Delete(b.ctx)
+		if attempt > 1 && errors.Is(err, storage.ErrObjectNotExist) {
+			// An earlier attempt deleted the blob but failed to say so.
+			return nil
+		}
+		return err
+	})
 }
@@ -3,7 +3,7 @@ package gcs
 import (
 	"sort"
 
-	"github.com/cloudfoundry/bosh-backup-and-restore/executor"
+	"common/executor"
 )
 
 type BucketPair struct {
@@ -12,7 +12,7 @@ type BucketPair struct {
 	ExecutionStrategy executor.Executor
 }
 
-func CreateBucketsForBackupArtifact(gcpSerivceAccountKey string, bucketBackups map[string]BucketBackup) (map[string]BucketBackup, error) {
+func CreateBucketsForBackupArtifact(gcpSerivceAccountKey string, bucketBackups map[string]BucketBackup, config map[string]Config) (map[string]BucketBackup, error) {
 	bucketBackupsWithGCSBucket := map[string]BucketBackup{}
 
 	for bucketID, bucketBackup := range bucketBackups {
@@ -23,7 +23,7 @@ func CreateBucketsForBackupArtifact(gcpSerivceAccountKey string, bucketBackups m
 		}
 
 		if bucketBackup.BucketName != "" {
-			bucket, err := NewSDKBucket(gcpSerivceAccountKey, bucketBackup.BucketName)
+			bucket, err := NewSDKBucketWithOptions(gcpSerivceAccountKey, bucketBackup.BucketName, config[bucketID].bucketOptions())
 			if err != nil {
 				return nil, err
 			}
@@ -36,15 +36,13 @@ func CreateBucketsForBackupArtifact(gcpSerivceAccountKey string, bucketBackups m
 }
 func BuildBackupsToComplete(gcpServiceAccountKey string, config map[string]Config) (map[string]BackupToComplete, error) {
 	backupsToComplete := map[string]BackupToComplete{}
-	exe := executor.NewParallelExecutor()
-	exe.SetMaxInFlight(200)
 	for bucketID, bucketConfig := range config {
-		bucket, err := NewSDKBucket(gcpServiceAccountKey, bucketConfig.BucketName)
+		bucket, err := NewSDKBucketWithOptions(gcpServiceAccountKey, bucketConfig.BucketName, bucketConfig.bucketOptions())
 		if err != nil {
 			return nil, err
 		}
 
-		backupBucket, err := NewSDKBucket(gcpServiceAccountKey, bucketConfig.BackupBucketName)
+		backupBucket, err := NewSDKBucketWithOptions(gcpServiceAccountKey, bucketConfig.BackupBucketName, bucketConfig.bucketOptions())
 		if err != nil {
 			return nil, err
 		}
@@ -52,7 +50,7 @@ func BuildBackupsToComplete(gcpServiceAccountKey string, config map[string]Confi
 		bucketPair := BucketPair{
 			LiveBucket:        bucket,
 			BackupBucket:      backupBucket,
-			ExecutionStrategy: exe,
+			ExecutionStrategy: executor.NewParallelExecutor(bucketConfig.MaxInFlight),
 		}
 		backupsToComplete[bucketID] = BackupToComplete{
 			BucketPair:     bucketPair,
@@ -49,7 +49,7 @@ func main() {
 		backupBuckets, err := artifact.Read()
 		exitOnError(err)
 
-		backupBuckets, err = gcs.CreateBucketsForBackupArtifact(gcpServiceAccountKey, backupBuckets)
+		backupBuckets, err = gcs.CreateBucketsForBackupArtifact(gcpServiceAccountKey, backupBuckets, config)
 		exitOnError(err)
 
 		err = restorer.Restore(backupBuckets)
@@ -1,25 +1,48 @@
 package gcs
 
 import (
-	"encoding/json"
+	"fmt"
 	"os"
+
+	"common/configloader"
+	"common/retry"
 )
 
 type Config struct {
 	BucketName       string `json:"bucket_name"`
 	BackupBucketName string `json:"backup_bucket_name"`
+	// MaxInFlight is the number of blobs copied at once during a backup, or
+	// executor.DefaultMaxInFlight when it is zero.
+	MaxInFlight int          `json:"max_in_flight,omitempty"`
+	Retry       retry.Policy `json:"retry"`
+	// MaxRequestsPerSecond limits the requests made for each of the buckets,
+	// or does not limit them when it is zero.
+	MaxRequestsPerSecond float64 `json:"max_requests_per_second,omitempty"`
+}
+
+func (c Config) bucketOptions() BucketOptions {
+	return BucketOptions{Retry: c.Retry, MaxRequestsPerSecond: c.MaxRequestsPerSecond}
 }
 
 func ParseConfig(configFilePath string) (map[string]Config, error) {
-	configContents, err := os.ReadFile(configFilePath)
+	var config map[string]Config
+	err := configloader.Load(configFilePath, &config)
 	if err != nil {
 		return nil, err
 	}
 
-	var config map[string]Config
-	err = json.Unmarshal(configContents, &config)
-	if err != nil {
-		return nil, err
+	for bucketID, bucketConfig := range config {
+		if bucketConfig.MaxInFlight < 0 {
+			return nil, fmt.Errorf("invalid max_in_flight for bucket %s: must be at least 1", bucketID)
+		}
+
+		if err := bucketConfig.Retry.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config for bucket %s: %s", bucketID, err)
+		}
+
+		if err := retry.ValidateRequestsPerSecond(bucketConfig.MaxRequestsPerSecond); err != nil {
+			return nil, fmt.Errorf("invalid config for bucket %s: %s", bucketID, err)
+		}
 	}
 
 	return config, nil
@@ -3,6 +3,7 @@ package gcs_test
 import (
 	"os"
 
+	"common/retry"
 	"gcs-blobstore-backup-restore"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -39,6 +40,110 @@ var _ = Describe("Config", func() {
 			})
 		})
 
+		Context("when the config file is YAML and refers to environment variables", func() {
+			BeforeEach(func() {
+				os.Setenv("GCS_TEST_BACKUP_BUCKET", "backup_bucket")
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("GCS_TEST_BACKUP_BUCKET")
+			})
+
+			It("parses the config with the variables replaced", func() {
+				configFile, err := os.CreateTemp("", "gcs_config*.yaml")
+				Expect(err).NotTo(HaveOccurred())
+				configYaml := `
+bucket_id:
+  bucket_name: bucket
+  backup_bucket_name: ${GCS_TEST_BACKUP_BUCKET}
+`
+				os.WriteFile(configFile.Name(), []byte(configYaml), 0644)
+
+				config, err := gcs.ParseConfig(configFile.Name())
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config["bucket_id"]).To(Equal(gcs.Config{
+					BucketName:       "bucket",
+					BackupBucketName: "backup_bucket",
+				}))
+			})
+		})
+
+		Context("when a bucket sets max_in_flight", func() {
+			It("parses it", func() {
+				configFile, err := os.CreateTemp("", "gcs_config")
+				Expect(err).NotTo(HaveOccurred())
+				configJson := `{"bucket_id": {"bucket_name": "bucket", "backup_bucket_name": "backup_bucket", "max_in_flight": 50}}`
+				os.WriteFile(configFile.Name(), []byte(configJson), 0644)
+
+				config, err := gcs.ParseConfig(configFile.Name())
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config["bucket_id"].MaxInFlight).To(Equal(50))
+			})
+
+			It("returns an error when it is negative", func() {
+				configFile, err := os.CreateTemp("", "gcs_config")
+				Expect(err).NotTo(HaveOccurred())
+				configJson := `{"bucket_id": {"bucket_name": "bucket", "backup_bucket_name": "backup_bucket", "max_in_flight": -1}}`
+				os.WriteFile(configFile.Name(), []byte(configJson), 0644)
+
+				_, err = gcs.ParseConfig(configFile.Name())
+
+				Expect(err).To(MatchError("invalid max_in_flight for bucket bucket_id: must be at least 1"))
+			})
+		})
+
+		Context("when a bucket sets a retry policy and request rate", func() {
+			It("parses them", func() {
+				configFile, err := os.CreateTemp("", "gcs_config")
+				Expect(err).NotTo(HaveOccurred())
+				configJson := `{"bucket_id": {"bucket_name": "bucket", "backup_bucket_name": "backup_bucket", "retry": {"max_attempts": 8, "base_delay_ms": 100}, "max_requests_per_second": 500}}`
+				os.WriteFile(configFile.Name(), []byte(configJson), 0644)
+
+				config, err := gcs.ParseConfig(configFile.Name())
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config["bucket_id"].Retry).To(Equal(retry.Policy{MaxAttempts: 8, BaseDelayMS: 100}))
+				Expect(config["bucket_id"].MaxRequestsPerSecond).To(Equal(500.0))
+			})
+
+			It("returns an error when the retry policy is invalid", func() {
+				configFile, err := os.CreateTemp("", "gcs_config")
+				Expect(err).NotTo(HaveOccurred())
+				configJson := `{"bucket_id": {"bucket_name": "bucket", "backup_bucket_name": "backup_bucket", "retry": {"base_delay_ms": -1}}}`
+				os.WriteFile(configFile.Name(), []byte(configJson), 0644)
+
+				_, err = gcs.ParseConfig(configFile.Name())
+
+				Expect(err).To(MatchError("invalid config for bucket bucket_id: retry.base_delay_ms must be at least 1"))
+			})
+
+			It("returns an error when the request rate is negative", func() {
+				configFile, err := os.CreateTemp("", "gcs_config")
+				Expect(err).NotTo(HaveOccurred())
+				configJson := `{"bucket_id": {"bucket_name": "bucket", "backup_bucket_name": "backup_bucket", "max_requests_per_second": -1}}`
+				os.WriteFile(configFile.Name(), []byte(configJson), 0644)
+
+				_, err = gcs.ParseConfig(configFile.Name())
+
+				Expect(err).To(MatchError("invalid config for bucket bucket_id: max_requests_per_second must not be negative"))
+			})
+		})
+
+		Context("when the config file has an unknown key", func() {
+			It("returns an error", func() {
+				configFile, err := os.CreateTemp("", "gcs_config")
+				Expect(err).NotTo(HaveOccurred())
+				configJson := `{"bucket_id": {"bucket_name": "bucket", "backup_bucket": "backup_bucket"}}`
+				os.WriteFile(configFile.Name(), []byte(configJson), 0644)
+
+				_, err = gcs.ParseConfig(configFile.Name())
+
+				Expect(err).To(MatchError(ContainSubstring(`unknown field "backup_bucket"`)))
+			})
+		})
+
 		Context("when the config file does not exist", func() {
 			It("returns an error", func() {
 				_, err := gcs.ParseConfig("")
@@ -88,7 +88,7 @@ var _ = Describe("Bucket", func() {
 				},
 			}
 
-			bucketBackups, err := gcs.CreateBucketsForBackupArtifact(MustHaveEnv("GCP_SERVICE_ACCOUNT_KEY"), config)
+			bucketBackups, err := gcs.CreateBucketsForBackupArtifact(MustHaveEnv("GCP_SERVICE_ACCOUNT_KEY"), config, map[string]gcs.Config{})
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(bucketBackups).To(HaveKey(id1))
@@ -109,7 +109,7 @@ var _ = Describe("Bucket", func() {
 					},
 				}
 
-				_, err := gcs.CreateBucketsForBackupArtifact("not-valid-json", config)
+				_, err := gcs.CreateBucketsForBackupArtifact("not-valid-json", config, map[string]gcs.Config{})
 				Expect(err).To(HaveOccurred())
 			})
 		})
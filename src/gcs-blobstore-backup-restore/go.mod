@@ -3,8 +3,8 @@ module gcs-blobstore-backup-restore
 go 1.25.0
 
 require (
+	common v0.0.0
 	cloud.google.com/go/storage v1.65.0
-	github.com/cloudfoundry/bosh-backup-and-restore v1.9.80
 	github.com/maxbrunsfeld/counterfeiter/v6 v6.12.2
 	github.com/onsi/ginkgo/v2 v2.32.1
 	github.com/onsi/gomega v1.42.1
@@ -65,3 +65,5 @@ require (
 	google.golang.org/grpc v1.83.1 // indirect
 	google.golang.org/protobuf v1.36.12 // indirect
 )
+
+replace common => ../common
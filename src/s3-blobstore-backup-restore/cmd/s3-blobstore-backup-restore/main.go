@@ -1,9 +1,9 @@
 package main
 
 import (
-	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"time"
@@ -11,13 +11,22 @@ import (
 	"s3-blobstore-backup-restore/unversioned"
 
 	"s3-blobstore-backup-restore/incremental"
+	"s3-blobstore-backup-restore/mirror"
 	"s3-blobstore-backup-restore/versioned"
+
+	"common/configloader"
 )
 
 type CommandFlags struct {
 	ConfigPath                          string
 	ArtifactFilePath                    string
 	ExistingBackupBlobsArtifactFilePath string
+	Format                              string
+	BackupTimestamp                     string
+	BucketID                            string
+	Mirror                              bool
+	MirrorReportOnly                    bool
+	At                                  time.Time
 
 	VersionedBackup  *bool
 	VersionedRestore *bool
@@ -25,6 +34,8 @@ type CommandFlags struct {
 	UnversionedBackupStart    *bool
 	UnversionedBackupComplete *bool
 	UnversionedRestore        *bool
+	UnversionedPrune          *bool
+	ListBackups               *bool
 }
 
 type Runner interface {
@@ -34,7 +45,7 @@ type Runner interface {
 type clock struct{}
 
 func (c clock) Now() string {
-	return time.Now().Format("2006_01_02_15_04_05")
+	return time.Now().Format(incremental.TimestampFormat)
 }
 
 func main() {
@@ -43,7 +54,7 @@ func main() {
 		exitWithError("Failed to parse flags", err)
 	}
 
-	rawConfig, err := os.ReadFile(flags.ConfigPath)
+	rawConfig, err := configloader.Read(flags.ConfigPath)
 	if err != nil {
 		exitWithError("Failed to read config", err)
 	}
@@ -51,32 +62,54 @@ func main() {
 	var runner Runner
 	if *flags.VersionedBackup || *flags.VersionedRestore {
 		var bucketsConfig map[string]versioned.BucketConfig
-		err = json.Unmarshal(rawConfig, &bucketsConfig)
+		err = configloader.Unmarshal(rawConfig, &bucketsConfig)
 		if err != nil {
 			exitWithError("Failed to parse config", err)
 		}
 
-		buckets, err := versioned.BuildVersionedBuckets(bucketsConfig, versioned.NewVersionedBucketWithRoleARN)
+		for identifier, bucketConfig := range bucketsConfig {
+			bucketConfig.DeleteExtraneous = flags.deleteExtraneous(bucketConfig.DeleteExtraneous)
+			bucketsConfig[identifier] = bucketConfig
+		}
+
+		buckets, err := versioned.BuildVersionedBuckets(bucketsConfig, versioned.NewVersionedBucketWithCredentials)
 		if err != nil {
 			exitWithError("Failed to establish build versioned buckets", err)
 		}
 
-		artifact := versioned.NewFileArtifact(flags.ArtifactFilePath)
+		var artifact versioned.Artifact = versioned.NewFileArtifact(flags.ArtifactFilePath)
+		if !flags.At.IsZero() {
+			artifact = versioned.NewPointInTimeArtifact(buckets, flags.At)
+		}
 
 		if *flags.VersionedBackup {
 			runner = versioned.NewBackuper(buckets, artifact)
 		} else {
-			runner = versioned.NewRestorer(buckets, artifact)
+			runner = versioned.NewRestorer(buckets, artifact, versioned.MaxInFlight(bucketsConfig)).
+				WithDeleteExtraneous(versioned.DeleteExtraneous(bucketsConfig), os.Stdout)
 		}
 	} else {
 		var bucketsConfig map[string]unversioned.UnversionedBucketConfig
-		err = json.Unmarshal(rawConfig, &bucketsConfig)
+		err = configloader.Unmarshal(rawConfig, &bucketsConfig)
 		if err != nil {
 			exitWithError("Failed to parse config", err)
 		}
 
+		if flags.BucketID != "" {
+			bucketConfig, ok := bucketsConfig[flags.BucketID]
+			if !ok {
+				exitWithError("Failed to parse config", fmt.Errorf("bucket ID '%s' is not in the config", flags.BucketID))
+			}
+			bucketsConfig = map[string]unversioned.UnversionedBucketConfig{flags.BucketID: bucketConfig}
+		}
+
+		for bucketID, bucketConfig := range bucketsConfig {
+			bucketConfig.DeleteExtraneous = flags.deleteExtraneous(bucketConfig.DeleteExtraneous)
+			bucketsConfig[bucketID] = bucketConfig
+		}
+
 		if *flags.UnversionedBackupStart {
-			backupsToStart, err := unversioned.BuildBackupsToStart(bucketsConfig, unversioned.NewUnversionedBucketWithRoleARN)
+			backupsToStart, err := unversioned.BuildBackupsToStart(bucketsConfig, unversioned.NewUnversionedBucketWithCredentials)
 			if err != nil {
 				exitWithError("Failed to build backups to start", err)
 			}
@@ -85,21 +118,45 @@ func main() {
 			runner = incremental.NewBackupStarter(backupsToStart, clock{}, backupArtifact, existingBackupBlobsArtifact)
 		} else if *flags.UnversionedBackupComplete {
 			existingBackupBlobsArtifact := incremental.NewArtifact(flags.ExistingBackupBlobsArtifactFilePath)
-			backupsToComplete, err := unversioned.BuildBackupsToComplete(bucketsConfig, existingBackupBlobsArtifact, unversioned.NewUnversionedBucketWithRoleARN)
+			backupsToComplete, err := unversioned.BuildBackupsToComplete(bucketsConfig, existingBackupBlobsArtifact, unversioned.NewUnversionedBucketWithCredentials)
 			if err != nil {
 				exitWithError("Failed to build backups to complete", err)
 			}
 			runner = incremental.BackupCompleter{
 				BackupsToComplete: backupsToComplete,
 			}
+		} else if *flags.ListBackups {
+			backupBuckets, err := unversioned.BuildBackupBuckets(bucketsConfig, unversioned.NewUnversionedBucketWithCredentials)
+			if err != nil {
+				exitWithError("Failed to build backup buckets", err)
+			}
+			runner = incremental.BackupLister{
+				BackupBuckets: backupBuckets,
+				Format:        flags.Format,
+				Output:        os.Stdout,
+			}
+		} else if *flags.UnversionedPrune {
+			backupsToPrune, err := unversioned.BuildBackupsToPrune(bucketsConfig, unversioned.NewUnversionedBucketWithCredentials)
+			if err != nil {
+				exitWithError("Failed to build backups to prune", err)
+			}
+			runner = incremental.NewPruner(backupsToPrune, clock{})
 		} else {
 			backupArtifact := incremental.NewArtifact(flags.ArtifactFilePath)
-			restoreBucketPairs, err := unversioned.BuildRestoreBucketPairs(bucketsConfig, backupArtifact, unversioned.NewUnversionedBucketWithRoleARN)
+			if flags.BackupTimestamp != "" {
+				backupsToFind, err := unversioned.BuildBackupsToFind(bucketsConfig, unversioned.NewUnversionedBucketWithCredentials)
+				if err != nil {
+					exitWithError("Failed to build backups to find", err)
+				}
+				backupArtifact = incremental.NewBackupDirectoryArtifact(backupsToFind, flags.BackupTimestamp)
+			}
+
+			restoreBucketPairs, err := unversioned.BuildRestoreBucketPairs(bucketsConfig, backupArtifact, unversioned.NewUnversionedBucketWithCredentials)
 			if err != nil {
 				exitWithError("Failed to build restore bucket pairs", err)
 			}
 
-			runner = incremental.NewRestorer(restoreBucketPairs, backupArtifact)
+			runner = incremental.NewRestorer(restoreBucketPairs, backupArtifact).WithReport(os.Stdout)
 		}
 	}
 
@@ -109,6 +166,19 @@ func main() {
 	}
 }
 
+// deleteExtraneous applies the --mirror and --mirror-report-only flags to the
+// delete_extraneous config of a bucket.
+func (f CommandFlags) deleteExtraneous(config mirror.Config) mirror.Config {
+	if f.Mirror {
+		config.Enabled = true
+	}
+	if f.MirrorReportOnly {
+		config.Enabled = true
+		config.DryRun = true
+	}
+	return config
+}
+
 func exitWithError(context string, err error) {
 	log.Fatalf("%s: %s", context, err)
 }
@@ -124,6 +194,14 @@ func parseFlags() (CommandFlags, error) {
 		unversionedBackupStart    = flag.Bool("unversioned-backup-start", false, "Run backup starter for unversioned buckets")
 		unversionedBackupComplete = flag.Bool("unversioned-backup-complete", false, "Run backup completer for unversioned buckets")
 		unversionedRestore        = flag.Bool("unversioned-restore", false, "Run unversioned blobstore restore")
+		unversionedPrune          = flag.Bool("unversioned-prune", false, "Delete unversioned backups which the retention policy no longer keeps")
+		listBackups               = flag.Bool("list-backups", false, "List the unversioned backups in the backup buckets")
+		format                    = flag.String("format", incremental.ListFormatTable, "Format of the list of backups, table or json")
+		backupTimestamp           = flag.String("backup-timestamp", "", "Restore unversioned buckets from the backup directories with this timestamp, or from the latest complete backups with 'latest', instead of from the artifact")
+		bucketID                  = flag.String("bucket-id", "", "Restore only this bucket ID from the backup directories")
+		mirrorRestore             = flag.Bool("mirror", false, "Delete the blobs which are not in the backup from the restored buckets")
+		mirrorReportOnly          = flag.Bool("mirror-report-only", false, "Restore the backed up blobs, but only report the blobs which --mirror or delete_extraneous would delete, without deleting them")
+		at                        = flag.String("at", "", "Restore versioned buckets to the versions which were current at this RFC 3339 time, such as 2024-01-31T12:00:00Z, instead of from the artifact")
 	)
 
 	flag.Parse()
@@ -133,7 +211,7 @@ func parseFlags() (CommandFlags, error) {
 	}
 
 	var count int
-	for _, b := range []*bool{versionedBackup, versionedRestore, unversionedBackupStart, unversionedBackupComplete, unversionedRestore} {
+	for _, b := range []*bool{versionedBackup, versionedRestore, unversionedBackupStart, unversionedBackupComplete, unversionedRestore, unversionedPrune, listBackups} {
 		if *b {
 			count++
 		}
@@ -143,7 +221,32 @@ func parseFlags() (CommandFlags, error) {
 		return CommandFlags{}, errors.New("exactly one action flag must be provided")
 	}
 
-	if (*versionedBackup || *versionedRestore || *unversionedBackupStart || *unversionedRestore) && *artifactPath == "" {
+	if (*backupTimestamp != "" || *bucketID != "") && !*unversionedRestore {
+		return CommandFlags{}, errors.New("--backup-timestamp and --bucket-id can only be used with --unversioned-restore")
+	}
+
+	if (*mirrorRestore || *mirrorReportOnly) && !*versionedRestore && !*unversionedRestore {
+		return CommandFlags{}, errors.New("--mirror and --mirror-report-only can only be used with --versioned-restore or --unversioned-restore")
+	}
+
+	if *at != "" && !*versionedRestore {
+		return CommandFlags{}, errors.New("--at can only be used with --versioned-restore")
+	}
+
+	var atTime time.Time
+	if *at != "" {
+		var err error
+		atTime, err = time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return CommandFlags{}, errors.New("invalid --at flag: must be an RFC 3339 time such as 2024-01-31T12:00:00Z")
+		}
+	}
+
+	if *bucketID != "" && *backupTimestamp == "" {
+		return CommandFlags{}, errors.New("--bucket-id requires the --backup-timestamp flag")
+	}
+
+	if (*versionedBackup || (*versionedRestore && *at == "") || *unversionedBackupStart || (*unversionedRestore && *backupTimestamp == "")) && *artifactPath == "" {
 		return CommandFlags{}, errors.New("missing --artifact flag")
 	}
 
@@ -151,10 +254,20 @@ func parseFlags() (CommandFlags, error) {
 		return CommandFlags{}, errors.New("missing --existing-artifact flag")
 	}
 
+	if *format != incremental.ListFormatTable && *format != incremental.ListFormatJSON {
+		return CommandFlags{}, fmt.Errorf("invalid --format flag: must be %s or %s", incremental.ListFormatTable, incremental.ListFormatJSON)
+	}
+
 	return CommandFlags{
 		ConfigPath:                          *configFilePath,
 		ArtifactFilePath:                    *artifactPath,
 		ExistingBackupBlobsArtifactFilePath: *existingArtifactPath,
+		Format:                              *format,
+		BackupTimestamp:                     *backupTimestamp,
+		BucketID:                            *bucketID,
+		Mirror:                              *mirrorRestore,
+		MirrorReportOnly:                    *mirrorReportOnly,
+		At:                                  atTime,
 
 		VersionedBackup:  versionedBackup,
 		VersionedRestore: versionedRestore,
@@ -162,5 +275,7 @@ func parseFlags() (CommandFlags, error) {
 		UnversionedBackupStart:    unversionedBackupStart,
 		UnversionedBackupComplete: unversionedBackupComplete,
 		UnversionedRestore:        unversionedRestore,
+		UnversionedPrune:          unversionedPrune,
+		ListBackups:               listBackups,
 	}, nil
 }
@@ -2,6 +2,7 @@ package main_test
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -29,6 +30,8 @@ var _ = Describe("Main", func() {
 			{"versioned-backup", "unversioned-backup-start"},
 			{"versioned-backup", "unversioned-backup-complete"},
 			{"versioned-backup", "unversioned-restore"},
+			{"unversioned-backup-complete", "unversioned-prune"},
+			{"unversioned-restore", "list-backups"},
 		} {
 			It(fmt.Sprintf("fails with --%s and --%s", actions[0], actions[1]), func() {
 				session, err := gexec.Start(
@@ -74,6 +77,121 @@ var _ = Describe("Main", func() {
 			exitsWithErrorMsg(err, session, "missing --existing-artifact flag")
 		})
 	})
+
+	Context("when restoring from backup directories", func() {
+		It("does not require the --artifact flag", func() {
+			session, err := gexec.Start(
+				exec.Command(binaryPath, "--unversioned-restore", "--config", "a-config-path", "--backup-timestamp", "latest"),
+				GinkgoWriter,
+				GinkgoWriter,
+			)
+			exitsWithErrorMsg(err, session, "Failed to read config")
+		})
+
+		It("fails when --bucket-id is given without --backup-timestamp", func() {
+			session, err := gexec.Start(
+				exec.Command(binaryPath, "--unversioned-restore", "--config", "a-config-path", "--artifact", "a-artifact-path", "--bucket-id", "droplets"),
+				GinkgoWriter,
+				GinkgoWriter,
+			)
+			exitsWithErrorMsg(err, session, "--bucket-id requires the --backup-timestamp flag")
+		})
+
+		It("fails when --backup-timestamp is given for another action", func() {
+			session, err := gexec.Start(
+				exec.Command(binaryPath, "--versioned-restore", "--config", "a-config-path", "--artifact", "a-artifact-path", "--backup-timestamp", "latest"),
+				GinkgoWriter,
+				GinkgoWriter,
+			)
+			exitsWithErrorMsg(err, session, "--backup-timestamp and --bucket-id can only be used with --unversioned-restore")
+		})
+	})
+
+	Context("when restoring versioned buckets to a point in time", func() {
+		It("does not require the --artifact flag", func() {
+			session, err := gexec.Start(
+				exec.Command(binaryPath, "--versioned-restore", "--config", "a-config-path", "--at", "2024-01-31T12:00:00Z"),
+				GinkgoWriter,
+				GinkgoWriter,
+			)
+			exitsWithErrorMsg(err, session, "Failed to read config")
+		})
+
+		It("fails when --at is not an RFC 3339 time", func() {
+			session, err := gexec.Start(
+				exec.Command(binaryPath, "--versioned-restore", "--config", "a-config-path", "--at", "yesterday"),
+				GinkgoWriter,
+				GinkgoWriter,
+			)
+			exitsWithErrorMsg(err, session, "invalid --at flag: must be an RFC 3339 time such as 2024-01-31T12:00:00Z")
+		})
+
+		It("fails when --at is given for another action", func() {
+			session, err := gexec.Start(
+				exec.Command(binaryPath, "--versioned-backup", "--config", "a-config-path", "--artifact", "a-artifact-path", "--at", "2024-01-31T12:00:00Z"),
+				GinkgoWriter,
+				GinkgoWriter,
+			)
+			exitsWithErrorMsg(err, session, "--at can only be used with --versioned-restore")
+		})
+	})
+
+	It("fails when --mirror is given for an action which is not a restore", func() {
+		session, err := gexec.Start(
+			exec.Command(binaryPath, "--versioned-backup", "--config", "a-config-path", "--artifact", "a-artifact-path", "--mirror"),
+			GinkgoWriter,
+			GinkgoWriter,
+		)
+		exitsWithErrorMsg(err, session, "--mirror and --mirror-report-only can only be used with --versioned-restore or --unversioned-restore")
+	})
+
+	It("fails when the --format flag is not table or json", func() {
+		session, err := gexec.Start(
+			exec.Command(binaryPath, "--list-backups", "--config", "a-config-path", "--format", "yaml"),
+			GinkgoWriter,
+			GinkgoWriter,
+		)
+		exitsWithErrorMsg(err, session, "invalid --format flag: must be table or json")
+	})
+
+	Context("when the config is invalid", func() {
+		var configPath string
+
+		writeConfig := func(pattern, contents string) {
+			configFile, err := os.CreateTemp("", pattern)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = configFile.WriteString(contents)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configFile.Close()).To(Succeed())
+			configPath = configFile.Name()
+		}
+
+		AfterEach(func() {
+			os.Remove(configPath)
+		})
+
+		It("fails when the config has a misspelled key", func() {
+			writeConfig("*.json", `{"droplets": {"name": "a-bucket", "region": "a-region", "force_pathstyle": true}}`)
+
+			session, err := gexec.Start(
+				exec.Command(binaryPath, "--versioned-backup", "--config", configPath, "--artifact", "a-artifact-path"),
+				GinkgoWriter,
+				GinkgoWriter,
+			)
+			exitsWithErrorMsg(err, session, `Failed to parse config: json: unknown field "force_pathstyle"`)
+		})
+
+		It("fails when a YAML config refers to an environment variable which is not set", func() {
+			writeConfig("*.yml", "droplets:\n  name: a-bucket\n  aws_secret_access_key: ${S3_TEST_UNSET_SECRET}\n")
+
+			session, err := gexec.Start(
+				exec.Command(binaryPath, "--versioned-backup", "--config", configPath, "--artifact", "a-artifact-path"),
+				GinkgoWriter,
+				GinkgoWriter,
+			)
+			exitsWithErrorMsg(err, session, "Failed to read config: config refers to environment variables which are not set: S3_TEST_UNSET_SECRET")
+		})
+	})
 })
 
 func exitsWithErrorMsg(err error, session *gexec.Session, errMsg string) {
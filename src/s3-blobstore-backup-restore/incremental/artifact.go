@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
+
+	"s3-blobstore-backup-restore/integrity"
 )
 
 //counterfeiter:generate -o fakes/fake_artifact.go . Artifact
@@ -29,6 +32,21 @@ type Backup struct {
 	SrcBackupDirectoryPath string   `json:"src_backup_directory_path"`
 	DstBackupDirectoryPath string   `json:"dst_backup_directory_path,omitempty"`
 	SameBucketAs           string   `json:",omitempty"`
+	// BlobAttributes holds the attributes of each of the Blobs, keyed by its
+	// path. Artifacts written before the attributes were recorded have none.
+	BlobAttributes map[string]BlobAttributes `json:"blob_attributes,omitempty"`
+}
+
+// BlobAttributes are the attributes S3 listed for a blob when it was backed
+// up: those of the live blob in a backup artifact, and those of the copy in
+// the previous backup in an existing blobs artifact.
+type BlobAttributes struct {
+	ETag         string    `json:"etag,omitempty"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	// Checksum is the checksum of the backed up copy of the blob, when the
+	// backup verified it.
+	Checksum integrity.Checksum `json:"checksum,omitzero"`
 }
 
 func (a artifact) Write(backups map[string]Backup) error {
@@ -1,18 +1,47 @@
 package incremental
 
 import (
+	"strings"
+	"time"
+
 	"s3-blobstore-backup-restore/blobpath"
 )
 
+// BackedUpBlob is a blob in a backup directory. Its ETag, Size and
+// LastModified are those of the copy in the backup bucket, when known.
 type BackedUpBlob struct {
 	Path                string
 	BackupDirectoryPath string
+	ETag                string
+	Size                int64
+	LastModified        time.Time
 }
 
 func (b BackedUpBlob) LiveBlobPath() string {
 	return blobpath.TrimPrefix(b.Path, b.BackupDirectoryPath)
 }
 
+// HasChanged reports whether liveBlob has changed since it was copied to the
+// backup, which it has if its size is different or if it was modified after
+// the copy was made.
+func (b BackedUpBlob) HasChanged(liveBlob Blob) bool {
+	return liveBlob.Size() != b.Size || liveBlob.LastModified().After(b.LastModified)
+}
+
+// HasDifferentETag reports whether liveBlob and the backed up copy have
+// different ETags which look like MD5 digests. The blob has only changed if
+// both ETags are digests of the contents, which S3 does not make them for
+// blobs encrypted with a KMS or customer key, even by the default encryption
+// of the bucket. The ETags of blobs which were uploaded or copied in parts are
+// not digests either, so they are not compared.
+func (b BackedUpBlob) HasDifferentETag(liveBlob Blob) bool {
+	return isDigestETag(liveBlob.ETag()) && isDigestETag(b.ETag) && liveBlob.ETag() != b.ETag
+}
+
+func isDigestETag(etag string) bool {
+	return etag != "" && !strings.Contains(etag, "-")
+}
+
 func joinBlobPath(prefix, suffix string) string {
 	return blobpath.Join(prefix, suffix)
 }
@@ -1,7 +1,10 @@
 package incremental_test
 
 import (
+	"time"
+
 	"s3-blobstore-backup-restore/incremental"
+	"s3-blobstore-backup-restore/incremental/fakes"
 
 	. "github.com/onsi/ginkgo/v2"
 
@@ -19,4 +22,59 @@ var _ = Describe("BackedUpBlob", func() {
 
 		Expect(path).To(Equal("fd/f0/blob1/uuid"))
 	})
+
+	Describe("HasChanged", func() {
+		var (
+			liveModified   time.Time
+			backupModified time.Time
+			backedUpBlob   incremental.BackedUpBlob
+			liveBlob       *fakes.FakeBlob
+		)
+
+		BeforeEach(func() {
+			liveModified = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+			backupModified = liveModified.Add(time.Hour)
+
+			backedUpBlob = incremental.BackedUpBlob{
+				Path:                "timestamp/bucket_id/fd/f0/blob1/uuid",
+				BackupDirectoryPath: "timestamp/bucket_id",
+				ETag:                `"etag"`,
+				Size:                10,
+				LastModified:        backupModified,
+			}
+
+			liveBlob = new(fakes.FakeBlob)
+			liveBlob.PathReturns("fd/f0/blob1/uuid")
+		})
+
+		DescribeTable("compares the live blob with the backed up copy",
+			func(etag string, size int64, modifiedAfterBackup bool, changed bool) {
+				liveBlob.ETagReturns(etag)
+				liveBlob.SizeReturns(size)
+				if modifiedAfterBackup {
+					liveBlob.LastModifiedReturns(backupModified.Add(time.Second))
+				} else {
+					liveBlob.LastModifiedReturns(liveModified)
+				}
+
+				Expect(backedUpBlob.HasChanged(liveBlob)).To(Equal(changed))
+			},
+			Entry("unchanged", `"etag"`, int64(10), false, false),
+			Entry("a different size", `"etag"`, int64(11), false, true),
+			Entry("a different ETag", `"other"`, int64(10), false, false),
+			Entry("modified after the backup was made", `"etag"`, int64(10), true, true),
+		)
+
+		DescribeTable("compares the ETags of the live blob and the backed up copy",
+			func(etag string, different bool) {
+				liveBlob.ETagReturns(etag)
+
+				Expect(backedUpBlob.HasDifferentETag(liveBlob)).To(Equal(different))
+			},
+			Entry("the same ETag", `"etag"`, false),
+			Entry("a different digest ETag", `"other"`, true),
+			Entry("a different multipart ETag", `"etag-2"`, false),
+			Entry("no ETag", "", false),
+		)
+	})
 })
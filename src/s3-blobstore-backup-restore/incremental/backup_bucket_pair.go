@@ -1,10 +1,38 @@
 package incremental
 
-import "github.com/cloudfoundry/bosh-backup-and-restore/executor"
+import (
+	"sync"
+
+	"common/executor"
+
+	"s3-blobstore-backup-restore/integrity"
+)
+
+// ChangeDetection is how a backup decides whether a live blob which is
+// already in the previous backup needs copying again.
+type ChangeDetection string
+
+const (
+	// ChangeDetectionContent copies a live blob again when its size, ETag or
+	// last modified time shows it has changed since it was backed up.
+	ChangeDetectionContent ChangeDetection = "content"
+	// ChangeDetectionPath never copies a live blob again once a blob with the
+	// same path is backed up.
+	ChangeDetectionPath ChangeDetection = "path"
+)
 
 type BackupBucketPair struct {
 	ConfigLiveBucket   Bucket
 	ConfigBackupBucket Bucket
+	// MaxInFlight is the number of blobs copied at once, or
+	// executor.DefaultMaxInFlight when it is zero.
+	MaxInFlight int
+	// ChangeDetection is ChangeDetectionContent when it is empty.
+	ChangeDetection ChangeDetection
+	// Verify is whether the checksums of the blobs copied to the backup
+	// bucket are recorded in the backup artifact, so that restores can check
+	// the blobs they restore against them.
+	Verify bool
 }
 
 func (b BackupBucketPair) CopyNewLiveBlobsToBackup(backedUpBlobs []BackedUpBlob, liveBlobs []Blob, backupDirPath string) ([]BackedUpBlob, error) {
@@ -16,27 +44,39 @@ func (b BackupBucketPair) CopyNewLiveBlobsToBackup(backedUpBlobs []BackedUpBlob,
 	var (
 		executables   []executor.Executable
 		existingBlobs []BackedUpBlob
+		mutex         sync.Mutex
 	)
+	keep := func(backedUpBlob BackedUpBlob) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		existingBlobs = append(existingBlobs, backedUpBlob)
+	}
+
 	for _, liveBlob := range liveBlobs {
 		backedUpBlob, exists := backedUpBlobsMap[liveBlob.Path()]
 
-		if !exists {
-			executable := copyBlobFromBucketExecutable{
-				src:       liveBlob.Path(),
-				dst:       joinBlobPath(backupDirPath, liveBlob.Path()),
-				srcBucket: b.ConfigLiveBucket,
-				dstBucket: b.ConfigBackupBucket,
-			}
-			executables = append(executables, executable)
-		} else {
-			existingBlobs = append(existingBlobs, backedUpBlob)
+		copyExecutable := copyBlobFromBucketExecutable{
+			src:       liveBlob.Path(),
+			dst:       joinBlobPath(backupDirPath, liveBlob.Path()),
+			srcBucket: b.ConfigLiveBucket,
+			dstBucket: b.ConfigBackupBucket,
 		}
-	}
 
-	e := executor.NewParallelExecutor()
-	e.SetMaxInFlight(200)
+		switch {
+		case !exists || (b.ChangeDetection != ChangeDetectionPath && backedUpBlob.HasChanged(liveBlob)):
+			executables = append(executables, copyExecutable)
+		case b.ChangeDetection != ChangeDetectionPath && backedUpBlob.HasDifferentETag(liveBlob):
+			executables = append(executables, copyBlobIfETagChangedExecutable{
+				copyBlobFromBucketExecutable: copyExecutable,
+				backedUpBlob:                 backedUpBlob,
+				keep:                         keep,
+			})
+		default:
+			keep(backedUpBlob)
+		}
+	}
 
-	errs := e.Run([][]executor.Executable{executables})
+	errs := executor.NewParallelExecutor(b.MaxInFlight).Run([][]executor.Executable{executables})
 	if len(errs) != 0 {
 		return nil, formatExecutorErrors("failing copying blobs in parallel", errs)
 	}
@@ -44,6 +84,62 @@ func (b BackupBucketPair) CopyNewLiveBlobsToBackup(backedUpBlobs []BackedUpBlob,
 	return existingBlobs, nil
 }
 
+// RecordChecksums records in backup the checksums of the blobs which were
+// copied to it from the live bucket, leaving out the existing blobs which are
+// copied from the previous backup.
+func (b BackupBucketPair) RecordChecksums(backup Backup, existingBlobs []BackedUpBlob) error {
+	existingPaths := map[string]bool{}
+	for _, blob := range existingBlobs {
+		existingPaths[blob.LiveBlobPath()] = true
+	}
+
+	var mutex sync.Mutex
+	record := func(path string, checksum integrity.Checksum) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		attributes := backup.BlobAttributes[path]
+		attributes.Checksum = checksum
+		backup.BlobAttributes[path] = attributes
+	}
+
+	var executables []executor.Executable
+	for _, path := range backup.Blobs {
+		backedUpBlob := BackedUpBlob{Path: path, BackupDirectoryPath: backup.SrcBackupDirectoryPath}
+		if existingPaths[backedUpBlob.LiveBlobPath()] {
+			continue
+		}
+
+		executables = append(executables, recordChecksumExecutable{
+			bucket: b.ConfigBackupBucket,
+			path:   path,
+			record: record,
+		})
+	}
+
+	errs := executor.NewParallelExecutor(b.MaxInFlight).Run([][]executor.Executable{executables})
+	if len(errs) != 0 {
+		return formatExecutorErrors("failed to record checksums of copied blobs", errs)
+	}
+
+	return nil
+}
+
+type recordChecksumExecutable struct {
+	bucket Bucket
+	path   string
+	record func(path string, checksum integrity.Checksum)
+}
+
+func (e recordChecksumExecutable) Execute() error {
+	checksum, err := e.bucket.Checksum(e.path)
+	if err != nil {
+		return err
+	}
+
+	e.record(e.path, checksum)
+	return nil
+}
+
 type copyBlobFromBucketExecutable struct {
 	src       string
 	dst       string
@@ -54,3 +150,34 @@ type copyBlobFromBucketExecutable struct {
 func (e copyBlobFromBucketExecutable) Execute() error {
 	return e.dstBucket.CopyBlobFromBucket(e.srcBucket, e.src, e.dst)
 }
+
+// copyBlobIfETagChangedExecutable copies a live blob whose ETag differs from
+// that of its backed up copy when both ETags are digests of the contents, and
+// otherwise keeps the backed up copy, since ETags which are not digests differ
+// however alike the contents are.
+type copyBlobIfETagChangedExecutable struct {
+	copyBlobFromBucketExecutable
+	backedUpBlob BackedUpBlob
+	keep         func(BackedUpBlob)
+}
+
+func (e copyBlobIfETagChangedExecutable) Execute() error {
+	liveETagIsDigest, err := e.srcBucket.ETagIsDigest(e.src)
+	if err != nil {
+		return err
+	}
+
+	if liveETagIsDigest {
+		backupETagIsDigest, err := e.dstBucket.ETagIsDigest(e.backedUpBlob.Path)
+		if err != nil {
+			return err
+		}
+
+		if backupETagIsDigest {
+			return e.copyBlobFromBucketExecutable.Execute()
+		}
+	}
+
+	e.keep(e.backedUpBlob)
+	return nil
+}
@@ -2,6 +2,7 @@ package incremental_test
 
 import (
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 
@@ -83,6 +84,163 @@ var _ = Describe("BackupBucketPair", func() {
 			}))
 		})
 
+		Context("when a backed up live blob has changed", func() {
+			var backedUpBlobs []incremental.BackedUpBlob
+
+			BeforeEach(func() {
+				backupModified := time.Date(2015, 12, 13, 5, 6, 7, 0, time.UTC)
+
+				liveBlob1.ETagReturns(`"etag1"`)
+				liveBlob1.SizeReturns(10)
+				liveBlob1.LastModifiedReturns(backupModified.Add(-time.Hour))
+				liveBlob2.ETagReturns(`"new-etag2"`)
+				liveBlob2.SizeReturns(20)
+				liveBlob2.LastModifiedReturns(backupModified.Add(time.Hour))
+
+				backedUpBlobs = []incremental.BackedUpBlob{
+					{
+						Path:                "2015-12-13-05-06-07/my_bucket_id/livebucketpath/to/real/blob1",
+						BackupDirectoryPath: "2015-12-13-05-06-07/my_bucket_id",
+						ETag:                `"etag1"`,
+						Size:                10,
+						LastModified:        backupModified,
+					},
+					{
+						Path:                "2015-12-13-05-06-07/my_bucket_id/livebucketpath/to/real/blob2",
+						BackupDirectoryPath: "2015-12-13-05-06-07/my_bucket_id",
+						ETag:                `"etag2"`,
+						Size:                20,
+						LastModified:        backupModified,
+					},
+				}
+			})
+
+			It("copies the changed blob again", func() {
+				existingBlobs, err = bucketPair.CopyNewLiveBlobsToBackup(
+					backedUpBlobs,
+					[]incremental.Blob{liveBlob1, liveBlob2},
+					"2015-12-14-05-06-07/my_bucket_id",
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(backupBucket.CopyBlobFromBucketCallCount()).To(Equal(1))
+				_, srcPath, dstPath := backupBucket.CopyBlobFromBucketArgsForCall(0)
+				Expect(srcPath).To(Equal("livebucketpath/to/real/blob2"))
+				Expect(dstPath).To(Equal("2015-12-14-05-06-07/my_bucket_id/livebucketpath/to/real/blob2"))
+
+				Expect(existingBlobs).To(Equal(backedUpBlobs[:1]))
+			})
+
+			Context("and change detection is by path", func() {
+				BeforeEach(func() {
+					bucketPair.ChangeDetection = incremental.ChangeDetectionPath
+				})
+
+				It("does not copy the changed blob again", func() {
+					existingBlobs, err = bucketPair.CopyNewLiveBlobsToBackup(
+						backedUpBlobs,
+						[]incremental.Blob{liveBlob1, liveBlob2},
+						"2015-12-14-05-06-07/my_bucket_id",
+					)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(backupBucket.CopyBlobFromBucketCallCount()).To(Equal(0))
+					Expect(existingBlobs).To(Equal(backedUpBlobs))
+				})
+			})
+		})
+
+		Context("when only the ETag of a backed up live blob is different", func() {
+			var backedUpBlobs []incremental.BackedUpBlob
+
+			BeforeEach(func() {
+				backupModified := time.Date(2015, 12, 13, 5, 6, 7, 0, time.UTC)
+
+				liveBlob1.ETagReturns(`"etag3"`)
+				liveBlob1.SizeReturns(10)
+				liveBlob1.LastModifiedReturns(backupModified.Add(-time.Hour))
+
+				backedUpBlobs = []incremental.BackedUpBlob{
+					{
+						Path:                "2015-12-13-05-06-07/my_bucket_id/livebucketpath/to/real/blob1",
+						BackupDirectoryPath: "2015-12-13-05-06-07/my_bucket_id",
+						ETag:                `"etag1"`,
+						Size:                10,
+						LastModified:        backupModified,
+					},
+				}
+			})
+
+			Context("and both ETags are digests", func() {
+				BeforeEach(func() {
+					liveBucket.ETagIsDigestReturns(true, nil)
+					backupBucket.ETagIsDigestReturns(true, nil)
+				})
+
+				It("copies the blob again", func() {
+					existingBlobs, err = bucketPair.CopyNewLiveBlobsToBackup(
+						backedUpBlobs,
+						[]incremental.Blob{liveBlob1},
+						"2015-12-14-05-06-07/my_bucket_id",
+					)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(liveBucket.ETagIsDigestArgsForCall(0)).To(Equal("livebucketpath/to/real/blob1"))
+					Expect(backupBucket.ETagIsDigestArgsForCall(0)).To(Equal("2015-12-13-05-06-07/my_bucket_id/livebucketpath/to/real/blob1"))
+					Expect(backupBucket.CopyBlobFromBucketCallCount()).To(Equal(1))
+					Expect(existingBlobs).To(BeEmpty())
+				})
+			})
+
+			Context("and the ETag of the backed up copy is not a digest", func() {
+				BeforeEach(func() {
+					liveBucket.ETagIsDigestReturns(true, nil)
+					backupBucket.ETagIsDigestReturns(false, nil)
+				})
+
+				It("keeps the backed up copy", func() {
+					existingBlobs, err = bucketPair.CopyNewLiveBlobsToBackup(
+						backedUpBlobs,
+						[]incremental.Blob{liveBlob1},
+						"2015-12-14-05-06-07/my_bucket_id",
+					)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(backupBucket.CopyBlobFromBucketCallCount()).To(Equal(0))
+					Expect(existingBlobs).To(Equal(backedUpBlobs))
+				})
+			})
+
+			Context("and the ETag of the live blob is not a digest", func() {
+				BeforeEach(func() {
+					liveBucket.ETagIsDigestReturns(false, nil)
+				})
+
+				It("keeps the backed up copy without checking it", func() {
+					existingBlobs, err = bucketPair.CopyNewLiveBlobsToBackup(
+						backedUpBlobs,
+						[]incremental.Blob{liveBlob1},
+						"2015-12-14-05-06-07/my_bucket_id",
+					)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(backupBucket.ETagIsDigestCallCount()).To(Equal(0))
+					Expect(backupBucket.CopyBlobFromBucketCallCount()).To(Equal(0))
+					Expect(existingBlobs).To(Equal(backedUpBlobs))
+				})
+			})
+
+			Context("and the encryption of the live blob cannot be read", func() {
+				BeforeEach(func() {
+					liveBucket.ETagIsDigestReturns(false, fmt.Errorf("cannot head object"))
+				})
+
+				It("errors", func() {
+					_, err = bucketPair.CopyNewLiveBlobsToBackup(
+						backedUpBlobs,
+						[]incremental.Blob{liveBlob1},
+						"2015-12-14-05-06-07/my_bucket_id",
+					)
+					Expect(err).To(MatchError(ContainSubstring("cannot head object")))
+				})
+			})
+		})
+
 		Context("When CopyObject errors", func() {
 			It("errors", func() {
 				backupBucket.CopyBlobFromBucketReturns(fmt.Errorf("cannot copy object"))
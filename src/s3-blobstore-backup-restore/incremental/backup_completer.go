@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/cloudfoundry/bosh-backup-and-restore/executor"
+	"common/executor"
 )
 
 type BackupCompleter struct {
@@ -17,10 +17,7 @@ func (b BackupCompleter) Run() error {
 			continue
 		}
 
-		e := executor.NewParallelExecutor()
-		e.SetMaxInFlight(200)
-
-		errs := e.Run(backupToComplete.executables())
+		errs := executor.NewParallelExecutor(backupToComplete.MaxInFlight).Run(backupToComplete.executables())
 		if len(errs) != 0 {
 			return formatExecutorErrors(fmt.Sprintf("failed to complete backup"), errs)
 		}
@@ -2,6 +2,12 @@ package incremental
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
+	"common/executor"
+
+	"s3-blobstore-backup-restore/blobpath"
 )
 
 const backupComplete = "backup_complete"
@@ -22,6 +28,9 @@ func (b BackupDirectory) ListBlobs() ([]BackedUpBlob, error) {
 		backedUpBlobs = append(backedUpBlobs, BackedUpBlob{
 			Path:                blob.Path(),
 			BackupDirectoryPath: b.Path,
+			ETag:                blob.ETag(),
+			Size:                blob.Size(),
+			LastModified:        blob.LastModified(),
 		})
 	}
 
@@ -46,6 +55,63 @@ func (b BackupDirectory) MarkComplete() error {
 	return nil
 }
 
+// Delete deletes the blobs in the backup directory, deleting at most
+// maxInFlight at once. The backup_complete marker is deleted first, so that a
+// directory which is only partly deleted is never taken for a complete backup.
+func (b BackupDirectory) Delete(maxInFlight int) error {
+	blobs, err := b.Bucket.ListBlobs(b.Path)
+	if err != nil {
+		return fmt.Errorf("failed deleting backup directory '%s': %s", b.Path, err)
+	}
+
+	var executables []executor.Executable
+	for _, blob := range blobs {
+		if blob.Path() == backupComplete {
+			err := b.Bucket.DeleteBlob(b.backupCompletePath())
+			if err != nil {
+				return fmt.Errorf("failed deleting backup directory '%s': %s", b.Path, err)
+			}
+			continue
+		}
+
+		executables = append(executables, deleteBlobExecutable{
+			bucket: b.Bucket,
+			path:   joinBlobPath(b.Path, blob.Path()),
+		})
+	}
+
+	errs := executor.NewParallelExecutor(maxInFlight).Run([][]executor.Executable{executables})
+	if len(errs) != 0 {
+		return formatExecutorErrors(fmt.Sprintf("failed deleting backup directory '%s'", b.Path), errs)
+	}
+
+	return nil
+}
+
+// Timestamp returns the timestamp which names the backup directory.
+func (b BackupDirectory) Timestamp() string {
+	return strings.SplitN(b.Path, blobpath.Delimiter, 2)[0]
+}
+
+// startTime returns the time the backup in the directory was started.
+func (b BackupDirectory) startTime() (time.Time, error) {
+	t, err := time.ParseInLocation(TimestampFormat, b.Timestamp(), time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp for backup directory '%s': %s", b.Path, err)
+	}
+
+	return t, nil
+}
+
 func (b BackupDirectory) backupCompletePath() string {
 	return joinBlobPath(b.Path, backupComplete)
 }
+
+type deleteBlobExecutable struct {
+	path   string
+	bucket Bucket
+}
+
+func (e deleteBlobExecutable) Execute() error {
+	return e.bucket.DeleteBlob(e.path)
+}
@@ -0,0 +1,140 @@
+package incremental
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LatestCompleteBackup selects the latest complete backup of each bucket ID
+// in place of a timestamp.
+const LatestCompleteBackup = "latest"
+
+type BackupToFind struct {
+	BackupBucket   Bucket
+	SameAsBucketID string
+}
+
+type backupDirectoryArtifact struct {
+	backupsToFind map[string]BackupToFind
+	timestamp     string
+	backups       map[string]Backup
+}
+
+// NewBackupDirectoryArtifact returns an artifact which is loaded from the
+// backup directories with the given timestamp, or from the latest complete
+// backup directories, instead of from a file. It allows a restore when the
+// artifact written at backup time is lost. The backups are listed once, and
+// only complete backup directories are loaded.
+func NewBackupDirectoryArtifact(backupsToFind map[string]BackupToFind, timestamp string) Artifact {
+	return &backupDirectoryArtifact{
+		backupsToFind: backupsToFind,
+		timestamp:     timestamp,
+	}
+}
+
+func (a *backupDirectoryArtifact) Write(map[string]Backup) error {
+	return errors.New("cannot write an artifact which is loaded from backup directories")
+}
+
+func (a *backupDirectoryArtifact) Load() (map[string]Backup, error) {
+	if a.backups != nil {
+		return a.backups, nil
+	}
+
+	if a.timestamp != LatestCompleteBackup {
+		if _, err := time.Parse(TimestampFormat, a.timestamp); err != nil {
+			return nil, fmt.Errorf("invalid backup timestamp '%s': must be in the format YYYY_MM_DD_HH_MM_SS or %s", a.timestamp, LatestCompleteBackup)
+		}
+	}
+
+	backups := map[string]Backup{}
+	for bucketID, backupToFind := range a.backupsToFind {
+		if backupToFind.SameAsBucketID != "" {
+			backups[bucketID] = Backup{SameBucketAs: backupToFind.SameAsBucketID}
+			continue
+		}
+
+		dir, err := a.findBackupDirectory(bucketID, backupToFind.BackupBucket)
+		if err != nil {
+			return nil, err
+		}
+
+		backup, err := loadBackup(dir)
+		if err != nil {
+			return nil, err
+		}
+		backups[bucketID] = backup
+	}
+
+	a.backups = backups
+	return backups, nil
+}
+
+func (a *backupDirectoryArtifact) findBackupDirectory(bucketID string, backupBucket Bucket) (BackupDirectory, error) {
+	if a.timestamp == LatestCompleteBackup {
+		backupDirs, err := listBackupDirectories(bucketID, backupBucket)
+		if err != nil {
+			return BackupDirectory{}, err
+		}
+
+		dir, err := Finder{}.findLastCompleteBackup(backupDirs)
+		if err != nil {
+			return BackupDirectory{}, err
+		}
+
+		if dir == nil {
+			return BackupDirectory{}, fmt.Errorf("no complete backup found for bucket %s in bucket %s", bucketID, backupBucket.Name())
+		}
+
+		return *dir, nil
+	}
+
+	dir := BackupDirectory{
+		Path:   joinBlobPath(a.timestamp, bucketID),
+		Bucket: backupBucket,
+	}
+
+	isComplete, err := dir.IsComplete()
+	if err != nil {
+		return BackupDirectory{}, err
+	}
+
+	if !isComplete {
+		return BackupDirectory{}, fmt.Errorf("backup directory '%s' in bucket %s is not a complete backup", dir.Path, backupBucket.Name())
+	}
+
+	return dir, nil
+}
+
+func loadBackup(dir BackupDirectory) (Backup, error) {
+	blobs, err := dir.ListBlobs()
+	if err != nil {
+		return Backup{}, err
+	}
+
+	backup := Backup{
+		BucketName:             dir.Bucket.Name(),
+		BucketRegion:           dir.Bucket.Region(),
+		SrcBackupDirectoryPath: dir.Path,
+	}
+	for _, blob := range blobs {
+		if blob.LiveBlobPath() == backupComplete {
+			continue
+		}
+
+		path := joinBlobPath(dir.Path, blob.LiveBlobPath())
+		backup.Blobs = append(backup.Blobs, path)
+
+		if backup.BlobAttributes == nil {
+			backup.BlobAttributes = map[string]BlobAttributes{}
+		}
+		backup.BlobAttributes[path] = BlobAttributes{
+			ETag:         blob.ETag,
+			Size:         blob.Size,
+			LastModified: blob.LastModified,
+		}
+	}
+
+	return backup, nil
+}
@@ -0,0 +1,148 @@
+package incremental_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+
+	"s3-blobstore-backup-restore/incremental"
+	"s3-blobstore-backup-restore/incremental/fakes"
+
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BackupDirectoryArtifact", func() {
+	var (
+		bucket       *fakes.FakeBucket
+		lastModified time.Time
+		timestamp    string
+		artifact     incremental.Artifact
+	)
+
+	BeforeEach(func() {
+		lastModified = time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+
+		bucket = new(fakes.FakeBucket)
+		bucket.NameReturns("backup-bucket")
+		bucket.RegionReturns("backup-region")
+		bucket.ListDirectoriesReturns([]string{"2000_01_01_00_00_00", "2000_01_02_00_00_00", "2000_01_03_00_00_00"}, nil)
+		bucket.HasBlobStub = func(path string) (bool, error) {
+			return path != "2000_01_03_00_00_00/droplets/backup_complete", nil
+		}
+		bucket.ListBlobsStub = func(path string) ([]incremental.Blob, error) {
+			blob := new(fakes.FakeBlob)
+			blob.PathReturns("f0/fd/blob1/uuid")
+			blob.ETagReturns(`"etag1"`)
+			blob.SizeReturns(10)
+			blob.LastModifiedReturns(lastModified)
+			backupCompleteBlob := new(fakes.FakeBlob)
+			backupCompleteBlob.PathReturns("backup_complete")
+			return []incremental.Blob{blob, backupCompleteBlob}, nil
+		}
+
+		timestamp = "2000_01_01_00_00_00"
+	})
+
+	JustBeforeEach(func() {
+		artifact = incremental.NewBackupDirectoryArtifact(map[string]incremental.BackupToFind{
+			"droplets": {BackupBucket: bucket},
+			"packages": {SameAsBucketID: "droplets"},
+		}, timestamp)
+	})
+
+	expectedBackup := func(backupDirectoryPath string) incremental.Backup {
+		return incremental.Backup{
+			BucketName:             "backup-bucket",
+			BucketRegion:           "backup-region",
+			Blobs:                  []string{backupDirectoryPath + "/f0/fd/blob1/uuid"},
+			SrcBackupDirectoryPath: backupDirectoryPath,
+			BlobAttributes: map[string]incremental.BlobAttributes{
+				backupDirectoryPath + "/f0/fd/blob1/uuid": {ETag: `"etag1"`, Size: 10, LastModified: lastModified},
+			},
+		}
+	}
+
+	It("loads the backups from the backup directories with the timestamp", func() {
+		backups, err := artifact.Load()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backups).To(Equal(map[string]incremental.Backup{
+			"droplets": expectedBackup("2000_01_01_00_00_00/droplets"),
+			"packages": {SameBucketAs: "droplets"},
+		}))
+		Expect(bucket.ListBlobsArgsForCall(0)).To(Equal("2000_01_01_00_00_00/droplets"))
+	})
+
+	It("lists the backup directories only once", func() {
+		_, err := artifact.Load()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = artifact.Load()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(bucket.ListBlobsCallCount()).To(Equal(1))
+	})
+
+	It("cannot be written", func() {
+		Expect(artifact.Write(map[string]incremental.Backup{})).To(MatchError(ContainSubstring("cannot write an artifact")))
+	})
+
+	Context("when the timestamp is latest", func() {
+		BeforeEach(func() {
+			timestamp = incremental.LatestCompleteBackup
+		})
+
+		It("loads the backups from the latest complete backup directories", func() {
+			backups, err := artifact.Load()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backups["droplets"]).To(Equal(expectedBackup("2000_01_02_00_00_00/droplets")))
+		})
+
+		Context("and there are no complete backups", func() {
+			BeforeEach(func() {
+				bucket.HasBlobStub = nil
+				bucket.HasBlobReturns(false, nil)
+			})
+
+			It("returns an error", func() {
+				_, err := artifact.Load()
+				Expect(err).To(MatchError("no complete backup found for bucket droplets in bucket backup-bucket"))
+			})
+		})
+	})
+
+	Context("when the backup directory with the timestamp is not complete", func() {
+		BeforeEach(func() {
+			timestamp = "2000_01_03_00_00_00"
+		})
+
+		It("returns an error", func() {
+			_, err := artifact.Load()
+			Expect(err).To(MatchError("backup directory '2000_01_03_00_00_00/droplets' in bucket backup-bucket is not a complete backup"))
+		})
+	})
+
+	Context("when the timestamp is not valid", func() {
+		BeforeEach(func() {
+			timestamp = "yesterday"
+		})
+
+		It("returns an error", func() {
+			_, err := artifact.Load()
+			Expect(err).To(MatchError("invalid backup timestamp 'yesterday': must be in the format YYYY_MM_DD_HH_MM_SS or latest"))
+		})
+	})
+
+	Context("when listing the blobs fails", func() {
+		BeforeEach(func() {
+			bucket.ListBlobsStub = nil
+			bucket.ListBlobsReturns(nil, errors.New("fail to list"))
+		})
+
+		It("returns an error", func() {
+			_, err := artifact.Load()
+			Expect(err).To(MatchError(ContainSubstring("fail to list")))
+		})
+	})
+})
@@ -14,34 +14,15 @@ type BackupDirectoryFinder interface {
 type Finder struct{}
 
 func (b Finder) ListBlobs(bucketID string, backupBucket Bucket) ([]BackedUpBlob, error) {
-	dirs, err := backupBucket.ListDirectories()
+	backupDirs, err := listBackupDirectories(bucketID, backupBucket)
 	if err != nil {
 		return nil, err
 	}
 
-	regex := regexp.MustCompile(`^\d{4}(_\d{2}){5}$`)
-
-	var filteredDirs []string
-	for _, dir := range dirs {
-		if regex.MatchString(dir) {
-			filteredDirs = append(filteredDirs, dir)
-		}
-	}
-
-	if len(filteredDirs) == 0 {
+	if len(backupDirs) == 0 {
 		return nil, nil
 	}
 
-	sort.Sort(sort.Reverse(sort.StringSlice(filteredDirs)))
-
-	var backupDirs []BackupDirectory
-	for _, filteredDir := range filteredDirs {
-		backupDirs = append(backupDirs, BackupDirectory{
-			Path:   joinBlobPath(filteredDir, bucketID),
-			Bucket: backupBucket,
-		})
-	}
-
 	lastCompleteBackupDir, err := b.findLastCompleteBackup(backupDirs)
 	if err != nil {
 		return nil, err
@@ -61,6 +42,9 @@ func (b Finder) ListBlobs(bucketID string, backupBucket Bucket) ([]BackedUpBlob,
 		backedUpBlobs = append(backedUpBlobs, BackedUpBlob{
 			Path:                joinBlobPath(lastCompleteBackupDir.Path, blob.Path()),
 			BackupDirectoryPath: lastCompleteBackupDir.Path,
+			ETag:                blob.ETag(),
+			Size:                blob.Size(),
+			LastModified:        blob.LastModified(),
 		})
 	}
 
@@ -81,3 +65,33 @@ func (b Finder) findLastCompleteBackup(dirs []BackupDirectory) (*BackupDirectory
 
 	return nil, nil
 }
+
+// listBackupDirectories returns the backup directories of bucketID in
+// backupBucket, newest first.
+func listBackupDirectories(bucketID string, backupBucket Bucket) ([]BackupDirectory, error) {
+	dirs, err := backupBucket.ListDirectories()
+	if err != nil {
+		return nil, err
+	}
+
+	regex := regexp.MustCompile(`^\d{4}(_\d{2}){5}$`)
+
+	var filteredDirs []string
+	for _, dir := range dirs {
+		if regex.MatchString(dir) {
+			filteredDirs = append(filteredDirs, dir)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(filteredDirs)))
+
+	var backupDirs []BackupDirectory
+	for _, filteredDir := range filteredDirs {
+		backupDirs = append(backupDirs, BackupDirectory{
+			Path:   joinBlobPath(filteredDir, bucketID),
+			Bucket: backupBucket,
+		})
+	}
+
+	return backupDirs, nil
+}
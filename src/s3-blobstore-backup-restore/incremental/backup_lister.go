@@ -0,0 +1,113 @@
+package incremental
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+const (
+	ListFormatTable = "table"
+	ListFormatJSON  = "json"
+)
+
+// BackupSummary describes a backup directory of a bucket ID.
+type BackupSummary struct {
+	BucketID  string `json:"bucket_id"`
+	Timestamp string `json:"timestamp"`
+	Path      string `json:"path"`
+	Complete  bool   `json:"complete"`
+	BlobCount int    `json:"blob_count"`
+	TotalSize int64  `json:"total_size"`
+}
+
+// BackupLister writes a summary of each backup in the backup bucket of each
+// bucket ID, newest first, as a table or as JSON.
+type BackupLister struct {
+	BackupBuckets map[string]Bucket
+	Format        string
+	Output        io.Writer
+}
+
+func (l BackupLister) Run() error {
+	var bucketIDs []string
+	for bucketID := range l.BackupBuckets {
+		bucketIDs = append(bucketIDs, bucketID)
+	}
+	sort.Strings(bucketIDs)
+
+	summaries := []BackupSummary{}
+	for _, bucketID := range bucketIDs {
+		bucketSummaries, err := summariseBackups(bucketID, l.BackupBuckets[bucketID])
+		if err != nil {
+			return fmt.Errorf("failed to list backups: %s", err)
+		}
+		summaries = append(summaries, bucketSummaries...)
+	}
+
+	switch l.Format {
+	case ListFormatJSON:
+		encoder := json.NewEncoder(l.Output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summaries)
+	case ListFormatTable, "":
+		return writeBackupsTable(l.Output, summaries)
+	default:
+		return fmt.Errorf("invalid format '%s': must be %s or %s", l.Format, ListFormatTable, ListFormatJSON)
+	}
+}
+
+func summariseBackups(bucketID string, backupBucket Bucket) ([]BackupSummary, error) {
+	backupDirs, err := listBackupDirectories(bucketID, backupBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []BackupSummary
+	for _, dir := range backupDirs {
+		blobs, err := dir.ListBlobs()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(blobs) == 0 {
+			continue
+		}
+
+		isComplete, err := dir.IsComplete()
+		if err != nil {
+			return nil, err
+		}
+
+		summary := BackupSummary{
+			BucketID:  bucketID,
+			Timestamp: dir.Timestamp(),
+			Path:      dir.Path,
+			Complete:  isComplete,
+		}
+		for _, blob := range blobs {
+			if blob.LiveBlobPath() == backupComplete {
+				continue
+			}
+
+			summary.BlobCount++
+			summary.TotalSize += blob.Size
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func writeBackupsTable(output io.Writer, summaries []BackupSummary) error {
+	writer := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "BUCKET ID\tTIMESTAMP\tCOMPLETE\tBLOBS\tSIZE (BYTES)")
+	for _, summary := range summaries {
+		fmt.Fprintf(writer, "%s\t%s\t%t\t%d\t%d\n", summary.BucketID, summary.Timestamp, summary.Complete, summary.BlobCount, summary.TotalSize)
+	}
+
+	return writer.Flush()
+}
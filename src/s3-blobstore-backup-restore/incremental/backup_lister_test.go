@@ -0,0 +1,141 @@
+package incremental_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega/gbytes"
+
+	"s3-blobstore-backup-restore/incremental"
+	"s3-blobstore-backup-restore/incremental/fakes"
+
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BackupLister", func() {
+	var (
+		dropletsBucket *fakes.FakeBucket
+		packagesBucket *fakes.FakeBucket
+		output         *gbytes.Buffer
+		format         string
+		err            error
+	)
+
+	newBlob := func(path string, size int64) incremental.Blob {
+		blob := new(fakes.FakeBlob)
+		blob.PathReturns(path)
+		blob.SizeReturns(size)
+		return blob
+	}
+
+	BeforeEach(func() {
+		dropletsBucket = new(fakes.FakeBucket)
+		dropletsBucket.ListDirectoriesReturns([]string{"2000_01_01_00_00_00", "2000_01_02_00_00_00", "not_a_backup_directory"}, nil)
+		dropletsBucket.ListBlobsStub = func(path string) ([]incremental.Blob, error) {
+			switch path {
+			case "2000_01_01_00_00_00/droplets":
+				return []incremental.Blob{newBlob("f0/fd/blob1/uuid", 10), newBlob("f0/fd/blob2/uuid", 20), newBlob("backup_complete", 0)}, nil
+			case "2000_01_02_00_00_00/droplets":
+				return []incremental.Blob{newBlob("f0/fd/blob1/uuid", 10)}, nil
+			}
+			return nil, nil
+		}
+		dropletsBucket.HasBlobStub = func(path string) (bool, error) {
+			return path == "2000_01_01_00_00_00/droplets/backup_complete", nil
+		}
+
+		packagesBucket = new(fakes.FakeBucket)
+		packagesBucket.ListDirectoriesReturns([]string{"2000_01_01_00_00_00", "2000_01_03_00_00_00"}, nil)
+		packagesBucket.ListBlobsStub = func(path string) ([]incremental.Blob, error) {
+			if path == "2000_01_03_00_00_00/packages" {
+				return []incremental.Blob{newBlob("f0/fd/package1/uuid", 5), newBlob("backup_complete", 0)}, nil
+			}
+			return nil, nil
+		}
+		packagesBucket.HasBlobReturns(true, nil)
+
+		output = gbytes.NewBuffer()
+		format = incremental.ListFormatTable
+	})
+
+	JustBeforeEach(func() {
+		err = incremental.BackupLister{
+			BackupBuckets: map[string]incremental.Bucket{
+				"droplets": dropletsBucket,
+				"packages": packagesBucket,
+			},
+			Format: format,
+			Output: output,
+		}.Run()
+	})
+
+	It("lists the backups of each bucket ID as a table, newest first", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(output.Contents())).To(Equal(
+			"BUCKET ID  TIMESTAMP            COMPLETE  BLOBS  SIZE (BYTES)\n" +
+				"droplets   2000_01_02_00_00_00  false     1      10\n" +
+				"droplets   2000_01_01_00_00_00  true      2      30\n" +
+				"packages   2000_01_03_00_00_00  true      1      5\n",
+		))
+	})
+
+	Context("when the format is json", func() {
+		BeforeEach(func() {
+			format = incremental.ListFormatJSON
+		})
+
+		It("lists the backups of each bucket ID as JSON", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Contents()).To(MatchJSON(`[
+				{"bucket_id": "droplets", "timestamp": "2000_01_02_00_00_00", "path": "2000_01_02_00_00_00/droplets", "complete": false, "blob_count": 1, "total_size": 10},
+				{"bucket_id": "droplets", "timestamp": "2000_01_01_00_00_00", "path": "2000_01_01_00_00_00/droplets", "complete": true, "blob_count": 2, "total_size": 30},
+				{"bucket_id": "packages", "timestamp": "2000_01_03_00_00_00", "path": "2000_01_03_00_00_00/packages", "complete": true, "blob_count": 1, "total_size": 5}
+			]`))
+		})
+
+		Context("and there are no backups", func() {
+			BeforeEach(func() {
+				dropletsBucket.ListDirectoriesReturns(nil, nil)
+				packagesBucket.ListDirectoriesReturns(nil, nil)
+			})
+
+			It("lists no backups", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output.Contents()).To(MatchJSON(`[]`))
+			})
+		})
+	})
+
+	Context("when the format is not known", func() {
+		BeforeEach(func() {
+			format = "yaml"
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError("invalid format 'yaml': must be table or json"))
+		})
+	})
+
+	Context("when listing the backup directories fails", func() {
+		BeforeEach(func() {
+			packagesBucket.ListDirectoriesReturns(nil, errors.New("fail to list"))
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError("failed to list backups: fail to list"))
+		})
+	})
+
+	Context("when checking if a backup is complete fails", func() {
+		BeforeEach(func() {
+			packagesBucket.HasBlobReturns(false, errors.New("fail to check"))
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring("failed to list backups"),
+				ContainSubstring("fail to check"),
+			)))
+		})
+	})
+})
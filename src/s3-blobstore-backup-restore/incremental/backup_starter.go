@@ -62,7 +62,14 @@ func (b BackupStarter) Run() error {
 			return fmt.Errorf("failed to copy blobs during backup: %s", err)
 		}
 
-		backups[bucketID] = generateBackupArtifact(filteredLiveBlobs, backupDir)
+		backup := generateBackupArtifact(filteredLiveBlobs, backupDir)
+		if backupToStart.BucketPair.Verify {
+			err = backupToStart.BucketPair.RecordChecksums(backup, existingBlobsArtifact)
+			if err != nil {
+				return fmt.Errorf("failed to start backup: %s", err)
+			}
+		}
+		backups[bucketID] = backup
 
 		existingBlobs[bucketID] = generateExistingBlobsArtifact(
 			existingBlobsArtifact,
@@ -97,8 +104,19 @@ func filterOutBackupComplete(liveBlobs []Blob) []Blob {
 
 func generateBackupArtifact(liveBlobs []Blob, dir BackupDirectory) Backup {
 	var blobs []string
+	var blobAttributes map[string]BlobAttributes
 	for _, blob := range liveBlobs {
-		blobs = append(blobs, joinBlobPath(dir.Path, blob.Path()))
+		path := joinBlobPath(dir.Path, blob.Path())
+		blobs = append(blobs, path)
+
+		if blobAttributes == nil {
+			blobAttributes = map[string]BlobAttributes{}
+		}
+		blobAttributes[path] = BlobAttributes{
+			ETag:         blob.ETag(),
+			Size:         blob.Size(),
+			LastModified: blob.LastModified(),
+		}
 	}
 
 	return Backup{
@@ -106,20 +124,28 @@ func generateBackupArtifact(liveBlobs []Blob, dir BackupDirectory) Backup {
 		Blobs:                  blobs,
 		SrcBackupDirectoryPath: dir.Path,
 		BucketRegion:           dir.Bucket.Region(),
+		BlobAttributes:         blobAttributes,
 	}
 }
 
 func generateExistingBlobsArtifact(existingBlobs []BackedUpBlob, dstBackupDirectoryPath string) Backup {
 	if len(existingBlobs) != 0 {
 		var backedUpblobs []string
+		blobAttributes := map[string]BlobAttributes{}
 		for _, blob := range existingBlobs {
 			backedUpblobs = append(backedUpblobs, blob.Path)
+			blobAttributes[blob.Path] = BlobAttributes{
+				ETag:         blob.ETag,
+				Size:         blob.Size,
+				LastModified: blob.LastModified,
+			}
 		}
 
 		return Backup{
 			Blobs:                  backedUpblobs,
 			SrcBackupDirectoryPath: existingBlobs[0].BackupDirectoryPath,
 			DstBackupDirectoryPath: dstBackupDirectoryPath,
+			BlobAttributes:         blobAttributes,
 		}
 	}
 
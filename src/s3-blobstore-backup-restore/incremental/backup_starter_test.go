@@ -3,12 +3,14 @@ package incremental_test
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"s3-blobstore-backup-restore/incremental"
 	"s3-blobstore-backup-restore/incremental/fakes"
+	"s3-blobstore-backup-restore/integrity"
 )
 
 var _ = Describe("BackupStarter", func() {
@@ -25,6 +27,8 @@ var _ = Describe("BackupStarter", func() {
 		existingBlobsArtifact *fakes.FakeArtifact
 		backupDirectoryFinder *fakes.FakeBackupDirectoryFinder
 		starter               incremental.BackupStarter
+		liveModified          time.Time
+		backupModified        time.Time
 	)
 
 	BeforeEach(func() {
@@ -37,12 +41,24 @@ var _ = Describe("BackupStarter", func() {
 		artifact = new(fakes.FakeArtifact)
 		existingBlobsArtifact = new(fakes.FakeArtifact)
 
+		liveModified = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		backupModified = time.Date(2000, 1, 1, 1, 1, 1, 0, time.UTC)
+
 		liveBlob1 = new(fakes.FakeBlob)
 		liveBlob1.PathReturns("f0/fd/blob1/uuid")
+		liveBlob1.ETagReturns(`"etag1"`)
+		liveBlob1.SizeReturns(1)
+		liveBlob1.LastModifiedReturns(liveModified)
 		liveBlob2 = new(fakes.FakeBlob)
 		liveBlob2.PathReturns("f0/fd/blob2/uuid")
+		liveBlob2.ETagReturns(`"etag2"`)
+		liveBlob2.SizeReturns(2)
+		liveBlob2.LastModifiedReturns(liveModified)
 		liveBlob3 = new(fakes.FakeBlob)
 		liveBlob3.PathReturns("f0/fd/blob3/uuid")
+		liveBlob3.ETagReturns(`"etag3"`)
+		liveBlob3.SizeReturns(3)
+		liveBlob3.LastModifiedReturns(liveModified)
 		backupCompleteBlob = new(fakes.FakeBlob)
 		backupCompleteBlob.PathReturns("backup_complete")
 
@@ -117,6 +133,11 @@ var _ = Describe("BackupStarter", func() {
 					},
 					SrcBackupDirectoryPath: "2000_01_02_03_04_05/bucket_id",
 					BucketRegion:           "us-east-1",
+					BlobAttributes: map[string]incremental.BlobAttributes{
+						"2000_01_02_03_04_05/bucket_id/f0/fd/blob1/uuid": {ETag: `"etag1"`, Size: 1, LastModified: liveModified},
+						"2000_01_02_03_04_05/bucket_id/f0/fd/blob2/uuid": {ETag: `"etag2"`, Size: 2, LastModified: liveModified},
+						"2000_01_02_03_04_05/bucket_id/f0/fd/blob3/uuid": {ETag: `"etag3"`, Size: 3, LastModified: liveModified},
+					},
 				},
 			}))
 		})
@@ -186,6 +207,11 @@ var _ = Describe("BackupStarter", func() {
 						},
 						SrcBackupDirectoryPath: "2000_01_02_03_04_05/bucket_id",
 						BucketRegion:           "us-east-1",
+						BlobAttributes: map[string]incremental.BlobAttributes{
+							"2000_01_02_03_04_05/bucket_id/f0/fd/blob1/uuid": {ETag: `"etag1"`, Size: 1, LastModified: liveModified},
+							"2000_01_02_03_04_05/bucket_id/f0/fd/blob2/uuid": {ETag: `"etag2"`, Size: 2, LastModified: liveModified},
+							"2000_01_02_03_04_05/bucket_id/f0/fd/blob3/uuid": {ETag: `"etag3"`, Size: 3, LastModified: liveModified},
+						},
 					},
 					"marked_bucket_id": {
 						SameBucketAs: "bucket_id",
@@ -214,10 +240,16 @@ var _ = Describe("BackupStarter", func() {
 			backedUpBlob1 := incremental.BackedUpBlob{
 				Path:                "2000_01_01_01_01_01/bucket_id/f0/fd/blob1/uuid",
 				BackupDirectoryPath: "2000_01_01_01_01_01/bucket_id",
+				ETag:                `"etag1"`,
+				Size:                1,
+				LastModified:        backupModified,
 			}
 			backedUpBlob2 := incremental.BackedUpBlob{
 				Path:                "2000_01_01_01_01_01/bucket_id/f0/fd/blob2/uuid",
 				BackupDirectoryPath: "2000_01_01_01_01_01/bucket_id",
+				ETag:                `"etag2"`,
+				Size:                2,
+				LastModified:        backupModified,
 			}
 
 			backupDirectoryFinder.ListBlobsReturns([]incremental.BackedUpBlob{backedUpBlob1, backedUpBlob2}, nil)
@@ -255,6 +287,11 @@ var _ = Describe("BackupStarter", func() {
 					},
 					SrcBackupDirectoryPath: "2000_01_02_03_04_05/bucket_id",
 					BucketRegion:           "us-east-1",
+					BlobAttributes: map[string]incremental.BlobAttributes{
+						"2000_01_02_03_04_05/bucket_id/f0/fd/blob1/uuid": {ETag: `"etag1"`, Size: 1, LastModified: liveModified},
+						"2000_01_02_03_04_05/bucket_id/f0/fd/blob2/uuid": {ETag: `"etag2"`, Size: 2, LastModified: liveModified},
+						"2000_01_02_03_04_05/bucket_id/f0/fd/blob3/uuid": {ETag: `"etag3"`, Size: 3, LastModified: liveModified},
+					},
 				},
 			}))
 
@@ -267,6 +304,10 @@ var _ = Describe("BackupStarter", func() {
 					},
 					SrcBackupDirectoryPath: "2000_01_01_01_01_01/bucket_id",
 					DstBackupDirectoryPath: "2000_01_02_03_04_05/bucket_id",
+					BlobAttributes: map[string]incremental.BlobAttributes{
+						"2000_01_01_01_01_01/bucket_id/f0/fd/blob1/uuid": {ETag: `"etag1"`, Size: 1, LastModified: backupModified},
+						"2000_01_01_01_01_01/bucket_id/f0/fd/blob2/uuid": {ETag: `"etag2"`, Size: 2, LastModified: backupModified},
+					},
 				},
 			}))
 		})
@@ -287,10 +328,63 @@ var _ = Describe("BackupStarter", func() {
 					},
 					SrcBackupDirectoryPath: "2000_01_02_03_04_05/bucket_id",
 					BucketRegion:           "us-east-1",
+					BlobAttributes: map[string]incremental.BlobAttributes{
+						"2000_01_02_03_04_05/bucket_id/f0/fd/blob1/uuid": {ETag: `"etag1"`, Size: 1, LastModified: liveModified},
+						"2000_01_02_03_04_05/bucket_id/f0/fd/blob2/uuid": {ETag: `"etag2"`, Size: 2, LastModified: liveModified},
+						"2000_01_02_03_04_05/bucket_id/f0/fd/blob3/uuid": {ETag: `"etag3"`, Size: 3, LastModified: liveModified},
+					},
 				},
 			}))
 		})
 
+		Context("and the backup verifies copies", func() {
+			BeforeEach(func() {
+				starter = incremental.NewBackupStarter(
+					map[string]incremental.BackupToStart{
+						"bucket_id": {
+							BucketPair: incremental.BackupBucketPair{
+								ConfigBackupBucket: backupBucket,
+								ConfigLiveBucket:   liveBucket,
+								Verify:             true,
+							},
+							BackupDirectoryFinder: backupDirectoryFinder,
+						},
+					},
+					clock,
+					artifact,
+					existingBlobsArtifact,
+				)
+				backupBucket.ChecksumReturns(integrity.Checksum{Algorithm: "CRC32C", Value: "crc3"}, nil)
+			})
+
+			It("records the checksums of the copied blobs in the backup artifact", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(backupBucket.ChecksumCallCount()).To(Equal(1))
+				Expect(backupBucket.ChecksumArgsForCall(0)).To(Equal("2000_01_02_03_04_05/bucket_id/f0/fd/blob3/uuid"))
+				Expect(artifact.WriteArgsForCall(0)["bucket_id"].BlobAttributes).To(Equal(map[string]incremental.BlobAttributes{
+					"2000_01_02_03_04_05/bucket_id/f0/fd/blob1/uuid": {ETag: `"etag1"`, Size: 1, LastModified: liveModified},
+					"2000_01_02_03_04_05/bucket_id/f0/fd/blob2/uuid": {ETag: `"etag2"`, Size: 2, LastModified: liveModified},
+					"2000_01_02_03_04_05/bucket_id/f0/fd/blob3/uuid": {
+						ETag:         `"etag3"`,
+						Size:         3,
+						LastModified: liveModified,
+						Checksum:     integrity.Checksum{Algorithm: "CRC32C", Value: "crc3"},
+					},
+				}))
+			})
+
+			Context("and getting a checksum fails", func() {
+				BeforeEach(func() {
+					backupBucket.ChecksumReturns(integrity.Checksum{}, errors.New("no checksum"))
+				})
+
+				It("returns an error", func() {
+					Expect(err).To(MatchError("failed to start backup: failed to record checksums of copied blobs: no checksum"))
+					Expect(artifact.WriteCallCount()).To(Equal(0))
+				})
+			})
+		})
+
 		Context("and the copying a blob from live bucket to backup bucket fails", func() {
 			BeforeEach(func() {
 				backupDirectoryFinder.ListBlobsReturns(nil, nil)
@@ -1,12 +1,15 @@
 package incremental
 
-import "github.com/cloudfoundry/bosh-backup-and-restore/executor"
+import "common/executor"
 
 type BackupToComplete struct {
 	BackupBucket    Bucket
 	BackupDirectory BackupDirectory
 	BlobsToCopy     []BackedUpBlob
 	SameAsBucketID  string
+	// MaxInFlight is the number of blobs copied at once, or
+	// executor.DefaultMaxInFlight when it is zero.
+	MaxInFlight int
 }
 
 func (b BackupToComplete) executables() [][]executor.Executable {
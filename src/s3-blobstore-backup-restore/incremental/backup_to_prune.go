@@ -0,0 +1,9 @@
+package incremental
+
+type BackupToPrune struct {
+	BackupBucket Bucket
+	Retention    RetentionPolicy
+	// MaxInFlight is the number of blobs deleted at once, or
+	// executor.DefaultMaxInFlight when it is zero.
+	MaxInFlight int
+}
@@ -1,19 +1,42 @@
 package incremental
 
+import (
+	"time"
+
+	"s3-blobstore-backup-restore/integrity"
+)
+
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate -o fakes/fake_bucket.go . Bucket
 type Bucket interface {
 	Name() string
 	Region() string
+	// ListBlobs lists the blobs in the directory at path, with their paths
+	// relative to it, or every blob in the bucket when path is empty.
 	ListBlobs(path string) ([]Blob, error)
 	ListDirectories() ([]string, error)
 	CopyBlobWithinBucket(src, dst string) error
 	CopyBlobFromBucket(bucket Bucket, src, dst string) error
 	UploadBlob(path, contents string) error
 	HasBlob(path string) (bool, error)
+	DeleteBlob(path string) error
+	// RestoreArchivedBlobs makes the blobs which are in archival storage
+	// available to copy, checking maxInFlight blobs at once, and waiting
+	// until they are.
+	RestoreArchivedBlobs(paths []string, maxInFlight int) error
+	// Checksum returns the checksum of the whole contents of a blob, which is
+	// zero when the blob has none.
+	Checksum(path string) (integrity.Checksum, error)
+	// ETagIsDigest returns whether the ETag of a blob is a digest of its
+	// contents, which it is not when the blob is encrypted with a KMS or
+	// customer key.
+	ETagIsDigest(path string) (bool, error)
 }
 
 //counterfeiter:generate -o fakes/fake_blob.go . Blob
 type Blob interface {
 	Path() string
+	ETag() string
+	Size() int64
+	LastModified() time.Time
 }
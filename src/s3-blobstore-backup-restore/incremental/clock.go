@@ -2,9 +2,13 @@ package incremental
 
 import "time"
 
+// TimestampFormat is the format of the timestamps which name backup
+// directories.
+const TimestampFormat = "2006_01_02_15_04_05"
+
 type clock struct {
 }
 
 func (c clock) Now() string {
-	return time.Now().Format("2006_01_02_15_04_05")
+	return time.Now().Format(TimestampFormat)
 }
@@ -4,9 +4,30 @@ package fakes
 import (
 	"s3-blobstore-backup-restore/incremental"
 	"sync"
+	"time"
 )
 
 type FakeBlob struct {
+	ETagStub        func() string
+	eTagMutex       sync.RWMutex
+	eTagArgsForCall []struct {
+	}
+	eTagReturns struct {
+		result1 string
+	}
+	eTagReturnsOnCall map[int]struct {
+		result1 string
+	}
+	LastModifiedStub        func() time.Time
+	lastModifiedMutex       sync.RWMutex
+	lastModifiedArgsForCall []struct {
+	}
+	lastModifiedReturns struct {
+		result1 time.Time
+	}
+	lastModifiedReturnsOnCall map[int]struct {
+		result1 time.Time
+	}
 	PathStub        func() string
 	pathMutex       sync.RWMutex
 	pathArgsForCall []struct {
@@ -17,10 +38,126 @@ type FakeBlob struct {
 	pathReturnsOnCall map[int]struct {
 		result1 string
 	}
+	SizeStub        func() int64
+	sizeMutex       sync.RWMutex
+	sizeArgsForCall []struct {
+	}
+	sizeReturns struct {
+		result1 int64
+	}
+	sizeReturnsOnCall map[int]struct {
+		result1 int64
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeBlob) ETag() string {
+	fake.eTagMutex.Lock()
+	ret, specificReturn := fake.eTagReturnsOnCall[len(fake.eTagArgsForCall)]
+	fake.eTagArgsForCall = append(fake.eTagArgsForCall, struct {
+	}{})
+	stub := fake.ETagStub
+	fakeReturns := fake.eTagReturns
+	fake.recordInvocation("ETag", []interface{}{})
+	fake.eTagMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBlob) ETagCallCount() int {
+	fake.eTagMutex.RLock()
+	defer fake.eTagMutex.RUnlock()
+	return len(fake.eTagArgsForCall)
+}
+
+func (fake *FakeBlob) ETagCalls(stub func() string) {
+	fake.eTagMutex.Lock()
+	defer fake.eTagMutex.Unlock()
+	fake.ETagStub = stub
+}
+
+func (fake *FakeBlob) ETagReturns(result1 string) {
+	fake.eTagMutex.Lock()
+	defer fake.eTagMutex.Unlock()
+	fake.ETagStub = nil
+	fake.eTagReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeBlob) ETagReturnsOnCall(i int, result1 string) {
+	fake.eTagMutex.Lock()
+	defer fake.eTagMutex.Unlock()
+	fake.ETagStub = nil
+	if fake.eTagReturnsOnCall == nil {
+		fake.eTagReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.eTagReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeBlob) LastModified() time.Time {
+	fake.lastModifiedMutex.Lock()
+	ret, specificReturn := fake.lastModifiedReturnsOnCall[len(fake.lastModifiedArgsForCall)]
+	fake.lastModifiedArgsForCall = append(fake.lastModifiedArgsForCall, struct {
+	}{})
+	stub := fake.LastModifiedStub
+	fakeReturns := fake.lastModifiedReturns
+	fake.recordInvocation("LastModified", []interface{}{})
+	fake.lastModifiedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBlob) LastModifiedCallCount() int {
+	fake.lastModifiedMutex.RLock()
+	defer fake.lastModifiedMutex.RUnlock()
+	return len(fake.lastModifiedArgsForCall)
+}
+
+func (fake *FakeBlob) LastModifiedCalls(stub func() time.Time) {
+	fake.lastModifiedMutex.Lock()
+	defer fake.lastModifiedMutex.Unlock()
+	fake.LastModifiedStub = stub
+}
+
+func (fake *FakeBlob) LastModifiedReturns(result1 time.Time) {
+	fake.lastModifiedMutex.Lock()
+	defer fake.lastModifiedMutex.Unlock()
+	fake.LastModifiedStub = nil
+	fake.lastModifiedReturns = struct {
+		result1 time.Time
+	}{result1}
+}
+
+func (fake *FakeBlob) LastModifiedReturnsOnCall(i int, result1 time.Time) {
+	fake.lastModifiedMutex.Lock()
+	defer fake.lastModifiedMutex.Unlock()
+	fake.LastModifiedStub = nil
+	if fake.lastModifiedReturnsOnCall == nil {
+		fake.lastModifiedReturnsOnCall = make(map[int]struct {
+			result1 time.Time
+		})
+	}
+	fake.lastModifiedReturnsOnCall[i] = struct {
+		result1 time.Time
+	}{result1}
+}
+
 func (fake *FakeBlob) Path() string {
 	fake.pathMutex.Lock()
 	ret, specificReturn := fake.pathReturnsOnCall[len(fake.pathArgsForCall)]
@@ -74,11 +211,70 @@ func (fake *FakeBlob) PathReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeBlob) Size() int64 {
+	fake.sizeMutex.Lock()
+	ret, specificReturn := fake.sizeReturnsOnCall[len(fake.sizeArgsForCall)]
+	fake.sizeArgsForCall = append(fake.sizeArgsForCall, struct {
+	}{})
+	stub := fake.SizeStub
+	fakeReturns := fake.sizeReturns
+	fake.recordInvocation("Size", []interface{}{})
+	fake.sizeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBlob) SizeCallCount() int {
+	fake.sizeMutex.RLock()
+	defer fake.sizeMutex.RUnlock()
+	return len(fake.sizeArgsForCall)
+}
+
+func (fake *FakeBlob) SizeCalls(stub func() int64) {
+	fake.sizeMutex.Lock()
+	defer fake.sizeMutex.Unlock()
+	fake.SizeStub = stub
+}
+
+func (fake *FakeBlob) SizeReturns(result1 int64) {
+	fake.sizeMutex.Lock()
+	defer fake.sizeMutex.Unlock()
+	fake.SizeStub = nil
+	fake.sizeReturns = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *FakeBlob) SizeReturnsOnCall(i int, result1 int64) {
+	fake.sizeMutex.Lock()
+	defer fake.sizeMutex.Unlock()
+	fake.SizeStub = nil
+	if fake.sizeReturnsOnCall == nil {
+		fake.sizeReturnsOnCall = make(map[int]struct {
+			result1 int64
+		})
+	}
+	fake.sizeReturnsOnCall[i] = struct {
+		result1 int64
+	}{result1}
+}
+
 func (fake *FakeBlob) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.eTagMutex.RLock()
+	defer fake.eTagMutex.RUnlock()
+	fake.lastModifiedMutex.RLock()
+	defer fake.lastModifiedMutex.RUnlock()
 	fake.pathMutex.RLock()
 	defer fake.pathMutex.RUnlock()
+	fake.sizeMutex.RLock()
+	defer fake.sizeMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -3,10 +3,24 @@ package fakes
 
 import (
 	"s3-blobstore-backup-restore/incremental"
+	"s3-blobstore-backup-restore/integrity"
 	"sync"
 )
 
 type FakeBucket struct {
+	ChecksumStub        func(string) (integrity.Checksum, error)
+	checksumMutex       sync.RWMutex
+	checksumArgsForCall []struct {
+		arg1 string
+	}
+	checksumReturns struct {
+		result1 integrity.Checksum
+		result2 error
+	}
+	checksumReturnsOnCall map[int]struct {
+		result1 integrity.Checksum
+		result2 error
+	}
 	CopyBlobFromBucketStub        func(incremental.Bucket, string, string) error
 	copyBlobFromBucketMutex       sync.RWMutex
 	copyBlobFromBucketArgsForCall []struct {
@@ -32,6 +46,30 @@ type FakeBucket struct {
 	copyBlobWithinBucketReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteBlobStub        func(string) error
+	deleteBlobMutex       sync.RWMutex
+	deleteBlobArgsForCall []struct {
+		arg1 string
+	}
+	deleteBlobReturns struct {
+		result1 error
+	}
+	deleteBlobReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ETagIsDigestStub        func(string) (bool, error)
+	eTagIsDigestMutex       sync.RWMutex
+	eTagIsDigestArgsForCall []struct {
+		arg1 string
+	}
+	eTagIsDigestReturns struct {
+		result1 bool
+		result2 error
+	}
+	eTagIsDigestReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
 	HasBlobStub        func(string) (bool, error)
 	hasBlobMutex       sync.RWMutex
 	hasBlobArgsForCall []struct {
@@ -90,6 +128,18 @@ type FakeBucket struct {
 	regionReturnsOnCall map[int]struct {
 		result1 string
 	}
+	RestoreArchivedBlobsStub        func([]string, int) error
+	restoreArchivedBlobsMutex       sync.RWMutex
+	restoreArchivedBlobsArgsForCall []struct {
+		arg1 []string
+		arg2 int
+	}
+	restoreArchivedBlobsReturns struct {
+		result1 error
+	}
+	restoreArchivedBlobsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UploadBlobStub        func(string, string) error
 	uploadBlobMutex       sync.RWMutex
 	uploadBlobArgsForCall []struct {
@@ -106,6 +156,70 @@ type FakeBucket struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeBucket) Checksum(arg1 string) (integrity.Checksum, error) {
+	fake.checksumMutex.Lock()
+	ret, specificReturn := fake.checksumReturnsOnCall[len(fake.checksumArgsForCall)]
+	fake.checksumArgsForCall = append(fake.checksumArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ChecksumStub
+	fakeReturns := fake.checksumReturns
+	fake.recordInvocation("Checksum", []interface{}{arg1})
+	fake.checksumMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBucket) ChecksumCallCount() int {
+	fake.checksumMutex.RLock()
+	defer fake.checksumMutex.RUnlock()
+	return len(fake.checksumArgsForCall)
+}
+
+func (fake *FakeBucket) ChecksumCalls(stub func(string) (integrity.Checksum, error)) {
+	fake.checksumMutex.Lock()
+	defer fake.checksumMutex.Unlock()
+	fake.ChecksumStub = stub
+}
+
+func (fake *FakeBucket) ChecksumArgsForCall(i int) string {
+	fake.checksumMutex.RLock()
+	defer fake.checksumMutex.RUnlock()
+	argsForCall := fake.checksumArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBucket) ChecksumReturns(result1 integrity.Checksum, result2 error) {
+	fake.checksumMutex.Lock()
+	defer fake.checksumMutex.Unlock()
+	fake.ChecksumStub = nil
+	fake.checksumReturns = struct {
+		result1 integrity.Checksum
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBucket) ChecksumReturnsOnCall(i int, result1 integrity.Checksum, result2 error) {
+	fake.checksumMutex.Lock()
+	defer fake.checksumMutex.Unlock()
+	fake.ChecksumStub = nil
+	if fake.checksumReturnsOnCall == nil {
+		fake.checksumReturnsOnCall = make(map[int]struct {
+			result1 integrity.Checksum
+			result2 error
+		})
+	}
+	fake.checksumReturnsOnCall[i] = struct {
+		result1 integrity.Checksum
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBucket) CopyBlobFromBucket(arg1 incremental.Bucket, arg2 string, arg3 string) error {
 	fake.copyBlobFromBucketMutex.Lock()
 	ret, specificReturn := fake.copyBlobFromBucketReturnsOnCall[len(fake.copyBlobFromBucketArgsForCall)]
@@ -231,6 +345,131 @@ func (fake *FakeBucket) CopyBlobWithinBucketReturnsOnCall(i int, result1 error)
 	}{result1}
 }
 
+func (fake *FakeBucket) DeleteBlob(arg1 string) error {
+	fake.deleteBlobMutex.Lock()
+	ret, specificReturn := fake.deleteBlobReturnsOnCall[len(fake.deleteBlobArgsForCall)]
+	fake.deleteBlobArgsForCall = append(fake.deleteBlobArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteBlobStub
+	fakeReturns := fake.deleteBlobReturns
+	fake.recordInvocation("DeleteBlob", []interface{}{arg1})
+	fake.deleteBlobMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBucket) DeleteBlobCallCount() int {
+	fake.deleteBlobMutex.RLock()
+	defer fake.deleteBlobMutex.RUnlock()
+	return len(fake.deleteBlobArgsForCall)
+}
+
+func (fake *FakeBucket) DeleteBlobCalls(stub func(string) error) {
+	fake.deleteBlobMutex.Lock()
+	defer fake.deleteBlobMutex.Unlock()
+	fake.DeleteBlobStub = stub
+}
+
+func (fake *FakeBucket) DeleteBlobArgsForCall(i int) string {
+	fake.deleteBlobMutex.RLock()
+	defer fake.deleteBlobMutex.RUnlock()
+	argsForCall := fake.deleteBlobArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBucket) DeleteBlobReturns(result1 error) {
+	fake.deleteBlobMutex.Lock()
+	defer fake.deleteBlobMutex.Unlock()
+	fake.DeleteBlobStub = nil
+	fake.deleteBlobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBucket) DeleteBlobReturnsOnCall(i int, result1 error) {
+	fake.deleteBlobMutex.Lock()
+	defer fake.deleteBlobMutex.Unlock()
+	fake.DeleteBlobStub = nil
+	if fake.deleteBlobReturnsOnCall == nil {
+		fake.deleteBlobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteBlobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBucket) ETagIsDigest(arg1 string) (bool, error) {
+	fake.eTagIsDigestMutex.Lock()
+	ret, specificReturn := fake.eTagIsDigestReturnsOnCall[len(fake.eTagIsDigestArgsForCall)]
+	fake.eTagIsDigestArgsForCall = append(fake.eTagIsDigestArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ETagIsDigestStub
+	fakeReturns := fake.eTagIsDigestReturns
+	fake.recordInvocation("ETagIsDigest", []interface{}{arg1})
+	fake.eTagIsDigestMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBucket) ETagIsDigestCallCount() int {
+	fake.eTagIsDigestMutex.RLock()
+	defer fake.eTagIsDigestMutex.RUnlock()
+	return len(fake.eTagIsDigestArgsForCall)
+}
+
+func (fake *FakeBucket) ETagIsDigestCalls(stub func(string) (bool, error)) {
+	fake.eTagIsDigestMutex.Lock()
+	defer fake.eTagIsDigestMutex.Unlock()
+	fake.ETagIsDigestStub = stub
+}
+
+func (fake *FakeBucket) ETagIsDigestArgsForCall(i int) string {
+	fake.eTagIsDigestMutex.RLock()
+	defer fake.eTagIsDigestMutex.RUnlock()
+	argsForCall := fake.eTagIsDigestArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBucket) ETagIsDigestReturns(result1 bool, result2 error) {
+	fake.eTagIsDigestMutex.Lock()
+	defer fake.eTagIsDigestMutex.Unlock()
+	fake.ETagIsDigestStub = nil
+	fake.eTagIsDigestReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBucket) ETagIsDigestReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.eTagIsDigestMutex.Lock()
+	defer fake.eTagIsDigestMutex.Unlock()
+	fake.ETagIsDigestStub = nil
+	if fake.eTagIsDigestReturnsOnCall == nil {
+		fake.eTagIsDigestReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.eTagIsDigestReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBucket) HasBlob(arg1 string) (bool, error) {
 	fake.hasBlobMutex.Lock()
 	ret, specificReturn := fake.hasBlobReturnsOnCall[len(fake.hasBlobArgsForCall)]
@@ -521,6 +760,73 @@ func (fake *FakeBucket) RegionReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeBucket) RestoreArchivedBlobs(arg1 []string, arg2 int) error {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.restoreArchivedBlobsMutex.Lock()
+	ret, specificReturn := fake.restoreArchivedBlobsReturnsOnCall[len(fake.restoreArchivedBlobsArgsForCall)]
+	fake.restoreArchivedBlobsArgsForCall = append(fake.restoreArchivedBlobsArgsForCall, struct {
+		arg1 []string
+		arg2 int
+	}{arg1Copy, arg2})
+	stub := fake.RestoreArchivedBlobsStub
+	fakeReturns := fake.restoreArchivedBlobsReturns
+	fake.recordInvocation("RestoreArchivedBlobs", []interface{}{arg1Copy, arg2})
+	fake.restoreArchivedBlobsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobsCallCount() int {
+	fake.restoreArchivedBlobsMutex.RLock()
+	defer fake.restoreArchivedBlobsMutex.RUnlock()
+	return len(fake.restoreArchivedBlobsArgsForCall)
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobsCalls(stub func([]string, int) error) {
+	fake.restoreArchivedBlobsMutex.Lock()
+	defer fake.restoreArchivedBlobsMutex.Unlock()
+	fake.RestoreArchivedBlobsStub = stub
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobsArgsForCall(i int) ([]string, int) {
+	fake.restoreArchivedBlobsMutex.RLock()
+	defer fake.restoreArchivedBlobsMutex.RUnlock()
+	argsForCall := fake.restoreArchivedBlobsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobsReturns(result1 error) {
+	fake.restoreArchivedBlobsMutex.Lock()
+	defer fake.restoreArchivedBlobsMutex.Unlock()
+	fake.RestoreArchivedBlobsStub = nil
+	fake.restoreArchivedBlobsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobsReturnsOnCall(i int, result1 error) {
+	fake.restoreArchivedBlobsMutex.Lock()
+	defer fake.restoreArchivedBlobsMutex.Unlock()
+	fake.RestoreArchivedBlobsStub = nil
+	if fake.restoreArchivedBlobsReturnsOnCall == nil {
+		fake.restoreArchivedBlobsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.restoreArchivedBlobsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeBucket) UploadBlob(arg1 string, arg2 string) error {
 	fake.uploadBlobMutex.Lock()
 	ret, specificReturn := fake.uploadBlobReturnsOnCall[len(fake.uploadBlobArgsForCall)]
@@ -586,10 +892,16 @@ func (fake *FakeBucket) UploadBlobReturnsOnCall(i int, result1 error) {
 func (fake *FakeBucket) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.checksumMutex.RLock()
+	defer fake.checksumMutex.RUnlock()
 	fake.copyBlobFromBucketMutex.RLock()
 	defer fake.copyBlobFromBucketMutex.RUnlock()
 	fake.copyBlobWithinBucketMutex.RLock()
 	defer fake.copyBlobWithinBucketMutex.RUnlock()
+	fake.deleteBlobMutex.RLock()
+	defer fake.deleteBlobMutex.RUnlock()
+	fake.eTagIsDigestMutex.RLock()
+	defer fake.eTagIsDigestMutex.RUnlock()
 	fake.hasBlobMutex.RLock()
 	defer fake.hasBlobMutex.RUnlock()
 	fake.listBlobsMutex.RLock()
@@ -600,6 +912,8 @@ func (fake *FakeBucket) Invocations() map[string][][]interface{} {
 	defer fake.nameMutex.RUnlock()
 	fake.regionMutex.RLock()
 	defer fake.regionMutex.RUnlock()
+	fake.restoreArchivedBlobsMutex.RLock()
+	defer fake.restoreArchivedBlobsMutex.RUnlock()
 	fake.uploadBlobMutex.RLock()
 	defer fake.uploadBlobMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
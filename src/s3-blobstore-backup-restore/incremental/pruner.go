@@ -0,0 +1,92 @@
+package incremental
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pruner deletes the backup directories of each bucket ID which its retention
+// policy no longer keeps, along with abandoned backup directories: those
+// without a backup_complete marker which are older than the latest complete
+// backup. Directories newer than the latest complete backup may belong to a
+// backup in progress, so they are never deleted.
+type Pruner struct {
+	BackupsToPrune map[string]BackupToPrune
+	clock          Clock
+}
+
+func NewPruner(backupsToPrune map[string]BackupToPrune, clock Clock) Pruner {
+	return Pruner{
+		BackupsToPrune: backupsToPrune,
+		clock:          clock,
+	}
+}
+
+// Run prunes the backups of every bucket ID, carrying on when pruning one of
+// them fails, and returns the errors of all of them together.
+func (p Pruner) Run() error {
+	now, err := time.ParseInLocation(TimestampFormat, p.clock.Now(), time.Local)
+	if err != nil {
+		return fmt.Errorf("failed to prune backups: %s", err)
+	}
+
+	var errs []error
+	for bucketID, backupToPrune := range p.BackupsToPrune {
+		dirs, err := backupToPrune.directoriesToDelete(bucketID, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, dir := range dirs {
+			err := dir.Delete(backupToPrune.MaxInFlight)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return formatExecutorErrors("failed to prune backups", errs)
+	}
+
+	return nil
+}
+
+func (b BackupToPrune) directoriesToDelete(bucketID string, now time.Time) ([]BackupDirectory, error) {
+	backupDirs, err := listBackupDirectories(bucketID, b.BackupBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dirsToDelete  []BackupDirectory
+		completeCount int
+	)
+	for _, dir := range backupDirs {
+		isComplete, err := dir.IsComplete()
+		if err != nil {
+			return nil, err
+		}
+
+		if !isComplete {
+			if completeCount > 0 {
+				dirsToDelete = append(dirsToDelete, dir)
+			}
+			continue
+		}
+
+		completeCount++
+
+		startTime, err := dir.startTime()
+		if err != nil {
+			return nil, err
+		}
+
+		if !b.Retention.keeps(completeCount, startTime, now) {
+			dirsToDelete = append(dirsToDelete, dir)
+		}
+	}
+
+	return dirsToDelete, nil
+}
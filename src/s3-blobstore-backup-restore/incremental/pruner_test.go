@@ -0,0 +1,205 @@
+package incremental_test
+
+import (
+	"errors"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+
+	"s3-blobstore-backup-restore/incremental"
+	"s3-blobstore-backup-restore/incremental/fakes"
+
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pruner", func() {
+	var (
+		bucket      *fakes.FakeBucket
+		otherBucket *fakes.FakeBucket
+		clock       *fakes.FakeClock
+		retention   incremental.RetentionPolicy
+		err         error
+	)
+
+	BeforeEach(func() {
+		bucket = new(fakes.FakeBucket)
+		bucket.ListDirectoriesReturns([]string{
+			"2000_01_01_00_00_00",
+			"2000_01_02_00_00_00",
+			"2000_01_03_00_00_00",
+			"2000_01_04_00_00_00",
+			"2000_01_05_00_00_00",
+			"not_a_backup_directory",
+		}, nil)
+
+		complete := map[string]bool{
+			"2000_01_01_00_00_00/bucket_id": true,
+			"2000_01_02_00_00_00/bucket_id": true,
+			"2000_01_04_00_00_00/bucket_id": true,
+		}
+		bucket.HasBlobStub = func(path string) (bool, error) {
+			return complete[strings.TrimSuffix(path, "/backup_complete")], nil
+		}
+		bucket.ListBlobsStub = func(path string) ([]incremental.Blob, error) {
+			blob := new(fakes.FakeBlob)
+			blob.PathReturns("f0/fd/blob1/uuid")
+			if !complete[path] {
+				return []incremental.Blob{blob}, nil
+			}
+
+			backupCompleteBlob := new(fakes.FakeBlob)
+			backupCompleteBlob.PathReturns("backup_complete")
+			return []incremental.Blob{blob, backupCompleteBlob}, nil
+		}
+
+		clock = new(fakes.FakeClock)
+		clock.NowReturns("2000_01_06_00_00_00")
+
+		retention = incremental.RetentionPolicy{}
+
+		otherBucket = new(fakes.FakeBucket)
+		otherBucket.ListDirectoriesReturns([]string{"2000_01_01_00_00_00", "2000_01_02_00_00_00"}, nil)
+		otherBucket.HasBlobStub = func(path string) (bool, error) {
+			return strings.HasPrefix(path, "2000_01_02_00_00_00"), nil
+		}
+		otherBucket.ListBlobsReturns([]incremental.Blob{new(fakes.FakeBlob)}, nil)
+	})
+
+	JustBeforeEach(func() {
+		err = incremental.NewPruner(map[string]incremental.BackupToPrune{
+			"bucket_id": {
+				BackupBucket: bucket,
+				Retention:    retention,
+			},
+			"other_bucket_id": {
+				BackupBucket: otherBucket,
+				Retention:    retention,
+			},
+		}, clock).Run()
+	})
+
+	deletedBlobs := func() []string {
+		var paths []string
+		for i := 0; i < bucket.DeleteBlobCallCount(); i++ {
+			paths = append(paths, bucket.DeleteBlobArgsForCall(i))
+		}
+		return paths
+	}
+
+	Context("when there is no retention policy", func() {
+		It("deletes only the abandoned backup directories", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deletedBlobs()).To(ConsistOf("2000_01_03_00_00_00/bucket_id/f0/fd/blob1/uuid"))
+		})
+	})
+
+	Context("when the retention policy keeps the last backups", func() {
+		BeforeEach(func() {
+			retention.KeepLast = 2
+		})
+
+		It("deletes the older complete backup directories", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deletedBlobs()).To(ConsistOf(
+				"2000_01_03_00_00_00/bucket_id/f0/fd/blob1/uuid",
+				"2000_01_01_00_00_00/bucket_id/backup_complete",
+				"2000_01_01_00_00_00/bucket_id/f0/fd/blob1/uuid",
+			))
+		})
+
+		It("deletes the backup_complete marker before the rest of a backup directory", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deletedBlobs()[1]).To(Equal("2000_01_01_00_00_00/bucket_id/backup_complete"))
+		})
+	})
+
+	Context("when the retention policy has a maximum age", func() {
+		BeforeEach(func() {
+			retention.MaxAgeDays = 3
+		})
+
+		It("deletes the complete backup directories older than the maximum age", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deletedBlobs()).To(ConsistOf(
+				"2000_01_03_00_00_00/bucket_id/f0/fd/blob1/uuid",
+				"2000_01_02_00_00_00/bucket_id/backup_complete",
+				"2000_01_02_00_00_00/bucket_id/f0/fd/blob1/uuid",
+				"2000_01_01_00_00_00/bucket_id/backup_complete",
+				"2000_01_01_00_00_00/bucket_id/f0/fd/blob1/uuid",
+			))
+		})
+
+		Context("and the latest complete backup is older than the maximum age", func() {
+			BeforeEach(func() {
+				retention.MaxAgeDays = 1
+			})
+
+			It("keeps the latest complete backup directory", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deletedBlobs()).NotTo(ContainElement(HavePrefix("2000_01_04_00_00_00")))
+				Expect(deletedBlobs()).To(ContainElement("2000_01_02_00_00_00/bucket_id/backup_complete"))
+			})
+		})
+	})
+
+	Context("when there are no complete backups", func() {
+		BeforeEach(func() {
+			bucket.HasBlobReturns(false, nil)
+			bucket.HasBlobStub = nil
+			retention.KeepLast = 1
+		})
+
+		It("deletes nothing", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bucket.DeleteBlobCallCount()).To(BeZero())
+		})
+	})
+
+	It("never deletes a backup directory newer than the latest complete backup", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deletedBlobs()).NotTo(ContainElement(HavePrefix("2000_01_05_00_00_00")))
+	})
+
+	Context("when listing the backup directories fails", func() {
+		BeforeEach(func() {
+			bucket.ListDirectoriesReturns(nil, errors.New("fail to list"))
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError("failed to prune backups: fail to list"))
+		})
+
+		It("prunes the backups of the other bucket IDs", func() {
+			Expect(otherBucket.DeleteBlobCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when pruning several bucket IDs fails", func() {
+		BeforeEach(func() {
+			bucket.ListDirectoriesReturns(nil, errors.New("fail to list"))
+			otherBucket.DeleteBlobReturns(errors.New("fail to delete"))
+		})
+
+		It("returns the errors of all of them", func() {
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring("failed to prune backups"),
+				ContainSubstring("fail to list"),
+				ContainSubstring("fail to delete"),
+			)))
+		})
+	})
+
+	Context("when deleting a blob fails", func() {
+		BeforeEach(func() {
+			bucket.DeleteBlobReturns(errors.New("fail to delete"))
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring("failed to prune backups"),
+				ContainSubstring("2000_01_03_00_00_00/bucket_id"),
+				ContainSubstring("fail to delete"),
+			)))
+		})
+	})
+})
@@ -2,17 +2,35 @@ package incremental
 
 import (
 	"fmt"
+	"io"
 
-	"github.com/cloudfoundry/bosh-backup-and-restore/executor"
+	"common/executor"
+
+	"s3-blobstore-backup-restore/integrity"
+	"s3-blobstore-backup-restore/mirror"
 )
 
 type RestoreBucketPair struct {
 	ConfigLiveBucket     Bucket
 	ArtifactBackupBucket Bucket
 	SameAsBucketID       string
+	// MaxInFlight is the number of blobs copied at once, or
+	// executor.DefaultMaxInFlight when it is zero.
+	MaxInFlight int
+	// DeleteExtraneous is whether the blobs of the live bucket which are not
+	// in the backup are deleted after it is restored.
+	DeleteExtraneous mirror.Config
+	// Verify is whether each restored blob is checked against the checksum
+	// the backup artifact recorded for it, when it recorded one.
+	Verify bool
 }
 
 func (p RestoreBucketPair) Restore(backup Backup) error {
+	err := p.ArtifactBackupBucket.RestoreArchivedBlobs(backup.Blobs, p.MaxInFlight)
+	if err != nil {
+		return fmt.Errorf("failed to restore bucket %s: %s", p.ConfigLiveBucket.Name(), err)
+	}
+
 	var executables []executor.Executable
 	for _, blob := range backup.Blobs {
 		backedUpBlob := BackedUpBlob{
@@ -27,10 +45,7 @@ func (p RestoreBucketPair) Restore(backup Backup) error {
 		})
 	}
 
-	e := executor.NewParallelExecutor()
-	e.SetMaxInFlight(200)
-
-	errs := e.Run([][]executor.Executable{executables})
+	errs := executor.NewParallelExecutor(p.MaxInFlight).Run([][]executor.Executable{executables})
 	if len(errs) != 0 {
 		return formatExecutorErrors(
 			fmt.Sprintf("failed to restore bucket %s", p.ConfigLiveBucket.Name()),
@@ -38,5 +53,114 @@ func (p RestoreBucketPair) Restore(backup Backup) error {
 		)
 	}
 
+	if p.Verify {
+		return p.verifyChecksums(backup)
+	}
+
+	return nil
+}
+
+func (p RestoreBucketPair) verifyChecksums(backup Backup) error {
+	var executables []executor.Executable
+	for _, blob := range backup.Blobs {
+		checksum := backup.BlobAttributes[blob].Checksum
+		if checksum == (integrity.Checksum{}) {
+			continue
+		}
+
+		backedUpBlob := BackedUpBlob{
+			Path:                blob,
+			BackupDirectoryPath: backup.SrcBackupDirectoryPath,
+		}
+		executables = append(executables, verifyChecksumExecutable{
+			bucket:   p.ConfigLiveBucket,
+			path:     backedUpBlob.LiveBlobPath(),
+			checksum: checksum,
+		})
+	}
+
+	errs := executor.NewParallelExecutor(p.MaxInFlight).Run([][]executor.Executable{executables})
+	if len(errs) != 0 {
+		return formatExecutorErrors(
+			fmt.Sprintf("failed to verify restored blobs in bucket %s", p.ConfigLiveBucket.Name()),
+			errs,
+		)
+	}
+
+	return nil
+}
+
+type verifyChecksumExecutable struct {
+	bucket   Bucket
+	path     string
+	checksum integrity.Checksum
+}
+
+func (e verifyChecksumExecutable) Execute() error {
+	checksum, err := e.bucket.Checksum(e.path)
+	if err != nil {
+		return err
+	}
+
+	err = integrity.VerifyChecksum(e.checksum, checksum)
+	if err != nil {
+		return fmt.Errorf("blob '%s' does not match its backup: %s", e.path, err)
+	}
+
+	return nil
+}
+
+// DeleteExtraneousBlobs deletes the blobs of the live bucket which are not in
+// backup, when DeleteExtraneous is enabled, and writes them to report.
+func (p RestoreBucketPair) DeleteExtraneousBlobs(backup Backup, report io.Writer) error {
+	if !p.DeleteExtraneous.Enabled {
+		return nil
+	}
+
+	liveBlobs, err := p.ConfigLiveBucket.ListBlobs("")
+	if err != nil {
+		return fmt.Errorf("failed to delete extraneous blobs from bucket %s: %s", p.ConfigLiveBucket.Name(), err)
+	}
+
+	var liveKeys []string
+	for _, blob := range liveBlobs {
+		liveKeys = append(liveKeys, blob.Path())
+	}
+
+	var backedUpKeys []string
+	for _, blob := range backup.Blobs {
+		backedUpBlob := BackedUpBlob{
+			Path:                blob,
+			BackupDirectoryPath: backup.SrcBackupDirectoryPath,
+		}
+		backedUpKeys = append(backedUpKeys, backedUpBlob.LiveBlobPath())
+	}
+
+	keysToDelete, err := p.DeleteExtraneous.KeysToDelete(p.ConfigLiveBucket.Name(), liveKeys, backedUpKeys)
+	if err != nil {
+		return err
+	}
+
+	p.DeleteExtraneous.Report(report, p.ConfigLiveBucket.Name(), keysToDelete)
+	if p.DeleteExtraneous.DryRun {
+		return nil
+	}
+
+	var executables []executor.Executable
+	for _, key := range keysToDelete {
+		executables = append(executables, deleteBlobExecutable{
+			bucket: p.ConfigLiveBucket,
+			path:   key,
+		})
+	}
+
+	errs := executor.NewParallelExecutor(p.MaxInFlight).Run([][]executor.Executable{executables})
+	if len(errs) != 0 {
+		return formatExecutorErrors(
+			fmt.Sprintf("failed to delete extraneous blobs from bucket %s", p.ConfigLiveBucket.Name()),
+			errs,
+		)
+	}
+
 	return nil
 }
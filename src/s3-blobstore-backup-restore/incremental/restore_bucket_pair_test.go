@@ -3,6 +3,7 @@ package incremental_test
 import (
 	"s3-blobstore-backup-restore/incremental"
 	"s3-blobstore-backup-restore/incremental/fakes"
+	"s3-blobstore-backup-restore/integrity"
 
 	"fmt"
 
@@ -77,6 +78,31 @@ var _ = Describe("RestoreBucketPair", func() {
 			))
 		})
 
+		It("restores the archived blobs in the backup bucket before copying them", func() {
+			bucketPair.MaxInFlight = 7
+			backupBucket.RestoreArchivedBlobsStub = func([]string, int) error {
+				Expect(liveBucket.CopyBlobFromBucketCallCount()).To(BeZero())
+				return nil
+			}
+
+			err = bucketPair.Restore(backup)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupBucket.RestoreArchivedBlobsCallCount()).To(Equal(1))
+			paths, maxInFlight := backupBucket.RestoreArchivedBlobsArgsForCall(0)
+			Expect(paths).To(Equal(backup.Blobs))
+			Expect(maxInFlight).To(Equal(7))
+		})
+
+		Context("When restoring the archived blobs errors", func() {
+			It("errors without copying any blobs", func() {
+				backupBucket.RestoreArchivedBlobsReturns(fmt.Errorf("cannot restore object"))
+				err = bucketPair.Restore(backup)
+				Expect(err).To(MatchError("failed to restore bucket config_live_bucket: cannot restore object"))
+				Expect(liveBucket.CopyBlobFromBucketCallCount()).To(BeZero())
+			})
+		})
+
 		Context("When CopyObject errors", func() {
 			It("errors", func() {
 				liveBucket.CopyBlobFromBucketReturns(fmt.Errorf("cannot copy object"))
@@ -84,5 +110,38 @@ var _ = Describe("RestoreBucketPair", func() {
 				Expect(err).To(MatchError(ContainSubstring("cannot copy object")))
 			})
 		})
+
+		Context("When the restore verifies the blobs", func() {
+			crc := func(value string) integrity.Checksum {
+				return integrity.Checksum{Algorithm: "CRC32C", Value: value}
+			}
+
+			BeforeEach(func() {
+				bucketPair.Verify = true
+				backup.BlobAttributes = map[string]incremental.BlobAttributes{
+					"2015-12-13-05-06-07/my_bucket_id/livebucketpath/to/real/blob1": {Size: 1, Checksum: crc("crc1")},
+					"2015-12-13-05-06-07/my_bucket_id/livebucketpath/to/real/blob2": {Size: 2},
+				}
+				liveBucket.ChecksumReturns(crc("crc1"), nil)
+			})
+
+			It("checks the restored blobs which have a recorded checksum", func() {
+				err = bucketPair.Restore(backup)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(liveBucket.ChecksumCallCount()).To(Equal(1))
+				Expect(liveBucket.ChecksumArgsForCall(0)).To(Equal("livebucketpath/to/real/blob1"))
+			})
+
+			Context("and a restored blob does not match its recorded checksum", func() {
+				BeforeEach(func() {
+					liveBucket.ChecksumReturns(crc("crc2"), nil)
+				})
+
+				It("errors", func() {
+					err = bucketPair.Restore(backup)
+					Expect(err).To(MatchError("failed to verify restored blobs in bucket config_live_bucket: blob 'livebucketpath/to/real/blob1' does not match its backup: CRC32C checksum crc2 does not match crc1"))
+				})
+			})
+		})
 	})
 })
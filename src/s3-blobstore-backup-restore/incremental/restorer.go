@@ -3,20 +3,30 @@ package incremental
 import (
 	"errors"
 	"fmt"
+	"io"
 )
 
 type Restorer struct {
 	bucketPairs map[string]RestoreBucketPair
 	artifact    Artifact
+	report      io.Writer
 }
 
 func NewRestorer(bucketPairs map[string]RestoreBucketPair, artifact Artifact) Restorer {
 	return Restorer{
 		bucketPairs: bucketPairs,
 		artifact:    artifact,
+		report:      io.Discard,
 	}
 }
 
+// WithReport returns a copy of the restorer which writes the blobs it deletes
+// from the live buckets to report.
+func (b Restorer) WithReport(report io.Writer) Restorer {
+	b.report = report
+	return b
+}
+
 func (b Restorer) Run() error {
 	backups, err := b.artifact.Load()
 	if err != nil {
@@ -55,6 +65,13 @@ func (b Restorer) Run() error {
 		if err != nil {
 			return err
 		}
+
+		if backup.SameBucketAs == "" {
+			err = pair.DeleteExtraneousBlobs(backup, b.report)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
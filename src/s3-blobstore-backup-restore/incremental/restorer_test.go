@@ -3,6 +3,9 @@ package incremental_test
 import (
 	"fmt"
 
+	"github.com/onsi/gomega/gbytes"
+
+	"s3-blobstore-backup-restore/mirror"
 	"s3-blobstore-backup-restore/s3bucket"
 
 	"s3-blobstore-backup-restore/incremental"
@@ -29,6 +32,7 @@ var _ = Describe("Restorer", func() {
 		packagesBucketPair            incremental.RestoreBucketPair
 		bucketPairs                   map[string]incremental.RestoreBucketPair
 		artifact                      *fakes.FakeArtifact
+		report                        *gbytes.Buffer
 
 		err error
 
@@ -84,7 +88,8 @@ var _ = Describe("Restorer", func() {
 	})
 
 	JustBeforeEach(func() {
-		restorer := incremental.NewRestorer(bucketPairs, artifact)
+		report = gbytes.NewBuffer()
+		restorer := incremental.NewRestorer(bucketPairs, artifact).WithReport(report)
 		err = restorer.Run()
 	})
 
@@ -118,6 +123,80 @@ var _ = Describe("Restorer", func() {
 		})
 	})
 
+	Context("when a bucket pair deletes extraneous blobs", func() {
+		BeforeEach(func() {
+			dropletsBucketPair.DeleteExtraneous = mirror.Config{Enabled: true}
+			bucketPairs["droplets"] = dropletsBucketPair
+
+			destinationLiveDropletsBucket.NameReturns("live_droplets_bucket")
+			destinationLiveDropletsBucket.ListBlobsReturns([]incremental.Blob{
+				s3bucket.NewBlob("my_droplet1"),
+				s3bucket.NewBlob("my_droplet2"),
+				s3bucket.NewBlob("my_droplet3"),
+			}, nil)
+			destinationLivePackagesBucket.ListBlobsReturns([]incremental.Blob{
+				s3bucket.NewBlob("my_package3"),
+			}, nil)
+		})
+
+		It("deletes the blobs of the live bucket which are not in the backup after restoring it", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(destinationLiveDropletsBucket.CopyBlobFromBucketCallCount()).To(Equal(2))
+			Expect(destinationLiveDropletsBucket.ListBlobsArgsForCall(0)).To(Equal(""))
+			Expect(destinationLiveDropletsBucket.DeleteBlobCallCount()).To(Equal(1))
+			Expect(destinationLiveDropletsBucket.DeleteBlobArgsForCall(0)).To(Equal("my_droplet3"))
+			Expect(string(report.Contents())).To(ContainSubstring("Deleting 1 blobs which are not in the backup from bucket live_droplets_bucket:\n  my_droplet3\n"))
+		})
+
+		It("does not delete blobs from the buckets which do not delete extraneous blobs", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(destinationLivePackagesBucket.ListBlobsCallCount()).To(BeZero())
+			Expect(destinationLivePackagesBucket.DeleteBlobCallCount()).To(BeZero())
+		})
+
+		Context("and it is a dry run", func() {
+			BeforeEach(func() {
+				dropletsBucketPair.DeleteExtraneous.DryRun = true
+				bucketPairs["droplets"] = dropletsBucketPair
+			})
+
+			It("reports the blobs without deleting them", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(destinationLiveDropletsBucket.DeleteBlobCallCount()).To(BeZero())
+				Expect(string(report.Contents())).To(ContainSubstring("Dry run: would delete 1 blobs which are not in the backup from bucket live_droplets_bucket:\n  my_droplet3\n"))
+			})
+		})
+
+		Context("and there are more blobs to delete than max_deletions", func() {
+			BeforeEach(func() {
+				destinationLiveDropletsBucket.ListBlobsReturns([]incremental.Blob{
+					s3bucket.NewBlob("my_droplet3"),
+					s3bucket.NewBlob("my_droplet4"),
+				}, nil)
+				dropletsBucketPair.DeleteExtraneous.MaxDeletions = 1
+				bucketPairs["droplets"] = dropletsBucketPair
+			})
+
+			It("fails without deleting any", func() {
+				Expect(err).To(MatchError(ContainSubstring("refusing to delete 2 blobs which are not in the backup from bucket live_droplets_bucket")))
+				Expect(destinationLiveDropletsBucket.DeleteBlobCallCount()).To(BeZero())
+			})
+		})
+
+		Context("and deleting a blob fails", func() {
+			BeforeEach(func() {
+				destinationLiveDropletsBucket.DeleteBlobReturns(fmt.Errorf("fail to delete"))
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring("failed to delete extraneous blobs from bucket live_droplets_bucket"),
+					ContainSubstring("fail to delete"),
+				)))
+			})
+		})
+	})
+
 	Context("when a bucket is marked same as another", func() {
 		BeforeEach(func() {
 			backups = map[string]incremental.Backup{
@@ -0,0 +1,49 @@
+package incremental
+
+import (
+	"errors"
+	"time"
+)
+
+// RetentionPolicy is which complete backups are kept when backup directories
+// are pruned. A backup is deleted once it is older than either limit. The
+// latest complete backup is always kept, and a zero limit does not apply.
+type RetentionPolicy struct {
+	KeepLast   int `json:"keep_last,omitempty"`
+	MaxAgeDays int `json:"max_age_days,omitempty"`
+}
+
+func (r RetentionPolicy) Validate() error {
+	if r.KeepLast < 0 {
+		return errors.New("retention.keep_last must not be negative")
+	}
+
+	if r.MaxAgeDays < 0 {
+		return errors.New("retention.max_age_days must not be negative")
+	}
+
+	return nil
+}
+
+// IsSet reports whether the policy limits the backups kept.
+func (r RetentionPolicy) IsSet() bool {
+	return r.KeepLast > 0 || r.MaxAgeDays > 0
+}
+
+// keeps reports whether the complete backup started at timestamp is kept, when
+// it is the nth newest complete backup, counting from 1, and it is now now.
+func (r RetentionPolicy) keeps(n int, timestamp, now time.Time) bool {
+	if n == 1 {
+		return true
+	}
+
+	if r.KeepLast > 0 && n > r.KeepLast {
+		return false
+	}
+
+	if r.MaxAgeDays > 0 && now.Sub(timestamp) > time.Duration(r.MaxAgeDays)*24*time.Hour {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,58 @@
+package integrity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Checksum is an S3 additional checksum of the whole contents of a blob.
+type Checksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
+}
+
+// Blob is what S3 reports about the contents of a blob. ETag is empty when it
+// is not a digest of the contents, and Checksum is zero when the blob has no
+// checksum of its whole contents.
+type Blob struct {
+	Size     int64
+	ETag     string
+	Checksum Checksum
+}
+
+// Verify returns an error when the copy of a blob does not match it. The
+// sizes are always compared. The checksums are compared when both have one
+// with the same algorithm, and otherwise the ETags when both are digests.
+func Verify(blob, copy Blob) error {
+	if copy.Size != blob.Size {
+		return fmt.Errorf("size %d does not match %d", copy.Size, blob.Size)
+	}
+
+	if comparable(blob.Checksum, copy.Checksum) {
+		return VerifyChecksum(blob.Checksum, copy.Checksum)
+	}
+
+	if isDigestETag(blob.ETag) && isDigestETag(copy.ETag) && copy.ETag != blob.ETag {
+		return fmt.Errorf("ETag %s does not match %s", copy.ETag, blob.ETag)
+	}
+
+	return nil
+}
+
+// VerifyChecksum returns an error when both checksums use the same algorithm
+// and their values differ.
+func VerifyChecksum(expected, actual Checksum) error {
+	if comparable(expected, actual) && actual.Value != expected.Value {
+		return fmt.Errorf("%s checksum %s does not match %s", actual.Algorithm, actual.Value, expected.Value)
+	}
+
+	return nil
+}
+
+func comparable(a, b Checksum) bool {
+	return a.Value != "" && b.Value != "" && a.Algorithm == b.Algorithm
+}
+
+func isDigestETag(etag string) bool {
+	return etag != "" && !strings.Contains(etag, "-")
+}
@@ -0,0 +1,13 @@
+package integrity_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestIntegrity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Integrity Suite")
+}
@@ -0,0 +1,57 @@
+package integrity_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"s3-blobstore-backup-restore/integrity"
+)
+
+var _ = Describe("Verify", func() {
+	crc32c := func(value string) integrity.Checksum {
+		return integrity.Checksum{Algorithm: "CRC32C", Value: value}
+	}
+
+	DescribeTable("comparing a copy with its blob",
+		func(blob, copy integrity.Blob, expectedError string) {
+			err := integrity.Verify(blob, copy)
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("matching sizes, checksums and ETags",
+			integrity.Blob{Size: 10, ETag: `"a"`, Checksum: crc32c("c1")},
+			integrity.Blob{Size: 10, ETag: `"a"`, Checksum: crc32c("c1")},
+			""),
+		Entry("different sizes",
+			integrity.Blob{Size: 10},
+			integrity.Blob{Size: 9},
+			"size 9 does not match 10"),
+		Entry("different checksums",
+			integrity.Blob{Size: 10, ETag: `"a"`, Checksum: crc32c("c1")},
+			integrity.Blob{Size: 10, ETag: `"a"`, Checksum: crc32c("c2")},
+			"CRC32C checksum c2 does not match c1"),
+		Entry("matching checksums and different ETags",
+			integrity.Blob{Size: 10, ETag: `"a"`, Checksum: crc32c("c1")},
+			integrity.Blob{Size: 10, ETag: `"b"`, Checksum: crc32c("c1")},
+			""),
+		Entry("checksums with different algorithms and different ETags",
+			integrity.Blob{Size: 10, ETag: `"a"`, Checksum: crc32c("c1")},
+			integrity.Blob{Size: 10, ETag: `"b"`, Checksum: integrity.Checksum{Algorithm: "SHA256", Value: "s1"}},
+			`ETag "b" does not match "a"`),
+		Entry("no checksum and different ETags",
+			integrity.Blob{Size: 10, ETag: `"a"`},
+			integrity.Blob{Size: 10, ETag: `"b"`, Checksum: crc32c("c1")},
+			`ETag "b" does not match "a"`),
+		Entry("different multipart ETags",
+			integrity.Blob{Size: 10, ETag: `"a-2"`},
+			integrity.Blob{Size: 10, ETag: `"b"`},
+			""),
+		Entry("ETags which are not digests",
+			integrity.Blob{Size: 10},
+			integrity.Blob{Size: 10, ETag: `"b"`},
+			""),
+	)
+})
@@ -0,0 +1,88 @@
+package mirror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DefaultMaxDeletions is how many blobs a restore deletes from a bucket at
+// most, when the config does not say.
+const DefaultMaxDeletions = 1000
+
+// Config is whether a restore deletes the blobs of the live bucket which are
+// not in the backup, so that the bucket is left as it was at the time of the
+// backup.
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// MaxDeletions is the most blobs deleted from the bucket. A restore which
+	// would delete more fails before deleting any, in case the backup is not
+	// the one intended. It is DefaultMaxDeletions when it is zero.
+	MaxDeletions int `json:"max_deletions,omitempty"`
+	// DryRun reports the blobs which would be deleted without deleting them.
+	// It only applies to the deletions: the backed up blobs are still
+	// restored.
+	DryRun bool `json:"dry_run"`
+}
+
+func (c Config) Validate() error {
+	if c.MaxDeletions < 0 {
+		return errors.New("delete_extraneous.max_deletions must not be negative")
+	}
+
+	return nil
+}
+
+func (c Config) maxDeletions() int {
+	if c.MaxDeletions == 0 {
+		return DefaultMaxDeletions
+	}
+	return c.MaxDeletions
+}
+
+// KeysToDelete returns the liveKeys which are not backedUpKeys, sorted. It
+// returns an error if there are more than the config allows.
+func (c Config) KeysToDelete(bucketName string, liveKeys, backedUpKeys []string) ([]string, error) {
+	backedUp := make(map[string]bool, len(backedUpKeys))
+	for _, key := range backedUpKeys {
+		backedUp[key] = true
+	}
+
+	var keysToDelete []string
+	for _, key := range liveKeys {
+		if !backedUp[key] {
+			keysToDelete = append(keysToDelete, key)
+		}
+	}
+	sort.Strings(keysToDelete)
+
+	if len(keysToDelete) > c.maxDeletions() {
+		return nil, fmt.Errorf(
+			"refusing to delete %d blobs which are not in the backup from bucket %s: more than delete_extraneous.max_deletions (%d)",
+			len(keysToDelete),
+			bucketName,
+			c.maxDeletions(),
+		)
+	}
+
+	return keysToDelete, nil
+}
+
+// Report writes the keys which are deleted from the bucket, or which would be
+// in a dry run.
+func (c Config) Report(output io.Writer, bucketName string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	if c.DryRun {
+		fmt.Fprintf(output, "Dry run: would delete %d blobs which are not in the backup from bucket %s:\n", len(keys), bucketName)
+	} else {
+		fmt.Fprintf(output, "Deleting %d blobs which are not in the backup from bucket %s:\n", len(keys), bucketName)
+	}
+
+	for _, key := range keys {
+		fmt.Fprintf(output, "  %s\n", key)
+	}
+}
@@ -0,0 +1,85 @@
+package mirror_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"s3-blobstore-backup-restore/mirror"
+)
+
+var _ = Describe("Config", func() {
+	Describe("KeysToDelete", func() {
+		It("returns the live keys which are not backed up, sorted", func() {
+			keys, err := mirror.Config{Enabled: true}.KeysToDelete(
+				"live-bucket",
+				[]string{"c", "a", "b", "d"},
+				[]string{"b", "d", "e"},
+			)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(Equal([]string{"a", "c"}))
+		})
+
+		It("fails when there are more keys than max_deletions", func() {
+			_, err := mirror.Config{Enabled: true, MaxDeletions: 1}.KeysToDelete(
+				"live-bucket",
+				[]string{"a", "b", "c"},
+				[]string{"c"},
+			)
+
+			Expect(err).To(MatchError("refusing to delete 2 blobs which are not in the backup from bucket live-bucket: more than delete_extraneous.max_deletions (1)"))
+		})
+
+		It("allows DefaultMaxDeletions keys when max_deletions is not set", func() {
+			var liveKeys []string
+			for i := 0; i <= mirror.DefaultMaxDeletions; i++ {
+				liveKeys = append(liveKeys, fmt.Sprintf("key%d", i))
+			}
+
+			_, err := mirror.Config{Enabled: true}.KeysToDelete("live-bucket", liveKeys[1:], nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = mirror.Config{Enabled: true}.KeysToDelete("live-bucket", liveKeys, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Report", func() {
+		It("reports the keys deleted", func() {
+			output := gbytes.NewBuffer()
+
+			mirror.Config{Enabled: true}.Report(output, "live-bucket", []string{"a", "c"})
+
+			Expect(string(output.Contents())).To(Equal("Deleting 2 blobs which are not in the backup from bucket live-bucket:\n  a\n  c\n"))
+		})
+
+		It("reports the keys which would be deleted in a dry run", func() {
+			output := gbytes.NewBuffer()
+
+			mirror.Config{Enabled: true, DryRun: true}.Report(output, "live-bucket", []string{"a"})
+
+			Expect(string(output.Contents())).To(Equal("Dry run: would delete 1 blobs which are not in the backup from bucket live-bucket:\n  a\n"))
+		})
+
+		It("reports nothing when there are no keys", func() {
+			output := gbytes.NewBuffer()
+
+			mirror.Config{Enabled: true}.Report(output, "live-bucket", nil)
+
+			Expect(output.Contents()).To(BeEmpty())
+		})
+	})
+
+	Describe("Validate", func() {
+		It("rejects a negative max_deletions", func() {
+			Expect(mirror.Config{MaxDeletions: -1}.Validate()).To(MatchError("delete_extraneous.max_deletions must not be negative"))
+		})
+
+		It("accepts the zero config", func() {
+			Expect(mirror.Config{}.Validate()).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package s3bucket
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// AccessConfig is the endpoint and credentials of a bucket which is reached
+// differently from the bucket it is configured with, such as a backup bucket
+// in another AWS account. What it does not set is the same as for the other
+// bucket.
+type AccessConfig struct {
+	Endpoint string `json:"endpoint,omitempty"`
+	// ForcePathStyle only applies when Endpoint is set.
+	ForcePathStyle            bool   `json:"force_path_style,omitempty"`
+	AwsAccessKeyId            string `json:"aws_access_key_id,omitempty"`
+	AwsSecretAccessKey        string `json:"aws_secret_access_key,omitempty"`
+	AwsSessionToken           string `json:"aws_session_token,omitempty"`
+	AwsAssumedRoleArn         string `json:"aws_assumed_role_arn,omitempty"`
+	AwsAssumedRoleExternalId  string `json:"aws_assumed_role_external_id,omitempty"`
+	AwsAssumedRoleSessionName string `json:"aws_assumed_role_session_name,omitempty"`
+	AwsProfile                string `json:"aws_profile,omitempty"`
+	AwsWebIdentityTokenFile   string `json:"aws_web_identity_token_file,omitempty"`
+	UseContainerCredentials   bool   `json:"use_container_credentials,omitempty"`
+	UseIAMProfile             bool   `json:"use_iam_profile,omitempty"`
+}
+
+func (c AccessConfig) credentials() Credentials {
+	return Credentials{
+		AccessKey: AccessKey{
+			Id:           c.AwsAccessKeyId,
+			Secret:       c.AwsSecretAccessKey,
+			SessionToken: c.AwsSessionToken,
+		},
+		UseIAMProfile:           c.UseIAMProfile,
+		UseContainerCredentials: c.UseContainerCredentials,
+		Profile:                 c.AwsProfile,
+		WebIdentityTokenFile:    c.AwsWebIdentityTokenFile,
+		RoleARN:                 c.AwsAssumedRoleArn,
+		ExternalID:              c.AwsAssumedRoleExternalId,
+		RoleSessionName:         c.AwsAssumedRoleSessionName,
+	}
+}
+
+func (c AccessConfig) Validate() error {
+	return c.credentials().Validate()
+}
+
+// Inherit returns the endpoint, credentials and addressing style of the
+// bucket, taking those it does not set from the bucket it is configured with.
+// The credentials are only taken when it sets none of its own.
+func (c AccessConfig) Inherit(endpoint string, credentials Credentials, forcePathStyle bool) (string, Credentials, bool) {
+	if c.Endpoint != "" {
+		endpoint = c.Endpoint
+		forcePathStyle = c.ForcePathStyle
+	}
+
+	if ownCredentials := c.credentials(); ownCredentials != (Credentials{}) {
+		credentials = ownCredentials
+	}
+
+	return endpoint, credentials, forcePathStyle
+}
+
+// access is how the clients of a bucket reach S3.
+type access struct {
+	endpoint       string
+	credentials    Credentials
+	provider       aws.CredentialsProvider
+	forcePathStyle bool
+}
+
+func newAccess(regionName, endpoint string, credentials Credentials, forcePathStyle bool) (access, error) {
+	provider, err := credentials.provider(regionName, endpoint)
+	if err != nil {
+		return access{}, err
+	}
+
+	return access{
+		endpoint:       endpoint,
+		credentials:    credentials,
+		provider:       provider,
+		forcePathStyle: forcePathStyle,
+	}, nil
+}
+
+func (a access) client(regionName string, fns ...func(*s3.Options)) *s3.Client {
+	return newS3Client(regionName, a.endpoint, a.provider, a.forcePathStyle, fns...)
+}
+
+// failedCredentials fail every request with the error of getting them, for
+// sources whose credentials are only got when the bucket's options are set.
+func failedCredentials(err error) aws.CredentialsProvider {
+	return aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{}, err
+	})
+}
+
+// origin is a bucket which blobs are copied from, and how it is reached.
+type origin struct {
+	name       string
+	region     string
+	access     access
+	encryption EncryptionConfig
+}
+
+// copyMethod is how a blob is copied from an origin.
+type copyMethod int
+
+const (
+	// serverSideCopy has S3 copy the blob, reading it with the credentials of
+	// the bucket copied to.
+	serverSideCopy copyMethod = iota
+	// serverSideCopyOrStream has S3 copy the blob, but streams it when S3
+	// denies access to the origin, as it does across AWS accounts unless the
+	// origin's bucket policy grants it.
+	serverSideCopyOrStream
+	// streamCopy reads the blob from the origin and writes it to the bucket,
+	// as S3 cannot copy between endpoints.
+	streamCopy
+)
+
+// copyMethodFrom returns how blobs are copied from an origin, streaming them
+// without trying S3 once it has denied the copies from the origin.
+func (b Bucket) copyMethodFrom(origin origin) copyMethod {
+	switch {
+	case origin.access.endpoint != b.access.endpoint:
+		return streamCopy
+	case origin.access.credentials == b.access.credentials:
+		return serverSideCopy
+	case b.deniedOrigins != nil:
+		if _, denied := b.deniedOrigins.Load(origin.name); denied {
+			return streamCopy
+		}
+	}
+	return serverSideCopyOrStream
+}
+
+func (b Bucket) recordDeniedOrigin(origin origin) {
+	if b.deniedOrigins != nil {
+		b.deniedOrigins.Store(origin.name, true)
+	}
+}
+
+// deniedOrigins are the names of the origins S3 has denied copies from, shared
+// by the copies of a bucket.
+type deniedOrigins = sync.Map
+
+// accessDeniedError is returned by a server side copy which S3 denied.
+type accessDeniedError struct {
+	message string
+}
+
+func (e accessDeniedError) Error() string {
+	return e.message
+}
+
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "AccessDenied"
+}
@@ -0,0 +1,285 @@
+package s3bucket_test
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"s3-blobstore-backup-restore/s3bucket"
+)
+
+var _ = Describe("Copying from a bucket reached differently", func() {
+	var sourceS3, destinationS3 *fakeS3Server
+	var sourceCredentials s3bucket.Credentials
+	var options s3bucket.Options
+	var sourceBucket, destinationBucket s3bucket.Bucket
+	var err error
+
+	BeforeEach(func() {
+		sourceS3 = newFakeS3Server(23 * mebibyte)
+		sourceS3.SetBlobAttributes(http.Header{
+			"Content-Type":     {"application/gzip"},
+			"X-Amz-Meta-Owner": {"the-team"},
+		}, map[string]string{"kind": "droplet"})
+		destinationS3 = sourceS3
+		sourceCredentials = s3bucket.Credentials{AccessKey: s3bucket.AccessKey{Id: "source-id", Secret: "source-secret"}}
+		options = s3bucket.Options{Multipart: s3bucket.MultipartConfig{ThresholdMB: 100, PartSizeMB: 100}}
+	})
+
+	AfterEach(func() {
+		sourceS3.Close()
+		destinationS3.Close()
+	})
+
+	JustBeforeEach(func() {
+		var newBucketErr error
+		sourceBucket, newBucketErr = s3bucket.NewBucketWithCredentials("source", "us-east-1", sourceS3.URL, sourceCredentials, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+
+		destinationBucket, newBucketErr = s3bucket.NewBucket("destination", "us-east-1", destinationS3.URL, s3bucket.AccessKey{Id: "destination-id", Secret: "destination-secret"}, false, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+		destinationBucket = destinationBucket.WithOptions(options)
+
+		err = destinationBucket.CopyBlobFromBucket(sourceBucket, "a-blob", "a-copy")
+	})
+
+	Context("when the source bucket is on another endpoint", func() {
+		BeforeEach(func() {
+			destinationS3 = newFakeS3Server(23 * mebibyte)
+		})
+
+		It("reads the blob with the source credentials and writes it with the destination credentials", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sourceS3.Header("HeadObject").Get("Authorization")).To(ContainSubstring("Credential=source-id/"))
+			Expect(sourceS3.Header("GetObject").Get("Authorization")).To(ContainSubstring("Credential=source-id/"))
+			Expect(destinationS3.Header("PutObject").Get("Authorization")).To(ContainSubstring("Credential=destination-id/"))
+			Expect(destinationS3.Header("CopyObject")).To(BeNil())
+			Expect(destinationS3.Header("HeadObject")).To(BeNil())
+		})
+
+		It("streams the blob with its metadata and tags", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sourceS3.GetRanges()).To(Equal([]string{""}))
+			Expect(destinationS3.PutBody()).To(Equal(blobContents(23 * mebibyte)))
+
+			header := destinationS3.Header("PutObject")
+			Expect(header.Get("Content-Type")).To(Equal("application/gzip"))
+			Expect(header.Get("X-Amz-Meta-Owner")).To(Equal("the-team"))
+			Expect(header.Get("X-Amz-Tagging")).To(Equal("kind=droplet"))
+		})
+
+		Context("and the blob is larger than a part", func() {
+			BeforeEach(func() {
+				options.Multipart = s3bucket.MultipartConfig{ThresholdMB: 10, PartSizeMB: 5}
+			})
+
+			It("streams it in parts", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sourceS3.GetRanges()).To(ConsistOf(
+					"bytes=0-5242879",
+					"bytes=5242880-10485759",
+					"bytes=10485760-15728639",
+					"bytes=15728640-20971519",
+					"bytes=20971520-24117247",
+				))
+				Expect(destinationS3.UploadedPart(5)).To(Equal(blobContents(23 * mebibyte)[20971520:]))
+				Expect(destinationS3.Header("CreateMultipartUpload").Get("X-Amz-Tagging")).To(Equal("kind=droplet"))
+
+				for partNumber := 1; partNumber <= 5; partNumber++ {
+					Expect(destinationS3.CompleteBody()).To(ContainSubstring(`<ETag>&#34;uploaded-etag-%d&#34;</ETag><PartNumber>%d</PartNumber>`, partNumber, partNumber))
+				}
+			})
+
+			Context("and the parts would take more memory than streamed copies may hold", func() {
+				var restoreMaxStreamedBytes func()
+
+				BeforeEach(func() {
+					restoreMaxStreamedBytes = s3bucket.SetMaxStreamedBytes(10 * mebibyte)
+					destinationS3.partDelay = 50 * time.Millisecond
+				})
+
+				AfterEach(func() {
+					restoreMaxStreamedBytes()
+				})
+
+				It("only streams as many parts at once as fit", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(destinationS3.MaxInFlight()).To(Equal(2))
+					Expect(destinationS3.UploadedPart(5)).To(Equal(blobContents(23 * mebibyte)[20971520:]))
+				})
+			})
+
+			Context("and a part is larger than streamed copies may hold", func() {
+				var restoreMaxStreamedBytes func()
+
+				BeforeEach(func() {
+					restoreMaxStreamedBytes = s3bucket.SetMaxStreamedBytes(mebibyte)
+					destinationS3.partDelay = 10 * time.Millisecond
+				})
+
+				AfterEach(func() {
+					restoreMaxStreamedBytes()
+				})
+
+				It("streams the parts one at a time", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(destinationS3.MaxInFlight()).To(Equal(1))
+					Expect(destinationS3.CompleteBody()).To(ContainSubstring("uploaded-etag-5"))
+				})
+			})
+		})
+	})
+
+	Context("when the source bucket has other credentials on the same endpoint", func() {
+		It("has S3 copy the blob with the destination credentials", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sourceS3.Header("HeadObject").Get("Authorization")).To(ContainSubstring("Credential=source-id/"))
+			Expect(sourceS3.Header("CopyObject").Get("Authorization")).To(ContainSubstring("Credential=destination-id/"))
+			Expect(sourceS3.GetRanges()).To(BeEmpty())
+		})
+
+		Context("and S3 denies the copy", func() {
+			BeforeEach(func() {
+				sourceS3.DenyCopies()
+			})
+
+			It("streams the blob instead", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sourceS3.DeniedCopies()).To(Equal(1))
+				Expect(sourceS3.PutBody()).To(Equal(blobContents(23 * mebibyte)))
+				Expect(sourceS3.Header("GetObject").Get("Authorization")).To(ContainSubstring("Credential=source-id/"))
+			})
+
+			It("streams the following blobs from the bucket without trying S3", func() {
+				Expect(err).NotTo(HaveOccurred())
+
+				err = destinationBucket.CopyBlobFromBucket(sourceBucket, "another-blob", "another-copy")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sourceS3.DeniedCopies()).To(Equal(1))
+				Expect(sourceS3.GetRanges()).To(HaveLen(2))
+			})
+
+			Context("and the blob is copied in parts", func() {
+				BeforeEach(func() {
+					options.Multipart = s3bucket.MultipartConfig{ThresholdMB: 10, PartSizeMB: 5}
+				})
+
+				It("aborts the copy without retrying the parts, and streams the blob in parts", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(sourceS3.Aborted()).To(BeTrue())
+					Expect(sourceS3.DeniedCopies()).To(Equal(5))
+					Expect(sourceS3.GetRanges()).To(HaveLen(5))
+					Expect(sourceS3.CompleteBody()).To(ContainSubstring("uploaded-etag-5"))
+				})
+			})
+		})
+	})
+
+	Context("when the source bucket has the same credentials and S3 denies the copy", func() {
+		BeforeEach(func() {
+			sourceCredentials = s3bucket.Credentials{AccessKey: s3bucket.AccessKey{Id: "destination-id", Secret: "destination-secret"}}
+			sourceS3.DenyCopies()
+		})
+
+		It("fails without streaming the blob", func() {
+			Expect(err).To(MatchError(ContainSubstring("AccessDenied")))
+			Expect(sourceS3.GetRanges()).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Copying versions from a source", func() {
+	var sourceS3, destinationS3 *fakeS3Server
+	var source *s3bucket.AccessConfig
+	var err error
+
+	BeforeEach(func() {
+		sourceS3 = newFakeS3Server(10)
+		destinationS3 = newFakeS3Server(10)
+		source = &s3bucket.AccessConfig{
+			Endpoint:           sourceS3.URL,
+			ForcePathStyle:     true,
+			AwsAccessKeyId:     "source-id",
+			AwsSecretAccessKey: "source-secret",
+		}
+	})
+
+	AfterEach(func() {
+		sourceS3.Close()
+		destinationS3.Close()
+	})
+
+	JustBeforeEach(func() {
+		bucket, newBucketErr := s3bucket.NewBucket("destination", "us-east-1", destinationS3.URL, s3bucket.AccessKey{Id: "destination-id", Secret: "destination-secret"}, false, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+
+		err = bucket.WithOptions(s3bucket.Options{Source: source}).CopyVersion("a-blob", "a-version", "source", "us-east-1")
+	})
+
+	It("reads the version from the source endpoint with the source credentials", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sourceS3.Header("HeadObject").Get("Authorization")).To(ContainSubstring("Credential=source-id/"))
+		Expect(sourceS3.Header("GetObject").Get("Authorization")).To(ContainSubstring("Credential=source-id/"))
+		Expect(destinationS3.Header("PutObject").Get("Authorization")).To(ContainSubstring("Credential=destination-id/"))
+		Expect(destinationS3.PutBody()).To(Equal(blobContents(10)))
+	})
+
+	Context("when the source only sets credentials", func() {
+		BeforeEach(func() {
+			source.Endpoint = ""
+			sourceS3.Close()
+			sourceS3 = destinationS3
+		})
+
+		It("heads the version with the source credentials on the bucket's endpoint", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(destinationS3.Header("HeadObject").Get("Authorization")).To(ContainSubstring("Credential=source-id/"))
+			Expect(destinationS3.Header("CopyObject").Get("Authorization")).To(ContainSubstring("Credential=destination-id/"))
+		})
+	})
+
+	Context("when the source credentials cannot be got", func() {
+		BeforeEach(func() {
+			source = &s3bucket.AccessConfig{UseContainerCredentials: true}
+			GinkgoT().Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+			GinkgoT().Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+		})
+
+		It("fails to copy the version", func() {
+			Expect(err).To(MatchError(ContainSubstring("failed to get credentials for the source of bucket destination: container credentials require")))
+		})
+	})
+})
+
+var _ = Describe("AccessConfig", func() {
+	credentials := s3bucket.Credentials{AccessKey: s3bucket.AccessKey{Id: "live-id", Secret: "live-secret"}}
+
+	It("uses the endpoint and credentials of the other bucket when it sets none", func() {
+		endpoint, inherited, forcePathStyle := s3bucket.AccessConfig{}.Inherit("https://live.example.com", credentials, true)
+
+		Expect(endpoint).To(Equal("https://live.example.com"))
+		Expect(inherited).To(Equal(credentials))
+		Expect(forcePathStyle).To(BeTrue())
+	})
+
+	It("uses its own endpoint with its own addressing style", func() {
+		endpoint, _, forcePathStyle := s3bucket.AccessConfig{Endpoint: "https://backup.example.com"}.Inherit("https://live.example.com", credentials, true)
+
+		Expect(endpoint).To(Equal("https://backup.example.com"))
+		Expect(forcePathStyle).To(BeFalse())
+	})
+
+	It("uses its own credentials without any of the other bucket's", func() {
+		_, inherited, _ := s3bucket.AccessConfig{AwsAssumedRoleArn: "a-role-arn", UseIAMProfile: true}.Inherit("", credentials, false)
+
+		Expect(inherited).To(Equal(s3bucket.Credentials{RoleARN: "a-role-arn", UseIAMProfile: true}))
+	})
+
+	It("validates its credentials", func() {
+		err := s3bucket.Options{Source: &s3bucket.AccessConfig{AwsProfile: "a-profile", UseIAMProfile: true}}.Validate()
+
+		Expect(err).To(MatchError("restore_source: only one of aws_access_key_id, use_iam_profile, use_container_credentials, aws_profile and aws_web_identity_token_file can be set"))
+	})
+})
@@ -1,7 +1,12 @@
 package s3bucket
 
+import "time"
+
 type Blob struct {
-	path string
+	path         string
+	etag         string
+	size         int64
+	lastModified time.Time
 }
 
 func NewBlob(path string) Blob {
@@ -10,6 +15,28 @@ func NewBlob(path string) Blob {
 	}
 }
 
+// NewBlobWithAttributes returns a blob with the attributes S3 lists for it.
+func NewBlobWithAttributes(path, etag string, size int64, lastModified time.Time) Blob {
+	return Blob{
+		path:         path,
+		etag:         etag,
+		size:         size,
+		lastModified: lastModified,
+	}
+}
+
 func (b Blob) Path() string {
 	return b.path
 }
+
+func (b Blob) ETag() string {
+	return b.etag
+}
+
+func (b Blob) Size() int64 {
+	return b.size
+}
+
+func (b Blob) LastModified() time.Time {
+	return b.lastModified
+}
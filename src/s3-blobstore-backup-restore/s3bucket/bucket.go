@@ -4,63 +4,55 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
-	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	awsretry "github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
-	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"common/executor"
 
 	"s3-blobstore-backup-restore/blobpath"
 	"s3-blobstore-backup-restore/incremental"
 )
 
-const partSize int64 = 100 * 1024 * 1024
-
 type Bucket struct {
-	name           string
-	regionName     string
-	accessKey      AccessKey
-	endpoint       string
-	s3Client       *s3.Client
-	useIAMProfile  bool
-	forcePathStyle bool
-	assumedRoleARN string
-	clientOptFns   []func(*s3.Options)
+	name         string
+	regionName   string
+	s3Client     *s3.Client
+	access       access
+	clientOptFns []func(*s3.Options)
+	options      Options
+	optionsOptFn func(*s3.Options)
+	// sourceAccess is how the buckets CopyVersion copies from are reached,
+	// when the options set a source.
+	sourceAccess  *access
+	deniedOrigins *deniedOrigins
 }
 
 type AccessKey struct {
 	Id     string
 	Secret string
+	// SessionToken is only set for temporary credentials.
+	SessionToken string
 }
 
 type Version struct {
 	Key      string
 	Id       string `json:"VersionId"`
 	IsLatest bool
+	// LastModified and IsDeleteMarker are only set by ListVersionHistory.
+	LastModified   time.Time
+	IsDeleteMarker bool
 }
 
 func NewBucket(bucketName, bucketRegion, endpoint string, accessKey AccessKey, useIAMProfile, forcePathStyle bool, clientOptFns ...func(*s3.Options)) (Bucket, error) {
-	s3Client, err := newS3Client(bucketRegion, endpoint, accessKey, useIAMProfile, forcePathStyle, clientOptFns...)
-	if err != nil {
-		return Bucket{}, err
-	}
-
-	return Bucket{
-		name:           bucketName,
-		regionName:     bucketRegion,
-		s3Client:       s3Client,
-		accessKey:      accessKey,
-		endpoint:       endpoint,
-		useIAMProfile:  useIAMProfile,
-		forcePathStyle: forcePathStyle,
-		clientOptFns:   clientOptFns,
-	}, nil
+	return NewBucketWithCredentials(bucketName, bucketRegion, endpoint, Credentials{
+		AccessKey:     accessKey,
+		UseIAMProfile: useIAMProfile,
+	}, forcePathStyle, clientOptFns...)
 }
 
 // NewBucketWithRoleARN
@@ -69,24 +61,56 @@ func NewBucket(bucketName, bucketRegion, endpoint string, accessKey AccessKey, u
 //
 // Utilising the assumed role is a highly experimental functionality and is provided as is. Use at your own risk.
 func NewBucketWithRoleARN(bucketName, bucketRegion, endpoint, roleARN string, accessKey AccessKey, useIAMProfile, forcePathStyle bool, clientOptFns ...func(*s3.Options)) (Bucket, error) {
-	s3Client, err := newS3ClientWithAssumedRole(bucketRegion, endpoint, accessKey, useIAMProfile, forcePathStyle, roleARN, clientOptFns...)
+	return NewBucketWithCredentials(bucketName, bucketRegion, endpoint, Credentials{
+		AccessKey:     accessKey,
+		UseIAMProfile: useIAMProfile,
+		RoleARN:       roleARN,
+	}, forcePathStyle, clientOptFns...)
+}
+
+func NewBucketWithCredentials(bucketName, bucketRegion, endpoint string, credentials Credentials, forcePathStyle bool, clientOptFns ...func(*s3.Options)) (Bucket, error) {
+	bucketAccess, err := newAccess(bucketRegion, endpoint, credentials, forcePathStyle)
 	if err != nil {
-		return Bucket{}, err
+		return Bucket{}, fmt.Errorf("failed to get credentials for bucket %s: %s", bucketName, err)
 	}
 
 	return Bucket{
-		name:           bucketName,
-		regionName:     bucketRegion,
-		s3Client:       s3Client,
-		accessKey:      accessKey,
-		endpoint:       endpoint,
-		useIAMProfile:  useIAMProfile,
-		forcePathStyle: forcePathStyle,
-		assumedRoleARN: roleARN,
-		clientOptFns:   clientOptFns,
+		name:          bucketName,
+		regionName:    bucketRegion,
+		s3Client:      bucketAccess.client(bucketRegion, clientOptFns...),
+		access:        bucketAccess,
+		clientOptFns:  clientOptFns,
+		deniedOrigins: &deniedOrigins{},
 	}, nil
 }
 
+// WithOptions returns a copy of the bucket which uses options for the
+// requests it makes and when copying blobs to it.
+func (b Bucket) WithOptions(options Options) Bucket {
+	b.options = options
+	b.optionsOptFn = options.clientOptFn()
+	b.s3Client = s3.New(b.s3Client.Options(), b.optionsOptFn)
+
+	b.sourceAccess = nil
+	if options.Source != nil {
+		endpoint, credentials, forcePathStyle := options.Source.Inherit(b.access.endpoint, b.access.credentials, b.access.forcePathStyle)
+		sourceAccess, err := newAccess(b.regionName, endpoint, credentials, forcePathStyle)
+		if err != nil {
+			// The source is only read when versions are copied, so the
+			// error is returned then.
+			sourceAccess = access{
+				endpoint:       endpoint,
+				credentials:    credentials,
+				provider:       failedCredentials(fmt.Errorf("failed to get credentials for the source of bucket %s: %s", b.name, err)),
+				forcePathStyle: forcePathStyle,
+			}
+		}
+		b.sourceAccess = &sourceAccess
+	}
+
+	return b
+}
+
 func (b Bucket) Name() string {
 	return b.name
 }
@@ -95,12 +119,19 @@ func (b Bucket) Region() string {
 	return b.regionName
 }
 
-func (b Bucket) listFiles(subfolder string) ([]string, error) {
-	var files []string
+func (b Bucket) ListBlobs(prefix string) ([]incremental.Blob, error) {
+	var blobs []incremental.Blob
+
+	// The prefix is listed as a directory, so that the blobs of a directory
+	// whose name starts with the same prefix are not listed with them.
+	listPrefix := prefix
+	if prefix != "" {
+		listPrefix = prefix + blobpath.Delimiter
+	}
 
 	params := &s3.ListObjectsV2Input{
 		Bucket: aws.String(b.name),
-		Prefix: aws.String(subfolder),
+		Prefix: aws.String(listPrefix),
 	}
 
 	paginator := s3.NewListObjectsV2Paginator(b.s3Client, params)
@@ -109,32 +140,24 @@ func (b Bucket) listFiles(subfolder string) ([]string, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to list blobs from bucket %s: %s", b.name, err)
 		}
-		for _, file := range output.Contents {
-			files = append(files, *file.Key)
-		}
-	}
-
-	if subfolder != "" {
-		for index, fileLocation := range files {
-			files[index] = strings.Replace(fileLocation, subfolder+blobpath.Delimiter, "", 1)
-		}
-	}
-
-	return files, nil
-}
+		for _, object := range output.Contents {
+			path := strings.TrimPrefix(*object.Key, listPrefix)
 
-func (b Bucket) ListBlobs(prefix string) ([]incremental.Blob, error) {
-	paths, err := b.listFiles(prefix)
-	if err != nil {
-		return nil, err
-	}
+			etag := aws.ToString(object.ETag)
+			if !b.options.Encryption.etagIsDigest() {
+				etag = ""
+			}
 
-	var blobs []incremental.Blob
-	for _, path := range paths {
-		blobs = append(blobs, NewBlob(path))
+			blobs = append(blobs, NewBlobWithAttributes(
+				path,
+				etag,
+				aws.ToInt64(object.Size),
+				aws.ToTime(object.LastModified),
+			))
+		}
 	}
 
-	return blobs, err
+	return blobs, nil
 }
 
 func (b Bucket) ListDirectories() ([]string, error) {
@@ -195,6 +218,55 @@ func (b Bucket) ListVersions() ([]Version, error) {
 	return versions, nil
 }
 
+// ListVersionHistory returns every version and delete marker in the bucket,
+// with the time each was created. The versions come before the delete
+// markers of each page, as S3 lists them apart.
+func (b Bucket) ListVersionHistory() ([]Version, error) {
+	isVersioned, err := b.IsVersioned()
+	if err != nil {
+		return nil, err
+	}
+
+	if !isVersioned {
+		return nil, fmt.Errorf("bucket %s is not versioned", b.Name())
+	}
+
+	var versions []Version
+
+	paginator := s3.NewListObjectVersionsPaginator(b.s3Client, &s3.ListObjectVersionsInput{
+		Bucket:  aws.String(b.name),
+		MaxKeys: aws.Int32(1000),
+	})
+
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve version history from bucket %s: %s", b.name, err)
+		}
+
+		for _, v := range output.Versions {
+			versions = append(versions, Version{
+				Key:          aws.ToString(v.Key),
+				Id:           aws.ToString(v.VersionId),
+				IsLatest:     aws.ToBool(v.IsLatest),
+				LastModified: aws.ToTime(v.LastModified),
+			})
+		}
+
+		for _, m := range output.DeleteMarkers {
+			versions = append(versions, Version{
+				Key:            aws.ToString(m.Key),
+				Id:             aws.ToString(m.VersionId),
+				IsLatest:       aws.ToBool(m.IsLatest),
+				LastModified:   aws.ToTime(m.LastModified),
+				IsDeleteMarker: true,
+			})
+		}
+	}
+
+	return versions, nil
+}
+
 func (b Bucket) IsVersioned() (bool, error) {
 	output, err := b.s3Client.GetBucketVersioning(context.TODO(), &s3.GetBucketVersioningInput{
 		Bucket: &b.name,
@@ -212,20 +284,35 @@ func (b Bucket) IsVersioned() (bool, error) {
 }
 
 func (b Bucket) CopyBlobWithinBucket(src, dst string) error {
-	return b.copyVersion(src, "null", dst, b.name, b.regionName)
+	return b.copyVersion(src, "null", dst, origin{
+		name:       b.name,
+		region:     b.regionName,
+		access:     b.access,
+		encryption: b.options.Encryption,
+	})
 }
 
+// CopyBlobFromBucket copies a blob from the source bucket, which is read with
+// its own credentials, streaming it when S3 cannot copy it.
 func (b Bucket) CopyBlobFromBucket(sourceBucket incremental.Bucket, src, dst string) error {
 	srcBucket := sourceBucket.(Bucket)
-	return b.copyVersion(src, "null", dst, srcBucket.name, srcBucket.regionName)
+	return b.copyVersion(src, "null", dst, origin{
+		name:       srcBucket.name,
+		region:     srcBucket.regionName,
+		access:     srcBucket.access,
+		encryption: srcBucket.options.Encryption,
+	})
 }
 
 func (b Bucket) UploadBlob(key, contents string) error {
-	_, err := b.s3Client.PutObject(context.TODO(), &s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket: aws.String(b.name),
 		Key:    aws.String(key),
 		Body:   strings.NewReader(contents),
-	})
+	}
+	b.options.Encryption.applyToPutObject(input)
+
+	_, err := b.s3Client.PutObject(context.TODO(), input)
 	if err != nil {
 		return fmt.Errorf("failed to upload blob '%s': %s", key, err)
 	}
@@ -234,10 +321,13 @@ func (b Bucket) UploadBlob(key, contents string) error {
 }
 
 func (b Bucket) HasBlob(key string) (bool, error) {
-	_, err := b.s3Client.GetObject(context.TODO(), &s3.GetObjectInput{
+	input := &s3.GetObjectInput{
 		Bucket: aws.String(b.name),
 		Key:    aws.String(key),
-	})
+	}
+	b.options.Encryption.applyToGetObject(input)
+
+	_, err := b.s3Client.GetObject(context.TODO(), input)
 	if err != nil {
 		var nsk *types.NoSuchKey
 		if errors.As(err, &nsk) {
@@ -249,112 +339,214 @@ func (b Bucket) HasBlob(key string) (bool, error) {
 	return true, nil
 }
 
+func (b Bucket) DeleteBlob(key string) error {
+	_, err := b.s3Client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete blob '%s': %s", key, err)
+	}
+
+	return nil
+}
+
+// CopyVersion copies a version of a blob from the origin bucket, which is
+// read with the customer key of this bucket when it uses sse-c, and reached
+// as the source in the options says when they set one.
 func (b Bucket) CopyVersion(blobKey, versionID, originBucketName, originBucketRegion string) error {
+	originAccess := b.access
+	if b.sourceAccess != nil {
+		originAccess = *b.sourceAccess
+	}
+
 	return b.copyVersion(
 		blobKey,
 		versionID,
 		blobKey,
-		originBucketName,
-		originBucketRegion,
+		origin{
+			name:       originBucketName,
+			region:     originBucketRegion,
+			access:     originAccess,
+			encryption: b.options.Encryption,
+		},
 	)
 }
 
-func (b Bucket) copyVersion(blobKey, versionID, destinationKey, originBucketName, originBucketRegion string) error {
-	blobSize, err := b.getBlobSize(originBucketName, originBucketRegion, blobKey, versionID)
+func (b Bucket) copyVersion(blobKey, versionID, destinationKey string, origin origin) error {
+	source, err := b.headSourceBlob(origin, blobKey, versionID)
 	if err != nil {
 		return err
 	}
 
-	copySource := blobpath.Delimiter + originBucketName + blobpath.Delimiter + blobKey
-	if versionID != "null" {
-		copySource = copySource + "?versionId=" + versionID
+	switch method := b.copyMethodFrom(origin); method {
+	case streamCopy:
+		err = b.streamVersion(source, destinationKey, origin.encryption)
+	default:
+		err = b.copyVersionServerSide(source, destinationKey, origin.encryption)
+
+		var deniedErr accessDeniedError
+		if method == serverSideCopyOrStream && errors.As(err, &deniedErr) {
+			b.recordDeniedOrigin(origin)
+			err = b.streamVersion(source, destinationKey, origin.encryption)
+		}
+	}
+	if err != nil {
+		return err
 	}
 
-	copySource = strings.Replace(copySource, blobpath.Delimiter+blobpath.Delimiter, blobpath.Delimiter, -1)
+	if b.options.Verification.Enabled {
+		err = b.verifyCopy(source, destinationKey)
+		if err != nil {
+			return err
+		}
+	}
 
-	if sizeInMbs(blobSize) <= 1024 {
-		return b.copyVersionWithSingleRequest(copySource, destinationKey)
-	} else {
-		return b.copyVersionWithMultipart(copySource, destinationKey, blobSize)
+	if b.options.CopyACL {
+		return b.copyACL(source, destinationKey)
 	}
+
+	return nil
 }
 
-func (b Bucket) getBlobSize(bucketName, bucketRegion, blobKey, versionID string) (int64, error) {
-	s3Client, err := newS3ClientWithAssumedRole(bucketRegion, b.endpoint, b.accessKey, b.useIAMProfile, b.forcePathStyle, b.assumedRoleARN, b.clientOptFns...)
+func (b Bucket) getBlobSize(origin origin, blobKey, versionID string) (int64, error) {
+	source, err := b.headSourceBlob(origin, blobKey, versionID)
 	if err != nil {
 		return 0, err
 	}
 
+	return source.size(), nil
+}
+
+// headSourceBlob heads a blob being copied with a client of the origin,
+// which the rest of the requests reading the blob share.
+func (b Bucket) headSourceBlob(origin origin, blobKey, versionID string) (sourceBlob, error) {
+	clientOptFns := b.clientOptFns
+	if b.optionsOptFn != nil {
+		clientOptFns = append(clientOptFns[:len(clientOptFns):len(clientOptFns)], b.optionsOptFn)
+	}
+
+	s3Client := origin.access.client(origin.region, clientOptFns...)
+
 	input := s3.HeadObjectInput{
-		Bucket: aws.String(bucketName),
+		Bucket: aws.String(origin.name),
 		Key:    aws.String(blobKey),
 	}
 	if versionID != "null" {
 		input.VersionId = &versionID
 	}
+	if b.options.Verification.Enabled {
+		input.ChecksumMode = types.ChecksumModeEnabled
+	}
+	origin.encryption.applyToHeadObject(&input)
 
 	headObjectOutput, err := s3Client.HeadObject(context.TODO(), &input)
 
 	if err != nil {
-		return 0, fmt.Errorf("failed to get blob size for blob '%s' in bucket '%s': %s", blobKey, bucketName, err)
+		return sourceBlob{}, fmt.Errorf("failed to get blob size for blob '%s' in bucket '%s': %s", blobKey, origin.name, err)
 	}
 
-	return *headObjectOutput.ContentLength, nil
+	return sourceBlob{
+		client:     s3Client,
+		bucketName: origin.name,
+		key:        blobKey,
+		versionID:  versionID,
+		head:       headObjectOutput,
+	}, nil
 }
 
-func sizeInMbs(sizeInBytes int64) int64 {
-	return sizeInBytes / (1024 * 1024)
+// copyVersionServerSide has S3 copy the blob, in parts when it is larger than
+// the multipart threshold. An accessDeniedError is returned when S3 denies
+// reading the blob with the credentials of this bucket.
+func (b Bucket) copyVersionServerSide(source sourceBlob, destinationKey string, sourceEncryption EncryptionConfig) error {
+	copySource := blobpath.Delimiter + source.bucketName + blobpath.Delimiter + source.key
+	if source.versionID != "null" {
+		copySource = copySource + "?versionId=" + source.versionID
+	}
+
+	copySource = strings.Replace(copySource, blobpath.Delimiter+blobpath.Delimiter, blobpath.Delimiter, -1)
+
+	if source.size() <= b.options.Multipart.threshold() {
+		return b.copyVersionWithSingleRequest(copySource, destinationKey, sourceEncryption)
+	}
+	return b.copyVersionWithMultipart(copySource, destinationKey, source, sourceEncryption)
 }
 
-func (b Bucket) copyVersionWithSingleRequest(copySourceString, destinationKey string) error {
-	_, err := b.s3Client.CopyObject(context.TODO(), &s3.CopyObjectInput{
+func (b Bucket) copyVersionWithSingleRequest(copySourceString, destinationKey string, sourceEncryption EncryptionConfig) error {
+	input := &s3.CopyObjectInput{
 		Bucket:     aws.String(b.name),
 		Key:        aws.String(destinationKey),
 		CopySource: aws.String(copySourceString),
-	})
+	}
+	if b.options.StorageClass != "" {
+		input.StorageClass = types.StorageClass(b.options.StorageClass)
+	}
+	b.options.Encryption.applyToCopyObject(input, sourceEncryption)
+	b.options.Verification.applyToCopyObject(input)
+
+	_, err := b.s3Client.CopyObject(context.TODO(), input)
+	if isAccessDenied(err) {
+		return accessDeniedError{message: err.Error()}
+	}
 	return err
 }
 
-func (b Bucket) copyVersionWithMultipart(copySourceString, destinationKey string, blobSize int64) error {
-	createOutput, err := b.s3Client.CreateMultipartUpload(context.TODO(),
-		&s3.CreateMultipartUploadInput{
-			Bucket: aws.String(b.Name()),
-			Key:    aws.String(destinationKey),
-		},
-	)
+func (b Bucket) copyVersionWithMultipart(copySourceString, destinationKey string, source sourceBlob, sourceEncryption EncryptionConfig) error {
+	return b.uploadInParts(source, destinationKey, func(part uploadPart) executor.Executable {
+		return copyPartExecutable{
+			uploadPart:       part,
+			copySource:       copySourceString,
+			sourceEncryption: sourceEncryption,
+		}
+	})
+}
 
+// uploadInParts makes a multipart upload of a blob with the metadata and tags
+// of the source blob, running the executable made for each part in parallel.
+// The upload is aborted when any part fails.
+func (b Bucket) uploadInParts(source sourceBlob, destinationKey string, newPartExecutable func(part uploadPart) executor.Executable) error {
+	tagging, err := source.tagging()
 	if err != nil {
-		return fmt.Errorf("failed to create multipart upload: %s", err)
+		return err
 	}
 
-	numParts := int32(math.Ceil(float64(blobSize) / float64(partSize)))
-	var parts []types.CompletedPart
-	var uploadErrors []error
-	var partNumber int32
+	createInput := &s3.CreateMultipartUploadInput{
+		Bucket:  aws.String(b.Name()),
+		Key:     aws.String(destinationKey),
+		Tagging: tagging,
+	}
+	source.applyMetadataToCreateMultipartUpload(createInput)
+	if b.options.StorageClass != "" {
+		createInput.StorageClass = types.StorageClass(b.options.StorageClass)
+	}
+	b.options.Encryption.applyToCreateMultipartUpload(createInput)
+	b.options.Verification.applyToCreateMultipartUpload(createInput)
 
-	for partNumber = 1; partNumber <= numParts; partNumber++ {
-		partStart := int64(partNumber-1) * partSize
-		partEnd := int64(math.Min(float64(int64(partNumber)*partSize-1), float64(blobSize-1)))
+	createOutput, err := b.s3Client.CreateMultipartUpload(context.TODO(), createInput)
 
-		copyPartOutput, err := b.s3Client.UploadPartCopy(context.TODO(), &s3.UploadPartCopyInput{
-			Bucket:          aws.String(b.Name()),
-			Key:             aws.String(destinationKey),
-			UploadId:        aws.String(*createOutput.UploadId),
-			CopySource:      aws.String(copySourceString),
-			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", partStart, partEnd)),
-			PartNumber:      aws.Int32(partNumber),
-		})
+	if err != nil {
+		return fmt.Errorf("failed to create multipart upload: %s", err)
+	}
 
-		if err != nil {
-			uploadErrors = append(uploadErrors, fmt.Errorf("failed to upload part with range: %d-%d: %s", partStart, partEnd, err))
-		} else {
-			parts = append(parts, types.CompletedPart{
-				PartNumber: aws.Int32(partNumber),
-				ETag:       copyPartOutput.CopyPartResult.ETag,
-			})
-		}
+	blobSize := source.size()
+	partSize := b.options.Multipart.partSizeFor(blobSize)
+	numParts := int32((blobSize + partSize - 1) / partSize)
+	parts := make([]types.CompletedPart, numParts)
+
+	var executables []executor.Executable
+	for partNumber := int32(1); partNumber <= numParts; partNumber++ {
+		executables = append(executables, newPartExecutable(uploadPart{
+			bucket:         b,
+			uploadID:       *createOutput.UploadId,
+			destinationKey: destinationKey,
+			partNumber:     partNumber,
+			partStart:      int64(partNumber-1) * partSize,
+			partEnd:        min(int64(partNumber)*partSize, blobSize) - 1,
+			parts:          parts,
+		}))
 	}
 
+	uploadErrors := executor.NewParallelExecutor(b.options.Multipart.maxPartsInFlight()).Run([][]executor.Executable{executables})
 	if len(uploadErrors) != 0 {
 		_, err := b.s3Client.AbortMultipartUpload(context.TODO(), &s3.AbortMultipartUploadInput{
 			Bucket:   aws.String(b.Name()),
@@ -366,21 +558,28 @@ func (b Bucket) copyVersionWithMultipart(copySourceString, destinationKey string
 			uploadErrors = append(uploadErrors, err)
 		}
 
-		return formatErrors("errors occurred in multipart upload", uploadErrors)
+		err = formatErrors("errors occurred in multipart upload", uploadErrors)
+		for _, uploadErr := range uploadErrors {
+			var deniedErr accessDeniedError
+			if errors.As(uploadErr, &deniedErr) {
+				return accessDeniedError{message: err.Error()}
+			}
+		}
+		return err
 	}
 
-	sort.Slice(parts, func(i, j int) bool {
-		return *parts[i].PartNumber < *parts[j].PartNumber
-	})
-
-	_, err = b.s3Client.CompleteMultipartUpload(context.TODO(), &s3.CompleteMultipartUploadInput{
+	completeInput := &s3.CompleteMultipartUploadInput{
 		Bucket:   aws.String(b.Name()),
 		Key:      aws.String(destinationKey),
 		UploadId: createOutput.UploadId,
 		MultipartUpload: &types.CompletedMultipartUpload{
 			Parts: parts,
 		},
-	})
+	}
+	b.options.Encryption.applyToCompleteMultipartUpload(completeInput)
+	b.options.Verification.applyToCompleteMultipartUpload(completeInput)
+
+	_, err = b.s3Client.CompleteMultipartUpload(context.TODO(), completeInput)
 
 	if err != nil {
 		return fmt.Errorf("failed to complete multipart upload: %s", err)
@@ -389,41 +588,94 @@ func (b Bucket) copyVersionWithMultipart(copySourceString, destinationKey string
 	return nil
 }
 
-func formatErrors(contextString string, errors []error) error {
-	errorStrings := make([]string, len(errors))
-	for i, err := range errors {
-		errorStrings[i] = err.Error()
-	}
-	return fmt.Errorf("%s: %s", contextString, strings.Join(errorStrings, "\n"))
+// uploadPart is one part of a multipart upload, which is recorded in parts
+// once it is uploaded.
+type uploadPart struct {
+	bucket         Bucket
+	uploadID       string
+	destinationKey string
+	partNumber     int32
+	partStart      int64
+	partEnd        int64
+	parts          []types.CompletedPart
 }
 
-func newS3Client(regionName, endpoint string, accessKey AccessKey, useIAMProfile, forcePathStyle bool, fns ...func(*s3.Options)) (*s3.Client, error) {
-	return newS3ClientWithAssumedRole(regionName, endpoint, accessKey, useIAMProfile, forcePathStyle, "", fns...)
+// attempt uploads the part, retrying it before giving up unless S3 denies
+// access, which an accessDeniedError is returned for. Errors which the
+// client's retryer has already retried as often as the bucket's retry policy
+// allows are not retried again, so that the part is only retried for errors
+// the retryer does not retry, such as a failed read of a streamed part.
+func (p uploadPart) attempt(upload func() (types.CompletedPart, error)) error {
+	var err error
+	for attempt := 1; attempt <= partAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Duration(attempt-1) * partRetryDelay)
+		}
+
+		var part types.CompletedPart
+		part, err = upload()
+		if err == nil {
+			p.parts[p.partNumber-1] = part
+			return nil
+		}
+
+		if isAccessDenied(err) {
+			return accessDeniedError{message: fmt.Sprintf("failed to upload part with range: %d-%d: %s", p.partStart, p.partEnd, err)}
+		}
+
+		if retriedByClient(err) {
+			return fmt.Errorf("failed to upload part with range: %d-%d: %s", p.partStart, p.partEnd, err)
+		}
+	}
+
+	return fmt.Errorf("failed to upload part with range: %d-%d after %d attempts: %s", p.partStart, p.partEnd, partAttempts, err)
 }
 
-func newS3ClientWithAssumedRole(regionName, endpoint string, accessKey AccessKey, useIAMProfile, forcePathStyle bool, role string, fns ...func(*s3.Options)) (*s3.Client, error) {
-	staticCredentialsProvider := aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(accessKey.Id, accessKey.Secret, ""))
+// retriedByClient is whether the error is returned after the client's
+// retryer made every attempt the retry policy allows.
+func retriedByClient(err error) bool {
+	var maxAttemptsErr *awsretry.MaxAttemptsError
+	return errors.As(err, &maxAttemptsErr)
+}
 
-	var creds aws.CredentialsProvider
+// copyPartExecutable has S3 copy one part of a multipart copy.
+type copyPartExecutable struct {
+	uploadPart
+	copySource       string
+	sourceEncryption EncryptionConfig
+}
 
-	if role != "" {
-		stsOptions := sts.Options{
-			Credentials: staticCredentialsProvider,
-			Region:      regionName,
+func (e copyPartExecutable) Execute() error {
+	return e.attempt(func() (types.CompletedPart, error) {
+		input := &s3.UploadPartCopyInput{
+			Bucket:          aws.String(e.bucket.Name()),
+			Key:             aws.String(e.destinationKey),
+			UploadId:        aws.String(e.uploadID),
+			CopySource:      aws.String(e.copySource),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", e.partStart, e.partEnd)),
+			PartNumber:      aws.Int32(e.partNumber),
 		}
-		if endpoint != "" {
-			stsOptions.EndpointResolver = sts.EndpointResolverFromURL(endpoint)
+		e.bucket.options.Encryption.applyToUploadPartCopy(input, e.sourceEncryption)
+
+		copyPartOutput, err := e.bucket.s3Client.UploadPartCopy(context.TODO(), input)
+		if err != nil {
+			return types.CompletedPart{}, err
 		}
-		stsClient := sts.New(stsOptions)
-		creds = stscreds.NewAssumeRoleProvider(stsClient, role)
-	} else if useIAMProfile {
-		creds = aws.NewCredentialsCache(ec2rolecreds.New())
-	} else {
-		creds = staticCredentialsProvider
+		return completedPart(e.partNumber, copyPartOutput.CopyPartResult), nil
+	})
+}
+
+func formatErrors(contextString string, errors []error) error {
+	errorStrings := make([]string, len(errors))
+	for i, err := range errors {
+		errorStrings[i] = err.Error()
 	}
+	return fmt.Errorf("%s: %s", contextString, strings.Join(errorStrings, "\n"))
+}
 
+func newS3Client(regionName, endpoint string, credentials aws.CredentialsProvider, forcePathStyle bool, fns ...func(*s3.Options)) *s3.Client {
 	options := s3.Options{
-		Credentials:  creds,
+		Credentials:  credentials,
 		Region:       regionName,
 		UsePathStyle: forcePathStyle,
 	}
@@ -432,7 +684,5 @@ func newS3ClientWithAssumedRole(regionName, endpoint string, accessKey AccessKey
 		options.EndpointResolver = s3.EndpointResolverFromURL(endpoint)
 	}
 
-	client := s3.New(options, fns...)
-
-	return client, nil
+	return s3.New(options, fns...)
 }
@@ -50,7 +50,8 @@ var _ = Describe("Creating an S3 Client", func() {
 			_, err := s3bucket.NewBucketWithRoleARN("", "", "", "someRole", s3bucket.AccessKey{Id: "C0FFEEC0C0ABEAD", Secret: "DEAFD011"}, false, false, spy)
 
 			Expect(err).NotTo(HaveOccurred())
-			Expect(creds).To(BeAssignableToTypeOf(&stscreds.AssumeRoleProvider{}))
+			Expect(creds).To(BeAssignableToTypeOf(&aws.CredentialsCache{}))
+			Expect(creds.(*aws.CredentialsCache).IsCredentialsProvider(&stscreds.AssumeRoleProvider{})).To(BeTrue())
 		})
 	})
 
@@ -102,3 +103,38 @@ var _ = Describe("Creating an S3 Client", func() {
 		})
 	})
 })
+
+var _ = Describe("Listing the blobs in a directory", func() {
+	var fakeS3 *fakeS3Server
+	var bucket s3bucket.Bucket
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(10)
+		fakeS3.SetKeys(
+			"2020_01_01_00_00_00/droplets/ab/blob1",
+			"2020_01_01_00_00_00/droplets-legacy/cd/blob2",
+		)
+
+		var err error
+		bucket, err = s3bucket.NewBucket("backup", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+	})
+
+	It("leaves out the blobs of directories whose names start with the same prefix", func() {
+		blobs, err := bucket.ListBlobs("2020_01_01_00_00_00/droplets")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blobs).To(HaveLen(1))
+		Expect(blobs[0].Path()).To(Equal("ab/blob1"))
+	})
+
+	It("lists every blob when the directory is empty", func() {
+		blobs, err := bucket.ListBlobs("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blobs).To(HaveLen(2))
+		Expect(blobs[1].Path()).To(Equal("2020_01_01_00_00_00/droplets-legacy/cd/blob2"))
+	})
+})
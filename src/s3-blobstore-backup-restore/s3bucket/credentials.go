@@ -0,0 +1,182 @@
+package s3bucket
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// containerCredentialsHost is the ECS container credentials endpoint, which
+// AWS_CONTAINER_CREDENTIALS_RELATIVE_URI is a path on.
+const containerCredentialsHost = "http://169.254.170.2"
+
+// Credentials are how a bucket's client authenticates. Its base credentials
+// come from at most one of a web identity token, a shared config profile, the
+// container credentials endpoint, the EC2 instance profile or the access key,
+// which is used when none of the others is set. When RoleARN is set the client
+// assumes the role with the base credentials.
+type Credentials struct {
+	AccessKey     AccessKey
+	UseIAMProfile bool
+	// UseContainerCredentials gets the base credentials from the endpoint
+	// named by AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or
+	// AWS_CONTAINER_CREDENTIALS_FULL_URI, as on ECS.
+	UseContainerCredentials bool
+	// Profile is a profile in the shared credentials and config files, which
+	// are ~/.aws/credentials and ~/.aws/config unless
+	// AWS_SHARED_CREDENTIALS_FILE and AWS_CONFIG_FILE name others.
+	Profile string
+	// WebIdentityTokenFile is a file holding an OIDC token, such as a
+	// Kubernetes service account token, which is exchanged for the
+	// credentials of RoleARN.
+	WebIdentityTokenFile string
+	RoleARN              string
+	ExternalID           string
+	RoleSessionName      string
+}
+
+func (c Credentials) Validate() error {
+	sources := 0
+	for _, isSet := range []bool{
+		c.AccessKey.Id != "",
+		c.UseIAMProfile,
+		c.UseContainerCredentials,
+		c.Profile != "",
+		c.WebIdentityTokenFile != "",
+	} {
+		if isSet {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return errors.New("only one of aws_access_key_id, use_iam_profile, use_container_credentials, aws_profile and aws_web_identity_token_file can be set")
+	}
+
+	if c.WebIdentityTokenFile != "" && c.RoleARN == "" {
+		return errors.New("aws_web_identity_token_file requires aws_assumed_role_arn")
+	}
+
+	if c.WebIdentityTokenFile != "" && c.ExternalID != "" {
+		return errors.New("aws_assumed_role_external_id cannot be used with aws_web_identity_token_file")
+	}
+
+	if c.RoleARN == "" && (c.ExternalID != "" || c.RoleSessionName != "") {
+		return errors.New("aws_assumed_role_external_id and aws_assumed_role_session_name require aws_assumed_role_arn")
+	}
+
+	return nil
+}
+
+// provider returns the credentials of a client of a bucket in the region,
+// which are shared by the clients the bucket makes for other regions. The STS
+// requests to assume roles are made to the endpoint when it is set.
+func (c Credentials) provider(regionName, endpoint string) (aws.CredentialsProvider, error) {
+	if c.WebIdentityTokenFile != "" {
+		return webIdentityProvider(regionName, endpoint, c.RoleARN, c.WebIdentityTokenFile, c.RoleSessionName), nil
+	}
+
+	var base aws.CredentialsProvider
+	switch {
+	case c.Profile != "":
+		profile, err := loadSharedProfile(c.Profile)
+		if err != nil {
+			return nil, err
+		}
+		base, err = profile.provider(regionName, endpoint)
+		if err != nil {
+			return nil, err
+		}
+	case c.UseContainerCredentials:
+		var err error
+		base, err = containerProvider()
+		if err != nil {
+			return nil, err
+		}
+	case c.UseIAMProfile:
+		base = aws.NewCredentialsCache(ec2rolecreds.New())
+	default:
+		base = aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(c.AccessKey.Id, c.AccessKey.Secret, c.AccessKey.SessionToken))
+	}
+
+	if c.RoleARN == "" {
+		return base, nil
+	}
+
+	return assumeRoleProvider(regionName, endpoint, base, c.RoleARN, c.ExternalID, c.RoleSessionName), nil
+}
+
+func assumeRoleProvider(regionName, endpoint string, base aws.CredentialsProvider, roleARN, externalID, sessionName string) aws.CredentialsProvider {
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(newSTSClient(regionName, endpoint, base), roleARN, func(options *stscreds.AssumeRoleOptions) {
+		if externalID != "" {
+			options.ExternalID = aws.String(externalID)
+		}
+		if sessionName != "" {
+			options.RoleSessionName = sessionName
+		}
+	}))
+}
+
+// webIdentityProvider exchanges the token in a file for the credentials of a
+// role. The file is read again whenever the credentials expire, so that tokens
+// rotated by Kubernetes are picked up.
+func webIdentityProvider(regionName, endpoint, roleARN, tokenFile, sessionName string) aws.CredentialsProvider {
+	if sessionName == "" {
+		// Unlike AssumeRole, AssumeRoleWithWebIdentity has no default
+		// session name.
+		sessionName = fmt.Sprintf("s3-blobstore-backup-restorer-%d", time.Now().UnixNano())
+	}
+
+	stsClient := newSTSClient(regionName, endpoint, aws.AnonymousCredentials{})
+	return aws.NewCredentialsCache(stscreds.NewWebIdentityRoleProvider(stsClient, roleARN, stscreds.IdentityTokenFile(tokenFile), func(options *stscreds.WebIdentityRoleOptions) {
+		options.RoleSessionName = sessionName
+	}))
+}
+
+func containerProvider() (aws.CredentialsProvider, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relativeURI := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relativeURI != "" {
+		endpoint = containerCredentialsHost + relativeURI
+	}
+	if endpoint == "" {
+		return nil, errors.New("container credentials require AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or AWS_CONTAINER_CREDENTIALS_FULL_URI to be set")
+	}
+
+	return aws.NewCredentialsCache(endpointcreds.New(endpoint, func(options *endpointcreds.Options) {
+		options.AuthorizationTokenProvider = endpointcreds.TokenProviderFunc(containerAuthorizationToken)
+	})), nil
+}
+
+// containerAuthorizationToken is the token sent to the container credentials
+// endpoint, which EKS Pod Identity rotates in AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE.
+func containerAuthorizationToken() (string, error) {
+	tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE")
+	if tokenFile == "" {
+		return os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"), nil
+	}
+
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read container authorization token: %s", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+func newSTSClient(regionName, endpoint string, provider aws.CredentialsProvider) *sts.Client {
+	options := sts.Options{
+		Credentials: provider,
+		Region:      regionName,
+	}
+	if endpoint != "" {
+		options.EndpointResolver = sts.EndpointResolverFromURL(endpoint)
+	}
+	return sts.New(options)
+}
@@ -0,0 +1,322 @@
+package s3bucket_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"s3-blobstore-backup-restore/s3bucket"
+)
+
+var _ = Describe("Credentials", func() {
+	var fakeS3 *fakeS3Server
+	var credentials s3bucket.Credentials
+	var newBucketErr error
+	var err error
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(10)
+		credentials = s3bucket.Credentials{}
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+	})
+
+	JustBeforeEach(func() {
+		var bucket s3bucket.Bucket
+		bucket, newBucketErr = s3bucket.NewBucketWithCredentials("destination", "us-east-1", fakeS3.URL, credentials, true)
+		if newBucketErr != nil {
+			return
+		}
+
+		err = bucket.CopyVersion("a-blob", "a-version", "source", "eu-west-1")
+	})
+
+	Context("when the access key has a session token", func() {
+		BeforeEach(func() {
+			credentials.AccessKey = s3bucket.AccessKey{Id: "an-id", Secret: "a-secret", SessionToken: "a-session-token"}
+		})
+
+		It("signs the requests with the temporary credentials", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.Header("CopyObject").Get("Authorization")).To(ContainSubstring("Credential=an-id/"))
+			Expect(fakeS3.Header("CopyObject").Get("X-Amz-Security-Token")).To(Equal("a-session-token"))
+		})
+	})
+
+	Context("when a role is assumed", func() {
+		BeforeEach(func() {
+			credentials.AccessKey = s3bucket.AccessKey{Id: "an-id", Secret: "a-secret"}
+			credentials.RoleARN = "a-role-arn"
+			credentials.ExternalID = "an-external-id"
+			credentials.RoleSessionName = "a-session-name"
+		})
+
+		It("assumes the role once with the access key, the external ID and the session name", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.STSRequests()).To(HaveLen(1))
+			Expect(fakeS3.STSRequests()[0].Get("Action")).To(Equal("AssumeRole"))
+			Expect(fakeS3.STSRequests()[0].Get("RoleArn")).To(Equal("a-role-arn"))
+			Expect(fakeS3.STSRequests()[0].Get("ExternalId")).To(Equal("an-external-id"))
+			Expect(fakeS3.STSRequests()[0].Get("RoleSessionName")).To(Equal("a-session-name"))
+			Expect(fakeS3.Header("STS").Get("Authorization")).To(ContainSubstring("Credential=an-id/"))
+		})
+
+		It("signs the requests to the source and the destination with the role's credentials", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.Header("HeadObject").Get("Authorization")).To(ContainSubstring("Credential=assumed-id/"))
+			Expect(fakeS3.Header("CopyObject").Get("Authorization")).To(ContainSubstring("Credential=assumed-id/"))
+			Expect(fakeS3.Header("CopyObject").Get("X-Amz-Security-Token")).To(Equal("assumed-token"))
+		})
+	})
+
+	Context("when a web identity token file is set", func() {
+		BeforeEach(func() {
+			tokenFile := filepath.Join(GinkgoT().TempDir(), "token")
+			Expect(os.WriteFile(tokenFile, []byte("a-web-identity-token"), 0600)).To(Succeed())
+
+			credentials.WebIdentityTokenFile = tokenFile
+			credentials.RoleARN = "a-role-arn"
+		})
+
+		It("exchanges the token for the role's credentials", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.STSRequests()).To(HaveLen(1))
+			Expect(fakeS3.STSRequests()[0].Get("Action")).To(Equal("AssumeRoleWithWebIdentity"))
+			Expect(fakeS3.STSRequests()[0].Get("RoleArn")).To(Equal("a-role-arn"))
+			Expect(fakeS3.STSRequests()[0].Get("WebIdentityToken")).To(Equal("a-web-identity-token"))
+			Expect(fakeS3.STSRequests()[0].Get("RoleSessionName")).NotTo(BeEmpty())
+			Expect(fakeS3.Header("STS").Get("Authorization")).To(BeEmpty())
+			Expect(fakeS3.Header("CopyObject").Get("Authorization")).To(ContainSubstring("Credential=assumed-id/"))
+		})
+	})
+
+	Context("when a profile is set", func() {
+		var configFile, credentialsFile string
+
+		BeforeEach(func() {
+			dir := GinkgoT().TempDir()
+			configFile = filepath.Join(dir, "config")
+			credentialsFile = filepath.Join(dir, "credentials")
+			GinkgoT().Setenv("AWS_CONFIG_FILE", configFile)
+			GinkgoT().Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+
+			Expect(os.WriteFile(credentialsFile, []byte(`
+[default]
+aws_access_key_id = default-id
+aws_secret_access_key = default-secret
+
+# the keys of a-profile
+[a-profile]
+aws_access_key_id = profile-id
+aws_secret_access_key = profile-secret
+aws_session_token = profile-token
+`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(configFile, []byte(`
+[profile a-profile]
+region = eu-west-1
+s3 =
+  addressing_style = path
+
+[sso-session a-role-profile]
+role_arn = not-a-profile
+
+[profile a-role-profile]
+role_arn = a-role-arn
+source_profile = a-profile
+external_id = an-external-id
+role_session_name = a-session-name
+`), 0600)).To(Succeed())
+
+			credentials.Profile = "a-profile"
+		})
+
+		It("signs the requests with the profile's keys", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.Header("CopyObject").Get("Authorization")).To(ContainSubstring("Credential=profile-id/"))
+			Expect(fakeS3.Header("CopyObject").Get("X-Amz-Security-Token")).To(Equal("profile-token"))
+		})
+
+		Context("and the profile assumes a role with a source profile", func() {
+			BeforeEach(func() {
+				credentials.Profile = "a-role-profile"
+			})
+
+			It("assumes the role with the source profile's keys", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.STSRequests()).To(HaveLen(1))
+				Expect(fakeS3.STSRequests()[0].Get("RoleArn")).To(Equal("a-role-arn"))
+				Expect(fakeS3.STSRequests()[0].Get("ExternalId")).To(Equal("an-external-id"))
+				Expect(fakeS3.STSRequests()[0].Get("RoleSessionName")).To(Equal("a-session-name"))
+				Expect(fakeS3.Header("STS").Get("Authorization")).To(ContainSubstring("Credential=profile-id/"))
+				Expect(fakeS3.Header("CopyObject").Get("Authorization")).To(ContainSubstring("Credential=assumed-id/"))
+			})
+
+			Context("and a role is also set", func() {
+				BeforeEach(func() {
+					credentials.RoleARN = "another-role-arn"
+				})
+
+				It("assumes the role with the profile's credentials", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(fakeS3.STSRequests()).To(HaveLen(2))
+					Expect(fakeS3.STSRequests()[0].Get("RoleArn")).To(Equal("a-role-arn"))
+					Expect(fakeS3.STSRequests()[1].Get("RoleArn")).To(Equal("another-role-arn"))
+				})
+			})
+		})
+
+		Context("and the profile has comments, quotes and nested settings", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(configFile, []byte(`
+[profile a-commented-profile] ; keys for the backups
+s3 =
+  addressing_style = path
+  aws_access_key_id = not-the-key-id
+region =
+aws_access_key_id = "commented-id" # rotated monthly
+	aws_secret_access_key = commented-secret ; indented
+aws_session_token = 'commented-token'
+description = the keys
+  of the backups
+`), 0600)).To(Succeed())
+
+				credentials.Profile = "a-commented-profile"
+			})
+
+			It("signs the requests with the profile's keys", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.Header("CopyObject").Get("Authorization")).To(ContainSubstring("Credential=commented-id/"))
+				Expect(fakeS3.Header("CopyObject").Get("X-Amz-Security-Token")).To(Equal("commented-token"))
+			})
+		})
+
+		DescribeTable("fails for profiles which get credentials in unsupported ways",
+			func(profile, expectedError string) {
+				Expect(os.WriteFile(configFile, []byte(profile), 0600)).To(Succeed())
+				credentials.Profile = "an-unsupported-profile"
+
+				_, profileErr := s3bucket.NewBucketWithCredentials("destination", "us-east-1", fakeS3.URL, credentials, true)
+				Expect(profileErr).To(MatchError(ContainSubstring(expectedError)))
+			},
+			Entry("SSO", `
+[profile an-unsupported-profile]
+sso_session = a-session
+sso_account_id = 123456789012
+sso_role_name = a-role
+aws_access_key_id = profile-id
+`, "AWS profile an-unsupported-profile sets sso_account_id, sso_role_name, sso_session, which is not supported"),
+			Entry("a credential process", `
+[profile an-unsupported-profile]
+credential_process = /usr/local/bin/get-credentials
+`, "AWS profile an-unsupported-profile sets credential_process, which is not supported"),
+			Entry("a source profile with a credential process", `
+[profile an-unsupported-profile]
+role_arn = a-role-arn
+source_profile = a-process-profile
+
+[profile a-process-profile]
+credential_process = /usr/local/bin/get-credentials
+`, "AWS profile a-process-profile sets credential_process, which is not supported"),
+		)
+
+		Context("and the profile does not exist", func() {
+			BeforeEach(func() {
+				credentials.Profile = "another-profile"
+			})
+
+			It("fails to create the bucket", func() {
+				Expect(newBucketErr).To(MatchError("failed to get credentials for bucket destination: failed to find AWS profile another-profile"))
+			})
+		})
+	})
+
+	Context("when container credentials are used", func() {
+		var credentialsServer *httptest.Server
+		var authorizations []string
+
+		BeforeEach(func() {
+			authorizations = nil
+			credentialsServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorizations = append(authorizations, r.Header.Get("Authorization"))
+				fmt.Fprint(w, `{"AccessKeyId": "container-id", "SecretAccessKey": "container-secret", "Token": "container-token", "Expiration": "2100-01-01T00:00:00Z"}`)
+			}))
+			GinkgoT().Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+			GinkgoT().Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", credentialsServer.URL)
+			GinkgoT().Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "an-authorization-token")
+			GinkgoT().Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", "")
+
+			credentials.UseContainerCredentials = true
+		})
+
+		AfterEach(func() {
+			credentialsServer.Close()
+		})
+
+		It("signs the requests with the container's credentials", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(authorizations).To(Equal([]string{"an-authorization-token"}))
+			Expect(fakeS3.Header("CopyObject").Get("Authorization")).To(ContainSubstring("Credential=container-id/"))
+			Expect(fakeS3.Header("CopyObject").Get("X-Amz-Security-Token")).To(Equal("container-token"))
+		})
+
+		Context("and the authorization token is in a file", func() {
+			BeforeEach(func() {
+				tokenFile := filepath.Join(GinkgoT().TempDir(), "token")
+				Expect(os.WriteFile(tokenFile, []byte("a-rotated-token\n"), 0600)).To(Succeed())
+				GinkgoT().Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", tokenFile)
+			})
+
+			It("sends the token in the file", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(authorizations).To(Equal([]string{"a-rotated-token"}))
+			})
+		})
+
+		Context("and no container credentials endpoint is set", func() {
+			BeforeEach(func() {
+				GinkgoT().Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+			})
+
+			It("fails to create the bucket", func() {
+				Expect(newBucketErr).To(MatchError(ContainSubstring("container credentials require AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or AWS_CONTAINER_CREDENTIALS_FULL_URI to be set")))
+			})
+		})
+	})
+
+	DescribeTable("Validate",
+		func(credentials s3bucket.Credentials, expectedErr string) {
+			err := credentials.Validate()
+			if expectedErr == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedErr))
+			}
+		},
+		Entry("no credentials", s3bucket.Credentials{}, ""),
+		Entry("an access key and a role", s3bucket.Credentials{
+			AccessKey: s3bucket.AccessKey{Id: "an-id", Secret: "a-secret"}, RoleARN: "a-role-arn", ExternalID: "an-external-id",
+		}, ""),
+		Entry("the instance profile and a role", s3bucket.Credentials{UseIAMProfile: true, RoleARN: "a-role-arn"}, ""),
+		Entry("a web identity token file and a role", s3bucket.Credentials{WebIdentityTokenFile: "a-file", RoleARN: "a-role-arn"}, ""),
+		Entry("an access key and the instance profile", s3bucket.Credentials{
+			AccessKey: s3bucket.AccessKey{Id: "an-id"}, UseIAMProfile: true,
+		}, "only one of aws_access_key_id, use_iam_profile, use_container_credentials, aws_profile and aws_web_identity_token_file can be set"),
+		Entry("a profile and container credentials", s3bucket.Credentials{
+			Profile: "a-profile", UseContainerCredentials: true,
+		}, "only one of aws_access_key_id, use_iam_profile, use_container_credentials, aws_profile and aws_web_identity_token_file can be set"),
+		Entry("a web identity token file without a role", s3bucket.Credentials{WebIdentityTokenFile: "a-file"},
+			"aws_web_identity_token_file requires aws_assumed_role_arn"),
+		Entry("a web identity token file and an external ID", s3bucket.Credentials{WebIdentityTokenFile: "a-file", RoleARN: "a-role-arn", ExternalID: "an-external-id"},
+			"aws_assumed_role_external_id cannot be used with aws_web_identity_token_file"),
+		Entry("a session name without a role", s3bucket.Credentials{RoleSessionName: "a-session-name"},
+			"aws_assumed_role_external_id and aws_assumed_role_session_name require aws_assumed_role_arn"),
+	)
+})
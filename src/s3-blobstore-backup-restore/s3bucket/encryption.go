@@ -0,0 +1,136 @@
+package s3bucket
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	EncryptionSSES3  = "sse-s3"
+	EncryptionSSEKMS = "sse-kms"
+	EncryptionSSEC   = "sse-c"
+
+	customerKeyAlgorithm = "AES256"
+	customerKeyBytes     = 32
+)
+
+// EncryptionConfig is how the blobs copied or uploaded to a bucket are
+// encrypted. The zero value leaves it to the default encryption of the
+// bucket.
+type EncryptionConfig struct {
+	Type string `json:"type,omitempty"`
+	// KMSKeyID and BucketKeyEnabled are only used with sse-kms. Without a
+	// key ID, S3 uses the AWS managed key.
+	KMSKeyID         string `json:"kms_key_id,omitempty"`
+	BucketKeyEnabled bool   `json:"bucket_key_enabled,omitempty"`
+	// CustomerKey is the base64 encoded 256-bit key used with sse-c, both to
+	// encrypt the blobs written to the bucket and to read the blobs copied
+	// from it.
+	CustomerKey string `json:"customer_key,omitempty"`
+}
+
+func (c EncryptionConfig) Validate() error {
+	switch c.Type {
+	case "", EncryptionSSES3, EncryptionSSEKMS, EncryptionSSEC:
+	default:
+		return fmt.Errorf("encryption.type must be %s, %s or %s", EncryptionSSES3, EncryptionSSEKMS, EncryptionSSEC)
+	}
+
+	if c.Type != EncryptionSSEKMS && (c.KMSKeyID != "" || c.BucketKeyEnabled) {
+		return fmt.Errorf("encryption.kms_key_id and encryption.bucket_key_enabled can only be used with %s", EncryptionSSEKMS)
+	}
+
+	if c.Type != EncryptionSSEC && c.CustomerKey != "" {
+		return fmt.Errorf("encryption.customer_key can only be used with %s", EncryptionSSEC)
+	}
+
+	if c.Type == EncryptionSSEC {
+		key, err := base64.StdEncoding.DecodeString(c.CustomerKey)
+		if err != nil || len(key) != customerKeyBytes {
+			return fmt.Errorf("encryption.customer_key must be a base64 encoded %d-bit key", customerKeyBytes*8)
+		}
+	}
+
+	return nil
+}
+
+// etagIsDigest is whether the ETags of blobs copied to the bucket are digests
+// of their contents, which S3 does not make them with sse-kms or sse-c, so
+// that comparing them would find every blob changed.
+func (c EncryptionConfig) etagIsDigest() bool {
+	return c.Type != EncryptionSSEKMS && c.Type != EncryptionSSEC
+}
+
+// serverSideEncryption returns the encryption S3 applies with its own keys,
+// or none when the bucket default applies or the blobs use a customer key.
+func (c EncryptionConfig) serverSideEncryption() (types.ServerSideEncryption, *string, *bool) {
+	switch c.Type {
+	case EncryptionSSES3:
+		return types.ServerSideEncryptionAes256, nil, nil
+	case EncryptionSSEKMS:
+		var keyID *string
+		if c.KMSKeyID != "" {
+			keyID = aws.String(c.KMSKeyID)
+		}
+		var bucketKeyEnabled *bool
+		if c.BucketKeyEnabled {
+			bucketKeyEnabled = aws.Bool(true)
+		}
+		return types.ServerSideEncryptionAwsKms, keyID, bucketKeyEnabled
+	}
+	return "", nil, nil
+}
+
+// customerKey returns the algorithm, key and key MD5 headers for sse-c, or
+// nils for the other types.
+func (c EncryptionConfig) customerKey() (*string, *string, *string) {
+	if c.Type != EncryptionSSEC {
+		return nil, nil, nil
+	}
+
+	key, _ := base64.StdEncoding.DecodeString(c.CustomerKey)
+	keyMD5 := md5.Sum(key)
+	return aws.String(customerKeyAlgorithm), aws.String(c.CustomerKey), aws.String(base64.StdEncoding.EncodeToString(keyMD5[:]))
+}
+
+func (c EncryptionConfig) applyToCopyObject(input *s3.CopyObjectInput, source EncryptionConfig) {
+	input.ServerSideEncryption, input.SSEKMSKeyId, input.BucketKeyEnabled = c.serverSideEncryption()
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = c.customerKey()
+	input.CopySourceSSECustomerAlgorithm, input.CopySourceSSECustomerKey, input.CopySourceSSECustomerKeyMD5 = source.customerKey()
+}
+
+func (c EncryptionConfig) applyToCreateMultipartUpload(input *s3.CreateMultipartUploadInput) {
+	input.ServerSideEncryption, input.SSEKMSKeyId, input.BucketKeyEnabled = c.serverSideEncryption()
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = c.customerKey()
+}
+
+func (c EncryptionConfig) applyToUploadPartCopy(input *s3.UploadPartCopyInput, source EncryptionConfig) {
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = c.customerKey()
+	input.CopySourceSSECustomerAlgorithm, input.CopySourceSSECustomerKey, input.CopySourceSSECustomerKeyMD5 = source.customerKey()
+}
+
+func (c EncryptionConfig) applyToUploadPart(input *s3.UploadPartInput) {
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = c.customerKey()
+}
+
+func (c EncryptionConfig) applyToCompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) {
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = c.customerKey()
+}
+
+func (c EncryptionConfig) applyToPutObject(input *s3.PutObjectInput) {
+	input.ServerSideEncryption, input.SSEKMSKeyId, input.BucketKeyEnabled = c.serverSideEncryption()
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = c.customerKey()
+}
+
+func (c EncryptionConfig) applyToHeadObject(input *s3.HeadObjectInput) {
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = c.customerKey()
+}
+
+func (c EncryptionConfig) applyToGetObject(input *s3.GetObjectInput) {
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = c.customerKey()
+}
@@ -0,0 +1,140 @@
+package s3bucket_test
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"s3-blobstore-backup-restore/s3bucket"
+)
+
+var _ = Describe("Copying with encryption", func() {
+	var fakeS3 *fakeS3Server
+	var options s3bucket.Options
+	var err error
+
+	customerKey := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	customerKeyMD5 := func() string {
+		sum := md5.Sum([]byte("0123456789abcdef0123456789abcdef"))
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}()
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(23 * mebibyte)
+		options = s3bucket.Options{}
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+	})
+
+	JustBeforeEach(func() {
+		bucket, newBucketErr := s3bucket.NewBucket("destination", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+
+		err = bucket.WithOptions(options).CopyVersion("a-blob", "a-version", "source", "us-east-1")
+	})
+
+	It("leaves the encryption to the bucket default", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeS3.Header("CopyObject")).NotTo(HaveKey("X-Amz-Server-Side-Encryption"))
+		Expect(fakeS3.Header("CopyObject")).NotTo(HaveKey("X-Amz-Server-Side-Encryption-Customer-Algorithm"))
+	})
+
+	Context("when the bucket uses sse-s3", func() {
+		BeforeEach(func() {
+			options.Encryption = s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSES3}
+		})
+
+		It("asks S3 to encrypt the copy with its own keys", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.Header("CopyObject").Get("X-Amz-Server-Side-Encryption")).To(Equal("AES256"))
+		})
+	})
+
+	Context("when the bucket uses sse-kms", func() {
+		BeforeEach(func() {
+			options.Encryption = s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSEKMS, KMSKeyID: "a-key-id", BucketKeyEnabled: true}
+		})
+
+		It("asks S3 to encrypt the copy with the KMS key", func() {
+			Expect(err).NotTo(HaveOccurred())
+			header := fakeS3.Header("CopyObject")
+			Expect(header.Get("X-Amz-Server-Side-Encryption")).To(Equal("aws:kms"))
+			Expect(header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id")).To(Equal("a-key-id"))
+			Expect(header.Get("X-Amz-Server-Side-Encryption-Bucket-Key-Enabled")).To(Equal("true"))
+		})
+
+		Context("and the blob is copied in parts", func() {
+			BeforeEach(func() {
+				options.Multipart = s3bucket.MultipartConfig{ThresholdMB: 10, PartSizeMB: 5}
+			})
+
+			It("asks S3 to encrypt the upload with the KMS key", func() {
+				Expect(err).NotTo(HaveOccurred())
+				header := fakeS3.Header("CreateMultipartUpload")
+				Expect(header.Get("X-Amz-Server-Side-Encryption")).To(Equal("aws:kms"))
+				Expect(header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id")).To(Equal("a-key-id"))
+			})
+		})
+	})
+
+	Context("when the bucket uses sse-c", func() {
+		BeforeEach(func() {
+			options.Encryption = s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSEC, CustomerKey: customerKey}
+		})
+
+		It("reads and encrypts the copy with the customer key", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			header := fakeS3.Header("HeadObject")
+			Expect(header.Get("X-Amz-Server-Side-Encryption-Customer-Algorithm")).To(Equal("AES256"))
+			Expect(header.Get("X-Amz-Server-Side-Encryption-Customer-Key")).To(Equal(customerKey))
+			Expect(header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5")).To(Equal(customerKeyMD5))
+
+			header = fakeS3.Header("CopyObject")
+			Expect(header.Get("X-Amz-Server-Side-Encryption-Customer-Key")).To(Equal(customerKey))
+			Expect(header.Get("X-Amz-Copy-Source-Server-Side-Encryption-Customer-Algorithm")).To(Equal("AES256"))
+			Expect(header.Get("X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key")).To(Equal(customerKey))
+			Expect(header.Get("X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key-Md5")).To(Equal(customerKeyMD5))
+		})
+
+		Context("and the blob is copied in parts", func() {
+			BeforeEach(func() {
+				options.Multipart = s3bucket.MultipartConfig{ThresholdMB: 10, PartSizeMB: 5}
+			})
+
+			It("uses the customer key for every request of the upload", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.Header("CreateMultipartUpload").Get("X-Amz-Server-Side-Encryption-Customer-Key")).To(Equal(customerKey))
+				Expect(fakeS3.Header("UploadPartCopy").Get("X-Amz-Server-Side-Encryption-Customer-Key")).To(Equal(customerKey))
+				Expect(fakeS3.Header("UploadPartCopy").Get("X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key")).To(Equal(customerKey))
+				Expect(fakeS3.Header("CompleteMultipartUpload").Get("X-Amz-Server-Side-Encryption-Customer-Key")).To(Equal(customerKey))
+			})
+		})
+	})
+})
+
+var _ = Describe("EncryptionConfig", func() {
+	DescribeTable("Validate",
+		func(config s3bucket.EncryptionConfig, expectedError string) {
+			err := config.Validate()
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("the bucket default", s3bucket.EncryptionConfig{}, ""),
+		Entry("sse-s3", s3bucket.EncryptionConfig{Type: "sse-s3"}, ""),
+		Entry("sse-kms with a key", s3bucket.EncryptionConfig{Type: "sse-kms", KMSKeyID: "a-key-id", BucketKeyEnabled: true}, ""),
+		Entry("sse-c with a 256-bit key", s3bucket.EncryptionConfig{Type: "sse-c", CustomerKey: base64.StdEncoding.EncodeToString(make([]byte, 32))}, ""),
+		Entry("an unknown type", s3bucket.EncryptionConfig{Type: "aes"}, "encryption.type must be sse-s3, sse-kms or sse-c"),
+		Entry("a KMS key without sse-kms", s3bucket.EncryptionConfig{Type: "sse-s3", KMSKeyID: "a-key-id"}, "encryption.kms_key_id and encryption.bucket_key_enabled can only be used with sse-kms"),
+		Entry("a customer key without sse-c", s3bucket.EncryptionConfig{CustomerKey: "a-key"}, "encryption.customer_key can only be used with sse-c"),
+		Entry("sse-c without a key", s3bucket.EncryptionConfig{Type: "sse-c"}, "encryption.customer_key must be a base64 encoded 256-bit key"),
+		Entry("sse-c with a short key", s3bucket.EncryptionConfig{Type: "sse-c", CustomerKey: base64.StdEncoding.EncodeToString(make([]byte, 16))}, "encryption.customer_key must be a base64 encoded 256-bit key"),
+	)
+})
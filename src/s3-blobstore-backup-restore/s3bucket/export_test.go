@@ -1,5 +1,43 @@
 package s3bucket
 
+import "time"
+
 func (b Bucket) GetBlobSizeImpl(bucketName, bucketRegion, blobKey, versionID string) (int64, error) {
-	return b.getBlobSize(bucketName, bucketRegion, blobKey, versionID)
+	return b.getBlobSize(origin{name: bucketName, region: bucketRegion, access: b.access, encryption: b.options.Encryption}, blobKey, versionID)
+}
+
+func (c MultipartConfig) PartSizeFor(blobSize int64) int64 {
+	return c.partSizeFor(blobSize)
+}
+
+func SetPartRetryDelay(delay time.Duration) func() {
+	previousDelay := partRetryDelay
+	partRetryDelay = delay
+	return func() {
+		partRetryDelay = previousDelay
+	}
+}
+
+func SetArchiveRestorePollInterval(interval time.Duration) func() {
+	previousInterval := archiveRestorePollInterval
+	archiveRestorePollInterval = interval
+	return func() {
+		archiveRestorePollInterval = previousInterval
+	}
+}
+
+func SetArchiveRestoreTimeoutUnit(unit time.Duration) func() {
+	previousUnit := archiveRestoreTimeoutUnit
+	archiveRestoreTimeoutUnit = unit
+	return func() {
+		archiveRestoreTimeoutUnit = previousUnit
+	}
+}
+
+func SetMaxStreamedBytes(bytes int64) func() {
+	previousBudget := streamedBytes
+	streamedBytes = newByteBudget(bytes)
+	return func() {
+		streamedBytes = previousBudget
+	}
 }
@@ -0,0 +1,452 @@
+package s3bucket_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeS3Server answers the requests made when copying a blob, so that copies
+// can be tested without an S3 endpoint.
+type fakeS3Server struct {
+	*httptest.Server
+
+	mutex            sync.Mutex
+	requestTimes     []time.Time
+	blobSize         int64
+	partDelay        time.Duration
+	failPart         func(partNumber, attempt int) bool
+	slowDownParts    bool
+	failCopyObject   func(attempt int) bool
+	partAttempts     map[int]int
+	copySourceRanges map[int]string
+	inFlight         int
+	maxInFlight      int
+	copyObjectCalls  int
+	completeBody     string
+	aborted          bool
+	headers          map[string]http.Header
+	storageClass     string
+	restoreStatuses  []string
+	restoreBodies    []string
+	blobHeader       http.Header
+	pathHeaders      map[string]http.Header
+	tags             map[string]string
+	putACLBody       string
+	stsRequests      []url.Values
+	denyCopy         bool
+	deniedCopies     int
+	getRanges        []string
+	putBody          []byte
+	uploadedParts    map[int][]byte
+	keys             []string
+}
+
+func newFakeS3Server(blobSize int64) *fakeS3Server {
+	fake := &fakeS3Server{
+		blobSize:         blobSize,
+		failPart:         func(int, int) bool { return false },
+		failCopyObject:   func(int) bool { return false },
+		partAttempts:     map[int]int{},
+		copySourceRanges: map[int]string{},
+		headers:          map[string]http.Header{},
+		uploadedParts:    map[int][]byte{},
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+	return fake
+}
+
+func (f *fakeS3Server) handle(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	f.mutex.Lock()
+	f.requestTimes = append(f.requestTimes, time.Now())
+	f.mutex.Unlock()
+
+	f.mutex.Lock()
+	f.headers[operation(r)] = r.Header.Clone()
+	f.mutex.Unlock()
+
+	switch {
+	case r.Method == http.MethodHead:
+		w.Header().Set("Content-Length", strconv.FormatInt(f.blobSize, 10))
+		f.mutex.Lock()
+		for name, values := range f.blobHeader {
+			w.Header()[name] = values
+		}
+		for name, values := range f.pathHeaders[r.URL.Path] {
+			w.Header()[name] = values
+		}
+		if f.storageClass != "" {
+			w.Header().Set("X-Amz-Storage-Class", f.storageClass)
+		}
+		if len(f.restoreStatuses) != 0 {
+			w.Header().Set("X-Amz-Restore", f.restoreStatuses[0])
+			if len(f.restoreStatuses) > 1 {
+				f.restoreStatuses = f.restoreStatuses[1:]
+			}
+		}
+		f.mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && query.Get("list-type") == "2":
+		f.handleListObjectsV2(w, query.Get("prefix"))
+	case r.Method == http.MethodGet && query.Has("tagging"):
+		f.mutex.Lock()
+		fmt.Fprint(w, `<Tagging><TagSet>`)
+		for key, value := range f.tags {
+			fmt.Fprintf(w, `<Tag><Key>%s</Key><Value>%s</Value></Tag>`, key, value)
+		}
+		fmt.Fprint(w, `</TagSet></Tagging>`)
+		f.mutex.Unlock()
+	case r.Method == http.MethodGet && query.Has("acl"):
+		fmt.Fprint(w, `<AccessControlPolicy><Owner><ID>the-owner</ID></Owner><AccessControlList>`+
+			`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>the-owner</ID></Grantee><Permission>FULL_CONTROL</Permission></Grant>`+
+			`<Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/global/AllUsers</URI></Grantee><Permission>READ</Permission></Grant>`+
+			`</AccessControlList></AccessControlPolicy>`)
+	case r.Method == http.MethodPut && query.Has("acl"):
+		body, _ := io.ReadAll(r.Body)
+		f.mutex.Lock()
+		f.putACLBody = string(body)
+		f.mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && query.Has("restore"):
+		body, _ := io.ReadAll(r.Body)
+		f.mutex.Lock()
+		f.restoreBodies = append(f.restoreBodies, string(body))
+		f.mutex.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodGet:
+		f.handleGetObject(w, r)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>the-upload-id</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "" && f.denyCopy:
+		f.mutex.Lock()
+		f.deniedCopies++
+		f.mutex.Unlock()
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+	case r.Method == http.MethodPut && query.Has("partNumber") && r.Header.Get("X-Amz-Copy-Source") == "":
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		body, _ := io.ReadAll(r.Body)
+		f.mutex.Lock()
+		f.uploadedParts[partNumber] = body
+		f.inFlight++
+		f.maxInFlight = max(f.maxInFlight, f.inFlight)
+		f.mutex.Unlock()
+
+		time.Sleep(f.partDelay)
+
+		f.mutex.Lock()
+		f.inFlight--
+		f.mutex.Unlock()
+		w.Header().Set("ETag", fmt.Sprintf(`"uploaded-etag-%d"`, partNumber))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		f.handleUploadPartCopy(w, r)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") == "":
+		body, _ := io.ReadAll(r.Body)
+		f.mutex.Lock()
+		f.putBody = body
+		f.mutex.Unlock()
+		w.Header().Set("ETag", `"the-etag"`)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPut:
+		f.mutex.Lock()
+		f.copyObjectCalls++
+		attempt := f.copyObjectCalls
+		f.mutex.Unlock()
+		if f.failCopyObject(attempt) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`)
+			return
+		}
+		fmt.Fprint(w, `<CopyObjectResult><ETag>"the-etag"</ETag></CopyObjectResult>`)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		body, _ := io.ReadAll(r.Body)
+		f.mutex.Lock()
+		f.completeBody = string(body)
+		f.mutex.Unlock()
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"the-etag"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		f.mutex.Lock()
+		f.aborted = true
+		f.mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && r.URL.Path == "/":
+		f.handleSTS(w, r)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// handleSTS answers the STS requests to assume a role, which are made to the
+// same endpoint as the S3 requests.
+func (f *fakeS3Server) handleSTS(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	action := r.PostForm.Get("Action")
+
+	f.mutex.Lock()
+	f.stsRequests = append(f.stsRequests, r.PostForm)
+	f.mutex.Unlock()
+
+	fmt.Fprintf(w, `<%[1]sResponse><%[1]sResult><Credentials>`+
+		`<AccessKeyId>assumed-id</AccessKeyId><SecretAccessKey>assumed-secret</SecretAccessKey>`+
+		`<SessionToken>assumed-token</SessionToken><Expiration>2100-01-01T00:00:00Z</Expiration>`+
+		`</Credentials></%[1]sResult></%[1]sResponse>`, action)
+}
+
+// operation names the S3 operation of a request, as far as the fake can tell
+// them apart.
+func operation(r *http.Request) string {
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodHead:
+		return "HeadObject"
+	case r.Method == http.MethodGet && query.Get("list-type") == "2":
+		return "ListObjectsV2"
+	case r.Method == http.MethodGet && query.Has("tagging"):
+		return "GetObjectTagging"
+	case r.Method == http.MethodGet && query.Has("acl"):
+		return "GetObjectAcl"
+	case r.Method == http.MethodPut && query.Has("acl"):
+		return "PutObjectAcl"
+	case r.Method == http.MethodGet:
+		return "GetObject"
+	case r.Method == http.MethodPost && query.Has("restore"):
+		return "RestoreObject"
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		return "CreateMultipartUpload"
+	case r.Method == http.MethodPut && query.Has("partNumber") && r.Header.Get("X-Amz-Copy-Source") == "":
+		return "UploadPart"
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		return "UploadPartCopy"
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") == "":
+		return "PutObject"
+	case r.Method == http.MethodPut:
+		return "CopyObject"
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		return "CompleteMultipartUpload"
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		return "AbortMultipartUpload"
+	case r.Method == http.MethodPost && r.URL.Path == "/":
+		return "STS"
+	}
+	return r.Method
+}
+
+// handleListObjectsV2 answers with the keys set by SetKeys which start with
+// the prefix, in a single page.
+func (f *fakeS3Server) handleListObjectsV2(w http.ResponseWriter, prefix string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`)
+	for _, key := range f.keys {
+		if strings.HasPrefix(key, prefix) {
+			fmt.Fprintf(w, `<Contents><Key>%s</Key><ETag>"the-etag"</ETag><Size>%d</Size></Contents>`, key, f.blobSize)
+		}
+	}
+	fmt.Fprint(w, `</ListBucketResult>`)
+}
+
+// handleGetObject answers with the contents of the blob, which are
+// blobContents of its size, or the requested range of them.
+func (f *fakeS3Server) handleGetObject(w http.ResponseWriter, r *http.Request) {
+	start, end := int64(0), f.blobSize-1
+	if byteRange := r.Header.Get("Range"); byteRange != "" {
+		fmt.Sscanf(byteRange, "bytes=%d-%d", &start, &end)
+	}
+
+	f.mutex.Lock()
+	f.getRanges = append(f.getRanges, r.Header.Get("Range"))
+	f.mutex.Unlock()
+
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(blobContents(f.blobSize)[start : end+1])
+}
+
+// blobContents are the contents of the fake's blobs of a size.
+func blobContents(size int64) []byte {
+	contents := make([]byte, size)
+	for i := range contents {
+		contents[i] = byte(i % 251)
+	}
+	return contents
+}
+
+func (f *fakeS3Server) handleUploadPartCopy(w http.ResponseWriter, r *http.Request) {
+	partNumber, _ := strconv.Atoi(r.URL.Query().Get("partNumber"))
+
+	f.mutex.Lock()
+	f.partAttempts[partNumber]++
+	attempt := f.partAttempts[partNumber]
+	f.copySourceRanges[partNumber] = r.Header.Get("X-Amz-Copy-Source-Range")
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	f.mutex.Unlock()
+
+	time.Sleep(f.partDelay)
+
+	f.mutex.Lock()
+	f.inFlight--
+	f.mutex.Unlock()
+
+	if f.failPart(partNumber, attempt) && f.slowDownParts {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`)
+		return
+	}
+
+	if f.failPart(partNumber, attempt) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `<Error><Code>InvalidRequest</Code><Message>part %d failed</Message></Error>`, partNumber)
+		return
+	}
+
+	fmt.Fprintf(w, `<CopyPartResult><ETag>"etag-%d"</ETag></CopyPartResult>`, partNumber)
+}
+
+func (f *fakeS3Server) PartAttempts(partNumber int) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.partAttempts[partNumber]
+}
+
+func (f *fakeS3Server) CopySourceRange(partNumber int) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.copySourceRanges[partNumber]
+}
+
+func (f *fakeS3Server) MaxInFlight() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.maxInFlight
+}
+
+func (f *fakeS3Server) CopyObjectCalls() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.copyObjectCalls
+}
+
+func (f *fakeS3Server) CompleteBody() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.completeBody
+}
+
+func (f *fakeS3Server) Aborted() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.aborted
+}
+
+// SetBlobAttributes makes heads of the blob return the headers, and gets of
+// its tags return the tags.
+func (f *fakeS3Server) SetBlobAttributes(header http.Header, tags map[string]string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.blobHeader = header
+	f.tags = tags
+}
+
+// SetHeaderFor makes heads of the blob with the path, such as
+// "/bucket/key", also return the headers.
+func (f *fakeS3Server) SetHeaderFor(path string, header http.Header) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.pathHeaders == nil {
+		f.pathHeaders = map[string]http.Header{}
+	}
+	f.pathHeaders[path] = header
+}
+
+func (f *fakeS3Server) PutACLBody() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.putACLBody
+}
+
+// SetArchived makes the blob report the storage class, and then each of the
+// restore statuses in turn for the following heads, repeating the last one.
+func (f *fakeS3Server) SetArchived(storageClass string, restoreStatuses ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.storageClass = storageClass
+	f.restoreStatuses = restoreStatuses
+}
+
+func (f *fakeS3Server) RestoreBodies() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.restoreBodies...)
+}
+
+// Header returns the headers of the last request made for the operation.
+func (f *fakeS3Server) Header(operation string) http.Header {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.headers[operation]
+}
+
+func (f *fakeS3Server) RequestTimes() []time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]time.Time{}, f.requestTimes...)
+}
+
+func (f *fakeS3Server) STSRequests() []url.Values {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]url.Values{}, f.stsRequests...)
+}
+
+// DenyCopies makes S3 deny the copies which read a blob, as it does when the
+// credentials of the bucket copied to cannot read the bucket copied from.
+func (f *fakeS3Server) DenyCopies() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.denyCopy = true
+}
+
+func (f *fakeS3Server) DeniedCopies() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.deniedCopies
+}
+
+// GetRanges returns the range of each read of the blob, which is empty for
+// reads of the whole blob.
+func (f *fakeS3Server) GetRanges() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.getRanges...)
+}
+
+func (f *fakeS3Server) PutBody() []byte {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.putBody
+}
+
+func (f *fakeS3Server) UploadedPart(partNumber int) []byte {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.uploadedParts[partNumber]
+}
+
+// SetKeys makes listings of the bucket return the keys.
+func (f *fakeS3Server) SetKeys(keys ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.keys = keys
+}
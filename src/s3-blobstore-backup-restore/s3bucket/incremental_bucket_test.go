@@ -7,6 +7,7 @@ import (
 
 	. "github.com/onsi/gomega"
 
+	"s3-blobstore-backup-restore/incremental"
 	"s3-blobstore-backup-restore/s3bucket"
 )
 
@@ -41,12 +42,21 @@ var _ = Describe("IncrementalBucket", func() {
 				It("lists all the blobs in the bucket", func() {
 					blobs, err := liveBucket.ListBlobs("")
 
-					blob1 := s3bucket.NewBlob("path1/blob1")
-					blob2 := s3bucket.NewBlob("live/location/leaf/node")
-					blob3 := s3bucket.NewBlob("path2/blob2")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(blobPaths(blobs)).To(ConsistOf("path1/blob1", "live/location/leaf/node", "path2/blob2"))
+				})
+
+				It("lists the attributes of the blobs", func() {
+					blobs, err := liveBucket.ListBlobs("")
 
 					Expect(err).NotTo(HaveOccurred())
-					Expect(blobs).To(ConsistOf(blob1, blob2, blob3))
+					for _, blob := range blobs {
+						if blob.Path() == "path1/blob1" {
+							Expect(blob.ETag()).NotTo(BeEmpty())
+							Expect(blob.Size()).To(Equal(int64(len("blob1-content"))))
+							Expect(blob.LastModified()).NotTo(BeZero())
+						}
+					}
 				})
 
 				Context("when s3 list-objects errors", func() {
@@ -78,7 +88,7 @@ var _ = Describe("IncrementalBucket", func() {
 					blobs, err := liveBucket.ListBlobs("live/location")
 
 					Expect(err).NotTo(HaveOccurred())
-					Expect(blobs).To(ConsistOf(s3bucket.NewBlob("leaf/node")))
+					Expect(blobPaths(blobs)).To(ConsistOf("leaf/node"))
 				})
 			})
 		})
@@ -259,6 +269,26 @@ var _ = Describe("IncrementalBucket", func() {
 				})
 			})
 		})
+
+		Describe("DeleteBlob", func() {
+			It("deletes the blob", func() {
+				err := liveBucket.DeleteBlob("path1/blob1")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(listFiles(liveBucketName, S3Endpoint)).NotTo(ContainElement("path1/blob1"))
+			})
+
+			Context("when the bucket does not exist", func() {
+				It("errors", func() {
+					bucket, err := s3bucket.NewBucketWithRoleARN("does-not-exist", LiveRegion, S3Endpoint, AssumedRoleARN, creds, false, false)
+					Expect(err).NotTo(HaveOccurred())
+
+					err = bucket.DeleteBlob("some/blob")
+
+					Expect(err).To(MatchError(ContainSubstring("failed to delete blob")))
+				})
+			})
+		})
 	})
 
 	Describe("S3 large file test", func() {
@@ -301,3 +331,11 @@ var _ = Describe("IncrementalBucket", func() {
 		})
 	})
 })
+
+func blobPaths(blobs []incremental.Blob) []string {
+	var paths []string
+	for _, blob := range blobs {
+		paths = append(paths, blob.Path())
+	}
+	return paths
+}
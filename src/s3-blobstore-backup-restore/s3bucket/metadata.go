@@ -0,0 +1,109 @@
+package s3bucket
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// sourceBlob is a version of a blob being copied, with the client of its
+// bucket's region and the attributes read by heading it.
+type sourceBlob struct {
+	client     *s3.Client
+	bucketName string
+	key        string
+	versionID  string
+	head       *s3.HeadObjectOutput
+}
+
+func (s sourceBlob) size() int64 {
+	return aws.ToInt64(s.head.ContentLength)
+}
+
+func (s sourceBlob) versionIDOrNil() *string {
+	if s.versionID == "null" {
+		return nil
+	}
+	return aws.String(s.versionID)
+}
+
+// applyMetadataToCreateMultipartUpload sets the metadata of a multipart
+// upload to that of the source blob, which CopyObject otherwise copies by
+// default.
+func (s sourceBlob) applyMetadataToCreateMultipartUpload(input *s3.CreateMultipartUploadInput) {
+	input.CacheControl = s.head.CacheControl
+	input.ContentDisposition = s.head.ContentDisposition
+	input.ContentEncoding = s.head.ContentEncoding
+	input.ContentLanguage = s.head.ContentLanguage
+	input.ContentType = s.head.ContentType
+	input.Expires = s.head.Expires
+	input.Metadata = s.head.Metadata
+	input.WebsiteRedirectLocation = s.head.WebsiteRedirectLocation
+}
+
+// applyMetadataToPutObject sets the metadata of a streamed copy to that of
+// the source blob.
+func (s sourceBlob) applyMetadataToPutObject(input *s3.PutObjectInput) {
+	input.CacheControl = s.head.CacheControl
+	input.ContentDisposition = s.head.ContentDisposition
+	input.ContentEncoding = s.head.ContentEncoding
+	input.ContentLanguage = s.head.ContentLanguage
+	input.ContentType = s.head.ContentType
+	input.Expires = s.head.Expires
+	input.Metadata = s.head.Metadata
+	input.WebsiteRedirectLocation = s.head.WebsiteRedirectLocation
+}
+
+// tagging returns the tags of the source blob encoded as a URL query, or nil
+// when it has none.
+func (s sourceBlob) tagging() (*string, error) {
+	output, err := s.client.GetObjectTagging(context.TODO(), &s3.GetObjectTaggingInput{
+		Bucket:    aws.String(s.bucketName),
+		Key:       aws.String(s.key),
+		VersionId: s.versionIDOrNil(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags of blob '%s' in bucket '%s': %s", s.key, s.bucketName, err)
+	}
+
+	if len(output.TagSet) == 0 {
+		return nil, nil
+	}
+
+	tags := url.Values{}
+	for _, tag := range output.TagSet {
+		tags.Add(aws.ToString(tag.Key), aws.ToString(tag.Value))
+	}
+	return aws.String(tags.Encode()), nil
+}
+
+// copyACL gives the copy of a blob the ACL of the source blob. Neither
+// CopyObject nor a multipart upload copies ACLs.
+func (b Bucket) copyACL(source sourceBlob, destinationKey string) error {
+	output, err := source.client.GetObjectAcl(context.TODO(), &s3.GetObjectAclInput{
+		Bucket:    aws.String(source.bucketName),
+		Key:       aws.String(source.key),
+		VersionId: source.versionIDOrNil(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get ACL of blob '%s' in bucket '%s': %s", source.key, source.bucketName, err)
+	}
+
+	_, err = b.s3Client.PutObjectAcl(context.TODO(), &s3.PutObjectAclInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(destinationKey),
+		AccessControlPolicy: &types.AccessControlPolicy{
+			Grants: output.Grants,
+			Owner:  output.Owner,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put ACL of blob '%s': %s", destinationKey, err)
+	}
+
+	return nil
+}
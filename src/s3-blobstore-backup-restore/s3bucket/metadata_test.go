@@ -0,0 +1,103 @@
+package s3bucket_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"s3-blobstore-backup-restore/s3bucket"
+)
+
+var _ = Describe("Copying metadata, tags and ACLs", func() {
+	var fakeS3 *fakeS3Server
+	var options s3bucket.Options
+	var err error
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(23 * mebibyte)
+		fakeS3.SetBlobAttributes(http.Header{
+			"Content-Type":        {"application/gzip"},
+			"Cache-Control":       {"max-age=3600"},
+			"Content-Disposition": {"attachment"},
+			"X-Amz-Meta-Owner":    {"the-team"},
+		}, map[string]string{"environment": "production", "kind": "droplet"})
+		options = s3bucket.Options{}
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+	})
+
+	JustBeforeEach(func() {
+		bucket, newBucketErr := s3bucket.NewBucket("destination", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+
+		err = bucket.WithOptions(options).CopyVersion("a-blob", "a-version", "source", "us-east-1")
+	})
+
+	It("leaves copying the metadata and tags to CopyObject", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeS3.Header("GetObjectTagging")).To(BeNil())
+		Expect(fakeS3.Header("CopyObject")).NotTo(HaveKey("X-Amz-Metadata-Directive"))
+		Expect(fakeS3.Header("CopyObject")).NotTo(HaveKey("X-Amz-Tagging-Directive"))
+	})
+
+	It("does not copy the ACL", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeS3.Header("GetObjectAcl")).To(BeNil())
+		Expect(fakeS3.Header("PutObjectAcl")).To(BeNil())
+	})
+
+	Context("when the blob is copied in parts", func() {
+		BeforeEach(func() {
+			options.Multipart = s3bucket.MultipartConfig{ThresholdMB: 10, PartSizeMB: 5}
+		})
+
+		It("creates the upload with the metadata and tags of the source blob", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			header := fakeS3.Header("CreateMultipartUpload")
+			Expect(header.Get("Content-Type")).To(Equal("application/gzip"))
+			Expect(header.Get("Cache-Control")).To(Equal("max-age=3600"))
+			Expect(header.Get("Content-Disposition")).To(Equal("attachment"))
+			Expect(header.Get("X-Amz-Meta-Owner")).To(Equal("the-team"))
+			Expect(header.Get("X-Amz-Tagging")).To(Equal("environment=production&kind=droplet"))
+		})
+
+		Context("and the source blob has no tags", func() {
+			BeforeEach(func() {
+				fakeS3.SetBlobAttributes(http.Header{}, nil)
+			})
+
+			It("creates the upload without tags", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.Header("CreateMultipartUpload")).NotTo(HaveKey("X-Amz-Tagging"))
+			})
+		})
+	})
+
+	Context("when the bucket copies ACLs", func() {
+		BeforeEach(func() {
+			options.CopyACL = true
+		})
+
+		It("gives the copy the ACL of the source blob", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.PutACLBody()).To(ContainSubstring("<ID>the-owner</ID>"))
+			Expect(fakeS3.PutACLBody()).To(ContainSubstring("<URI>http://acs.amazonaws.com/groups/global/AllUsers</URI>"))
+			Expect(fakeS3.PutACLBody()).To(ContainSubstring("<Permission>READ</Permission>"))
+		})
+
+		Context("and the blob is copied in parts", func() {
+			BeforeEach(func() {
+				options.Multipart = s3bucket.MultipartConfig{ThresholdMB: 10, PartSizeMB: 5}
+			})
+
+			It("gives the copy the ACL of the source blob", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.PutACLBody()).To(ContainSubstring("<Permission>FULL_CONTROL</Permission>"))
+			})
+		})
+	})
+})
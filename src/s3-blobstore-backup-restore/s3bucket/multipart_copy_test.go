@@ -0,0 +1,165 @@
+package s3bucket_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"common/retry"
+
+	"s3-blobstore-backup-restore/s3bucket"
+)
+
+const mebibyte = 1024 * 1024
+
+var _ = Describe("Copying a version in parts", func() {
+	var fakeS3 *fakeS3Server
+	var multipartConfig s3bucket.MultipartConfig
+	var retryPolicy retry.Policy
+	var restorePartRetryDelay func()
+	var err error
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(23 * mebibyte)
+		multipartConfig = s3bucket.MultipartConfig{ThresholdMB: 10, PartSizeMB: 5}
+		retryPolicy = retry.Policy{}
+		restorePartRetryDelay = s3bucket.SetPartRetryDelay(time.Millisecond)
+	})
+
+	AfterEach(func() {
+		restorePartRetryDelay()
+		fakeS3.Close()
+	})
+
+	JustBeforeEach(func() {
+		bucket, newBucketErr := s3bucket.NewBucket("destination", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+
+		err = bucket.WithOptions(s3bucket.Options{Multipart: multipartConfig, Retry: retryPolicy}).CopyVersion("a-blob", "a-version", "source", "us-east-1")
+	})
+
+	It("copies each part of the configured size and completes the upload with every part", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeS3.CopyObjectCalls()).To(Equal(0))
+
+		Expect(fakeS3.CopySourceRange(1)).To(Equal("bytes=0-5242879"))
+		Expect(fakeS3.CopySourceRange(4)).To(Equal("bytes=15728640-20971519"))
+		Expect(fakeS3.CopySourceRange(5)).To(Equal("bytes=20971520-24117247"))
+		Expect(fakeS3.PartAttempts(6)).To(Equal(0))
+
+		for partNumber := 1; partNumber <= 5; partNumber++ {
+			Expect(fakeS3.CompleteBody()).To(ContainSubstring(`<ETag>&#34;etag-%d&#34;</ETag><PartNumber>%d</PartNumber>`, partNumber, partNumber))
+		}
+	})
+
+	Context("when the blob is no larger than the threshold", func() {
+		BeforeEach(func() {
+			multipartConfig.ThresholdMB = 23
+		})
+
+		It("copies it with a single request", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.CopyObjectCalls()).To(Equal(1))
+			Expect(fakeS3.PartAttempts(1)).To(Equal(0))
+		})
+	})
+
+	Context("when the parts are slow to copy", func() {
+		BeforeEach(func() {
+			fakeS3.partDelay = 50 * time.Millisecond
+			multipartConfig.MaxPartsInFlight = 3
+		})
+
+		It("copies several parts at once, up to the max parts in flight", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.MaxInFlight()).To(Equal(3))
+		})
+	})
+
+	Context("when copying a part fails and then succeeds", func() {
+		BeforeEach(func() {
+			fakeS3.failPart = func(partNumber, attempt int) bool {
+				return partNumber == 2 && attempt < 3
+			}
+		})
+
+		It("retries the part and completes the upload", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.PartAttempts(2)).To(Equal(3))
+			Expect(fakeS3.PartAttempts(1)).To(Equal(1))
+			Expect(fakeS3.Aborted()).To(BeFalse())
+			Expect(fakeS3.CompleteBody()).To(ContainSubstring("<PartNumber>2</PartNumber>"))
+		})
+	})
+
+	Context("when copying a part keeps failing", func() {
+		BeforeEach(func() {
+			fakeS3.failPart = func(partNumber, _ int) bool {
+				return partNumber == 2
+			}
+		})
+
+		It("aborts the upload", func() {
+			Expect(err).To(MatchError(ContainSubstring("failed to upload part with range: 5242880-10485759 after 3 attempts")))
+			Expect(fakeS3.PartAttempts(2)).To(Equal(3))
+			Expect(fakeS3.Aborted()).To(BeTrue())
+			Expect(fakeS3.CompleteBody()).To(BeEmpty())
+		})
+	})
+
+	Context("when S3 keeps throttling a part", func() {
+		BeforeEach(func() {
+			retryPolicy = retry.Policy{MaxAttempts: 4, BaseDelayMS: 1}
+			fakeS3.slowDownParts = true
+			fakeS3.failPart = func(partNumber, _ int) bool {
+				return partNumber == 2
+			}
+		})
+
+		It("only makes the attempts of the retry policy before aborting the upload", func() {
+			Expect(err).To(MatchError(ContainSubstring("failed to upload part with range: 5242880-10485759")))
+			Expect(err).To(MatchError(ContainSubstring("SlowDown")))
+			Expect(fakeS3.PartAttempts(2)).To(Equal(4))
+			Expect(fakeS3.Aborted()).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("MultipartConfig", func() {
+	Describe("PartSizeFor", func() {
+		It("uses 100MiB parts by default", func() {
+			Expect(s3bucket.MultipartConfig{}.PartSizeFor(2 * 1024 * mebibyte)).To(Equal(int64(100 * mebibyte)))
+		})
+
+		It("uses the configured part size", func() {
+			Expect(s3bucket.MultipartConfig{PartSizeMB: 64}.PartSizeFor(2 * 1024 * mebibyte)).To(Equal(int64(64 * mebibyte)))
+		})
+
+		It("scales the part size up to a whole MiB when the blob would need more than 10,000 parts", func() {
+			blobSize := int64(2 * 1024 * 1024 * mebibyte)
+			partSize := s3bucket.MultipartConfig{PartSizeMB: 100}.PartSizeFor(blobSize)
+
+			Expect(partSize).To(Equal(int64(210 * mebibyte)))
+			Expect((blobSize + partSize - 1) / partSize).To(BeNumerically("<=", 10000))
+		})
+	})
+
+	DescribeTable("Validate",
+		func(config s3bucket.MultipartConfig, expectedError string) {
+			err := config.Validate()
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("the defaults", s3bucket.MultipartConfig{}, ""),
+		Entry("a valid config", s3bucket.MultipartConfig{ThresholdMB: 5120, PartSizeMB: 5, MaxPartsInFlight: 20}, ""),
+		Entry("a threshold above 5GiB", s3bucket.MultipartConfig{ThresholdMB: 5121}, "multipart.threshold_mb must be between 1 and 5120"),
+		Entry("a negative threshold", s3bucket.MultipartConfig{ThresholdMB: -1}, "multipart.threshold_mb must be between 1 and 5120"),
+		Entry("a part size below 5MiB", s3bucket.MultipartConfig{PartSizeMB: 4}, "multipart.part_size_mb must be between 5 and 5120"),
+		Entry("a part size above 5GiB", s3bucket.MultipartConfig{PartSizeMB: 5121}, "multipart.part_size_mb must be between 5 and 5120"),
+		Entry("a negative max parts in flight", s3bucket.MultipartConfig{MaxPartsInFlight: -1}, "multipart.max_parts_in_flight must be at least 1"),
+	)
+})
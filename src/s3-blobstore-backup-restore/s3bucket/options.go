@@ -0,0 +1,188 @@
+package s3bucket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	awsretry "github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+
+	"common/retry"
+)
+
+const (
+	mebibyte int64 = 1024 * 1024
+
+	// S3 limits on copies: a single CopyObject request can copy at most
+	// 5GiB, and a multipart upload has parts of 5MiB to 5GiB, and at most
+	// 10,000 of them.
+	maxCopyObjectSizeMB int64 = 5 * 1024
+	minPartSizeMB       int64 = 5
+	maxPartSizeMB       int64 = 5 * 1024
+	maxParts            int64 = 10000
+
+	DefaultMultipartThresholdMB int64 = 1024
+	DefaultMultipartPartSizeMB  int64 = 100
+	DefaultMaxPartsInFlight           = 10
+
+	partAttempts = 3
+
+	rateLimitMiddlewareID = "BlobstoreRateLimit"
+)
+
+var partRetryDelay = time.Second
+
+// Options holds the settings of a bucket which do not affect which S3
+// endpoint its client connects to or how it authenticates.
+type Options struct {
+	Multipart MultipartConfig
+	Retry     retry.Policy
+	// MaxRequestsPerSecond limits the requests made for the bucket, or does
+	// not limit them when it is zero.
+	MaxRequestsPerSecond float64
+	Encryption           EncryptionConfig
+	// StorageClass is the storage class of the blobs copied to the bucket, or
+	// STANDARD when it is empty. Blobs uploaded to the bucket, such as the
+	// backup_complete marker, are always STANDARD.
+	StorageClass   string
+	ArchiveRestore ArchiveRestoreConfig
+	// CopyACL is whether the blobs copied to the bucket are given the ACLs of
+	// the blobs they are copied from.
+	CopyACL      bool
+	Verification VerificationConfig
+	// Source is how the buckets which versions are copied from with
+	// CopyVersion are reached, when it is not the same as this bucket.
+	Source *AccessConfig
+}
+
+func (o Options) Validate() error {
+	if err := o.Multipart.Validate(); err != nil {
+		return err
+	}
+
+	if err := o.Retry.Validate(); err != nil {
+		return err
+	}
+
+	if err := retry.ValidateRequestsPerSecond(o.MaxRequestsPerSecond); err != nil {
+		return err
+	}
+
+	if err := o.Encryption.Validate(); err != nil {
+		return err
+	}
+
+	if err := ValidateStorageClass(o.StorageClass); err != nil {
+		return err
+	}
+
+	if err := o.Verification.Validate(); err != nil {
+		return err
+	}
+
+	if o.Source != nil {
+		if err := o.Source.Validate(); err != nil {
+			return fmt.Errorf("restore_source: %s", err)
+		}
+	}
+
+	return o.ArchiveRestore.Validate()
+}
+
+// clientOptFn configures an S3 client to retry failed requests, including
+// throttled ones, with the retry policy, and to wait for the rate limiter
+// before each attempt.
+func (o Options) clientOptFn() func(*s3.Options) {
+	limiter := retry.NewLimiter(o.MaxRequestsPerSecond)
+
+	return func(clientOptions *s3.Options) {
+		clientOptions.Retryer = awsretry.NewStandard(func(standardOptions *awsretry.StandardOptions) {
+			standardOptions.MaxAttempts = o.Retry.Attempts()
+			standardOptions.MaxBackoff = retry.MaxDelay
+			standardOptions.Backoff = retryBackoff{policy: o.Retry}
+			// The retry quota stops retries once many requests have failed,
+			// which is when a busy blobstore most needs them.
+			standardOptions.RateLimiter = ratelimit.None
+		})
+
+		clientOptions.APIOptions = append(clientOptions.APIOptions[:len(clientOptions.APIOptions):len(clientOptions.APIOptions)], func(stack *middleware.Stack) error {
+			// A client copied from another bucket's client already waits for
+			// that bucket's limiter.
+			if _, ok := stack.Finalize.Get(rateLimitMiddlewareID); ok {
+				stack.Finalize.Remove(rateLimitMiddlewareID)
+			}
+
+			return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc(rateLimitMiddlewareID,
+				func(ctx context.Context, input middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+					limiter.Wait()
+					return next.HandleFinalize(ctx, input)
+				},
+			), middleware.After)
+		})
+	}
+}
+
+type retryBackoff struct {
+	policy retry.Policy
+}
+
+func (b retryBackoff) BackoffDelay(attempt int, _ error) (time.Duration, error) {
+	return b.policy.Delay(attempt), nil
+}
+
+// MultipartConfig controls how blobs larger than the threshold are copied in
+// parts. Zero values mean the defaults.
+type MultipartConfig struct {
+	ThresholdMB      int64 `json:"threshold_mb,omitempty"`
+	PartSizeMB       int64 `json:"part_size_mb,omitempty"`
+	MaxPartsInFlight int   `json:"max_parts_in_flight,omitempty"`
+}
+
+func (c MultipartConfig) Validate() error {
+	if c.ThresholdMB < 0 || c.ThresholdMB > maxCopyObjectSizeMB {
+		return fmt.Errorf("multipart.threshold_mb must be between 1 and %d", maxCopyObjectSizeMB)
+	}
+
+	if c.PartSizeMB != 0 && (c.PartSizeMB < minPartSizeMB || c.PartSizeMB > maxPartSizeMB) {
+		return fmt.Errorf("multipart.part_size_mb must be between %d and %d", minPartSizeMB, maxPartSizeMB)
+	}
+
+	if c.MaxPartsInFlight < 0 {
+		return fmt.Errorf("multipart.max_parts_in_flight must be at least 1")
+	}
+
+	return nil
+}
+
+// threshold is the size in bytes above which blobs are copied in parts.
+func (c MultipartConfig) threshold() int64 {
+	if c.ThresholdMB == 0 {
+		return DefaultMultipartThresholdMB * mebibyte
+	}
+	return c.ThresholdMB * mebibyte
+}
+
+// partSizeFor returns the configured part size in bytes, scaled up to a whole
+// number of MiB when the blob would otherwise need more than 10,000 parts.
+func (c MultipartConfig) partSizeFor(blobSize int64) int64 {
+	partSize := DefaultMultipartPartSizeMB * mebibyte
+	if c.PartSizeMB != 0 {
+		partSize = c.PartSizeMB * mebibyte
+	}
+
+	if minPartSize := (blobSize + maxParts - 1) / maxParts; partSize < minPartSize {
+		partSize = (minPartSize + mebibyte - 1) / mebibyte * mebibyte
+	}
+
+	return partSize
+}
+
+func (c MultipartConfig) maxPartsInFlight() int {
+	if c.MaxPartsInFlight == 0 {
+		return DefaultMaxPartsInFlight
+	}
+	return c.MaxPartsInFlight
+}
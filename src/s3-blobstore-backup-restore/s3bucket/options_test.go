@@ -0,0 +1,94 @@
+package s3bucket_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"common/retry"
+
+	"s3-blobstore-backup-restore/s3bucket"
+)
+
+var _ = Describe("Bucket options", func() {
+	var fakeS3 *fakeS3Server
+	var options s3bucket.Options
+	var err error
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(mebibyte)
+		options = s3bucket.Options{Retry: retry.Policy{MaxAttempts: 4, BaseDelayMS: 1}}
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+	})
+
+	JustBeforeEach(func() {
+		bucket, newBucketErr := s3bucket.NewBucket("destination", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+
+		err = bucket.WithOptions(options).CopyVersion("a-blob", "a-version", "source", "us-east-1")
+	})
+
+	Context("when S3 asks for requests to slow down", func() {
+		BeforeEach(func() {
+			fakeS3.failCopyObject = func(attempt int) bool {
+				return attempt < 4
+			}
+		})
+
+		It("retries the request until it succeeds", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.CopyObjectCalls()).To(Equal(4))
+		})
+	})
+
+	Context("when S3 keeps asking for requests to slow down", func() {
+		BeforeEach(func() {
+			fakeS3.failCopyObject = func(int) bool {
+				return true
+			}
+		})
+
+		It("gives up after the max attempts", func() {
+			Expect(err).To(MatchError(ContainSubstring("SlowDown")))
+			Expect(fakeS3.CopyObjectCalls()).To(Equal(4))
+		})
+	})
+
+	Context("when the requests per second are limited", func() {
+		BeforeEach(func() {
+			options.MaxRequestsPerSecond = 20
+			fakeS3.failCopyObject = func(attempt int) bool {
+				return attempt < 3
+			}
+		})
+
+		It("spaces out the requests, including retries", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			requestTimes := fakeS3.RequestTimes()
+			Expect(requestTimes).To(HaveLen(4))
+			Expect(requestTimes[3].Sub(requestTimes[0])).To(BeNumerically(">=", 140*time.Millisecond))
+		})
+	})
+})
+
+var _ = Describe("Options", func() {
+	DescribeTable("Validate",
+		func(options s3bucket.Options, expectedError string) {
+			err := options.Validate()
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("the defaults", s3bucket.Options{}, ""),
+		Entry("an invalid multipart config", s3bucket.Options{Multipart: s3bucket.MultipartConfig{PartSizeMB: 1}}, "multipart.part_size_mb must be between 5 and 5120"),
+		Entry("an invalid retry policy", s3bucket.Options{Retry: retry.Policy{MaxAttempts: -1}}, "retry.max_attempts must be at least 1"),
+		Entry("a negative request rate", s3bucket.Options{MaxRequestsPerSecond: -1}, "max_requests_per_second must not be negative"),
+	)
+})
@@ -0,0 +1,268 @@
+package s3bucket
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+)
+
+// maxSourceProfiles limits how many profiles a role_arn profile can be chained
+// through with source_profile.
+const maxSourceProfiles = 10
+
+// sharedProfile is a profile read from the shared credentials and config
+// files, with the settings in the credentials file taking precedence.
+type sharedProfile struct {
+	name     string
+	settings map[string]string
+	files    sharedFiles
+}
+
+// sharedFiles are the profiles in the shared credentials and config files,
+// by name.
+type sharedFiles map[string]map[string]string
+
+func loadSharedProfile(name string) (sharedProfile, error) {
+	files := sharedFiles{}
+
+	configFile, err := sharedFilePath("AWS_CONFIG_FILE", "config")
+	if err != nil {
+		return sharedProfile{}, err
+	}
+	if err := files.read(configFile, true); err != nil {
+		return sharedProfile{}, err
+	}
+
+	credentialsFile, err := sharedFilePath("AWS_SHARED_CREDENTIALS_FILE", "credentials")
+	if err != nil {
+		return sharedProfile{}, err
+	}
+	if err := files.read(credentialsFile, false); err != nil {
+		return sharedProfile{}, err
+	}
+
+	return files.profile(name)
+}
+
+func sharedFilePath(variable, name string) (string, error) {
+	if path := os.Getenv(variable); path != "" {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the shared %s file: %s", name, err)
+	}
+	return filepath.Join(homeDir, ".aws", name), nil
+}
+
+// read adds the settings of the profiles in an INI file, replacing those read
+// before. In the config file, profiles other than the default are named
+// "profile <name>". Missing files have no profiles.
+//
+// The file is read as the AWS SDKs read it: comments start a line, or follow
+// whitespace after a setting; a setting with an empty value, such as s3, is
+// followed by the indented settings nested in it; and an indented line which
+// is not a setting continues the value of the setting before it.
+func (f sharedFiles) read(path string, isConfigFile bool) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", path, err)
+	}
+	defer file.Close()
+
+	var settings map[string]string
+	var key string
+	var isNested bool
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+
+		if section := strings.TrimSpace(trimSectionComment(trimmed)); strings.HasPrefix(section, "[") && strings.HasSuffix(section, "]") {
+			key = ""
+			name := strings.TrimSpace(strings.Trim(section, "[]"))
+			if isConfigFile {
+				if profileName, ok := strings.CutPrefix(name, "profile "); ok {
+					name = strings.TrimSpace(profileName)
+				} else if name != "default" {
+					// Other sections, such as sso-session, are not
+					// profiles.
+					settings = nil
+					continue
+				}
+			}
+			if f[name] == nil {
+				f[name] = map[string]string{}
+			}
+			settings = f[name]
+			continue
+		}
+
+		if settings == nil {
+			continue
+		}
+
+		isIndented := line[0] == ' ' || line[0] == '\t'
+		name, value, isSetting := splitSetting(trimSettingComment(trimmed))
+		switch {
+		case isIndented && key != "" && isNested:
+			// The settings nested in another, such as those of s3, do not
+			// affect credentials.
+		case isIndented && key != "" && !isSetting:
+			settings[key] += "\n" + trimmed
+		case isSetting:
+			key = name
+			isNested = value == ""
+			settings[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %s", path, err)
+	}
+
+	return nil
+}
+
+func trimSectionComment(line string) string {
+	line, _, _ = strings.Cut(line, "#")
+	line, _, _ = strings.Cut(line, ";")
+	return line
+}
+
+func trimSettingComment(line string) string {
+	for _, commentStart := range []string{" #", " ;", "\t#", "\t;"} {
+		line, _, _ = strings.Cut(line, commentStart)
+	}
+	return line
+}
+
+// splitSetting returns the lower case name and the value of a setting, which
+// is separated from its name by = or, as in older files, by :. Quotes around
+// the value are removed.
+func splitSetting(line string) (string, string, bool) {
+	separator := "="
+	if equals, colon := strings.Index(line, "="), strings.Index(line, ":"); equals == -1 || colon != -1 && colon < equals {
+		separator = ":"
+	}
+
+	name, value, ok := strings.Cut(line, separator)
+	if !ok {
+		return "", "", false
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return strings.ToLower(strings.TrimSpace(name)), value, true
+}
+
+func (f sharedFiles) profile(name string) (sharedProfile, error) {
+	settings, ok := f[name]
+	if !ok {
+		return sharedProfile{}, fmt.Errorf("failed to find AWS profile %s", name)
+	}
+	return sharedProfile{name: name, settings: settings, files: f}, nil
+}
+
+// provider returns the credentials of the profile: its access key, or a role
+// assumed with the credentials of its source_profile, credential_source or
+// web_identity_token_file.
+func (p sharedProfile) provider(regionName, endpoint string) (aws.CredentialsProvider, error) {
+	return p.chainedProvider(regionName, endpoint, 0)
+}
+
+func (p sharedProfile) chainedProvider(regionName, endpoint string, depth int) (aws.CredentialsProvider, error) {
+	if err := p.checkSupported(); err != nil {
+		return nil, err
+	}
+
+	roleARN := p.settings["role_arn"]
+	if roleARN == "" {
+		return p.accessKeyProvider()
+	}
+
+	if tokenFile := p.settings["web_identity_token_file"]; tokenFile != "" {
+		return webIdentityProvider(regionName, endpoint, roleARN, tokenFile, p.settings["role_session_name"]), nil
+	}
+
+	var base aws.CredentialsProvider
+	var err error
+	switch sourceProfile, credentialSource := p.settings["source_profile"], p.settings["credential_source"]; {
+	case sourceProfile == p.name:
+		// A profile which is its own source assumes its role with its own
+		// access key.
+		base, err = p.accessKeyProvider()
+	case sourceProfile != "":
+		if depth == maxSourceProfiles {
+			return nil, fmt.Errorf("AWS profile %s is chained through more than %d source profiles", p.name, maxSourceProfiles)
+		}
+		var source sharedProfile
+		source, err = p.files.profile(sourceProfile)
+		if err == nil {
+			base, err = source.chainedProvider(regionName, endpoint, depth+1)
+		}
+	case credentialSource == "Ec2InstanceMetadata":
+		base = aws.NewCredentialsCache(ec2rolecreds.New())
+	case credentialSource == "EcsContainer":
+		base, err = containerProvider()
+	case credentialSource == "Environment":
+		base = aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(
+			os.Getenv("AWS_ACCESS_KEY_ID"),
+			os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			os.Getenv("AWS_SESSION_TOKEN"),
+		))
+	case credentialSource != "":
+		return nil, fmt.Errorf("AWS profile %s has unsupported credential_source %s", p.name, credentialSource)
+	default:
+		return nil, fmt.Errorf("AWS profile %s sets role_arn without source_profile, credential_source or web_identity_token_file", p.name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return assumeRoleProvider(regionName, endpoint, base, roleARN, p.settings["external_id"], p.settings["role_session_name"]), nil
+}
+
+// checkSupported fails for profiles which get their credentials in ways that
+// are not supported, rather than using other credentials they set.
+func (p sharedProfile) checkSupported() error {
+	var unsupported []string
+	for name := range p.settings {
+		if name == "credential_process" || strings.HasPrefix(name, "sso_") {
+			unsupported = append(unsupported, name)
+		}
+	}
+	if len(unsupported) != 0 {
+		sort.Strings(unsupported)
+		return fmt.Errorf("AWS profile %s sets %s, which is not supported; set access keys, role_arn or web_identity_token_file instead", p.name, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+func (p sharedProfile) accessKeyProvider() (aws.CredentialsProvider, error) {
+	if p.settings["aws_access_key_id"] == "" {
+		return nil, fmt.Errorf("AWS profile %s has no credentials", p.name)
+	}
+
+	return aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(
+		p.settings["aws_access_key_id"],
+		p.settings["aws_secret_access_key"],
+		p.settings["aws_session_token"],
+	)), nil
+}
@@ -0,0 +1,234 @@
+package s3bucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"common/executor"
+)
+
+const (
+	DefaultArchiveRestoreTier               = string(types.TierStandard)
+	DefaultArchiveRestoreDays         int32 = 1
+	DefaultArchiveRestoreTimeoutHours       = 48
+)
+
+var (
+	archiveRestorePollInterval = time.Minute
+	archiveRestoreTimeoutUnit  = time.Hour
+)
+
+// storageClasses are the classes blobs can be copied to. Blobs in GLACIER or
+// DEEP_ARCHIVE cannot be copied until they are restored, and each backup
+// copies the unchanged blobs of the previous one, so backups must not be
+// written to those classes.
+var storageClasses = []types.StorageClass{
+	types.StorageClassStandard,
+	types.StorageClassStandardIa,
+	types.StorageClassOnezoneIa,
+	types.StorageClassIntelligentTiering,
+	types.StorageClassGlacierIr,
+}
+
+func ValidateStorageClass(storageClass string) error {
+	if storageClass == "" {
+		return nil
+	}
+
+	names := make([]string, len(storageClasses))
+	for i, class := range storageClasses {
+		if storageClass == string(class) {
+			return nil
+		}
+		names[i] = string(class)
+	}
+
+	return fmt.Errorf("storage_class must be one of %s", strings.Join(names, ", "))
+}
+
+// ArchiveRestoreConfig is how blobs in GLACIER or DEEP_ARCHIVE, or in an
+// archive tier of INTELLIGENT_TIERING, are restored before they are copied.
+// Zero values mean the defaults.
+type ArchiveRestoreConfig struct {
+	// Enabled is whether the blobs are checked and restored when the bucket
+	// does not copy blobs to INTELLIGENT_TIERING, such as when lifecycle
+	// rules move them to GLACIER or DEEP_ARCHIVE.
+	Enabled      bool   `json:"enabled,omitempty"`
+	Tier         string `json:"tier,omitempty"`
+	Days         int32  `json:"days,omitempty"`
+	TimeoutHours int    `json:"timeout_hours,omitempty"`
+}
+
+func (c ArchiveRestoreConfig) Validate() error {
+	switch types.Tier(c.Tier) {
+	case "", types.TierStandard, types.TierBulk, types.TierExpedited:
+	default:
+		return fmt.Errorf("archive_restore.tier must be %s, %s or %s", types.TierStandard, types.TierBulk, types.TierExpedited)
+	}
+
+	if c.Days < 0 {
+		return fmt.Errorf("archive_restore.days must be at least 1")
+	}
+
+	if c.TimeoutHours < 0 {
+		return fmt.Errorf("archive_restore.timeout_hours must be at least 1")
+	}
+
+	return nil
+}
+
+func (c ArchiveRestoreConfig) tier() types.Tier {
+	if c.Tier == "" {
+		return types.Tier(DefaultArchiveRestoreTier)
+	}
+	return types.Tier(c.Tier)
+}
+
+func (c ArchiveRestoreConfig) days() int32 {
+	if c.Days == 0 {
+		return DefaultArchiveRestoreDays
+	}
+	return c.Days
+}
+
+func (c ArchiveRestoreConfig) timeout() time.Duration {
+	if c.TimeoutHours == 0 {
+		return DefaultArchiveRestoreTimeoutHours * archiveRestoreTimeoutUnit
+	}
+	return time.Duration(c.TimeoutHours) * archiveRestoreTimeoutUnit
+}
+
+// restoresArchivedBlobs is whether blobs in the bucket may be archived, which
+// they can only be when they are copied to INTELLIGENT_TIERING or when
+// archive restores are enabled.
+func (o Options) restoresArchivedBlobs() bool {
+	return o.StorageClass == string(types.StorageClassIntelligentTiering) || o.ArchiveRestore.Enabled
+}
+
+// RestoreArchivedBlobs requests a temporary copy of each of the blobs which
+// is archived, checking maxInFlight blobs at once, and waits until all of
+// them can be copied or the archive restore timeout elapses. The copies are
+// requested at once, since restoring from an archive takes hours. It does
+// nothing when blobs in the bucket cannot be archived.
+func (b Bucket) RestoreArchivedBlobs(paths []string, maxInFlight int) error {
+	if !b.options.restoresArchivedBlobs() {
+		return nil
+	}
+
+	timeout := b.options.ArchiveRestore.timeout()
+	deadline := time.Now().Add(timeout)
+
+	var mutex sync.Mutex
+	var pending []string
+	onPending := func(path string) {
+		mutex.Lock()
+		pending = append(pending, path)
+		mutex.Unlock()
+	}
+
+	pathsToCheck := paths
+	for len(pathsToCheck) != 0 {
+		var executables []executor.Executable
+		for _, path := range pathsToCheck {
+			executables = append(executables, restoreArchivedBlobExecutable{bucket: b, path: path, onPending: onPending})
+		}
+
+		pending = nil
+		errs := executor.NewParallelExecutor(maxInFlight).Run([][]executor.Executable{executables})
+		if len(errs) != 0 {
+			return formatErrors(fmt.Sprintf("failed to restore archived blobs in bucket %s", b.name), errs)
+		}
+
+		if len(pending) != 0 {
+			if !time.Now().Before(deadline) {
+				return fmt.Errorf(
+					"timed out after %s waiting for archived blobs in bucket %s to be restored: %s",
+					timeout,
+					b.name,
+					strings.Join(pending, ", "),
+				)
+			}
+			time.Sleep(archiveRestorePollInterval)
+		}
+		pathsToCheck = pending
+	}
+
+	return nil
+}
+
+// restoreArchivedBlobExecutable requests a restore of a blob when it is
+// archived and not already being restored, and calls onPending when the blob
+// cannot be copied yet.
+type restoreArchivedBlobExecutable struct {
+	bucket    Bucket
+	path      string
+	onPending func(path string)
+}
+
+func (e restoreArchivedBlobExecutable) Execute() error {
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(e.bucket.name),
+		Key:    aws.String(e.path),
+	}
+	e.bucket.options.Encryption.applyToHeadObject(input)
+
+	output, err := e.bucket.s3Client.HeadObject(context.TODO(), input)
+	if err != nil {
+		return fmt.Errorf("failed to check if blob '%s' is archived: %s", e.path, err)
+	}
+
+	restore := aws.ToString(output.Restore)
+	switch {
+	case strings.Contains(restore, `ongoing-request="false"`):
+		return nil
+	case strings.Contains(restore, `ongoing-request="true"`):
+		e.onPending(e.path)
+		return nil
+	case output.ArchiveStatus != "":
+		// Blobs in an archive tier of INTELLIGENT_TIERING are moved back to
+		// the frequent access tier, so the request takes no number of days.
+		err = e.requestRestore(&types.RestoreRequest{
+			GlacierJobParameters: &types.GlacierJobParameters{Tier: e.bucket.options.ArchiveRestore.tier()},
+		})
+	case output.StorageClass == types.StorageClassGlacier || output.StorageClass == types.StorageClassDeepArchive:
+		err = e.requestRestore(&types.RestoreRequest{
+			Days:                 aws.Int32(e.bucket.options.ArchiveRestore.days()),
+			GlacierJobParameters: &types.GlacierJobParameters{Tier: e.bucket.options.ArchiveRestore.tier()},
+		})
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+	e.onPending(e.path)
+	return nil
+}
+
+func (e restoreArchivedBlobExecutable) requestRestore(request *types.RestoreRequest) error {
+	_, err := e.bucket.s3Client.RestoreObject(context.TODO(), &s3.RestoreObjectInput{
+		Bucket:         aws.String(e.bucket.name),
+		Key:            aws.String(e.path),
+		RestoreRequest: request,
+	})
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "RestoreAlreadyInProgress" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore archived blob '%s': %s", e.path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package s3bucket_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"s3-blobstore-backup-restore/s3bucket"
+)
+
+var _ = Describe("Copying with a storage class", func() {
+	var fakeS3 *fakeS3Server
+	var options s3bucket.Options
+	var err error
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(23 * mebibyte)
+		options = s3bucket.Options{}
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+	})
+
+	JustBeforeEach(func() {
+		bucket, newBucketErr := s3bucket.NewBucket("destination", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+
+		err = bucket.WithOptions(options).CopyVersion("a-blob", "a-version", "source", "us-east-1")
+	})
+
+	It("leaves the storage class to S3", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeS3.Header("CopyObject")).NotTo(HaveKey("X-Amz-Storage-Class"))
+	})
+
+	Context("when the bucket sets a storage class", func() {
+		BeforeEach(func() {
+			options.StorageClass = "STANDARD_IA"
+		})
+
+		It("copies the blob to the storage class", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.Header("CopyObject").Get("X-Amz-Storage-Class")).To(Equal("STANDARD_IA"))
+		})
+
+		Context("and the blob is copied in parts", func() {
+			BeforeEach(func() {
+				options.Multipart = s3bucket.MultipartConfig{ThresholdMB: 10, PartSizeMB: 5}
+			})
+
+			It("uploads the blob to the storage class", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.Header("CreateMultipartUpload").Get("X-Amz-Storage-Class")).To(Equal("STANDARD_IA"))
+			})
+		})
+	})
+})
+
+var _ = Describe("Restoring archived blobs", func() {
+	var fakeS3 *fakeS3Server
+	var options s3bucket.Options
+	var resetPollInterval func()
+	var err error
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(10)
+		options = s3bucket.Options{ArchiveRestore: s3bucket.ArchiveRestoreConfig{Enabled: true}}
+		resetPollInterval = s3bucket.SetArchiveRestorePollInterval(0)
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+		resetPollInterval()
+	})
+
+	JustBeforeEach(func() {
+		bucket, newBucketErr := s3bucket.NewBucket("backup", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+
+		err = bucket.WithOptions(options).RestoreArchivedBlobs([]string{"a-blob"}, 2)
+	})
+
+	It("does not restore blobs which are not archived", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeS3.RestoreBodies()).To(BeEmpty())
+	})
+
+	Context("when archive restores are not enabled", func() {
+		BeforeEach(func() {
+			options.ArchiveRestore.Enabled = false
+			fakeS3.SetArchived("GLACIER", "")
+		})
+
+		It("does not check the blobs", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.Header("HeadObject")).To(BeNil())
+			Expect(fakeS3.RestoreBodies()).To(BeEmpty())
+		})
+
+		Context("and the bucket copies blobs to INTELLIGENT_TIERING", func() {
+			BeforeEach(func() {
+				options.StorageClass = "INTELLIGENT_TIERING"
+			})
+
+			It("restores the archived blobs", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.RestoreBodies()).To(HaveLen(1))
+			})
+		})
+	})
+
+	Context("when the blob is in GLACIER", func() {
+		BeforeEach(func() {
+			fakeS3.SetArchived("GLACIER", "", `ongoing-request="true"`, `ongoing-request="false", expiry-date="Fri, 23 Dec 2050 00:00:00 GMT"`)
+		})
+
+		It("requests a restore with the default tier and days, and waits for it", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.RestoreBodies()).To(HaveLen(1))
+			Expect(fakeS3.RestoreBodies()[0]).To(ContainSubstring("<Days>1</Days>"))
+			Expect(fakeS3.RestoreBodies()[0]).To(ContainSubstring("<Tier>Standard</Tier>"))
+		})
+
+		Context("and the bucket sets how blobs are restored", func() {
+			BeforeEach(func() {
+				options.ArchiveRestore = s3bucket.ArchiveRestoreConfig{Enabled: true, Tier: "Bulk", Days: 3}
+			})
+
+			It("requests a restore with the tier and days", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.RestoreBodies()).To(HaveLen(1))
+				Expect(fakeS3.RestoreBodies()[0]).To(ContainSubstring("<Days>3</Days>"))
+				Expect(fakeS3.RestoreBodies()[0]).To(ContainSubstring("<Tier>Bulk</Tier>"))
+			})
+		})
+
+		Context("and the restore does not complete before the timeout", func() {
+			var resetTimeoutUnit func()
+
+			BeforeEach(func() {
+				fakeS3.SetArchived("GLACIER", "", `ongoing-request="true"`)
+				options.ArchiveRestore.TimeoutHours = 1
+				resetTimeoutUnit = s3bucket.SetArchiveRestoreTimeoutUnit(0)
+			})
+
+			AfterEach(func() {
+				resetTimeoutUnit()
+			})
+
+			It("errors with the blobs which are not restored", func() {
+				Expect(err).To(MatchError("timed out after 0s waiting for archived blobs in bucket backup to be restored: a-blob"))
+			})
+		})
+
+		Context("and the blob is already restored", func() {
+			BeforeEach(func() {
+				fakeS3.SetArchived("GLACIER", `ongoing-request="false", expiry-date="Fri, 23 Dec 2050 00:00:00 GMT"`)
+			})
+
+			It("does not request another restore", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.RestoreBodies()).To(BeEmpty())
+			})
+		})
+	})
+})
+
+var _ = Describe("ArchiveRestoreConfig", func() {
+	DescribeTable("Validate",
+		func(config s3bucket.ArchiveRestoreConfig, expectedError string) {
+			err := config.Validate()
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("the defaults", s3bucket.ArchiveRestoreConfig{}, ""),
+		Entry("a tier and days", s3bucket.ArchiveRestoreConfig{Tier: "Expedited", Days: 2}, ""),
+		Entry("an unknown tier", s3bucket.ArchiveRestoreConfig{Tier: "Fast"}, "archive_restore.tier must be Standard, Bulk or Expedited"),
+		Entry("negative days", s3bucket.ArchiveRestoreConfig{Days: -1}, "archive_restore.days must be at least 1"),
+		Entry("a timeout", s3bucket.ArchiveRestoreConfig{TimeoutHours: 12}, ""),
+		Entry("a negative timeout", s3bucket.ArchiveRestoreConfig{TimeoutHours: -1}, "archive_restore.timeout_hours must be at least 1"),
+	)
+
+	DescribeTable("ValidateStorageClass",
+		func(storageClass string, expectedError string) {
+			err := s3bucket.ValidateStorageClass(storageClass)
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("the default", "", ""),
+		Entry("STANDARD_IA", "STANDARD_IA", ""),
+		Entry("GLACIER_IR", "GLACIER_IR", ""),
+		Entry("GLACIER", "GLACIER", "storage_class must be one of STANDARD, STANDARD_IA, ONEZONE_IA, INTELLIGENT_TIERING, GLACIER_IR"),
+	)
+})
@@ -0,0 +1,178 @@
+package s3bucket
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"common/executor"
+)
+
+// maxStreamedBytes is how many bytes of the blobs being streamed are held in
+// memory at once, across the copies of every bucket.
+const maxStreamedBytes = 1024 * mebibyte
+
+// streamedBytes is the budget which each streamed blob, or part of one, is
+// held against from before it is read until it is written. Without it, a
+// bucket copying max_in_flight blobs at once, each in max_parts_in_flight
+// parts, could hold hundreds of GiB.
+var streamedBytes = newByteBudget(maxStreamedBytes)
+
+// streamVersion copies a blob S3 cannot copy by reading it with the client of
+// its bucket and writing it to this one. Blobs larger than a part are
+// streamed in parts. Each blob or part is read into memory, so that the
+// client can retry the requests writing it, and waits for room in the
+// streamedBytes budget before it is read.
+func (b Bucket) streamVersion(source sourceBlob, destinationKey string, sourceEncryption EncryptionConfig) error {
+	if source.size() <= b.options.Multipart.partSizeFor(source.size()) {
+		return b.streamVersionWithSingleRequest(source, destinationKey, sourceEncryption)
+	}
+
+	return b.uploadInParts(source, destinationKey, func(part uploadPart) executor.Executable {
+		return streamPartExecutable{
+			uploadPart:       part,
+			source:           source,
+			sourceEncryption: sourceEncryption,
+		}
+	})
+}
+
+func (b Bucket) streamVersionWithSingleRequest(source sourceBlob, destinationKey string, sourceEncryption EncryptionConfig) error {
+	tagging, err := source.tagging()
+	if err != nil {
+		return err
+	}
+
+	reserved := streamedBytes.acquire(source.size())
+	defer streamedBytes.release(reserved)
+
+	contents, err := source.read(nil, sourceEncryption)
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(b.name),
+		Key:           aws.String(destinationKey),
+		Body:          bytes.NewReader(contents),
+		ContentLength: aws.Int64(int64(len(contents))),
+		Tagging:       tagging,
+	}
+	source.applyMetadataToPutObject(input)
+	if b.options.StorageClass != "" {
+		input.StorageClass = types.StorageClass(b.options.StorageClass)
+	}
+	b.options.Encryption.applyToPutObject(input)
+	b.options.Verification.applyToPutObject(input)
+
+	_, err = b.s3Client.PutObject(context.TODO(), input)
+	if err != nil {
+		return fmt.Errorf("failed to stream blob '%s' from bucket '%s' to '%s': %s", source.key, source.bucketName, destinationKey, err)
+	}
+
+	return nil
+}
+
+// read returns the contents of the blob, or of the range of it when one is
+// given. Reads fail if the blob changed since it was headed.
+func (s sourceBlob) read(byteRange *string, encryption EncryptionConfig) ([]byte, error) {
+	input := &s3.GetObjectInput{
+		Bucket:    aws.String(s.bucketName),
+		Key:       aws.String(s.key),
+		VersionId: s.versionIDOrNil(),
+		Range:     byteRange,
+		IfMatch:   s.head.ETag,
+	}
+	encryption.applyToGetObject(input)
+
+	output, err := s.client.GetObject(context.TODO(), input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob '%s' in bucket '%s': %s", s.key, s.bucketName, err)
+	}
+	defer output.Body.Close()
+
+	contents, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob '%s' in bucket '%s': %s", s.key, s.bucketName, err)
+	}
+
+	return contents, nil
+}
+
+// streamPartExecutable reads one part of a blob and uploads it.
+type streamPartExecutable struct {
+	uploadPart
+	source           sourceBlob
+	sourceEncryption EncryptionConfig
+}
+
+func (e streamPartExecutable) Execute() error {
+	return e.attempt(func() (types.CompletedPart, error) {
+		reserved := streamedBytes.acquire(e.partEnd - e.partStart + 1)
+		defer streamedBytes.release(reserved)
+
+		contents, err := e.source.read(aws.String(fmt.Sprintf("bytes=%d-%d", e.partStart, e.partEnd)), e.sourceEncryption)
+		if err != nil {
+			return types.CompletedPart{}, err
+		}
+
+		input := &s3.UploadPartInput{
+			Bucket:        aws.String(e.bucket.Name()),
+			Key:           aws.String(e.destinationKey),
+			UploadId:      aws.String(e.uploadID),
+			PartNumber:    aws.Int32(e.partNumber),
+			Body:          bytes.NewReader(contents),
+			ContentLength: aws.Int64(int64(len(contents))),
+		}
+		e.bucket.options.Encryption.applyToUploadPart(input)
+		e.bucket.options.Verification.applyToUploadPart(input)
+
+		output, err := e.bucket.s3Client.UploadPart(context.TODO(), input)
+		if err != nil {
+			return types.CompletedPart{}, err
+		}
+		return uploadedPart(e.partNumber, output), nil
+	})
+}
+
+// byteBudget is a number of bytes which callers wait for a share of.
+type byteBudget struct {
+	mutex     sync.Mutex
+	released  *sync.Cond
+	size      int64
+	available int64
+}
+
+func newByteBudget(size int64) *byteBudget {
+	budget := &byteBudget{size: size, available: size}
+	budget.released = sync.NewCond(&budget.mutex)
+	return budget
+}
+
+// acquire waits until bytes are available and returns how many were taken,
+// which is the whole budget when more than it are asked for, so that a blob
+// larger than the budget is streamed on its own rather than never.
+func (b *byteBudget) acquire(bytes int64) int64 {
+	bytes = min(bytes, b.size)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for b.available < bytes {
+		b.released.Wait()
+	}
+	b.available -= bytes
+	return bytes
+}
+
+func (b *byteBudget) release(bytes int64) {
+	b.mutex.Lock()
+	b.available += bytes
+	b.mutex.Unlock()
+	b.released.Broadcast()
+}
@@ -0,0 +1,212 @@
+package s3bucket
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"s3-blobstore-backup-restore/integrity"
+)
+
+// checksumAlgorithms are the S3 additional checksums which can be requested
+// on copies.
+var checksumAlgorithms = []types.ChecksumAlgorithm{
+	types.ChecksumAlgorithmCrc32,
+	types.ChecksumAlgorithmCrc32c,
+	types.ChecksumAlgorithmCrc64nvme,
+	types.ChecksumAlgorithmSha1,
+	types.ChecksumAlgorithmSha256,
+}
+
+// VerificationConfig is whether each blob copied to a bucket is checked
+// against the blob it was copied from, and which checksum S3 computes for the
+// copies.
+type VerificationConfig struct {
+	Enabled bool `json:"enabled"`
+	// ChecksumAlgorithm is the additional checksum S3 computes for the whole
+	// contents of each copy. Without one, only the sizes and the ETags which
+	// are digests are compared. The CRC algorithms also give blobs copied in
+	// parts a checksum of their whole contents; the SHA algorithms do not.
+	ChecksumAlgorithm string `json:"checksum_algorithm,omitempty"`
+}
+
+func (c VerificationConfig) Validate() error {
+	if c.ChecksumAlgorithm == "" {
+		return nil
+	}
+
+	names := make([]string, len(checksumAlgorithms))
+	for i, algorithm := range checksumAlgorithms {
+		if c.ChecksumAlgorithm == string(algorithm) {
+			return nil
+		}
+		names[i] = string(algorithm)
+	}
+
+	return fmt.Errorf("verify.checksum_algorithm must be one of %s", strings.Join(names, ", "))
+}
+
+// checksumType is FULL_OBJECT for the CRC algorithms, so that copies in parts
+// have a checksum comparable with that of a copy made in one request.
+func (c VerificationConfig) checksumType() types.ChecksumType {
+	switch types.ChecksumAlgorithm(c.ChecksumAlgorithm) {
+	case types.ChecksumAlgorithmCrc32, types.ChecksumAlgorithmCrc32c, types.ChecksumAlgorithmCrc64nvme:
+		return types.ChecksumTypeFullObject
+	}
+	return ""
+}
+
+func (c VerificationConfig) applyToCopyObject(input *s3.CopyObjectInput) {
+	input.ChecksumAlgorithm = types.ChecksumAlgorithm(c.ChecksumAlgorithm)
+}
+
+func (c VerificationConfig) applyToCreateMultipartUpload(input *s3.CreateMultipartUploadInput) {
+	input.ChecksumAlgorithm = types.ChecksumAlgorithm(c.ChecksumAlgorithm)
+	input.ChecksumType = c.checksumType()
+}
+
+func (c VerificationConfig) applyToUploadPart(input *s3.UploadPartInput) {
+	input.ChecksumAlgorithm = types.ChecksumAlgorithm(c.ChecksumAlgorithm)
+}
+
+func (c VerificationConfig) applyToPutObject(input *s3.PutObjectInput) {
+	input.ChecksumAlgorithm = types.ChecksumAlgorithm(c.ChecksumAlgorithm)
+}
+
+func (c VerificationConfig) applyToCompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) {
+	input.ChecksumType = c.checksumType()
+}
+
+// Checksum returns the checksum of the whole contents of a blob, which is zero
+// when S3 has none.
+func (b Bucket) Checksum(path string) (integrity.Checksum, error) {
+	output, err := b.headWithChecksum(path)
+	if err != nil {
+		return integrity.Checksum{}, fmt.Errorf("failed to get checksum of blob '%s': %s", path, err)
+	}
+
+	return checksumOf(output), nil
+}
+
+// ETagIsDigest returns whether the ETag of a blob is a digest of its contents,
+// which it is not when the blob is encrypted with a KMS or customer key.
+func (b Bucket) ETagIsDigest(path string) (bool, error) {
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(path),
+	}
+	b.options.Encryption.applyToHeadObject(input)
+
+	output, err := b.s3Client.HeadObject(context.TODO(), input)
+	if err != nil {
+		return false, fmt.Errorf("failed to get encryption of blob '%s': %s", path, err)
+	}
+
+	return encryptionKeepsETagDigest(output), nil
+}
+
+func (b Bucket) verifyCopy(source sourceBlob, destinationKey string) error {
+	output, err := b.headWithChecksum(destinationKey)
+	if err != nil {
+		return fmt.Errorf("failed to verify copy of blob '%s' to '%s' in bucket %s: %s", source.key, destinationKey, b.name, err)
+	}
+
+	err = integrity.Verify(integrityOf(source.head), integrityOf(output))
+	if err != nil {
+		return fmt.Errorf("copy of blob '%s' to '%s' in bucket %s does not match: %s", source.key, destinationKey, b.name, err)
+	}
+
+	return nil
+}
+
+func (b Bucket) headWithChecksum(key string) (*s3.HeadObjectOutput, error) {
+	input := &s3.HeadObjectInput{
+		Bucket:       aws.String(b.name),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	}
+	b.options.Encryption.applyToHeadObject(input)
+
+	return b.s3Client.HeadObject(context.TODO(), input)
+}
+
+// integrityOf returns what a head says about the contents of a blob. The ETag
+// is only a digest of the contents when the blob was not uploaded in parts
+// and not encrypted with a KMS or customer key.
+func integrityOf(output *s3.HeadObjectOutput) integrity.Blob {
+	blob := integrity.Blob{
+		Size:     aws.ToInt64(output.ContentLength),
+		Checksum: checksumOf(output),
+	}
+
+	if encryptionKeepsETagDigest(output) {
+		blob.ETag = aws.ToString(output.ETag)
+	}
+
+	return blob
+}
+
+// encryptionKeepsETagDigest is whether a blob is encrypted, if at all, with
+// S3's own keys, which leaves its ETag a digest of its contents.
+func encryptionKeepsETagDigest(output *s3.HeadObjectOutput) bool {
+	encryption := output.ServerSideEncryption
+	return (encryption == "" || encryption == types.ServerSideEncryptionAes256) && output.SSECustomerAlgorithm == nil
+}
+
+// checksumOf returns the checksum of the whole contents of a blob from a head
+// made with the checksum mode enabled. Checksums of the parts of a blob, which
+// end with the number of parts, are left out.
+func checksumOf(output *s3.HeadObjectOutput) integrity.Checksum {
+	if output.ChecksumType == types.ChecksumTypeComposite {
+		return integrity.Checksum{}
+	}
+
+	for _, checksum := range []struct {
+		algorithm types.ChecksumAlgorithm
+		value     *string
+	}{
+		{types.ChecksumAlgorithmCrc32, output.ChecksumCRC32},
+		{types.ChecksumAlgorithmCrc32c, output.ChecksumCRC32C},
+		{types.ChecksumAlgorithmCrc64nvme, output.ChecksumCRC64NVME},
+		{types.ChecksumAlgorithmSha1, output.ChecksumSHA1},
+		{types.ChecksumAlgorithmSha256, output.ChecksumSHA256},
+	} {
+		value := aws.ToString(checksum.value)
+		if value != "" && !strings.Contains(value, "-") {
+			return integrity.Checksum{Algorithm: string(checksum.algorithm), Value: value}
+		}
+	}
+
+	return integrity.Checksum{}
+}
+
+// completedPart records a part copied to an upload with its checksums, which
+// S3 needs to complete an upload with a checksum algorithm.
+func completedPart(partNumber int32, result *types.CopyPartResult) types.CompletedPart {
+	return types.CompletedPart{
+		PartNumber:        aws.Int32(partNumber),
+		ETag:              result.ETag,
+		ChecksumCRC32:     result.ChecksumCRC32,
+		ChecksumCRC32C:    result.ChecksumCRC32C,
+		ChecksumCRC64NVME: result.ChecksumCRC64NVME,
+		ChecksumSHA1:      result.ChecksumSHA1,
+		ChecksumSHA256:    result.ChecksumSHA256,
+	}
+}
+
+// uploadedPart records a part streamed to an upload with its checksums.
+func uploadedPart(partNumber int32, output *s3.UploadPartOutput) types.CompletedPart {
+	return types.CompletedPart{
+		PartNumber:        aws.Int32(partNumber),
+		ETag:              output.ETag,
+		ChecksumCRC32:     output.ChecksumCRC32,
+		ChecksumCRC32C:    output.ChecksumCRC32C,
+		ChecksumCRC64NVME: output.ChecksumCRC64NVME,
+		ChecksumSHA1:      output.ChecksumSHA1,
+		ChecksumSHA256:    output.ChecksumSHA256,
+	}
+}
@@ -0,0 +1,170 @@
+package s3bucket_test
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"s3-blobstore-backup-restore/integrity"
+	"s3-blobstore-backup-restore/s3bucket"
+)
+
+var _ = Describe("Verifying copies", func() {
+	var fakeS3 *fakeS3Server
+	var options s3bucket.Options
+	var err error
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(23 * mebibyte)
+		fakeS3.SetBlobAttributes(http.Header{
+			"Etag":                  {`"5d41402abc4b2a76"`},
+			"X-Amz-Checksum-Crc32c": {"AAAAAA=="},
+		}, nil)
+		options = s3bucket.Options{}
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+	})
+
+	JustBeforeEach(func() {
+		bucket, newBucketErr := s3bucket.NewBucket("destination", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(newBucketErr).NotTo(HaveOccurred())
+
+		err = bucket.WithOptions(options).CopyVersion("a-blob", "a-version", "source", "us-east-1")
+	})
+
+	It("does not check the copy", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeS3.Header("HeadObject")).NotTo(HaveKey("X-Amz-Checksum-Mode"))
+		Expect(fakeS3.Header("CopyObject")).NotTo(HaveKey("X-Amz-Checksum-Algorithm"))
+	})
+
+	Context("when the bucket verifies copies", func() {
+		BeforeEach(func() {
+			options.Verification = s3bucket.VerificationConfig{Enabled: true, ChecksumAlgorithm: "CRC32C"}
+		})
+
+		It("requests the checksum on the copy and heads it with its checksum", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeS3.Header("CopyObject").Get("X-Amz-Checksum-Algorithm")).To(Equal("CRC32C"))
+			Expect(fakeS3.Header("HeadObject").Get("X-Amz-Checksum-Mode")).To(Equal("ENABLED"))
+		})
+
+		Context("and the checksum of the copy does not match", func() {
+			BeforeEach(func() {
+				fakeS3.SetHeaderFor("/destination/a-blob", http.Header{"X-Amz-Checksum-Crc32c": {"BBBBBB=="}})
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError("copy of blob 'a-blob' to 'a-blob' in bucket destination does not match: CRC32C checksum BBBBBB== does not match AAAAAA=="))
+			})
+		})
+
+		Context("and the source has no checksum and the ETag of the copy does not match", func() {
+			BeforeEach(func() {
+				fakeS3.SetHeaderFor("/source/a-blob", http.Header{"X-Amz-Checksum-Crc32c": {""}})
+				fakeS3.SetHeaderFor("/destination/a-blob", http.Header{"Etag": {`"7d793037a0760186"`}})
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(`copy of blob 'a-blob' to 'a-blob' in bucket destination does not match: ETag "7d793037a0760186" does not match "5d41402abc4b2a76"`))
+			})
+		})
+
+		Context("and the blob is copied in parts", func() {
+			BeforeEach(func() {
+				options.Multipart = s3bucket.MultipartConfig{ThresholdMB: 10, PartSizeMB: 5}
+			})
+
+			It("requests a checksum of the whole contents", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeS3.Header("CreateMultipartUpload").Get("X-Amz-Checksum-Algorithm")).To(Equal("CRC32C"))
+				Expect(fakeS3.Header("CreateMultipartUpload").Get("X-Amz-Checksum-Type")).To(Equal("FULL_OBJECT"))
+				Expect(fakeS3.Header("CompleteMultipartUpload").Get("X-Amz-Checksum-Type")).To(Equal("FULL_OBJECT"))
+			})
+		})
+	})
+})
+
+var _ = Describe("Getting the checksum of a blob", func() {
+	var fakeS3 *fakeS3Server
+	var bucket s3bucket.Bucket
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(10)
+
+		var err error
+		bucket, err = s3bucket.NewBucket("backup", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+	})
+
+	It("returns the checksum of the whole contents", func() {
+		fakeS3.SetBlobAttributes(http.Header{"X-Amz-Checksum-Sha256": {"c2hhMjU2"}}, nil)
+
+		checksum, err := bucket.Checksum("a-blob")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checksum).To(Equal(integrity.Checksum{Algorithm: "SHA256", Value: "c2hhMjU2"}))
+	})
+
+	It("leaves out the checksums of the parts", func() {
+		fakeS3.SetBlobAttributes(http.Header{"X-Amz-Checksum-Sha256": {"c2hhMjU2-3"}, "X-Amz-Checksum-Type": {"COMPOSITE"}}, nil)
+
+		checksum, err := bucket.Checksum("a-blob")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checksum).To(BeZero())
+	})
+})
+
+var _ = Describe("VerificationConfig", func() {
+	DescribeTable("Validate",
+		func(config s3bucket.VerificationConfig, expectedError string) {
+			err := config.Validate()
+			if expectedError == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(expectedError))
+			}
+		},
+		Entry("no checksum algorithm", s3bucket.VerificationConfig{Enabled: true}, ""),
+		Entry("CRC32C", s3bucket.VerificationConfig{Enabled: true, ChecksumAlgorithm: "CRC32C"}, ""),
+		Entry("SHA256", s3bucket.VerificationConfig{Enabled: true, ChecksumAlgorithm: "SHA256"}, ""),
+		Entry("an unknown algorithm", s3bucket.VerificationConfig{Enabled: true, ChecksumAlgorithm: "MD5"}, "verify.checksum_algorithm must be one of CRC32, CRC32C, CRC64NVME, SHA1, SHA256"),
+	)
+})
+
+var _ = Describe("Checking whether the ETag of a blob is a digest", func() {
+	var fakeS3 *fakeS3Server
+	var bucket s3bucket.Bucket
+
+	BeforeEach(func() {
+		fakeS3 = newFakeS3Server(10)
+
+		var err error
+		bucket, err = s3bucket.NewBucket("backup", "us-east-1", fakeS3.URL, s3bucket.AccessKey{Id: "id", Secret: "secret"}, false, true)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		fakeS3.Close()
+	})
+
+	DescribeTable("reads the encryption of the blob",
+		func(header http.Header, isDigest bool) {
+			fakeS3.SetBlobAttributes(header, nil)
+
+			etagIsDigest, err := bucket.ETagIsDigest("a-blob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(etagIsDigest).To(Equal(isDigest))
+		},
+		Entry("not encrypted", http.Header{}, true),
+		Entry("encrypted with S3 keys", http.Header{"X-Amz-Server-Side-Encryption": {"AES256"}}, true),
+		Entry("encrypted with a KMS key by the bucket default", http.Header{"X-Amz-Server-Side-Encryption": {"aws:kms"}}, false),
+		Entry("encrypted with a customer key", http.Header{"X-Amz-Server-Side-Encryption-Customer-Algorithm": {"AES256"}}, false),
+	)
+})
@@ -126,6 +126,51 @@ var _ = Describe("VersionedBucket", func() {
 			})
 		})
 
+		Describe("ListVersionHistory", func() {
+			var versions []s3bucket.Version
+
+			JustBeforeEach(func() {
+				versions, err = bucketObjectUnderTest.ListVersionHistory()
+			})
+
+			It("returns all versions and delete markers in the bucket with their modification times", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(versions).To(HaveLen(5))
+				for _, version := range versions {
+					Expect(version.LastModified).NotTo(BeZero())
+				}
+
+				var deleteMarkers []s3bucket.Version
+				for _, version := range versions {
+					if version.IsDeleteMarker {
+						deleteMarkers = append(deleteMarkers, version)
+					}
+				}
+				Expect(deleteMarkers).To(HaveLen(1))
+				Expect(deleteMarkers[0].Key).To(Equal("test-2"))
+				Expect(deleteMarkers[0].IsLatest).To(BeTrue())
+			})
+
+			Context("when the bucket is not versioned", func() {
+				var unversionedBucketName string
+
+				BeforeEach(func() {
+					unversionedBucketName = setUpUnversionedBucket(LiveRegion, S3Endpoint)
+
+					bucketObjectUnderTest, err = s3bucket.NewBucketWithRoleARN(unversionedBucketName, LiveRegion, S3Endpoint, AssumedRoleARN, creds, false, false)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				AfterEach(func() {
+					tearDownBucket(unversionedBucketName, S3Endpoint)
+				})
+
+				It("fails", func() {
+					Expect(err).To(MatchError(ContainSubstring("is not versioned")))
+				})
+			})
+		})
+
 		Describe("IsVersioned", func() {
 			var (
 				unversionedBucketName string
@@ -11,10 +11,18 @@ type Bucket interface {
 	IsVersioned() (bool, error)
 }
 
-func NewUnversionedBucket(bucketName, bucketRegion, endpoint string, accessKey s3bucket.AccessKey, useIAMProfile, forcePathStyle bool) (bucket Bucket, e error) {
-	return s3bucket.NewBucket(bucketName, bucketRegion, endpoint, accessKey, useIAMProfile, forcePathStyle)
+func NewUnversionedBucket(bucketName, bucketRegion, endpoint string, accessKey s3bucket.AccessKey, useIAMProfile, forcePathStyle bool, options s3bucket.Options) (bucket Bucket, e error) {
+	s3Bucket, err := s3bucket.NewBucket(bucketName, bucketRegion, endpoint, accessKey, useIAMProfile, forcePathStyle)
+	if err != nil {
+		return nil, err
+	}
+	return s3Bucket.WithOptions(options), nil
 }
 
-func NewUnversionedBucketWithRoleARN(bucketName, bucketRegion, endpoint, roleARN string, accessKey s3bucket.AccessKey, useIAMProfile, forcePathStyle bool) (bucket Bucket, e error) {
-	return s3bucket.NewBucketWithRoleARN(bucketName, bucketRegion, endpoint, roleARN, accessKey, useIAMProfile, forcePathStyle)
+func NewUnversionedBucketWithCredentials(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (bucket Bucket, e error) {
+	s3Bucket, err := s3bucket.NewBucketWithCredentials(bucketName, bucketRegion, endpoint, credentials, forcePathStyle)
+	if err != nil {
+		return nil, err
+	}
+	return s3Bucket.WithOptions(options), nil
 }
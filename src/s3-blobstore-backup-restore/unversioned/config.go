@@ -2,8 +2,12 @@ package unversioned
 
 import (
 	"fmt"
+	"sort"
+
+	"common/retry"
 
 	"s3-blobstore-backup-restore/incremental"
+	"s3-blobstore-backup-restore/mirror"
 	"s3-blobstore-backup-restore/s3bucket"
 )
 
@@ -12,38 +16,138 @@ type UnversionedBucketConfig struct {
 	Region             string `json:"region"`
 	AwsAccessKeyId     string `json:"aws_access_key_id"`
 	AwsSecretAccessKey string `json:"aws_secret_access_key"`
+	AwsSessionToken    string `json:"aws_session_token,omitempty"`
 	// # Warning
 	//
 	// AwsAssumedRoleArn is provided as is and isn't thoroughly tested
-	AwsAssumedRoleArn string             `json:"aws_assumed_role_arn,omitempty"`
-	Endpoint          string             `json:"endpoint"`
-	UseIAMProfile     bool               `json:"use_iam_profile"`
-	Backup            BackupBucketConfig `json:"backup"`
-	ForcePathStyle    bool               `json:"force_path_style"`
+	AwsAssumedRoleArn         string `json:"aws_assumed_role_arn,omitempty"`
+	AwsAssumedRoleExternalId  string `json:"aws_assumed_role_external_id,omitempty"`
+	AwsAssumedRoleSessionName string `json:"aws_assumed_role_session_name,omitempty"`
+	// AwsProfile is a profile in the shared credentials and config files.
+	AwsProfile string `json:"aws_profile,omitempty"`
+	// AwsWebIdentityTokenFile is exchanged for the credentials of the assumed
+	// role, as with IAM roles for Kubernetes service accounts.
+	AwsWebIdentityTokenFile string                   `json:"aws_web_identity_token_file,omitempty"`
+	UseContainerCredentials bool                     `json:"use_container_credentials,omitempty"`
+	Endpoint                string                   `json:"endpoint"`
+	UseIAMProfile           bool                     `json:"use_iam_profile"`
+	Backup                  BackupBucketConfig       `json:"backup"`
+	ForcePathStyle          bool                     `json:"force_path_style"`
+	MaxInFlight             int                      `json:"max_in_flight,omitempty"`
+	Multipart               s3bucket.MultipartConfig `json:"multipart"`
+	Retry                   retry.Policy             `json:"retry"`
+	// MaxRequestsPerSecond limits the requests made for the bucket, or does not
+	// limit them when it is zero.
+	MaxRequestsPerSecond float64 `json:"max_requests_per_second,omitempty"`
+	// Encryption is how the blobs restored to the live bucket are encrypted,
+	// and how the backups are encrypted unless the backup bucket sets its own.
+	Encryption s3bucket.EncryptionConfig `json:"encryption"`
+	// CopyACL is whether backups and restores give the blobs they copy the
+	// ACLs of the blobs they copy them from.
+	CopyACL bool `json:"copy_acl,omitempty"`
+	// Verify is whether each blob copied by backups and restores is checked
+	// against the blob it was copied from.
+	Verify s3bucket.VerificationConfig `json:"verify"`
+	// ChangeDetection is how a backup decides whether a blob already in the
+	// previous backup needs copying again, by content unless it is "path".
+	ChangeDetection incremental.ChangeDetection `json:"change_detection,omitempty"`
+	Retention       incremental.RetentionPolicy `json:"retention"`
+	// DeleteExtraneous is whether a restore deletes the blobs which are not in
+	// the backup.
+	DeleteExtraneous mirror.Config `json:"delete_extraneous"`
+}
+
+func (c UnversionedBucketConfig) credentials() s3bucket.Credentials {
+	return s3bucket.Credentials{
+		AccessKey: s3bucket.AccessKey{
+			Id:           c.AwsAccessKeyId,
+			Secret:       c.AwsSecretAccessKey,
+			SessionToken: c.AwsSessionToken,
+		},
+		UseIAMProfile:           c.UseIAMProfile,
+		UseContainerCredentials: c.UseContainerCredentials,
+		Profile:                 c.AwsProfile,
+		WebIdentityTokenFile:    c.AwsWebIdentityTokenFile,
+		RoleARN:                 c.AwsAssumedRoleArn,
+		ExternalID:              c.AwsAssumedRoleExternalId,
+		RoleSessionName:         c.AwsAssumedRoleSessionName,
+	}
+}
+
+func (c UnversionedBucketConfig) options() s3bucket.Options {
+	return s3bucket.Options{
+		Multipart:            c.Multipart,
+		Retry:                c.Retry,
+		MaxRequestsPerSecond: c.MaxRequestsPerSecond,
+		Encryption:           c.Encryption,
+		CopyACL:              c.CopyACL,
+		Verification:         c.Verify,
+	}
+}
+
+// backupOptions are the options of the backup bucket, which uses the
+// encryption of the live bucket unless it sets its own.
+func (c UnversionedBucketConfig) backupOptions() s3bucket.Options {
+	options := c.options()
+	if c.Backup.Encryption != (s3bucket.EncryptionConfig{}) {
+		options.Encryption = c.Backup.Encryption
+	}
+	options.StorageClass = c.Backup.StorageClass
+	options.ArchiveRestore = c.Backup.ArchiveRestore
+	return options
 }
 
 type BackupBucketConfig struct {
 	Name   string `json:"name"`
 	Region string `json:"region"`
+	// AccessConfig is the endpoint and credentials of the backup bucket, such
+	// as those of another AWS account, when they are not those of the live
+	// bucket.
+	s3bucket.AccessConfig
+	Encryption s3bucket.EncryptionConfig `json:"encryption"`
+	// StorageClass is the storage class of the backed up blobs, or STANDARD
+	// when it is empty.
+	StorageClass string `json:"storage_class,omitempty"`
+	// ArchiveRestore is how backed up blobs which lifecycle rules moved to an
+	// archival storage class are restored before a restore copies them.
+	ArchiveRestore s3bucket.ArchiveRestoreConfig `json:"archive_restore"`
+}
+
+func (c BackupBucketConfig) validate() error {
+	if err := c.Encryption.Validate(); err != nil {
+		return err
+	}
+
+	if err := s3bucket.ValidateStorageClass(c.StorageClass); err != nil {
+		return err
+	}
+
+	return c.ArchiveRestore.Validate()
+}
+
+// backupAccess returns the endpoint, credentials and addressing style of the
+// backup bucket, which are those of the live bucket unless it sets its own.
+func (c UnversionedBucketConfig) backupAccess() (string, s3bucket.Credentials, bool) {
+	return c.Backup.Inherit(c.Endpoint, c.credentials(), c.ForcePathStyle)
 }
 
-type NewBucket func(bucketName, bucketRegion, endpoint, roleARN string, accessKey s3bucket.AccessKey, useIAMProfile, forcePathStyle bool) (Bucket, error)
+type NewBucket func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (Bucket, error)
 
 func BuildBackupsToStart(configs map[string]UnversionedBucketConfig, newBucket NewBucket) (map[string]incremental.BackupToStart, error) {
 	backupsToStart := make(map[string]incremental.BackupToStart)
 
 	for bucketID, config := range configs {
+		if err := validateConfig(bucketID, config); err != nil {
+			return nil, err
+		}
+
 		liveBucket, err := newBucket(
 			config.Name,
 			config.Region,
 			config.Endpoint,
-			config.AwsAssumedRoleArn,
-			s3bucket.AccessKey{
-				Id:     config.AwsAccessKeyId,
-				Secret: config.AwsSecretAccessKey,
-			},
-			config.UseIAMProfile,
+			config.credentials(),
 			config.ForcePathStyle,
+			config.options(),
 		)
 		if err != nil {
 			return nil, err
@@ -53,17 +157,14 @@ func BuildBackupsToStart(configs map[string]UnversionedBucketConfig, newBucket N
 			return nil, err
 		}
 
+		backupEndpoint, backupCredentials, backupForcePathStyle := config.backupAccess()
 		backupBucket, err := newBucket(
 			config.Backup.Name,
 			config.Backup.Region,
-			config.Endpoint,
-			config.AwsAssumedRoleArn,
-			s3bucket.AccessKey{
-				Id:     config.AwsAccessKeyId,
-				Secret: config.AwsSecretAccessKey,
-			},
-			config.UseIAMProfile,
-			config.ForcePathStyle,
+			backupEndpoint,
+			backupCredentials,
+			backupForcePathStyle,
+			config.backupOptions(),
 		)
 		if err != nil {
 			return nil, err
@@ -77,6 +178,9 @@ func BuildBackupsToStart(configs map[string]UnversionedBucketConfig, newBucket N
 			BucketPair: incremental.BackupBucketPair{
 				ConfigLiveBucket:   liveBucket,
 				ConfigBackupBucket: backupBucket,
+				MaxInFlight:        config.MaxInFlight,
+				ChangeDetection:    config.ChangeDetection,
+				Verify:             config.Verify.Enabled,
 			},
 			BackupDirectoryFinder: incremental.Finder{},
 		}
@@ -87,6 +191,44 @@ func BuildBackupsToStart(configs map[string]UnversionedBucketConfig, newBucket N
 	return backupsToStart, nil
 }
 
+func validateConfig(bucketID string, config UnversionedBucketConfig) error {
+	if config.MaxInFlight < 0 {
+		return fmt.Errorf("invalid max_in_flight for bucket %s: must be at least 1", bucketID)
+	}
+
+	switch config.ChangeDetection {
+	case "", incremental.ChangeDetectionContent, incremental.ChangeDetectionPath:
+	default:
+		return fmt.Errorf("invalid change_detection for bucket %s: must be %s or %s", bucketID, incremental.ChangeDetectionContent, incremental.ChangeDetectionPath)
+	}
+
+	if err := config.credentials().Validate(); err != nil {
+		return fmt.Errorf("invalid config for bucket %s: %s", bucketID, err)
+	}
+
+	if err := config.options().Validate(); err != nil {
+		return fmt.Errorf("invalid config for bucket %s: %s", bucketID, err)
+	}
+
+	if err := config.Backup.validate(); err != nil {
+		return fmt.Errorf("invalid config for bucket %s: backup.%s", bucketID, err)
+	}
+
+	if err := config.Backup.AccessConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid config for bucket %s: backup credentials: %s", bucketID, err)
+	}
+
+	if err := config.Retention.Validate(); err != nil {
+		return fmt.Errorf("invalid config for bucket %s: %s", bucketID, err)
+	}
+
+	if err := config.DeleteExtraneous.Validate(); err != nil {
+		return fmt.Errorf("invalid config for bucket %s: %s", bucketID, err)
+	}
+
+	return nil
+}
+
 func bucketIsVersioned(bucket Bucket) error {
 	isVersioned, err := bucket.IsVersioned()
 	if err != nil {
@@ -112,6 +254,10 @@ func BuildBackupsToComplete(
 	}
 
 	for bucketID, config := range configs {
+		if err := validateConfig(bucketID, config); err != nil {
+			return nil, err
+		}
+
 		existingBackup, exists := existingBackups[bucketID]
 		if !exists {
 			return nil, fmt.Errorf("failed to find bucket identifier '%s' in buckets config", bucketID)
@@ -124,17 +270,14 @@ func BuildBackupsToComplete(
 			continue
 		}
 
+		backupEndpoint, backupCredentials, backupForcePathStyle := config.backupAccess()
 		backupBucket, err := newBucket(
 			config.Backup.Name,
 			config.Backup.Region,
-			config.Endpoint,
-			config.AwsAssumedRoleArn,
-			s3bucket.AccessKey{
-				Id:     config.AwsAccessKeyId,
-				Secret: config.AwsSecretAccessKey,
-			},
-			config.UseIAMProfile,
-			config.ForcePathStyle,
+			backupEndpoint,
+			backupCredentials,
+			backupForcePathStyle,
+			config.backupOptions(),
 		)
 		if err != nil {
 			return nil, err
@@ -142,9 +285,13 @@ func BuildBackupsToComplete(
 
 		var blobsToCopy []incremental.BackedUpBlob
 		for _, path := range existingBackup.Blobs {
+			attributes := existingBackup.BlobAttributes[path]
 			blobsToCopy = append(blobsToCopy, incremental.BackedUpBlob{
 				Path:                path,
 				BackupDirectoryPath: existingBackup.SrcBackupDirectoryPath,
+				ETag:                attributes.ETag,
+				Size:                attributes.Size,
+				LastModified:        attributes.LastModified,
 			})
 		}
 
@@ -155,12 +302,91 @@ func BuildBackupsToComplete(
 				Bucket: backupBucket,
 			},
 			BlobsToCopy: blobsToCopy,
+			MaxInFlight: config.MaxInFlight,
 		}
 	}
 
 	return backupsToComplete, nil
 }
 
+func BuildBackupsToPrune(configs map[string]UnversionedBucketConfig, newBucket NewBucket) (map[string]incremental.BackupToPrune, error) {
+	backupBuckets, err := BuildBackupBuckets(configs, newBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	backupsToPrune := make(map[string]incremental.BackupToPrune)
+	for bucketID, config := range configs {
+		if !config.Retention.IsSet() {
+			continue
+		}
+
+		backupsToPrune[bucketID] = incremental.BackupToPrune{
+			BackupBucket: backupBuckets[bucketID],
+			Retention:    config.Retention,
+			MaxInFlight:  config.MaxInFlight,
+		}
+	}
+
+	return backupsToPrune, nil
+}
+
+func BuildBackupBuckets(configs map[string]UnversionedBucketConfig, newBucket NewBucket) (map[string]incremental.Bucket, error) {
+	backupBuckets := make(map[string]incremental.Bucket)
+
+	for bucketID, config := range configs {
+		if err := validateConfig(bucketID, config); err != nil {
+			return nil, err
+		}
+
+		backupEndpoint, backupCredentials, backupForcePathStyle := config.backupAccess()
+		backupBucket, err := newBucket(
+			config.Backup.Name,
+			config.Backup.Region,
+			backupEndpoint,
+			backupCredentials,
+			backupForcePathStyle,
+			config.backupOptions(),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		backupBuckets[bucketID] = backupBucket
+	}
+
+	return backupBuckets, nil
+}
+
+// BuildBackupsToFind returns the backup bucket of each bucket ID, marking the
+// bucket IDs which share a live bucket the same way BuildBackupsToStart does.
+func BuildBackupsToFind(configs map[string]UnversionedBucketConfig, newBucket NewBucket) (map[string]incremental.BackupToFind, error) {
+	backupBuckets, err := BuildBackupBuckets(configs, newBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	liveBucketNamesToBucketIDs := make(map[string][]string)
+	for bucketID, config := range configs {
+		liveBucketNamesToBucketIDs[config.Name] = append(liveBucketNamesToBucketIDs[config.Name], bucketID)
+	}
+
+	backupsToFind := make(map[string]incremental.BackupToFind)
+	for _, bucketIDs := range liveBucketNamesToBucketIDs {
+		sort.Strings(bucketIDs)
+
+		for i, bucketID := range bucketIDs {
+			if i == 0 {
+				backupsToFind[bucketID] = incremental.BackupToFind{BackupBucket: backupBuckets[bucketID]}
+			} else {
+				backupsToFind[bucketID] = incremental.BackupToFind{SameAsBucketID: bucketIDs[0]}
+			}
+		}
+	}
+
+	return backupsToFind, nil
+}
+
 func BuildRestoreBucketPairs(
 	configs map[string]UnversionedBucketConfig,
 	artifact incremental.Artifact,
@@ -174,6 +400,10 @@ func BuildRestoreBucketPairs(
 	}
 
 	for bucketID, config := range configs {
+		if err := validateConfig(bucketID, config); err != nil {
+			return nil, err
+		}
+
 		if _, ok := backups[bucketID]; !ok {
 			return nil, fmt.Errorf("backup artifact does not contain bucket ID '%s'", bucketID)
 		}
@@ -189,13 +419,9 @@ func BuildRestoreBucketPairs(
 			config.Name,
 			config.Region,
 			config.Endpoint,
-			config.AwsAssumedRoleArn,
-			s3bucket.AccessKey{
-				Id:     config.AwsAccessKeyId,
-				Secret: config.AwsSecretAccessKey,
-			},
-			config.UseIAMProfile,
+			config.credentials(),
 			config.ForcePathStyle,
+			config.options(),
 		)
 
 		if err != nil {
@@ -206,17 +432,14 @@ func BuildRestoreBucketPairs(
 			return nil, err
 		}
 
+		backupEndpoint, backupCredentials, backupForcePathStyle := config.backupAccess()
 		backupBucket, err := newBucket(
 			backups[bucketID].BucketName,
 			backups[bucketID].BucketRegion,
-			config.Endpoint,
-			config.AwsAssumedRoleArn,
-			s3bucket.AccessKey{
-				Id:     config.AwsAccessKeyId,
-				Secret: config.AwsSecretAccessKey,
-			},
-			config.UseIAMProfile,
-			config.ForcePathStyle,
+			backupEndpoint,
+			backupCredentials,
+			backupForcePathStyle,
+			config.backupOptions(),
 		)
 
 		if err != nil {
@@ -230,6 +453,9 @@ func BuildRestoreBucketPairs(
 		pairs[bucketID] = incremental.RestoreBucketPair{
 			ConfigLiveBucket:     liveBucket,
 			ArtifactBackupBucket: backupBucket,
+			MaxInFlight:          config.MaxInFlight,
+			DeleteExtraneous:     config.DeleteExtraneous,
+			Verify:               config.Verify.Enabled,
 		}
 	}
 
@@ -3,12 +3,14 @@ package unversioned_test
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"s3-blobstore-backup-restore/incremental"
 	"s3-blobstore-backup-restore/incremental/fakes"
+	"s3-blobstore-backup-restore/mirror"
 	"s3-blobstore-backup-restore/s3bucket"
 	"s3-blobstore-backup-restore/unversioned"
 	unversionedFakes "s3-blobstore-backup-restore/unversioned/fakes"
@@ -67,8 +69,8 @@ var _ = Describe("Unversioned", func() {
 		fakeBackupBucket2 = new(unversionedFakes.FakeBucket)
 		fakeBackupBucket2.NameReturns("backup-name2")
 
-		newBucket = func(bucketName, bucketRegion, endpoint, _ string, accessKey s3bucket.AccessKey, useIAMProfile, _ bool) (unversioned.Bucket, error) {
-			if endpoint == "my-s3-endpoint.aws" && accessKey.Secret == "my-secret-key" && accessKey.Id == "my-id" && !useIAMProfile {
+		newBucket = func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, _ bool, _ s3bucket.Options) (unversioned.Bucket, error) {
+			if endpoint == "my-s3-endpoint.aws" && credentials.AccessKey.Secret == "my-secret-key" && credentials.AccessKey.Id == "my-id" && !credentials.UseIAMProfile {
 				if bucketName == "live-name1" && bucketRegion == "live-region1" {
 					return fakeLiveBucket1, nil
 				} else if bucketName == "live-name2" && bucketRegion == "live-region2" {
@@ -115,7 +117,7 @@ var _ = Describe("Unversioned", func() {
 			}
 
 			forcePathStyles := []bool{}
-			newBucketSpy := func(_, _, _, _ string, _ s3bucket.AccessKey, _, forcePathStyle bool) (unversioned.Bucket, error) {
+			newBucketSpy := func(_, _, _ string, _ s3bucket.Credentials, forcePathStyle bool, _ s3bucket.Options) (unversioned.Bucket, error) {
 				forcePathStyles = append(forcePathStyles, forcePathStyle)
 				return fakeLiveBucket1, nil
 			}
@@ -136,11 +138,11 @@ var _ = Describe("Unversioned", func() {
 			)
 
 			JustBeforeEach(func() {
-				newBucketFails = func(bucketName, bucketRegion, endpoint, roleArn string, accessKey s3bucket.AccessKey, useIAMProfile, forcePathStyle bool) (unversioned.Bucket, error) {
+				newBucketFails = func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (unversioned.Bucket, error) {
 					if bucketName == bucketToFail {
 						return nil, errors.New("oups")
 					} else {
-						return newBucket(bucketName, bucketRegion, endpoint, roleArn, accessKey, useIAMProfile, forcePathStyle)
+						return newBucket(bucketName, bucketRegion, endpoint, credentials, forcePathStyle, options)
 					}
 				}
 			})
@@ -272,7 +274,7 @@ var _ = Describe("Unversioned", func() {
 			backupsToComplete, err := unversioned.BuildBackupsToComplete(
 				configs,
 				existingBlobsArtifact,
-				unversioned.NewUnversionedBucketWithRoleARN,
+				unversioned.NewUnversionedBucketWithCredentials,
 			)
 
 			Expect(err).NotTo(HaveOccurred())
@@ -318,7 +320,7 @@ var _ = Describe("Unversioned", func() {
 			backupsToComplete, err := unversioned.BuildBackupsToComplete(
 				configs,
 				existingBlobsArtifact,
-				unversioned.NewUnversionedBucketWithRoleARN,
+				unversioned.NewUnversionedBucketWithCredentials,
 			)
 
 			Expect(err).NotTo(HaveOccurred())
@@ -337,7 +339,7 @@ var _ = Describe("Unversioned", func() {
 			}, nil)
 
 			forcePathStyles := []bool{}
-			newBucketSpy := func(_, _, _, _ string, _ s3bucket.AccessKey, _, forcePathStyle bool) (unversioned.Bucket, error) {
+			newBucketSpy := func(_, _, _ string, _ s3bucket.Credentials, forcePathStyle bool, _ s3bucket.Options) (unversioned.Bucket, error) {
 				forcePathStyles = append(forcePathStyles, forcePathStyle)
 				return fakeLiveBucket1, nil
 			}
@@ -357,7 +359,7 @@ var _ = Describe("Unversioned", func() {
 			_, err := unversioned.BuildBackupsToComplete(
 				configs,
 				existingBlobsArtifact,
-				unversioned.NewUnversionedBucketWithRoleARN,
+				unversioned.NewUnversionedBucketWithCredentials,
 			)
 
 			Expect(err).To(MatchError(ContainSubstring("fake load error")))
@@ -369,7 +371,7 @@ var _ = Describe("Unversioned", func() {
 			_, err := unversioned.BuildBackupsToComplete(
 				configs,
 				existingBlobsArtifact,
-				unversioned.NewUnversionedBucketWithRoleARN,
+				unversioned.NewUnversionedBucketWithCredentials,
 			)
 
 			Expect(err).To(Or(
@@ -426,7 +428,7 @@ var _ = Describe("Unversioned", func() {
 			}, nil)
 
 			forcePathStyles := []bool{}
-			newBucketSpy := func(_, _, _, _ string, _ s3bucket.AccessKey, _, forcePathStyle bool) (unversioned.Bucket, error) {
+			newBucketSpy := func(_, _, _ string, _ s3bucket.Credentials, forcePathStyle bool, _ s3bucket.Options) (unversioned.Bucket, error) {
 				forcePathStyles = append(forcePathStyles, forcePathStyle)
 				return fakeLiveBucket1, nil
 			}
@@ -495,11 +497,11 @@ var _ = Describe("Unversioned", func() {
 			)
 
 			JustBeforeEach(func() {
-				newBucketFails = func(bucketName, bucketRegion, endpoint, roleArn string, accessKey s3bucket.AccessKey, useIAMProfile, forcePathStyle bool) (unversioned.Bucket, error) {
+				newBucketFails = func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (unversioned.Bucket, error) {
 					if bucketName == bucketToFail {
 						return nil, errors.New("oups")
 					} else {
-						return newBucket(bucketName, bucketRegion, endpoint, roleArn, accessKey, useIAMProfile, forcePathStyle)
+						return newBucket(bucketName, bucketRegion, endpoint, credentials, forcePathStyle, options)
 					}
 				}
 			})
@@ -575,4 +577,598 @@ var _ = Describe("Unversioned", func() {
 			Expect(err).To(MatchError(ContainSubstring("backup artifact does not contain bucket ID")))
 		})
 	})
+
+	Context("when a bucket sets max_in_flight", func() {
+		var artifact *fakes.FakeArtifact
+
+		BeforeEach(func() {
+			bucket1Config.MaxInFlight = 50
+			configs["bucket1"] = bucket1Config
+
+			artifact = new(fakes.FakeArtifact)
+			artifact.LoadReturns(map[string]incremental.Backup{
+				"bucket1": {BucketName: "backup-name1", BucketRegion: "backup-region1"},
+				"bucket2": {BucketName: "backup-name2", BucketRegion: "backup-region2"},
+			}, nil)
+		})
+
+		It("passes it to the backups to start", func() {
+			backupsToStart, err := unversioned.BuildBackupsToStart(configs, newBucket)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupsToStart["bucket1"].BucketPair.MaxInFlight).To(Equal(50))
+			Expect(backupsToStart["bucket2"].BucketPair.MaxInFlight).To(Equal(0))
+		})
+
+		It("passes it to the backups to complete", func() {
+			backupsToComplete, err := unversioned.BuildBackupsToComplete(configs, artifact, newBucket)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupsToComplete["bucket1"].MaxInFlight).To(Equal(50))
+			Expect(backupsToComplete["bucket2"].MaxInFlight).To(Equal(0))
+		})
+
+		It("passes it to the restore bucket pairs", func() {
+			restoreBucketPairs, err := unversioned.BuildRestoreBucketPairs(configs, artifact, newBucket)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restoreBucketPairs["bucket1"].MaxInFlight).To(Equal(50))
+			Expect(restoreBucketPairs["bucket2"].MaxInFlight).To(Equal(0))
+		})
+
+		Context("and it is negative", func() {
+			BeforeEach(func() {
+				bucket1Config.MaxInFlight = -1
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("fails to build anything", func() {
+				expectedError := "invalid max_in_flight for bucket bucket1: must be at least 1"
+
+				_, err := unversioned.BuildBackupsToStart(configs, newBucket)
+				Expect(err).To(MatchError(expectedError))
+
+				_, err = unversioned.BuildBackupsToComplete(configs, artifact, newBucket)
+				Expect(err).To(MatchError(expectedError))
+
+				_, err = unversioned.BuildRestoreBucketPairs(configs, artifact, newBucket)
+				Expect(err).To(MatchError(expectedError))
+			})
+		})
+	})
+
+	Context("when a bucket sets change_detection", func() {
+		BeforeEach(func() {
+			bucket1Config.ChangeDetection = incremental.ChangeDetectionPath
+			configs["bucket1"] = bucket1Config
+		})
+
+		It("passes it to the backups to start", func() {
+			backupsToStart, err := unversioned.BuildBackupsToStart(configs, newBucket)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupsToStart["bucket1"].BucketPair.ChangeDetection).To(Equal(incremental.ChangeDetectionPath))
+			Expect(backupsToStart["bucket2"].BucketPair.ChangeDetection).To(BeEmpty())
+		})
+
+		Context("and it is not content or path", func() {
+			BeforeEach(func() {
+				bucket1Config.ChangeDetection = "mtime"
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("fails to build the backups to start", func() {
+				_, err := unversioned.BuildBackupsToStart(configs, newBucket)
+				Expect(err).To(MatchError("invalid change_detection for bucket bucket1: must be content or path"))
+			})
+		})
+	})
+
+	Context("when a bucket sets copy_acl", func() {
+		var passedOptions map[string]s3bucket.Options
+
+		BeforeEach(func() {
+			bucket1Config.CopyACL = true
+			configs["bucket1"] = bucket1Config
+			passedOptions = map[string]s3bucket.Options{}
+		})
+
+		It("passes it to the live and backup buckets", func() {
+			_, err := unversioned.BuildBackupsToStart(configs, func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (unversioned.Bucket, error) {
+				passedOptions[bucketName] = options
+				return newBucket(bucketName, bucketRegion, endpoint, credentials, forcePathStyle, options)
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedOptions["live-name1"].CopyACL).To(BeTrue())
+			Expect(passedOptions["backup-name1"].CopyACL).To(BeTrue())
+			Expect(passedOptions["live-name2"].CopyACL).To(BeFalse())
+		})
+	})
+
+	Context("when a bucket sets a session token and assumed role options", func() {
+		var passedCredentials map[string]s3bucket.Credentials
+
+		BeforeEach(func() {
+			bucket1Config.AwsSessionToken = "my-session-token"
+			bucket1Config.AwsAssumedRoleArn = "my-assumed-role-arn"
+			bucket1Config.AwsAssumedRoleExternalId = "my-external-id"
+			bucket1Config.AwsAssumedRoleSessionName = "my-session-name"
+			configs["bucket1"] = bucket1Config
+			passedCredentials = map[string]s3bucket.Credentials{}
+		})
+
+		It("passes them to the live and backup buckets", func() {
+			_, err := unversioned.BuildBackupsToStart(configs, func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (unversioned.Bucket, error) {
+				passedCredentials[bucketName] = credentials
+				return newBucket(bucketName, bucketRegion, endpoint, credentials, forcePathStyle, options)
+			})
+
+			expectedCredentials := s3bucket.Credentials{
+				AccessKey:       s3bucket.AccessKey{Id: "my-id", Secret: "my-secret-key", SessionToken: "my-session-token"},
+				RoleARN:         "my-assumed-role-arn",
+				ExternalID:      "my-external-id",
+				RoleSessionName: "my-session-name",
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedCredentials["live-name1"]).To(Equal(expectedCredentials))
+			Expect(passedCredentials["backup-name1"]).To(Equal(expectedCredentials))
+			Expect(passedCredentials["live-name2"].RoleARN).To(BeEmpty())
+		})
+	})
+
+	Context("when a bucket sets more than one source of credentials", func() {
+		BeforeEach(func() {
+			bucket1Config.AwsProfile = "my-profile"
+			configs["bucket1"] = bucket1Config
+		})
+
+		It("fails to build the backups to start", func() {
+			_, err := unversioned.BuildBackupsToStart(configs, newBucket)
+			Expect(err).To(MatchError("invalid config for bucket bucket1: only one of aws_access_key_id, use_iam_profile, use_container_credentials, aws_profile and aws_web_identity_token_file can be set"))
+		})
+	})
+
+	Context("when the backup bucket sets its own endpoint and credentials", func() {
+		type access struct {
+			endpoint       string
+			credentials    s3bucket.Credentials
+			forcePathStyle bool
+		}
+
+		var (
+			passedAccess map[string]access
+			newBucketSpy unversioned.NewBucket
+		)
+
+		BeforeEach(func() {
+			bucket1Config.ForcePathStyle = true
+			bucket1Config.Backup.AccessConfig = s3bucket.AccessConfig{
+				Endpoint:          "backup-endpoint.aws",
+				AwsProfile:        "backup-profile",
+				AwsAssumedRoleArn: "backup-role-arn",
+			}
+			configs["bucket1"] = bucket1Config
+
+			passedAccess = map[string]access{}
+			newBucketSpy = func(bucketName, _, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, _ s3bucket.Options) (unversioned.Bucket, error) {
+				passedAccess[bucketName] = access{endpoint, credentials, forcePathStyle}
+				return new(unversionedFakes.FakeBucket), nil
+			}
+		})
+
+		expectBackupAccess := func() {
+			Expect(passedAccess["backup-name1"]).To(Equal(access{
+				endpoint:    "backup-endpoint.aws",
+				credentials: s3bucket.Credentials{Profile: "backup-profile", RoleARN: "backup-role-arn"},
+			}))
+			Expect(passedAccess["backup-name2"]).To(Equal(access{
+				endpoint:    "my-s3-endpoint.aws",
+				credentials: s3bucket.Credentials{AccessKey: s3bucket.AccessKey{Id: "my-id", Secret: "my-secret-key"}},
+			}))
+		}
+
+		It("passes them to the backup bucket when starting backups, and the live bucket's to the live bucket", func() {
+			_, err := unversioned.BuildBackupsToStart(configs, newBucketSpy)
+
+			Expect(err).NotTo(HaveOccurred())
+			expectBackupAccess()
+			Expect(passedAccess["live-name1"]).To(Equal(access{
+				endpoint:       "my-s3-endpoint.aws",
+				credentials:    s3bucket.Credentials{AccessKey: s3bucket.AccessKey{Id: "my-id", Secret: "my-secret-key"}},
+				forcePathStyle: true,
+			}))
+		})
+
+		It("passes them to the backup bucket when restoring", func() {
+			artifact := new(fakes.FakeArtifact)
+			artifact.LoadReturns(map[string]incremental.Backup{
+				"bucket1": {BucketName: "backup-name1", BucketRegion: "backup-region1"},
+				"bucket2": {BucketName: "backup-name2", BucketRegion: "backup-region2"},
+			}, nil)
+
+			_, err := unversioned.BuildRestoreBucketPairs(configs, artifact, newBucketSpy)
+
+			Expect(err).NotTo(HaveOccurred())
+			expectBackupAccess()
+		})
+
+		It("passes them to the backup bucket when pruning backups", func() {
+			_, err := unversioned.BuildBackupBuckets(configs, newBucketSpy)
+
+			Expect(err).NotTo(HaveOccurred())
+			expectBackupAccess()
+		})
+
+		Context("and only sets credentials", func() {
+			BeforeEach(func() {
+				bucket1Config.Backup.AccessConfig = s3bucket.AccessConfig{UseIAMProfile: true}
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("passes the live bucket's endpoint and addressing style to the backup bucket", func() {
+				_, err := unversioned.BuildBackupBuckets(configs, newBucketSpy)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(passedAccess["backup-name1"]).To(Equal(access{
+					endpoint:       "my-s3-endpoint.aws",
+					credentials:    s3bucket.Credentials{UseIAMProfile: true},
+					forcePathStyle: true,
+				}))
+			})
+		})
+
+		Context("and sets more than one source of credentials", func() {
+			BeforeEach(func() {
+				bucket1Config.Backup.AwsAccessKeyId = "backup-id"
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("fails to build the backups to start", func() {
+				_, err := unversioned.BuildBackupsToStart(configs, newBucketSpy)
+				Expect(err).To(MatchError("invalid config for bucket bucket1: backup credentials: only one of aws_access_key_id, use_iam_profile, use_container_credentials, aws_profile and aws_web_identity_token_file can be set"))
+			})
+		})
+	})
+
+	Context("when a bucket sets verify", func() {
+		var passedOptions map[string]s3bucket.Options
+
+		BeforeEach(func() {
+			bucket1Config.Verify = s3bucket.VerificationConfig{Enabled: true, ChecksumAlgorithm: "CRC32C"}
+			configs["bucket1"] = bucket1Config
+			passedOptions = map[string]s3bucket.Options{}
+		})
+
+		It("passes it to the live and backup buckets and the bucket pair", func() {
+			backupsToStart, err := unversioned.BuildBackupsToStart(configs, func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (unversioned.Bucket, error) {
+				passedOptions[bucketName] = options
+				return newBucket(bucketName, bucketRegion, endpoint, credentials, forcePathStyle, options)
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedOptions["live-name1"].Verification).To(Equal(s3bucket.VerificationConfig{Enabled: true, ChecksumAlgorithm: "CRC32C"}))
+			Expect(passedOptions["backup-name1"].Verification).To(Equal(s3bucket.VerificationConfig{Enabled: true, ChecksumAlgorithm: "CRC32C"}))
+			Expect(backupsToStart["bucket1"].BucketPair.Verify).To(BeTrue())
+			Expect(backupsToStart["bucket2"].BucketPair.Verify).To(BeFalse())
+		})
+
+		Context("and the checksum algorithm is not known", func() {
+			BeforeEach(func() {
+				bucket1Config.Verify.ChecksumAlgorithm = "MD5"
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("fails to build the backups to start", func() {
+				_, err := unversioned.BuildBackupsToStart(configs, newBucket)
+				Expect(err).To(MatchError("invalid config for bucket bucket1: verify.checksum_algorithm must be one of CRC32, CRC32C, CRC64NVME, SHA1, SHA256"))
+			})
+		})
+	})
+
+	Context("when a bucket sets encryption", func() {
+		var (
+			artifact      *fakes.FakeArtifact
+			passedOptions map[string]s3bucket.Options
+			newBucketSpy  unversioned.NewBucket
+		)
+
+		BeforeEach(func() {
+			bucket1Config.Encryption = s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSES3}
+			configs["bucket1"] = bucket1Config
+
+			artifact = new(fakes.FakeArtifact)
+			artifact.LoadReturns(map[string]incremental.Backup{
+				"bucket1": {BucketName: "backup-name1", BucketRegion: "backup-region1"},
+				"bucket2": {BucketName: "backup-name2", BucketRegion: "backup-region2"},
+			}, nil)
+
+			passedOptions = map[string]s3bucket.Options{}
+			newBucketSpy = func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (unversioned.Bucket, error) {
+				passedOptions[bucketName] = options
+				return newBucket(bucketName, bucketRegion, endpoint, credentials, forcePathStyle, options)
+			}
+		})
+
+		It("passes it to the live and backup buckets", func() {
+			_, err := unversioned.BuildBackupsToStart(configs, newBucketSpy)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedOptions["live-name1"].Encryption).To(Equal(s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSES3}))
+			Expect(passedOptions["backup-name1"].Encryption).To(Equal(s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSES3}))
+			Expect(passedOptions["live-name2"].Encryption).To(Equal(s3bucket.EncryptionConfig{}))
+		})
+
+		Context("and the backup bucket sets its own encryption", func() {
+			BeforeEach(func() {
+				bucket1Config.Backup.Encryption = s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSEKMS, KMSKeyID: "a-key-id"}
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("passes it to the backup bucket", func() {
+				_, err := unversioned.BuildRestoreBucketPairs(configs, artifact, newBucketSpy)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(passedOptions["live-name1"].Encryption).To(Equal(s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSES3}))
+				Expect(passedOptions["backup-name1"].Encryption).To(Equal(s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSEKMS, KMSKeyID: "a-key-id"}))
+			})
+		})
+
+		Context("and the backup encryption is invalid", func() {
+			BeforeEach(func() {
+				bucket1Config.Backup.Encryption = s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSEC}
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("fails to build the backups to start", func() {
+				_, err := unversioned.BuildBackupsToStart(configs, newBucketSpy)
+				Expect(err).To(MatchError("invalid config for bucket bucket1: backup.encryption.customer_key must be a base64 encoded 256-bit key"))
+			})
+		})
+	})
+
+	Context("when a backup bucket sets storage_class and archive_restore", func() {
+		var (
+			artifact      *fakes.FakeArtifact
+			passedOptions map[string]s3bucket.Options
+			newBucketSpy  unversioned.NewBucket
+		)
+
+		BeforeEach(func() {
+			bucket1Config.Backup.StorageClass = "STANDARD_IA"
+			bucket1Config.Backup.ArchiveRestore = s3bucket.ArchiveRestoreConfig{Tier: "Bulk", Days: 3}
+			configs["bucket1"] = bucket1Config
+
+			artifact = new(fakes.FakeArtifact)
+			artifact.LoadReturns(map[string]incremental.Backup{
+				"bucket1": {BucketName: "backup-name1", BucketRegion: "backup-region1"},
+				"bucket2": {BucketName: "backup-name2", BucketRegion: "backup-region2"},
+			}, nil)
+
+			passedOptions = map[string]s3bucket.Options{}
+			newBucketSpy = func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (unversioned.Bucket, error) {
+				passedOptions[bucketName] = options
+				return newBucket(bucketName, bucketRegion, endpoint, credentials, forcePathStyle, options)
+			}
+		})
+
+		It("passes them to the backup bucket only", func() {
+			_, err := unversioned.BuildRestoreBucketPairs(configs, artifact, newBucketSpy)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedOptions["backup-name1"].StorageClass).To(Equal("STANDARD_IA"))
+			Expect(passedOptions["backup-name1"].ArchiveRestore).To(Equal(s3bucket.ArchiveRestoreConfig{Tier: "Bulk", Days: 3}))
+			Expect(passedOptions["live-name1"].StorageClass).To(BeEmpty())
+			Expect(passedOptions["live-name1"].ArchiveRestore).To(Equal(s3bucket.ArchiveRestoreConfig{}))
+		})
+
+		Context("and the storage class is archival", func() {
+			BeforeEach(func() {
+				bucket1Config.Backup.StorageClass = "GLACIER"
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("fails to build the backups to start", func() {
+				_, err := unversioned.BuildBackupsToStart(configs, newBucketSpy)
+				Expect(err).To(MatchError("invalid config for bucket bucket1: backup.storage_class must be one of STANDARD, STANDARD_IA, ONEZONE_IA, INTELLIGENT_TIERING, GLACIER_IR"))
+			})
+		})
+
+		Context("and the archive restore tier is not known", func() {
+			BeforeEach(func() {
+				bucket1Config.Backup.ArchiveRestore.Tier = "Fast"
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("fails to build the restore bucket pairs", func() {
+				_, err := unversioned.BuildRestoreBucketPairs(configs, artifact, newBucketSpy)
+				Expect(err).To(MatchError("invalid config for bucket bucket1: backup.archive_restore.tier must be Standard, Bulk or Expedited"))
+			})
+		})
+	})
+
+	Context("when a bucket sets delete_extraneous", func() {
+		var artifact *fakes.FakeArtifact
+
+		BeforeEach(func() {
+			bucket1Config.DeleteExtraneous = mirror.Config{Enabled: true, MaxDeletions: 10}
+			configs["bucket1"] = bucket1Config
+
+			artifact = new(fakes.FakeArtifact)
+			artifact.LoadReturns(map[string]incremental.Backup{
+				"bucket1": {BucketName: "backup-name1", BucketRegion: "backup-region1"},
+				"bucket2": {BucketName: "backup-name2", BucketRegion: "backup-region2"},
+			}, nil)
+		})
+
+		It("passes it to the restore bucket pairs", func() {
+			restoreBucketPairs, err := unversioned.BuildRestoreBucketPairs(configs, artifact, newBucket)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restoreBucketPairs["bucket1"].DeleteExtraneous).To(Equal(mirror.Config{Enabled: true, MaxDeletions: 10}))
+			Expect(restoreBucketPairs["bucket2"].DeleteExtraneous).To(Equal(mirror.Config{}))
+		})
+
+		Context("and max_deletions is negative", func() {
+			BeforeEach(func() {
+				bucket1Config.DeleteExtraneous.MaxDeletions = -1
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("fails to build the restore bucket pairs", func() {
+				_, err := unversioned.BuildRestoreBucketPairs(configs, artifact, newBucket)
+				Expect(err).To(MatchError("invalid config for bucket bucket1: delete_extraneous.max_deletions must not be negative"))
+			})
+		})
+	})
+
+	Context("when the existing blobs artifact records blob attributes", func() {
+		It("passes them to the blobs to copy", func() {
+			lastModified := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+			existingBlobsArtifact := new(fakes.FakeArtifact)
+			existingBlobsArtifact.LoadReturns(map[string]incremental.Backup{
+				"bucket1": {
+					SrcBackupDirectoryPath: "source-backup-dir1",
+					DstBackupDirectoryPath: "destination-backup-dir1",
+					Blobs:                  []string{"source-backup-dir1/blob-path1"},
+					BlobAttributes: map[string]incremental.BlobAttributes{
+						"source-backup-dir1/blob-path1": {ETag: `"etag1"`, Size: 10, LastModified: lastModified},
+					},
+				},
+				"bucket2": {SameBucketAs: "bucket1"},
+			}, nil)
+
+			backupsToComplete, err := unversioned.BuildBackupsToComplete(configs, existingBlobsArtifact, newBucket)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupsToComplete["bucket1"].BlobsToCopy).To(ConsistOf(incremental.BackedUpBlob{
+				Path:                "source-backup-dir1/blob-path1",
+				BackupDirectoryPath: "source-backup-dir1",
+				ETag:                `"etag1"`,
+				Size:                10,
+				LastModified:        lastModified,
+			}))
+		})
+	})
+
+	Context("BuildBackupsToPrune", func() {
+		BeforeEach(func() {
+			bucket1Config.Retention = incremental.RetentionPolicy{KeepLast: 3, MaxAgeDays: 30}
+			bucket1Config.MaxInFlight = 50
+			configs["bucket1"] = bucket1Config
+		})
+
+		It("builds backups to prune from a config, leaving out the buckets without a retention policy", func() {
+			backupsToPrune, err := unversioned.BuildBackupsToPrune(configs, newBucket)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupsToPrune).To(Equal(map[string]incremental.BackupToPrune{
+				"bucket1": {
+					BackupBucket: fakeBackupBucket1,
+					Retention:    incremental.RetentionPolicy{KeepLast: 3, MaxAgeDays: 30},
+					MaxInFlight:  50,
+				},
+			}))
+		})
+
+		Context("when the retention policy is invalid", func() {
+			BeforeEach(func() {
+				bucket1Config.Retention.KeepLast = -1
+				configs["bucket1"] = bucket1Config
+			})
+
+			It("returns an error", func() {
+				_, err := unversioned.BuildBackupsToPrune(configs, newBucket)
+				Expect(err).To(MatchError("invalid config for bucket bucket1: retention.keep_last must not be negative"))
+			})
+		})
+
+		Context("when bucket initialisation fails", func() {
+			It("returns an error", func() {
+				_, err := unversioned.BuildBackupsToPrune(configs, func(string, string, string, s3bucket.Credentials, bool, s3bucket.Options) (unversioned.Bucket, error) {
+					return nil, errors.New("oups")
+				})
+				Expect(err).To(MatchError("oups"))
+			})
+		})
+	})
+
+	Context("BuildBackupBuckets", func() {
+		It("builds the backup bucket of each bucket ID", func() {
+			backupBuckets, err := unversioned.BuildBackupBuckets(configs, newBucket)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupBuckets).To(Equal(map[string]incremental.Bucket{
+				"bucket1": fakeBackupBucket1,
+				"bucket2": fakeBackupBucket2,
+			}))
+		})
+	})
+
+	Context("BuildBackupsToFind", func() {
+		It("builds the backups to find from a config", func() {
+			backupsToFind, err := unversioned.BuildBackupsToFind(configs, newBucket)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backupsToFind).To(Equal(map[string]incremental.BackupToFind{
+				"bucket1": {BackupBucket: fakeBackupBucket1},
+				"bucket2": {BackupBucket: fakeBackupBucket2},
+			}))
+		})
+
+		Context("when the same live bucket is configured for two bucket IDs", func() {
+			BeforeEach(func() {
+				bucket2Config := configs["bucket2"]
+				bucket2Config.Name = "live-name1"
+				bucket2Config.Region = "live-region1"
+				configs["bucket2"] = bucket2Config
+			})
+
+			It("marks the second bucket ID the same as the first", func() {
+				backupsToFind, err := unversioned.BuildBackupsToFind(configs, newBucket)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(backupsToFind).To(Equal(map[string]incremental.BackupToFind{
+					"bucket1": {BackupBucket: fakeBackupBucket1},
+					"bucket2": {SameAsBucketID: "bucket1"},
+				}))
+			})
+		})
+	})
+
+	Context("when a bucket has a negative retention.max_age_days", func() {
+		BeforeEach(func() {
+			bucket1Config.Retention.MaxAgeDays = -1
+			configs["bucket1"] = bucket1Config
+		})
+
+		It("fails to build the backups to start", func() {
+			_, err := unversioned.BuildBackupsToStart(configs, newBucket)
+			Expect(err).To(MatchError("invalid config for bucket bucket1: retention.max_age_days must not be negative"))
+		})
+	})
+
+	Context("when a bucket has an invalid multipart config", func() {
+		BeforeEach(func() {
+			bucket1Config.Multipart = s3bucket.MultipartConfig{ThresholdMB: 6000}
+			configs["bucket1"] = bucket1Config
+		})
+
+		It("fails to build the backups to start", func() {
+			_, err := unversioned.BuildBackupsToStart(configs, newBucket)
+			Expect(err).To(MatchError("invalid config for bucket bucket1: multipart.threshold_mb must be between 1 and 5120"))
+		})
+	})
+
+	Context("when a bucket has a negative max_requests_per_second", func() {
+		BeforeEach(func() {
+			bucket1Config.MaxRequestsPerSecond = -1
+			configs["bucket1"] = bucket1Config
+		})
+
+		It("fails to build the backups to start", func() {
+			_, err := unversioned.BuildBackupsToStart(configs, newBucket)
+			Expect(err).To(MatchError("invalid config for bucket bucket1: max_requests_per_second must not be negative"))
+		})
+	})
 })
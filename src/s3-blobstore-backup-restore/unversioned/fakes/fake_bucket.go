@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"s3-blobstore-backup-restore/incremental"
+	"s3-blobstore-backup-restore/integrity"
 	"s3-blobstore-backup-restore/unversioned"
 )
 
@@ -101,6 +102,55 @@ type FakeBucket struct {
 		result1 bool
 		result2 error
 	}
+	DeleteBlobStub        func(path string) error
+	deleteBlobMutex       sync.RWMutex
+	deleteBlobArgsForCall []struct {
+		path string
+	}
+	deleteBlobReturns struct {
+		result1 error
+	}
+	deleteBlobReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RestoreArchivedBlobsStub        func(paths []string, maxInFlight int) error
+	restoreArchivedBlobsMutex       sync.RWMutex
+	restoreArchivedBlobsArgsForCall []struct {
+		paths       []string
+		maxInFlight int
+	}
+	restoreArchivedBlobsReturns struct {
+		result1 error
+	}
+	restoreArchivedBlobsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ChecksumStub        func(path string) (integrity.Checksum, error)
+	checksumMutex       sync.RWMutex
+	checksumArgsForCall []struct {
+		path string
+	}
+	checksumReturns struct {
+		result1 integrity.Checksum
+		result2 error
+	}
+	checksumReturnsOnCall map[int]struct {
+		result1 integrity.Checksum
+		result2 error
+	}
+	ETagIsDigestStub        func(path string) (bool, error)
+	eTagIsDigestMutex       sync.RWMutex
+	eTagIsDigestArgsForCall []struct {
+		path string
+	}
+	eTagIsDigestReturns struct {
+		result1 bool
+		result2 error
+	}
+	eTagIsDigestReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
 	IsVersionedStub        func() (bool, error)
 	isVersionedMutex       sync.RWMutex
 	isVersionedArgsForCall []struct{}
@@ -489,6 +539,210 @@ func (fake *FakeBucket) HasBlobReturnsOnCall(i int, result1 bool, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeBucket) DeleteBlob(path string) error {
+	fake.deleteBlobMutex.Lock()
+	ret, specificReturn := fake.deleteBlobReturnsOnCall[len(fake.deleteBlobArgsForCall)]
+	fake.deleteBlobArgsForCall = append(fake.deleteBlobArgsForCall, struct {
+		path string
+	}{path})
+	fake.recordInvocation("DeleteBlob", []interface{}{path})
+	fake.deleteBlobMutex.Unlock()
+	if fake.DeleteBlobStub != nil {
+		return fake.DeleteBlobStub(path)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.deleteBlobReturns.result1
+}
+
+func (fake *FakeBucket) DeleteBlobCallCount() int {
+	fake.deleteBlobMutex.RLock()
+	defer fake.deleteBlobMutex.RUnlock()
+	return len(fake.deleteBlobArgsForCall)
+}
+
+func (fake *FakeBucket) DeleteBlobArgsForCall(i int) string {
+	fake.deleteBlobMutex.RLock()
+	defer fake.deleteBlobMutex.RUnlock()
+	return fake.deleteBlobArgsForCall[i].path
+}
+
+func (fake *FakeBucket) DeleteBlobReturns(result1 error) {
+	fake.DeleteBlobStub = nil
+	fake.deleteBlobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBucket) DeleteBlobReturnsOnCall(i int, result1 error) {
+	fake.DeleteBlobStub = nil
+	if fake.deleteBlobReturnsOnCall == nil {
+		fake.deleteBlobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteBlobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobs(paths []string, maxInFlight int) error {
+	var pathsCopy []string
+	if paths != nil {
+		pathsCopy = make([]string, len(paths))
+		copy(pathsCopy, paths)
+	}
+	fake.restoreArchivedBlobsMutex.Lock()
+	ret, specificReturn := fake.restoreArchivedBlobsReturnsOnCall[len(fake.restoreArchivedBlobsArgsForCall)]
+	fake.restoreArchivedBlobsArgsForCall = append(fake.restoreArchivedBlobsArgsForCall, struct {
+		paths       []string
+		maxInFlight int
+	}{pathsCopy, maxInFlight})
+	fake.recordInvocation("RestoreArchivedBlobs", []interface{}{pathsCopy, maxInFlight})
+	fake.restoreArchivedBlobsMutex.Unlock()
+	if fake.RestoreArchivedBlobsStub != nil {
+		return fake.RestoreArchivedBlobsStub(paths, maxInFlight)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.restoreArchivedBlobsReturns.result1
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobsCallCount() int {
+	fake.restoreArchivedBlobsMutex.RLock()
+	defer fake.restoreArchivedBlobsMutex.RUnlock()
+	return len(fake.restoreArchivedBlobsArgsForCall)
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobsArgsForCall(i int) ([]string, int) {
+	fake.restoreArchivedBlobsMutex.RLock()
+	defer fake.restoreArchivedBlobsMutex.RUnlock()
+	return fake.restoreArchivedBlobsArgsForCall[i].paths, fake.restoreArchivedBlobsArgsForCall[i].maxInFlight
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobsReturns(result1 error) {
+	fake.RestoreArchivedBlobsStub = nil
+	fake.restoreArchivedBlobsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBucket) RestoreArchivedBlobsReturnsOnCall(i int, result1 error) {
+	fake.RestoreArchivedBlobsStub = nil
+	if fake.restoreArchivedBlobsReturnsOnCall == nil {
+		fake.restoreArchivedBlobsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.restoreArchivedBlobsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBucket) Checksum(path string) (integrity.Checksum, error) {
+	fake.checksumMutex.Lock()
+	ret, specificReturn := fake.checksumReturnsOnCall[len(fake.checksumArgsForCall)]
+	fake.checksumArgsForCall = append(fake.checksumArgsForCall, struct {
+		path string
+	}{path})
+	fake.recordInvocation("Checksum", []interface{}{path})
+	fake.checksumMutex.Unlock()
+	if fake.ChecksumStub != nil {
+		return fake.ChecksumStub(path)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.checksumReturns.result1, fake.checksumReturns.result2
+}
+
+func (fake *FakeBucket) ChecksumCallCount() int {
+	fake.checksumMutex.RLock()
+	defer fake.checksumMutex.RUnlock()
+	return len(fake.checksumArgsForCall)
+}
+
+func (fake *FakeBucket) ChecksumArgsForCall(i int) string {
+	fake.checksumMutex.RLock()
+	defer fake.checksumMutex.RUnlock()
+	return fake.checksumArgsForCall[i].path
+}
+
+func (fake *FakeBucket) ChecksumReturns(result1 integrity.Checksum, result2 error) {
+	fake.ChecksumStub = nil
+	fake.checksumReturns = struct {
+		result1 integrity.Checksum
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBucket) ChecksumReturnsOnCall(i int, result1 integrity.Checksum, result2 error) {
+	fake.ChecksumStub = nil
+	if fake.checksumReturnsOnCall == nil {
+		fake.checksumReturnsOnCall = make(map[int]struct {
+			result1 integrity.Checksum
+			result2 error
+		})
+	}
+	fake.checksumReturnsOnCall[i] = struct {
+		result1 integrity.Checksum
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBucket) ETagIsDigest(path string) (bool, error) {
+	fake.eTagIsDigestMutex.Lock()
+	ret, specificReturn := fake.eTagIsDigestReturnsOnCall[len(fake.eTagIsDigestArgsForCall)]
+	fake.eTagIsDigestArgsForCall = append(fake.eTagIsDigestArgsForCall, struct {
+		path string
+	}{path})
+	fake.recordInvocation("ETagIsDigest", []interface{}{path})
+	fake.eTagIsDigestMutex.Unlock()
+	if fake.ETagIsDigestStub != nil {
+		return fake.ETagIsDigestStub(path)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.eTagIsDigestReturns.result1, fake.eTagIsDigestReturns.result2
+}
+
+func (fake *FakeBucket) ETagIsDigestCallCount() int {
+	fake.eTagIsDigestMutex.RLock()
+	defer fake.eTagIsDigestMutex.RUnlock()
+	return len(fake.eTagIsDigestArgsForCall)
+}
+
+func (fake *FakeBucket) ETagIsDigestArgsForCall(i int) string {
+	fake.eTagIsDigestMutex.RLock()
+	defer fake.eTagIsDigestMutex.RUnlock()
+	return fake.eTagIsDigestArgsForCall[i].path
+}
+
+func (fake *FakeBucket) ETagIsDigestReturns(result1 bool, result2 error) {
+	fake.ETagIsDigestStub = nil
+	fake.eTagIsDigestReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBucket) ETagIsDigestReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.ETagIsDigestStub = nil
+	if fake.eTagIsDigestReturnsOnCall == nil {
+		fake.eTagIsDigestReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.eTagIsDigestReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBucket) IsVersioned() (bool, error) {
 	fake.isVersionedMutex.Lock()
 	ret, specificReturn := fake.isVersionedReturnsOnCall[len(fake.isVersionedArgsForCall)]
@@ -551,6 +805,14 @@ func (fake *FakeBucket) Invocations() map[string][][]interface{} {
 	defer fake.uploadBlobMutex.RUnlock()
 	fake.hasBlobMutex.RLock()
 	defer fake.hasBlobMutex.RUnlock()
+	fake.deleteBlobMutex.RLock()
+	defer fake.deleteBlobMutex.RUnlock()
+	fake.restoreArchivedBlobsMutex.RLock()
+	defer fake.restoreArchivedBlobsMutex.RUnlock()
+	fake.checksumMutex.RLock()
+	defer fake.checksumMutex.RUnlock()
+	fake.eTagIsDigestMutex.RLock()
+	defer fake.eTagIsDigestMutex.RUnlock()
 	fake.isVersionedMutex.RLock()
 	defer fake.isVersionedMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
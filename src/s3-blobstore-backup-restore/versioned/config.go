@@ -1,6 +1,11 @@
 package versioned
 
 import (
+	"fmt"
+
+	"common/retry"
+
+	"s3-blobstore-backup-restore/mirror"
 	"s3-blobstore-backup-restore/s3bucket"
 )
 
@@ -9,32 +14,122 @@ type BucketConfig struct {
 	Region             string `json:"region"`
 	AwsAccessKeyId     string `json:"aws_access_key_id"`
 	AwsSecretAccessKey string `json:"aws_secret_access_key"`
+	AwsSessionToken    string `json:"aws_session_token,omitempty"`
 	// # Warning
 	//
 	// AwsAssumedRoleArn is provided as is and isn't thoroughly tested
-	AwsAssumedRoleArn string `json:"aws_assumed_role_arn,omitempty"`
-	Endpoint          string `json:"endpoint"`
-	UseIAMProfile     bool   `json:"use_iam_profile"`
-	ForcePathStyle    bool   `json:"force_path_style"`
+	AwsAssumedRoleArn         string `json:"aws_assumed_role_arn,omitempty"`
+	AwsAssumedRoleExternalId  string `json:"aws_assumed_role_external_id,omitempty"`
+	AwsAssumedRoleSessionName string `json:"aws_assumed_role_session_name,omitempty"`
+	// AwsProfile is a profile in the shared credentials and config files.
+	AwsProfile string `json:"aws_profile,omitempty"`
+	// AwsWebIdentityTokenFile is exchanged for the credentials of the assumed
+	// role, as with IAM roles for Kubernetes service accounts.
+	AwsWebIdentityTokenFile string                   `json:"aws_web_identity_token_file,omitempty"`
+	UseContainerCredentials bool                     `json:"use_container_credentials,omitempty"`
+	Endpoint                string                   `json:"endpoint"`
+	UseIAMProfile           bool                     `json:"use_iam_profile"`
+	ForcePathStyle          bool                     `json:"force_path_style"`
+	MaxInFlight             int                      `json:"max_in_flight,omitempty"`
+	Multipart               s3bucket.MultipartConfig `json:"multipart"`
+	Retry                   retry.Policy             `json:"retry"`
+	// MaxRequestsPerSecond limits the requests made for the bucket, or does not
+	// limit them when it is zero.
+	MaxRequestsPerSecond float64 `json:"max_requests_per_second,omitempty"`
+	// Encryption is how the versions restored to the bucket are encrypted.
+	Encryption s3bucket.EncryptionConfig `json:"encryption"`
+	// CopyACL is whether the versions restored to the bucket are given their
+	// ACLs.
+	CopyACL bool `json:"copy_acl,omitempty"`
+	// Verify is whether each restored version is checked against the version
+	// it was copied from.
+	Verify s3bucket.VerificationConfig `json:"verify"`
+	// DeleteExtraneous is whether a restore adds delete markers to the blobs
+	// which are not in the backup.
+	DeleteExtraneous mirror.Config `json:"delete_extraneous"`
+	// RestoreSource is the endpoint and credentials of the buckets which
+	// versions are restored from, when they are not those of the bucket.
+	RestoreSource *s3bucket.AccessConfig `json:"restore_source,omitempty"`
 }
 
-type NewBucket func(bucketName, bucketRegion, endpoint, role string, accessKey s3bucket.AccessKey, useIAMProfile, forcePathStyle bool) (Bucket, error)
+func (c BucketConfig) credentials() s3bucket.Credentials {
+	return s3bucket.Credentials{
+		AccessKey: s3bucket.AccessKey{
+			Id:           c.AwsAccessKeyId,
+			Secret:       c.AwsSecretAccessKey,
+			SessionToken: c.AwsSessionToken,
+		},
+		UseIAMProfile:           c.UseIAMProfile,
+		UseContainerCredentials: c.UseContainerCredentials,
+		Profile:                 c.AwsProfile,
+		WebIdentityTokenFile:    c.AwsWebIdentityTokenFile,
+		RoleARN:                 c.AwsAssumedRoleArn,
+		ExternalID:              c.AwsAssumedRoleExternalId,
+		RoleSessionName:         c.AwsAssumedRoleSessionName,
+	}
+}
+
+func (c BucketConfig) options() s3bucket.Options {
+	return s3bucket.Options{
+		Multipart:            c.Multipart,
+		Retry:                c.Retry,
+		MaxRequestsPerSecond: c.MaxRequestsPerSecond,
+		Encryption:           c.Encryption,
+		CopyACL:              c.CopyACL,
+		Verification:         c.Verify,
+		Source:               c.RestoreSource,
+	}
+}
+
+type NewBucket func(bucketName, bucketRegion, endpoint string, credentials s3bucket.Credentials, forcePathStyle bool, options s3bucket.Options) (Bucket, error)
+
+// MaxInFlight returns the max_in_flight setting of each bucket which has one.
+func MaxInFlight(config map[string]BucketConfig) map[string]int {
+	maxInFlight := map[string]int{}
+	for identifier, bucketConfig := range config {
+		if bucketConfig.MaxInFlight != 0 {
+			maxInFlight[identifier] = bucketConfig.MaxInFlight
+		}
+	}
+	return maxInFlight
+}
+
+// DeleteExtraneous returns the delete_extraneous setting of each bucket.
+func DeleteExtraneous(config map[string]BucketConfig) map[string]mirror.Config {
+	deleteExtraneous := map[string]mirror.Config{}
+	for identifier, bucketConfig := range config {
+		deleteExtraneous[identifier] = bucketConfig.DeleteExtraneous
+	}
+	return deleteExtraneous
+}
 
 func BuildVersionedBuckets(config map[string]BucketConfig, newbucket NewBucket) (map[string]Bucket, error) {
 	var buckets = map[string]Bucket{}
 
 	for identifier, bucketConfig := range config {
+		if bucketConfig.MaxInFlight < 0 {
+			return nil, fmt.Errorf("invalid max_in_flight for bucket %s: must be at least 1", identifier)
+		}
+
+		if err := bucketConfig.credentials().Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config for bucket %s: %s", identifier, err)
+		}
+
+		if err := bucketConfig.options().Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config for bucket %s: %s", identifier, err)
+		}
+
+		if err := bucketConfig.DeleteExtraneous.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config for bucket %s: %s", identifier, err)
+		}
+
 		s3Bucket, err := newbucket(
 			bucketConfig.Name,
 			bucketConfig.Region,
 			bucketConfig.Endpoint,
-			bucketConfig.AwsAssumedRoleArn,
-			s3bucket.AccessKey{
-				Id:     bucketConfig.AwsAccessKeyId,
-				Secret: bucketConfig.AwsSecretAccessKey,
-			},
-			bucketConfig.UseIAMProfile,
+			bucketConfig.credentials(),
 			bucketConfig.ForcePathStyle,
+			bucketConfig.options(),
 		)
 		if err != nil {
 			return nil, err
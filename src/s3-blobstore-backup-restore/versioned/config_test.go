@@ -1,6 +1,9 @@
 package versioned_test
 
 import (
+	"common/retry"
+
+	"s3-blobstore-backup-restore/mirror"
 	"s3-blobstore-backup-restore/s3bucket"
 	"s3-blobstore-backup-restore/versioned"
 	"s3-blobstore-backup-restore/versioned/fakes"
@@ -33,7 +36,7 @@ var _ = Describe("Versioned", func() {
 				},
 			}
 
-			buckets, err := versioned.BuildVersionedBuckets(configs, versioned.NewVersionedBucketWithRoleARN)
+			buckets, err := versioned.BuildVersionedBuckets(configs, versioned.NewVersionedBucketWithCredentials)
 
 			Expect(err).NotTo(HaveOccurred())
 			Expect(buckets).To(HaveLen(2))
@@ -52,7 +55,7 @@ var _ = Describe("Versioned", func() {
 			}
 
 			forcePathStyles := []bool{}
-			newBucketSpy := func(_, _, _, _ string, _ s3bucket.AccessKey, _, forcePathStyle bool) (versioned.Bucket, error) {
+			newBucketSpy := func(_, _, _ string, _ s3bucket.Credentials, forcePathStyle bool, _ s3bucket.Options) (versioned.Bucket, error) {
 				forcePathStyles = append(forcePathStyles, forcePathStyle)
 				return fakeBucket, nil
 			}
@@ -65,5 +68,222 @@ var _ = Describe("Versioned", func() {
 			Entry("we force path style", true),
 			Entry("we allow vhost style", false),
 		)
+
+		It("fails when a bucket has a negative max_in_flight", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {MaxInFlight: -1},
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, versioned.NewVersionedBucketWithCredentials)
+			Expect(err).To(MatchError("invalid max_in_flight for bucket bucket: must be at least 1"))
+		})
+
+		It("passes the multipart, retry and rate limit config of each bucket to newBucket", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {
+					Multipart:            s3bucket.MultipartConfig{ThresholdMB: 512, PartSizeMB: 64, MaxPartsInFlight: 4},
+					Retry:                retry.Policy{MaxAttempts: 8, BaseDelayMS: 100},
+					MaxRequestsPerSecond: 500,
+				},
+			}
+
+			var passedOptions []s3bucket.Options
+			newBucketSpy := func(_, _, _ string, _ s3bucket.Credentials, _ bool, options s3bucket.Options) (versioned.Bucket, error) {
+				passedOptions = append(passedOptions, options)
+				return new(fakes.FakeBucket), nil
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, newBucketSpy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedOptions).To(Equal([]s3bucket.Options{
+				{
+					Multipart:            s3bucket.MultipartConfig{ThresholdMB: 512, PartSizeMB: 64, MaxPartsInFlight: 4},
+					Retry:                retry.Policy{MaxAttempts: 8, BaseDelayMS: 100},
+					MaxRequestsPerSecond: 500,
+				},
+			}))
+		})
+
+		It("fails when a bucket has an invalid multipart config", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {Multipart: s3bucket.MultipartConfig{PartSizeMB: 1}},
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, versioned.NewVersionedBucketWithCredentials)
+			Expect(err).To(MatchError("invalid config for bucket bucket: multipart.part_size_mb must be between 5 and 5120"))
+		})
+
+		It("fails when a bucket has an invalid retry policy", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {Retry: retry.Policy{BaseDelayMS: -1}},
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, versioned.NewVersionedBucketWithCredentials)
+			Expect(err).To(MatchError("invalid config for bucket bucket: retry.base_delay_ms must be at least 1"))
+		})
+
+		It("passes the encryption config of each bucket to newBucket", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {Encryption: s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSEKMS, KMSKeyID: "a-key-id"}},
+			}
+
+			var passedOptions []s3bucket.Options
+			newBucketSpy := func(_, _, _ string, _ s3bucket.Credentials, _ bool, options s3bucket.Options) (versioned.Bucket, error) {
+				passedOptions = append(passedOptions, options)
+				return new(fakes.FakeBucket), nil
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, newBucketSpy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedOptions).To(Equal([]s3bucket.Options{
+				{Encryption: s3bucket.EncryptionConfig{Type: s3bucket.EncryptionSSEKMS, KMSKeyID: "a-key-id"}},
+			}))
+		})
+
+		It("passes the copy_acl setting of each bucket to newBucket", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {CopyACL: true},
+			}
+
+			var passedOptions []s3bucket.Options
+			newBucketSpy := func(_, _, _ string, _ s3bucket.Credentials, _ bool, options s3bucket.Options) (versioned.Bucket, error) {
+				passedOptions = append(passedOptions, options)
+				return new(fakes.FakeBucket), nil
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, newBucketSpy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedOptions).To(Equal([]s3bucket.Options{{CopyACL: true}}))
+		})
+
+		It("passes the verify config of each bucket to newBucket", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {Verify: s3bucket.VerificationConfig{Enabled: true, ChecksumAlgorithm: "SHA256"}},
+			}
+
+			var passedOptions []s3bucket.Options
+			newBucketSpy := func(_, _, _ string, _ s3bucket.Credentials, _ bool, options s3bucket.Options) (versioned.Bucket, error) {
+				passedOptions = append(passedOptions, options)
+				return new(fakes.FakeBucket), nil
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, newBucketSpy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedOptions).To(Equal([]s3bucket.Options{
+				{Verification: s3bucket.VerificationConfig{Enabled: true, ChecksumAlgorithm: "SHA256"}},
+			}))
+		})
+
+		It("fails when a bucket has an invalid encryption config", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {Encryption: s3bucket.EncryptionConfig{Type: "aes"}},
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, versioned.NewVersionedBucketWithCredentials)
+			Expect(err).To(MatchError("invalid config for bucket bucket: encryption.type must be sse-s3, sse-kms or sse-c"))
+		})
+
+		It("passes the credentials config of each bucket to newBucket", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {
+					AwsAccessKeyId:            "my-id",
+					AwsSecretAccessKey:        "my-secret-key",
+					AwsSessionToken:           "my-session-token",
+					AwsAssumedRoleArn:         "my-assumed-role-arn",
+					AwsAssumedRoleExternalId:  "my-external-id",
+					AwsAssumedRoleSessionName: "my-session-name",
+				},
+			}
+
+			var passedCredentials []s3bucket.Credentials
+			newBucketSpy := func(_, _, _ string, credentials s3bucket.Credentials, _ bool, _ s3bucket.Options) (versioned.Bucket, error) {
+				passedCredentials = append(passedCredentials, credentials)
+				return new(fakes.FakeBucket), nil
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, newBucketSpy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedCredentials).To(Equal([]s3bucket.Credentials{
+				{
+					AccessKey:       s3bucket.AccessKey{Id: "my-id", Secret: "my-secret-key", SessionToken: "my-session-token"},
+					RoleARN:         "my-assumed-role-arn",
+					ExternalID:      "my-external-id",
+					RoleSessionName: "my-session-name",
+				},
+			}))
+		})
+
+		It("fails when a bucket sets more than one source of credentials", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {AwsProfile: "my-profile", UseContainerCredentials: true},
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, versioned.NewVersionedBucketWithCredentials)
+			Expect(err).To(MatchError("invalid config for bucket bucket: only one of aws_access_key_id, use_iam_profile, use_container_credentials, aws_profile and aws_web_identity_token_file can be set"))
+		})
+
+		It("passes the restore_source of each bucket to newBucket", func() {
+			restoreSource := &s3bucket.AccessConfig{
+				Endpoint:          "https://source.example.com",
+				AwsProfile:        "source-profile",
+				AwsAssumedRoleArn: "source-role-arn",
+			}
+			config := map[string]versioned.BucketConfig{
+				"bucket": {RestoreSource: restoreSource},
+			}
+
+			var passedOptions []s3bucket.Options
+			newBucketSpy := func(_, _, _ string, _ s3bucket.Credentials, _ bool, options s3bucket.Options) (versioned.Bucket, error) {
+				passedOptions = append(passedOptions, options)
+				return new(fakes.FakeBucket), nil
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, newBucketSpy)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passedOptions).To(Equal([]s3bucket.Options{{Source: restoreSource}}))
+		})
+
+		It("fails when a restore_source sets more than one source of credentials", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {RestoreSource: &s3bucket.AccessConfig{AwsAccessKeyId: "source-id", UseIAMProfile: true}},
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, versioned.NewVersionedBucketWithCredentials)
+			Expect(err).To(MatchError("invalid config for bucket bucket: restore_source: only one of aws_access_key_id, use_iam_profile, use_container_credentials, aws_profile and aws_web_identity_token_file can be set"))
+		})
+
+		It("fails when a bucket has a negative delete_extraneous.max_deletions", func() {
+			config := map[string]versioned.BucketConfig{
+				"bucket": {DeleteExtraneous: mirror.Config{Enabled: true, MaxDeletions: -1}},
+			}
+
+			_, err := versioned.BuildVersionedBuckets(config, versioned.NewVersionedBucketWithCredentials)
+			Expect(err).To(MatchError("invalid config for bucket bucket: delete_extraneous.max_deletions must not be negative"))
+		})
+	})
+
+	Context("MaxInFlight", func() {
+		It("returns the max_in_flight of each bucket which sets it", func() {
+			config := map[string]versioned.BucketConfig{
+				"droplets":   {MaxInFlight: 50},
+				"buildpacks": {},
+			}
+
+			Expect(versioned.MaxInFlight(config)).To(Equal(map[string]int{"droplets": 50}))
+		})
+	})
+
+	Context("DeleteExtraneous", func() {
+		It("returns the delete_extraneous setting of each bucket", func() {
+			config := map[string]versioned.BucketConfig{
+				"droplets":   {DeleteExtraneous: mirror.Config{Enabled: true, MaxDeletions: 10}},
+				"buildpacks": {},
+			}
+
+			Expect(versioned.DeleteExtraneous(config)).To(Equal(map[string]mirror.Config{
+				"droplets":   {Enabled: true, MaxDeletions: 10},
+				"buildpacks": {},
+			}))
+		})
 	})
 })
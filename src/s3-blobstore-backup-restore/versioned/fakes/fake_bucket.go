@@ -22,6 +22,17 @@ type FakeBucket struct {
 	copyVersionReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteBlobStub        func(string) error
+	deleteBlobMutex       sync.RWMutex
+	deleteBlobArgsForCall []struct {
+		arg1 string
+	}
+	deleteBlobReturns struct {
+		result1 error
+	}
+	deleteBlobReturnsOnCall map[int]struct {
+		result1 error
+	}
 	IsVersionedStub        func() (bool, error)
 	isVersionedMutex       sync.RWMutex
 	isVersionedArgsForCall []struct {
@@ -34,6 +45,18 @@ type FakeBucket struct {
 		result1 bool
 		result2 error
 	}
+	ListVersionHistoryStub        func() ([]s3bucket.Version, error)
+	listVersionHistoryMutex       sync.RWMutex
+	listVersionHistoryArgsForCall []struct {
+	}
+	listVersionHistoryReturns struct {
+		result1 []s3bucket.Version
+		result2 error
+	}
+	listVersionHistoryReturnsOnCall map[int]struct {
+		result1 []s3bucket.Version
+		result2 error
+	}
 	ListVersionsStub        func() ([]s3bucket.Version, error)
 	listVersionsMutex       sync.RWMutex
 	listVersionsArgsForCall []struct {
@@ -134,6 +157,67 @@ func (fake *FakeBucket) CopyVersionReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeBucket) DeleteBlob(arg1 string) error {
+	fake.deleteBlobMutex.Lock()
+	ret, specificReturn := fake.deleteBlobReturnsOnCall[len(fake.deleteBlobArgsForCall)]
+	fake.deleteBlobArgsForCall = append(fake.deleteBlobArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteBlobStub
+	fakeReturns := fake.deleteBlobReturns
+	fake.recordInvocation("DeleteBlob", []interface{}{arg1})
+	fake.deleteBlobMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBucket) DeleteBlobCallCount() int {
+	fake.deleteBlobMutex.RLock()
+	defer fake.deleteBlobMutex.RUnlock()
+	return len(fake.deleteBlobArgsForCall)
+}
+
+func (fake *FakeBucket) DeleteBlobCalls(stub func(string) error) {
+	fake.deleteBlobMutex.Lock()
+	defer fake.deleteBlobMutex.Unlock()
+	fake.DeleteBlobStub = stub
+}
+
+func (fake *FakeBucket) DeleteBlobArgsForCall(i int) string {
+	fake.deleteBlobMutex.RLock()
+	defer fake.deleteBlobMutex.RUnlock()
+	argsForCall := fake.deleteBlobArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBucket) DeleteBlobReturns(result1 error) {
+	fake.deleteBlobMutex.Lock()
+	defer fake.deleteBlobMutex.Unlock()
+	fake.DeleteBlobStub = nil
+	fake.deleteBlobReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBucket) DeleteBlobReturnsOnCall(i int, result1 error) {
+	fake.deleteBlobMutex.Lock()
+	defer fake.deleteBlobMutex.Unlock()
+	fake.DeleteBlobStub = nil
+	if fake.deleteBlobReturnsOnCall == nil {
+		fake.deleteBlobReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteBlobReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeBucket) IsVersioned() (bool, error) {
 	fake.isVersionedMutex.Lock()
 	ret, specificReturn := fake.isVersionedReturnsOnCall[len(fake.isVersionedArgsForCall)]
@@ -190,6 +274,62 @@ func (fake *FakeBucket) IsVersionedReturnsOnCall(i int, result1 bool, result2 er
 	}{result1, result2}
 }
 
+func (fake *FakeBucket) ListVersionHistory() ([]s3bucket.Version, error) {
+	fake.listVersionHistoryMutex.Lock()
+	ret, specificReturn := fake.listVersionHistoryReturnsOnCall[len(fake.listVersionHistoryArgsForCall)]
+	fake.listVersionHistoryArgsForCall = append(fake.listVersionHistoryArgsForCall, struct {
+	}{})
+	stub := fake.ListVersionHistoryStub
+	fakeReturns := fake.listVersionHistoryReturns
+	fake.recordInvocation("ListVersionHistory", []interface{}{})
+	fake.listVersionHistoryMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBucket) ListVersionHistoryCallCount() int {
+	fake.listVersionHistoryMutex.RLock()
+	defer fake.listVersionHistoryMutex.RUnlock()
+	return len(fake.listVersionHistoryArgsForCall)
+}
+
+func (fake *FakeBucket) ListVersionHistoryCalls(stub func() ([]s3bucket.Version, error)) {
+	fake.listVersionHistoryMutex.Lock()
+	defer fake.listVersionHistoryMutex.Unlock()
+	fake.ListVersionHistoryStub = stub
+}
+
+func (fake *FakeBucket) ListVersionHistoryReturns(result1 []s3bucket.Version, result2 error) {
+	fake.listVersionHistoryMutex.Lock()
+	defer fake.listVersionHistoryMutex.Unlock()
+	fake.ListVersionHistoryStub = nil
+	fake.listVersionHistoryReturns = struct {
+		result1 []s3bucket.Version
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBucket) ListVersionHistoryReturnsOnCall(i int, result1 []s3bucket.Version, result2 error) {
+	fake.listVersionHistoryMutex.Lock()
+	defer fake.listVersionHistoryMutex.Unlock()
+	fake.ListVersionHistoryStub = nil
+	if fake.listVersionHistoryReturnsOnCall == nil {
+		fake.listVersionHistoryReturnsOnCall = make(map[int]struct {
+			result1 []s3bucket.Version
+			result2 error
+		})
+	}
+	fake.listVersionHistoryReturnsOnCall[i] = struct {
+		result1 []s3bucket.Version
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBucket) ListVersions() ([]s3bucket.Version, error) {
 	fake.listVersionsMutex.Lock()
 	ret, specificReturn := fake.listVersionsReturnsOnCall[len(fake.listVersionsArgsForCall)]
@@ -357,8 +497,12 @@ func (fake *FakeBucket) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.copyVersionMutex.RLock()
 	defer fake.copyVersionMutex.RUnlock()
+	fake.deleteBlobMutex.RLock()
+	defer fake.deleteBlobMutex.RUnlock()
 	fake.isVersionedMutex.RLock()
 	defer fake.isVersionedMutex.RUnlock()
+	fake.listVersionHistoryMutex.RLock()
+	defer fake.listVersionHistoryMutex.RUnlock()
 	fake.listVersionsMutex.RLock()
 	defer fake.listVersionsMutex.RUnlock()
 	fake.nameMutex.RLock()
@@ -0,0 +1,93 @@
+package versioned
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"s3-blobstore-backup-restore/s3bucket"
+)
+
+// PointInTimeArtifact loads the versions of each bucket which were current at
+// an instant from the version history of the bucket itself, so that a bucket
+// can be restored to a time between backups.
+type PointInTimeArtifact struct {
+	buckets map[string]Bucket
+	at      time.Time
+}
+
+func NewPointInTimeArtifact(buckets map[string]Bucket, at time.Time) PointInTimeArtifact {
+	return PointInTimeArtifact{buckets: buckets, at: at}
+}
+
+func (a PointInTimeArtifact) Save(map[string]BucketSnapshot) error {
+	return errors.New("cannot save a point-in-time artifact")
+}
+
+func (a PointInTimeArtifact) Load() (map[string]BucketSnapshot, error) {
+	bucketSnapshots := map[string]BucketSnapshot{}
+
+	for identifier, bucket := range a.buckets {
+		versions, err := bucket.ListVersionHistory()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the versions of bucket %s at %s: %s", bucket.Name(), a.at.Format(time.RFC3339), err)
+		}
+
+		bucketSnapshots[identifier] = BucketSnapshot{
+			BucketName: bucket.Name(),
+			RegionName: bucket.Region(),
+			Versions:   versionsAt(versions, a.at),
+		}
+	}
+
+	return bucketSnapshots, nil
+}
+
+// versionsAt returns, for each key, the version which was the latest at the
+// instant, leaving out the keys which did not exist then or whose latest
+// version was a delete marker.
+func versionsAt(versions []s3bucket.Version, at time.Time) []BlobVersion {
+	latest := map[string]s3bucket.Version{}
+	for _, version := range versions {
+		if version.LastModified.After(at) {
+			continue
+		}
+
+		current, found := latest[version.Key]
+		if !found || isNewer(version, current) {
+			latest[version.Key] = version
+		}
+	}
+
+	var blobVersions []BlobVersion
+	for key, version := range latest {
+		if !version.IsDeleteMarker {
+			blobVersions = append(blobVersions, BlobVersion{BlobKey: key, Id: version.Id})
+		}
+	}
+	sort.Slice(blobVersions, func(i, j int) bool {
+		return blobVersions[i].BlobKey < blobVersions[j].BlobKey
+	})
+
+	return blobVersions
+}
+
+// isNewer is whether version was created after current, a version of the same
+// key listed before it. S3 lists the times to the second, and lists the
+// versions and the delete markers of a key apart, each newest first. So of a
+// version and a delete marker created in the same second, the one S3 says is
+// the latest is newer, or else the delete marker, as a blob is more often
+// deleted just after it is written than written just after it is deleted. Of
+// two versions or two delete markers, the one listed first is newer.
+func isNewer(version, current s3bucket.Version) bool {
+	if !version.LastModified.Equal(current.LastModified) {
+		return version.LastModified.After(current.LastModified)
+	}
+
+	if version.IsLatest != current.IsLatest {
+		return version.IsLatest
+	}
+
+	return version.IsDeleteMarker && !current.IsDeleteMarker
+}
@@ -0,0 +1,145 @@
+package versioned_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"s3-blobstore-backup-restore/s3bucket"
+	"s3-blobstore-backup-restore/versioned"
+	"s3-blobstore-backup-restore/versioned/fakes"
+)
+
+var _ = Describe("PointInTimeArtifact", func() {
+	var (
+		dropletsBucket *fakes.FakeBucket
+		packagesBucket *fakes.FakeBucket
+		at             time.Time
+		artifact       versioned.PointInTimeArtifact
+	)
+
+	day := func(d int) time.Time {
+		return time.Date(2000, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	BeforeEach(func() {
+		dropletsBucket = new(fakes.FakeBucket)
+		dropletsBucket.NameReturns("my_droplets_bucket")
+		dropletsBucket.RegionReturns("my_droplets_region")
+		dropletsBucket.ListVersionHistoryReturns([]s3bucket.Version{
+			{Key: "one", Id: "13", LastModified: day(5), IsLatest: true},
+			{Key: "one", Id: "12", LastModified: day(3)},
+			{Key: "one", Id: "11", LastModified: day(1)},
+			{Key: "two", Id: "21", LastModified: day(1)},
+			{Key: "three", Id: "31", LastModified: day(4), IsLatest: true},
+			{Key: "four", Id: "41", LastModified: day(1)},
+			{Key: "two", Id: "22", LastModified: day(5), IsLatest: true, IsDeleteMarker: true},
+			{Key: "four", Id: "42", LastModified: day(2), IsLatest: true, IsDeleteMarker: true},
+		}, nil)
+
+		packagesBucket = new(fakes.FakeBucket)
+		packagesBucket.NameReturns("my_packages_bucket")
+		packagesBucket.RegionReturns("my_packages_region")
+
+		at = day(3)
+	})
+
+	JustBeforeEach(func() {
+		artifact = versioned.NewPointInTimeArtifact(map[string]versioned.Bucket{
+			"droplets": dropletsBucket,
+			"packages": packagesBucket,
+		}, at)
+	})
+
+	It("loads the version of each blob which was current at the time", func() {
+		bucketSnapshots, err := artifact.Load()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bucketSnapshots).To(Equal(map[string]versioned.BucketSnapshot{
+			"droplets": {
+				BucketName: "my_droplets_bucket",
+				RegionName: "my_droplets_region",
+				Versions: []versioned.BlobVersion{
+					{BlobKey: "one", Id: "12"},
+					{BlobKey: "two", Id: "21"},
+				},
+			},
+			"packages": {
+				BucketName: "my_packages_bucket",
+				RegionName: "my_packages_region",
+			},
+		}))
+	})
+
+	Context("when the time is before a blob was deleted", func() {
+		BeforeEach(func() {
+			at = day(1)
+		})
+
+		It("loads the blob", func() {
+			bucketSnapshots, err := artifact.Load()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bucketSnapshots["droplets"].Versions).To(Equal([]versioned.BlobVersion{
+				{BlobKey: "four", Id: "41"},
+				{BlobKey: "one", Id: "11"},
+				{BlobKey: "two", Id: "21"},
+			}))
+		})
+	})
+
+	Context("when the time is after the latest versions", func() {
+		BeforeEach(func() {
+			at = day(6)
+		})
+
+		It("loads the latest version of each blob which is not deleted", func() {
+			bucketSnapshots, err := artifact.Load()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bucketSnapshots["droplets"].Versions).To(Equal([]versioned.BlobVersion{
+				{BlobKey: "one", Id: "13"},
+				{BlobKey: "three", Id: "31"},
+			}))
+		})
+	})
+
+	Context("when a blob was deleted in the same second as it was written", func() {
+		BeforeEach(func() {
+			dropletsBucket.ListVersionHistoryReturns([]s3bucket.Version{
+				{Key: "one", Id: "12", LastModified: day(2)},
+				{Key: "one", Id: "11", LastModified: day(1)},
+				{Key: "two", Id: "22", LastModified: day(2), IsLatest: true},
+				{Key: "two", Id: "21", LastModified: day(1)},
+				{Key: "one", Id: "13", LastModified: day(2), IsDeleteMarker: true},
+				{Key: "two", Id: "23", LastModified: day(2), IsDeleteMarker: true},
+			}, nil)
+		})
+
+		It("takes the delete marker as newer, unless S3 says the version is the latest", func() {
+			bucketSnapshots, err := artifact.Load()
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bucketSnapshots["droplets"].Versions).To(Equal([]versioned.BlobVersion{
+				{BlobKey: "two", Id: "22"},
+			}))
+		})
+	})
+
+	It("cannot be saved", func() {
+		Expect(artifact.Save(map[string]versioned.BucketSnapshot{})).To(MatchError("cannot save a point-in-time artifact"))
+	})
+
+	Context("when listing the version history fails", func() {
+		BeforeEach(func() {
+			packagesBucket.ListVersionHistoryReturns(nil, errors.New("fail to list"))
+		})
+
+		It("returns an error", func() {
+			_, err := artifact.Load()
+			Expect(err).To(MatchError("failed to find the versions of bucket my_packages_bucket at 2000-01-03T00:00:00Z: fail to list"))
+		})
+	})
+})
@@ -1,16 +1,46 @@
 package versioned
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"common/executor"
+
+	"s3-blobstore-backup-restore/mirror"
 )
 
+// maxReportedErrors limits how many copy failures are listed in the error for
+// a bucket, so that a bucket where every copy fails does not produce millions
+// of lines of output.
+const maxReportedErrors = 10
+
 type Restorer struct {
 	destinationBuckets map[string]Bucket
 	sourceArtifact     Artifact
+	maxInFlight        map[string]int
+	deleteExtraneous   map[string]mirror.Config
+	report             io.Writer
+}
+
+// NewRestorer builds a Restorer which copies the versions of each bucket
+// concurrently, with at most maxInFlight[identifier] copies in flight for a
+// bucket, or executor.DefaultMaxInFlight when the bucket has no entry.
+func NewRestorer(destinationBuckets map[string]Bucket, sourceArtifact Artifact, maxInFlight map[string]int) Restorer {
+	return Restorer{destinationBuckets: destinationBuckets, sourceArtifact: sourceArtifact, maxInFlight: maxInFlight, report: io.Discard}
 }
 
-func NewRestorer(destinationBuckets map[string]Bucket, sourceArtifact Artifact) Restorer {
-	return Restorer{destinationBuckets: destinationBuckets, sourceArtifact: sourceArtifact}
+// WithDeleteExtraneous returns a copy of the restorer which, once the versions
+// of a bucket are restored, adds delete markers to the blobs of the bucket
+// which are not in the backup when deleteExtraneous[identifier] is enabled.
+// The blobs are written to report.
+func (r Restorer) WithDeleteExtraneous(deleteExtraneous map[string]mirror.Config, report io.Writer) Restorer {
+	r.deleteExtraneous = deleteExtraneous
+	r.report = report
+	return r
 }
 
 func (r Restorer) Run() error {
@@ -28,7 +58,7 @@ func (r Restorer) Run() error {
 	}
 
 	for identifier, destinationBucket := range r.destinationBuckets {
-		bucketSnapshot, exists := bucketSnapshots[identifier]
+		_, exists := bucketSnapshots[identifier]
 
 		if !exists {
 			return fmt.Errorf("no entry found in backup artifact for bucket: %s", identifier)
@@ -43,20 +73,167 @@ func (r Restorer) Run() error {
 		if !isVersioned {
 			return fmt.Errorf("bucket %s is not versioned", destinationBucket.Name())
 		}
+	}
+
+	var waitGroup sync.WaitGroup
+	var mutex sync.Mutex
+	bucketErrors := map[string]error{}
+
+	for identifier, destinationBucket := range r.destinationBuckets {
+		waitGroup.Add(1)
+		go func(identifier string, destinationBucket Bucket) {
+			defer waitGroup.Done()
 
-		for _, versionToCopy := range bucketSnapshot.Versions {
-			err = destinationBucket.CopyVersion(
-				versionToCopy.BlobKey,
-				versionToCopy.Id,
-				bucketSnapshot.BucketName,
-				bucketSnapshot.RegionName,
-			)
+			err := restoreBucket(destinationBucket, bucketSnapshots[identifier], r.maxInFlight[identifier])
+			if err == nil {
+				var report bytes.Buffer
+				err = deleteExtraneousBlobs(destinationBucket, bucketSnapshots[identifier], r.deleteExtraneous[identifier], r.maxInFlight[identifier], &report)
 
+				mutex.Lock()
+				r.report.Write(report.Bytes())
+				mutex.Unlock()
+			}
 			if err != nil {
-				return err
+				mutex.Lock()
+				bucketErrors[identifier] = err
+				mutex.Unlock()
 			}
+		}(identifier, destinationBucket)
+	}
+	waitGroup.Wait()
+
+	return formatBucketErrors(bucketErrors)
+}
+
+func restoreBucket(destinationBucket Bucket, bucketSnapshot BucketSnapshot, maxInFlight int) error {
+	var executables []executor.Executable
+	for _, versionToCopy := range bucketSnapshot.Versions {
+		executables = append(executables, copyVersionExecutable{
+			version:      versionToCopy,
+			sourceBucket: bucketSnapshot,
+			bucket:       destinationBucket,
+		})
+	}
+
+	copyErrors := executor.NewParallelExecutor(maxInFlight).Run([][]executor.Executable{executables})
+	if len(copyErrors) == 0 {
+		return nil
+	}
+
+	messages := []string{
+		fmt.Sprintf("failed to restore %d of %d versions to bucket %s", len(copyErrors), len(bucketSnapshot.Versions), destinationBucket.Name()),
+	}
+	for i, err := range copyErrors {
+		if i == maxReportedErrors {
+			messages = append(messages, fmt.Sprintf("and %d more", len(copyErrors)-maxReportedErrors))
+			break
+		}
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Errorf("%s", strings.Join(messages, "\n"))
+}
+
+// deleteExtraneousBlobs adds delete markers to the blobs whose latest version
+// is not in bucketSnapshot, when config is enabled.
+func deleteExtraneousBlobs(destinationBucket Bucket, bucketSnapshot BucketSnapshot, config mirror.Config, maxInFlight int, report io.Writer) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	versions, err := destinationBucket.ListVersions()
+	if err != nil {
+		return fmt.Errorf("failed to delete extraneous blobs from bucket %s: %s", destinationBucket.Name(), err)
+	}
+
+	var liveKeys []string
+	for _, version := range versions {
+		if version.IsLatest {
+			liveKeys = append(liveKeys, version.Key)
+		}
+	}
+
+	var backedUpKeys []string
+	for _, version := range bucketSnapshot.Versions {
+		backedUpKeys = append(backedUpKeys, version.BlobKey)
+	}
+
+	keysToDelete, err := config.KeysToDelete(destinationBucket.Name(), liveKeys, backedUpKeys)
+	if err != nil {
+		return err
+	}
+
+	config.Report(report, destinationBucket.Name(), keysToDelete)
+	if config.DryRun {
+		return nil
+	}
+
+	var executables []executor.Executable
+	for _, key := range keysToDelete {
+		executables = append(executables, deleteBlobExecutable{key: key, bucket: destinationBucket})
+	}
+
+	deleteErrors := executor.NewParallelExecutor(maxInFlight).Run([][]executor.Executable{executables})
+	if len(deleteErrors) == 0 {
+		return nil
+	}
+
+	messages := []string{
+		fmt.Sprintf("failed to delete %d of %d extraneous blobs from bucket %s", len(deleteErrors), len(keysToDelete), destinationBucket.Name()),
+	}
+	for i, err := range deleteErrors {
+		if i == maxReportedErrors {
+			messages = append(messages, fmt.Sprintf("and %d more", len(deleteErrors)-maxReportedErrors))
+			break
 		}
+		messages = append(messages, err.Error())
 	}
 
+	return fmt.Errorf("%s", strings.Join(messages, "\n"))
+}
+
+type deleteBlobExecutable struct {
+	key    string
+	bucket Bucket
+}
+
+func (e deleteBlobExecutable) Execute() error {
+	err := e.bucket.DeleteBlob(e.key)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %s", e.key, err)
+	}
+	return nil
+}
+
+type copyVersionExecutable struct {
+	version      BlobVersion
+	sourceBucket BucketSnapshot
+	bucket       Bucket
+}
+
+func (e copyVersionExecutable) Execute() error {
+	err := e.bucket.CopyVersion(e.version.BlobKey, e.version.Id, e.sourceBucket.BucketName, e.sourceBucket.RegionName)
+	if err != nil {
+		return fmt.Errorf("failed to copy version %s of %s: %s", e.version.Id, e.version.BlobKey, err)
+	}
 	return nil
 }
+
+func formatBucketErrors(bucketErrors map[string]error) error {
+	if len(bucketErrors) == 0 {
+		return nil
+	}
+
+	var identifiers []string
+	for identifier := range bucketErrors {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+
+	messages := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		messages[i] = bucketErrors[identifier].Error()
+	}
+
+	return fmt.Errorf("%s", strings.Join(messages, "\n"))
+}
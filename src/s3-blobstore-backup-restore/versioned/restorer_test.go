@@ -3,13 +3,19 @@ package versioned_test
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"s3-blobstore-backup-restore/versioned/fakes"
 
+	"s3-blobstore-backup-restore/mirror"
+	"s3-blobstore-backup-restore/s3bucket"
 	"s3-blobstore-backup-restore/versioned"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("Restorer", func() {
@@ -38,7 +44,7 @@ var _ = Describe("Restorer", func() {
 			"droplets":   dropletsBucket,
 			"buildpacks": buildpacksBucket,
 			"packages":   packagesBucket,
-		}, artifact)
+		}, artifact, nil)
 	})
 
 	JustBeforeEach(func() {
@@ -94,17 +100,15 @@ var _ = Describe("Restorer", func() {
 			By("Calling CopyVersion for each object in the droplets bucket with the old region", func() {
 				Expect(dropletsBucket.CopyVersionCallCount()).To(Equal(2))
 
-				expectedBlobKey, expectedVersionId, expectedSourceBucketName, expectedSourceRegionName := dropletsBucket.CopyVersionArgsForCall(0)
-				Expect(expectedBlobKey).To(Equal("one"))
-				Expect(expectedVersionId).To(Equal("13"))
-				Expect(expectedSourceBucketName).To(Equal("my_droplets_bucket"))
-				Expect(expectedSourceRegionName).To(Equal("my_droplets_source_region"))
-
-				expectedBlobKey, expectedVersionId, expectedSourceBucketName, expectedSourceRegionName = dropletsBucket.CopyVersionArgsForCall(1)
-				Expect(expectedBlobKey).To(Equal("two"))
-				Expect(expectedVersionId).To(Equal("22"))
-				Expect(expectedSourceBucketName).To(Equal("my_droplets_bucket"))
-				Expect(expectedSourceRegionName).To(Equal("my_droplets_source_region"))
+				var copiedVersions [][]string
+				for i := 0; i < dropletsBucket.CopyVersionCallCount(); i++ {
+					blobKey, versionId, sourceBucketName, sourceRegionName := dropletsBucket.CopyVersionArgsForCall(i)
+					copiedVersions = append(copiedVersions, []string{blobKey, versionId, sourceBucketName, sourceRegionName})
+				}
+				Expect(copiedVersions).To(ConsistOf(
+					[]string{"one", "13", "my_droplets_bucket", "my_droplets_source_region"},
+					[]string{"two", "22", "my_droplets_bucket", "my_droplets_source_region"},
+				))
 			})
 
 			By("Calling CopyVersions for each object in the buildpacks bucket with the old region", func() {
@@ -171,12 +175,204 @@ var _ = Describe("Restorer", func() {
 			}, nil)
 
 			dropletsBucket.CopyVersionReturns(nil)
+			dropletsBucket.NameReturns("my_droplets_bucket")
 			buildpacksBucket.CopyVersionReturns(errors.New("failed to put version to bucket 'buildpacks'"))
+			buildpacksBucket.NameReturns("my_buildpacks_bucket")
 			packagesBucket.CopyVersionReturns(nil)
 		})
 
-		It("stops and returns an error", func() {
-			Expect(err).To(MatchError("failed to put version to bucket 'buildpacks'"))
+		It("restores the other buckets and returns an error for the failed bucket", func() {
+			Expect(err).To(MatchError("failed to restore 1 of 1 versions to bucket my_buildpacks_bucket\n" +
+				"failed to copy version 32 of three: failed to put version to bucket 'buildpacks'"))
+			Expect(dropletsBucket.CopyVersionCallCount()).To(Equal(2))
+			Expect(packagesBucket.CopyVersionCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when copying some versions on several buckets fails", func() {
+		BeforeEach(func() {
+			var versions []versioned.BlobVersion
+			for i := 0; i < 15; i++ {
+				versions = append(versions, versioned.BlobVersion{BlobKey: fmt.Sprintf("blob%d", i), Id: fmt.Sprintf("%d", i)})
+			}
+
+			artifact.LoadReturns(map[string]versioned.BucketSnapshot{
+				"droplets":   {BucketName: "my_droplets_bucket", Versions: versions},
+				"buildpacks": {BucketName: "my_buildpacks_bucket", Versions: versions[:2]},
+				"packages":   {BucketName: "my_packages_bucket", Versions: versions[:1]},
+			}, nil)
+
+			dropletsBucket.NameReturns("my_droplets_bucket")
+			dropletsBucket.CopyVersionStub = func(blobKey, _, _, _ string) error {
+				if blobKey == "blob0" {
+					return nil
+				}
+				return errors.New("droplet copy failed")
+			}
+			buildpacksBucket.NameReturns("my_buildpacks_bucket")
+			buildpacksBucket.CopyVersionStub = func(blobKey, _, _, _ string) error {
+				if blobKey == "blob1" {
+					return errors.New("buildpack copy failed")
+				}
+				return nil
+			}
+		})
+
+		It("copies every version and reports the failures of each bucket", func() {
+			Expect(dropletsBucket.CopyVersionCallCount()).To(Equal(15))
+			Expect(buildpacksBucket.CopyVersionCallCount()).To(Equal(2))
+			Expect(packagesBucket.CopyVersionCallCount()).To(Equal(1))
+
+			lines := strings.Split(err.Error(), "\n")
+			Expect(lines).To(HaveLen(14))
+			Expect(lines[0]).To(Equal("failed to restore 1 of 2 versions to bucket my_buildpacks_bucket"))
+			Expect(lines[1]).To(Equal("failed to copy version 1 of blob1: buildpack copy failed"))
+			Expect(lines[2]).To(Equal("failed to restore 14 of 15 versions to bucket my_droplets_bucket"))
+			Expect(lines[3:13]).To(HaveEach(HaveSuffix("droplet copy failed")))
+			Expect(lines[13]).To(Equal("and 4 more"))
+		})
+	})
+
+	Context("when a bucket sets a max in flight", func() {
+		var inFlight, maxObservedInFlight int32
+
+		BeforeEach(func() {
+			inFlight, maxObservedInFlight = 0, 0
+
+			var versions []versioned.BlobVersion
+			for i := 0; i < 20; i++ {
+				versions = append(versions, versioned.BlobVersion{BlobKey: fmt.Sprintf("blob%d", i), Id: fmt.Sprintf("%d", i)})
+			}
+
+			artifact.LoadReturns(map[string]versioned.BucketSnapshot{
+				"droplets": {BucketName: "my_droplets_bucket", Versions: versions},
+			}, nil)
+
+			dropletsBucket.CopyVersionStub = func(_, _, _, _ string) error {
+				current := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+
+				for {
+					observed := atomic.LoadInt32(&maxObservedInFlight)
+					if current <= observed || atomic.CompareAndSwapInt32(&maxObservedInFlight, observed, current) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				return nil
+			}
+
+			restorer = versioned.NewRestorer(map[string]versioned.Bucket{
+				"droplets": dropletsBucket,
+			}, artifact, map[string]int{"droplets": 3})
+		})
+
+		It("copies no more than that many versions at once", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dropletsBucket.CopyVersionCallCount()).To(Equal(20))
+			Expect(atomic.LoadInt32(&maxObservedInFlight)).To(BeNumerically("<=", 3))
+			Expect(atomic.LoadInt32(&maxObservedInFlight)).To(BeNumerically(">", 1))
+		})
+	})
+
+	Context("when a bucket deletes extraneous blobs", func() {
+		var report *gbytes.Buffer
+
+		BeforeEach(func() {
+			artifact.LoadReturns(map[string]versioned.BucketSnapshot{
+				"droplets": {
+					BucketName: "my_droplets_bucket",
+					Versions: []versioned.BlobVersion{
+						{BlobKey: "one", Id: "13"},
+						{BlobKey: "two", Id: "22"},
+					},
+				},
+				"buildpacks": {BucketName: "my_buildpacks_bucket"},
+				"packages":   {BucketName: "my_packages_bucket"},
+			}, nil)
+
+			dropletsBucket.NameReturns("my_droplets_bucket")
+			dropletsBucket.ListVersionsReturns([]s3bucket.Version{
+				{Key: "one", Id: "13", IsLatest: false},
+				{Key: "one", Id: "14", IsLatest: true},
+				{Key: "two", Id: "23", IsLatest: true},
+				{Key: "three", Id: "31", IsLatest: true},
+				{Key: "four", Id: "41", IsLatest: false},
+			}, nil)
+
+			report = gbytes.NewBuffer()
+			restorer = restorer.WithDeleteExtraneous(map[string]mirror.Config{
+				"droplets": {Enabled: true},
+			}, report)
+		})
+
+		It("adds delete markers to the blobs whose latest version is not in the backup", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dropletsBucket.CopyVersionCallCount()).To(Equal(2))
+			Expect(dropletsBucket.DeleteBlobCallCount()).To(Equal(1))
+			Expect(dropletsBucket.DeleteBlobArgsForCall(0)).To(Equal("three"))
+			Expect(string(report.Contents())).To(Equal("Deleting 1 blobs which are not in the backup from bucket my_droplets_bucket:\n  three\n"))
+		})
+
+		It("does not delete blobs from the other buckets", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buildpacksBucket.ListVersionsCallCount()).To(BeZero())
+			Expect(buildpacksBucket.DeleteBlobCallCount()).To(BeZero())
+			Expect(packagesBucket.DeleteBlobCallCount()).To(BeZero())
+		})
+
+		Context("and it is a dry run", func() {
+			BeforeEach(func() {
+				restorer = restorer.WithDeleteExtraneous(map[string]mirror.Config{
+					"droplets": {Enabled: true, DryRun: true},
+				}, report)
+			})
+
+			It("reports the blobs without deleting them", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dropletsBucket.DeleteBlobCallCount()).To(BeZero())
+				Expect(string(report.Contents())).To(Equal("Dry run: would delete 1 blobs which are not in the backup from bucket my_droplets_bucket:\n  three\n"))
+			})
+		})
+
+		Context("and there are more blobs to delete than max_deletions", func() {
+			BeforeEach(func() {
+				dropletsBucket.ListVersionsReturns([]s3bucket.Version{
+					{Key: "three", Id: "31", IsLatest: true},
+					{Key: "five", Id: "51", IsLatest: true},
+				}, nil)
+				restorer = restorer.WithDeleteExtraneous(map[string]mirror.Config{
+					"droplets": {Enabled: true, MaxDeletions: 1},
+				}, report)
+			})
+
+			It("fails without deleting any", func() {
+				Expect(err).To(MatchError("refusing to delete 2 blobs which are not in the backup from bucket my_droplets_bucket: more than delete_extraneous.max_deletions (1)"))
+				Expect(dropletsBucket.DeleteBlobCallCount()).To(BeZero())
+			})
+		})
+
+		Context("and copying a version fails", func() {
+			BeforeEach(func() {
+				dropletsBucket.CopyVersionReturns(errors.New("copy failed"))
+			})
+
+			It("does not delete any blobs", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(dropletsBucket.ListVersionsCallCount()).To(BeZero())
+				Expect(dropletsBucket.DeleteBlobCallCount()).To(BeZero())
+			})
+		})
+
+		Context("and adding a delete marker fails", func() {
+			BeforeEach(func() {
+				dropletsBucket.DeleteBlobReturns(errors.New("delete failed"))
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError("failed to delete 1 of 1 extraneous blobs from bucket my_droplets_bucket\n" +
+					"failed to delete three: delete failed"))
+			})
 		})
 	})
 
@@ -284,7 +480,7 @@ var _ = Describe("Restorer", func() {
 				"droplets":   dropletsBucket,
 				"buildpacks": buildpacksBucket,
 				"packages":   packagesBucket,
-			}, artifact)
+			}, artifact, nil)
 		})
 
 		It("fails and returns a useful error", func() {
@@ -315,7 +511,7 @@ var _ = Describe("Restorer", func() {
 
 			restorer = versioned.NewRestorer(map[string]versioned.Bucket{
 				"droplets": dropletsBucket,
-			}, artifact)
+			}, artifact, nil)
 		})
 
 		It("fails and returns a useful error", func() {